@@ -22,7 +22,6 @@ import (
 	"github.com/hashicorp/waypoint-plugin-sdk/docs"
 	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
 	clientpkg "github.com/hashicorp/waypoint/internal/client"
-	"github.com/hashicorp/waypoint/internal/clierrors"
 	"github.com/hashicorp/waypoint/internal/config/funcs"
 	"github.com/hashicorp/waypoint/internal/factory"
 	"github.com/hashicorp/waypoint/internal/pkg/flag"
@@ -715,8 +714,7 @@ func (c *AppDocsCommand) Run(args []string) int {
 	err = c.DoApp(c.Ctx, func(ctx context.Context, app *clientpkg.App) error {
 		docs, err := app.Docs(ctx, &pb.Job_DocsOp{})
 		if err != nil {
-			app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-			return ErrSentinel
+			return appOpError(app.UI, err)
 		}
 
 		var (
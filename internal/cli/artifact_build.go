@@ -7,7 +7,6 @@ import (
 
 	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
 	clientpkg "github.com/hashicorp/waypoint/internal/client"
-	"github.com/hashicorp/waypoint/internal/clierrors"
 	"github.com/hashicorp/waypoint/internal/pkg/flag"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 )
@@ -24,18 +23,20 @@ func (c *ArtifactBuildCommand) Run(args []string) int {
 		WithArgs(args),
 		WithFlags(c.Flags()),
 		WithMultipleApp(),
+		WithServerAppCheck(),
 	); err != nil {
 		return 1
 	}
 
 	err := c.DoApp(c.Ctx, func(ctx context.Context, app *clientpkg.App) error {
-		app.UI.Output("Building %s...", app.Ref().Application, terminal.WithHeaderStyle())
+		if c.atVerbosity(verbosityNormal) {
+			app.UI.Output("Building %s...", app.Ref().Application, terminal.WithHeaderStyle())
+		}
 		_, err := app.Build(ctx, &pb.Job_BuildOp{
 			DisablePush: !c.flagPush,
 		})
 		if err != nil {
-			app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-			return ErrSentinel
+			return appOpError(app.UI, err)
 		}
 
 		return nil
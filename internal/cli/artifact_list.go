@@ -2,7 +2,6 @@ package cli
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -78,7 +77,7 @@ func (c *ArtifactListCommand) Run(args []string) int {
 		sort.Sort(serversort.ArtifactStartDesc(resp.Artifacts))
 
 		if c.flagJson {
-			return c.displayJson(resp.Artifacts)
+			return c.displayJson(app.Ref().Application, resp.Artifacts)
 		}
 
 		const bullet = "●"
@@ -188,7 +187,7 @@ func (c *ArtifactListCommand) Run(args []string) int {
 	return 0
 }
 
-func (c *ArtifactListCommand) displayJson(artifacts []*pb.PushedArtifact) error {
+func (c *ArtifactListCommand) displayJson(app string, artifacts []*pb.PushedArtifact) error {
 	var output []map[string]interface{}
 
 	for _, art := range artifacts {
@@ -206,14 +205,7 @@ func (c *ArtifactListCommand) displayJson(artifacts []*pb.PushedArtifact) error
 		output = append(output, i)
 	}
 
-	data, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	c.ui.Output(string(data))
-
-	return nil
+	return c.outputJSON([]string{app}, output)
 }
 
 func (c *ArtifactListCommand) statusJson(status *pb.Status) interface{} {
@@ -7,7 +7,6 @@ import (
 
 	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
 	clientpkg "github.com/hashicorp/waypoint/internal/client"
-	"github.com/hashicorp/waypoint/internal/clierrors"
 	"github.com/hashicorp/waypoint/internal/pkg/flag"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 )
@@ -30,14 +29,15 @@ func (c *ArtifactPushCommand) Run(args []string) int {
 
 	err := c.DoApp(c.Ctx, func(ctx context.Context, app *clientpkg.App) error {
 		// Get the most recent build
-		app.UI.Output("Pushing artifact for %s...", app.Ref().Application, terminal.WithHeaderStyle())
+		if c.atVerbosity(verbosityNormal) {
+			app.UI.Output("Pushing artifact for %s...", app.Ref().Application, terminal.WithHeaderStyle())
+		}
 		build, err := client.GetLatestBuild(ctx, &pb.GetLatestBuildRequest{
 			Application: app.Ref(),
 			Workspace:   c.project.WorkspaceRef(),
 		})
 		if err != nil {
-			app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-			return ErrSentinel
+			return appOpError(app.UI, err)
 		}
 
 		// Push it
@@ -45,8 +45,7 @@ func (c *ArtifactPushCommand) Run(args []string) int {
 			Build: build,
 		})
 		if err != nil {
-			app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-			return ErrSentinel
+			return appOpError(app.UI, err)
 		}
 
 		return nil
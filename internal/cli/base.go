@@ -4,15 +4,26 @@ import (
 	"context"
 	"errors"
 	stdflag "flag"
+	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/adrg/xdg"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
 	"github.com/hashicorp/waypoint/internal/clicontext"
@@ -20,7 +31,10 @@ import (
 	"github.com/hashicorp/waypoint/internal/clierrors"
 	"github.com/hashicorp/waypoint/internal/config"
 	"github.com/hashicorp/waypoint/internal/config/variables"
+	"github.com/hashicorp/waypoint/internal/env"
+	"github.com/hashicorp/waypoint/internal/pkg/finalcontext"
 	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	runnerpkg "github.com/hashicorp/waypoint/internal/runner"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 	"github.com/hashicorp/waypoint/internal/server/grpcmetadata"
 )
@@ -28,6 +42,11 @@ import (
 const (
 	defaultWorkspace        = "default"
 	defaultWorkspaceEnvName = "WAYPOINT_WORKSPACE"
+
+	// Phases that commands can allow selecting with "-only" via WithPhases.
+	phaseBuild   = "build"
+	phaseDeploy  = "deploy"
+	phaseRelease = "release"
 )
 
 // baseCommand is embedded in all commands to provide common logic and data.
@@ -51,8 +70,10 @@ type baseCommand struct {
 	//---------------------------------------------------------------
 	// The fields below are only available after calling Init.
 
-	// cfg is the parsed configuration
-	cfg *config.Config
+	// cfg is the parsed configuration and configPath is the file it was
+	// loaded from.
+	cfg        *config.Config
+	configPath string
 
 	// UI is used to write to the CLI.
 	ui terminal.UI
@@ -76,72 +97,438 @@ type baseCommand struct {
 	// variables hold the values set via flags and local env vars
 	variables []*pb.Variable
 
+	// flagOnly is the list of phases selected with -only. phases is the
+	// validated set of those, or nil if all phases should be performed.
+	flagOnly []string
+	phases   map[string]bool
+
+	// varFiles are the paths of the -var-file files that variables were
+	// read from, in precedence order.
+	varFiles []string
+
+	// flagAutoVarDirs are extra directories to load auto var files from,
+	// and autoVarFiles are the files that were loaded from them.
+	flagAutoVarDirs []string
+	autoVarFiles    []string
+
+	// appVariables are the variables of the apps with app-scoped
+	// "-var app:key=value" flags, keyed by app name. These are used in
+	// place of variables for the operations of that app.
+	appVariables map[string][]*pb.Variable
+
+	// verbosity is the tier of informational output to show. Check this
+	// with atVerbosity.
+	verbosity verbosity
+
 	//---------------------------------------------------------------
 	// Internal fields that should not be accessed directly
 
 	// flagPlain is whether the output should be in plain mode.
 	flagPlain bool
 
+	// flagPlainProgress is plain mode that also prints periodic status
+	// lines for long-running steps, every flagProgressInterval.
+	flagPlainProgress    bool
+	flagProgressInterval time.Duration
+
+	// flagVerbosity is the raw verbosity tier, parsed into verbosity.
+	flagVerbosity string
+
+	// flagSyslog and flagSyslogAddr forward the logs to the local syslog
+	// daemon or the one at the address. flagSyslogOutput also forwards
+	// the UI output.
+	flagSyslog         bool
+	flagSyslogAddr     string
+	flagSyslogFacility string
+	flagSyslogTag      string
+	flagSyslogOutput   bool
+
+	// syslog and syslogSink are set by initSyslog if syslog is enabled.
+	syslog     syslogWriter
+	syslogSink *syslogSink
+
+	// flagAnnotations are set via -annotation if flagSetOperation is set.
+	// These are forwarded as labels with annotationLabelPrefix, along with
+	// the annotations detected from CI unless flagNoCIAnnotations is set.
+	flagAnnotations     map[string]string
+	flagNoCIAnnotations bool
+
 	// flagLabels are set via -label if flagSetOperation is set.
 	flagLabels map[string]string
 
+	// flagLabelFiles are files of labels that are merged into flagLabels.
+	flagLabelFiles []string
+
+	// flagSpec is a job spec file that sets the same values as the flags
+	// for an operation. See jobSpec. specLabels are the labels from the
+	// spec, which are merged into flagLabels by initLabelFiles.
+	flagSpec   string
+	specLabels map[string]string
+
 	// flagVars sets values for defined input variables
 	flagVars map[string]string
 
+	// vaultVars are the names of the variables whose -var values were read
+	// from Vault. See initVaultVars.
+	vaultVars map[string]struct{}
+
+	// flagConfigVars sets values for input variables that are only used
+	// while evaluating the configuration in the CLI. Unlike flagVars,
+	// these aren't set on the jobs for operations.
+	flagConfigVars map[string]string
+
 	// flagVarFile is a HCL or JSON file setting one or more values
 	// for defined input variables
 	flagVarFile []string
 
+	// flagVarPrecedence is whether -var or -var-file values win when
+	// both set the same variable.
+	flagVarPrecedence string
+
+	// flagStrictVars makes warnings from loading variable values errors.
+	flagStrictVars bool
+
+	// flagWriteVarLock writes the resolved variable values to varLockFile
+	// and flagVarLock is a lockfile to replay the variable values from.
+	flagWriteVarLock bool
+	flagVarLock      string
+
+	// flagVarExport is a path to write the resolved variable values to for
+	// other tools, including sensitive values if flagVarExportSensitive.
+	flagVarExport          string
+	flagVarExportSensitive bool
+
 	// flagRemote is whether to execute using a remote runner or use
 	// a local runner.
 	flagRemote bool
 
+	// flagLocal is the explicit way to request a local runner. This is
+	// preferred over "-remote=false".
+	flagLocal bool
+
+	// flagNoLocalRunner forbids executing on a local runner, so operations
+	// execute remotely or fail. This can also be set with EnvNoLocalRunner.
+	flagNoLocalRunner bool
+
+	// flagAutoServerDir is the directory for the state of the in-memory
+	// server started for local operations.
+	flagAutoServerDir string
+
 	// flagRemoteSource are the remote data source overrides for jobs.
 	flagRemoteSource map[string]string
 
+	// flagRequireExplicitRef refuses remote operations on a project with a
+	// Git data source unless the ref is set with "-remote-source=ref=...".
+	flagRequireExplicitRef bool
+
+	// flagCheckpoint records the apps that succeeded in a multi-app
+	// operation so that it can be resumed with flagResume, which is the
+	// run ID of the checkpoint.
+	flagCheckpoint bool
+	flagResume     string
+
+	// flagRetryFailed targets the apps that failed in the last operation
+	// for the project and workspace instead of the usual app targets.
+	flagRetryFailed bool
+
+	// flagSkipEnvCheck disables checking that the env vars listed in the
+	// "required_env" setting of the configuration are set. envCheck is true
+	// for commands with the operation flags, which are the commands that
+	// check the env.
+	flagSkipEnvCheck bool
+	envCheck         bool
+
+	// flagRunnerEnv and flagRunnerEnvSensitive are env vars to set on the
+	// remote runner while it executes the jobs for this command. The values
+	// of flagRunnerEnvSensitive are never logged by the runner.
+	flagRunnerEnv          map[string]string
+	flagRunnerEnvSensitive map[string]string
+
+	// flagRunnerLabels are labels that the remote runner executing the jobs
+	// for this command must have.
+	flagRunnerLabels map[string]string
+
+	// flagPrintJob is whether to print the jobs as JSON rather than
+	// executing them.
+	flagPrintJob bool
+
+	// flagFailOnNoApps makes DoApp return ErrNoApps rather than succeed
+	// if no apps are targeted.
+	flagFailOnNoApps bool
+
+	// flagCheckOnly is whether to stop after Init and target resolution
+	// without executing the operation.
+	flagCheckOnly bool
+
+	// flagExportJob is a file to write the jobs to as JSON before they're
+	// executed. exportJobFile is the open file, which is closed by Close.
+	flagExportJob string
+	exportJobFile *os.File
+
 	// flagApp is the app to target.
 	flagApp string
 
-	// flagProject is the project to target.
-	flagProject string
+	// flagAppSelector is a label selector to filter the targeted apps by.
+	flagAppSelector string
+
+	// flagStrictWorkspace makes targeting an app outside of its configured
+	// workspaces an error rather than skipping the app.
+	flagStrictWorkspace bool
+
+	// flagProject is the project to target. If -project was repeated,
+	// this is the first project and flagProjects holds all of them.
+	flagProject  string
+	flagProjects []string
+
+	// flagProjectFilter is a label selector for the projects to target. The
+	// matching projects are set as flagProjects once the client is created.
+	flagProjectFilter string
+
+	// flagProjectConfig is the path to a project config file that is merged
+	// with the waypoint.hcl when loading the configuration.
+	flagProjectConfig string
+
+	// flagConfigProfile is the profile in the configuration to merge with
+	// the base configuration.
+	flagConfigProfile string
 
-	// flagWorkspace is the workspace to work in.
-	flagWorkspace string
+	// flagConfigFilename is the file name of the configuration to search
+	// for instead of "waypoint.hcl". See configFilename.
+	flagConfigFilename string
+
+	// flagWorkspace is the workspace to work in. This may be a fallback
+	// chain, which is split into workspaceChain. flagWorkspaceCreate uses
+	// the first workspace of the chain if none of them exist.
+	flagWorkspace       string
+	flagWorkspaceCreate bool
+	workspaceChain      []string
+
+	// flagContextSet are values to set in the session context.
+	flagContextSet map[string]string
 
 	// flagConnection contains manual flag-based connection info.
 	flagConnection clicontext.Config
 
+	// flagServerInsecure disables TLS for the flag-based connection. This
+	// is an explicit alternative to "-server-tls=false". serverTLSDisabled
+	// is true if TLS was disabled with either flag, rather than defaulted.
+	flagServerInsecure bool
+	serverTLSDisabled  bool
+
+	// flagServerIPVersion constrains the connection to IPv4 or IPv6.
+	flagServerIPVersion string
+
+	// flagServerKeepalive is the interval of keepalive pings on an idle
+	// connection to the server. Zero uses the default.
+	flagServerKeepalive time.Duration
+
+	// flagRPS limits the requests per second to the server. Zero is
+	// unlimited. The limit is shared by every app and project targeted.
+	flagRPS float64
+
+	// flagServerConfigDir is a directory with a file for each connection
+	// field, such as mounted secrets. See clicontext.LoadDir.
+	flagServerConfigDir string
+
+	// flagServerSSHBastion is the "[user@]host[:port]" of an SSH bastion
+	// to tunnel the server connection through, authenticating with the
+	// SSH agent and optionally flagServerSSHKey.
+	flagServerSSHBastion string
+	flagServerSSHKey     string
+
+	// flagServerTLSPKCS12 is a PKCS#12 file with a client certificate for
+	// mutual TLS, and flagServerTLSPKCS12PassFile is a file with its
+	// passphrase.
+	flagServerTLSPKCS12         string
+	flagServerTLSPKCS12PassFile string
+
+	// flagHeaders are headers to send as gRPC metadata with every RPC,
+	// such as for an authenticating proxy in front of the server.
+	flagHeaders map[string]string
+
+	// flagContextCreate is the name of a context to save flagConnection to
+	// once connected, if a context with that name doesn't already exist.
+	// flagContextCreateDefault makes it the default context and
+	// flagContextCreateForce overwrites an existing context.
+	flagContextCreate        string
+	flagContextCreateDefault bool
+	flagContextCreateForce   bool
+
 	// args that were present after parsing flags
 	args []string
 
 	// options passed in at the global level
 	globalOptions []Option
 
+	// httpClient is used for HTTP requests that aren't to the Waypoint
+	// server. Use httpClientOrDefault to read it since it may be nil.
+	httpClient *http.Client
+
+	// serverAppCheck is true if DoApp checks that the targeted apps exist
+	// on the server for remote operations. See WithServerAppCheck.
+	serverAppCheck bool
+
 	// autoServer will be set to true if an automatic in-memory server
 	// is allowd.
 	autoServer bool
 
 	// The home directory that we loaded the waypoint config from
 	homeConfigPath string
+
+	// metrics tracks timing about this command execution.
+	metrics commandMetrics
+
+	// flagTimings outputs the duration of each phase of the command at the
+	// end. timings are the phases recorded so far.
+	flagTimings bool
+	timings     []*phaseTiming
+	timingsLock sync.Mutex
+
+	// runnerProfiles caches the list of runner profiles for this
+	// invocation. Use runnerProfileList to read it.
+	runnerProfiles runnerProfileCache
+
+	// flagResultsJSON outputs the outcome of each app as JSON. resultsLock
+	// serializes the output of each app's outcome.
+	flagResultsJSON bool
+	resultsLock     sync.Mutex
+
+	// operation is true for commands with the operation flags. Only these
+	// output the outcome of each app and the summaries, since the other
+	// commands that operate on apps, such as listing deployments, have
+	// their own output. commandJSON is true if the command's own -json
+	// flag is set, in which case nothing else is output with it.
+	operation   bool
+	commandJSON bool
+
+	// flagNoSummary suppresses the summary tables output after operating
+	// on multiple apps. This doesn't affect -results-json.
+	flagNoSummary bool
+
+	// flagNoVersionCheck disables checking for a newer CLI version. The
+	// result of the check is sent on versionCheckCh.
+	flagNoVersionCheck bool
+	versionCheckCh     chan string
+
+	// flagProtectedOverride allows operating on a protected workspace
+	// without confirmation. See checkProtectedWorkspace.
+	flagProtectedOverride bool
+
+	// flagNoCheckpoint disables all outbound requests other than to the
+	// server, including the version check. See checkpointDisabled.
+	flagNoCheckpoint bool
+
+	// flagAppTimeout is the -app-timeout for the operation on each app in
+	// DoApp. Zero disables the per-app timeout.
+	flagAppTimeout time.Duration
+
+	// flagOutputDir is the directory to record the output and result of
+	// each app in DoApp. flagOutputDirForce allows replacing the output of
+	// an existing, non-empty directory.
+	flagOutputDir      string
+	flagOutputDirForce bool
+
+	// flagRequireClean fails the operation if the git working tree has
+	// uncommitted changes, unless flagAllowDirty is set.
+	flagRequireClean bool
+	flagAllowDirty   bool
+
+	// flagIdleTimeout is the -idle-timeout for attached streaming commands
+	// that register the flag. Zero disables the idle timeout.
+	flagIdleTimeout time.Duration
+
+	// localRunnerCleanup removes the record tracking our local runner. This
+	// is set if we started a local runner.
+	localRunnerCleanup func()
+
+	// projectRecord is the server record for refProject, cached by
+	// getProject. Use getProject rather than accessing this directly.
+	projectRecord *pb.Project
+
+	// projectClients are the clients for the other projects targeted with a
+	// repeated -project flag. These are tracked so that the jobs they start
+	// are canceled along with our own if we're interrupted.
+	projectClients []*clientpkg.Project
 }
 
 // Close cleans up any resources that the command created. This should be
 // deferred by any CLI command that embeds baseCommand in the Run command.
 func (c *baseCommand) Close() error {
+	// If we were interrupted, cancel the jobs we were waiting on so they
+	// don't keep running on the server without us.
+	if c.project != nil && c.Ctx != nil && c.Ctx.Err() != nil {
+		c.cancelIncompleteJobs()
+	}
+
 	// Close the project client, which gracefully shuts down the local runner
 	if c.project != nil {
 		c.project.Close()
 	}
 
+	// All jobs have been exported by now.
+	if c.exportJobFile != nil {
+		if err := c.exportJobFile.Close(); err != nil {
+			c.Log.Warn("error closing job export file", "error", err)
+		}
+	}
+
+	// The local runner is closed so we no longer need to track it.
+	if c.localRunnerCleanup != nil {
+		c.localRunnerCleanup()
+	}
+
 	// Close our UI if it implements it. The glint-based UI does for example
 	// to finish up all the CLI output.
 	if closer, ok := c.ui.(io.Closer); ok && closer != nil {
 		closer.Close()
 	}
 
+	c.closeSyslog()
+
 	return nil
 }
 
+// cancelIncompleteJobs cancels the jobs started by this command that we
+// didn't see complete. This uses a final context since c.Ctx is already
+// canceled, which gives the cancellations a short grace period.
+func (c *baseCommand) cancelIncompleteJobs() {
+	jobs := c.project.IncompleteJobs()
+	for _, project := range c.projectClients {
+		for id, job := range project.IncompleteJobs() {
+			jobs[id] = job
+		}
+	}
+	if len(jobs) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(jobs))
+	for id := range jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	ctx, cancel := finalcontext.Context(c.Log)
+	defer cancel()
+
+	client := c.project.Client()
+	for _, id := range ids {
+		app := jobs[id].Application.GetApplication()
+		if _, err := client.CancelJob(ctx, &pb.CancelJobRequest{JobId: id}); err != nil {
+			c.Log.Warn("error canceling job", "job_id", id, "error", err)
+			if c.ui != nil {
+				c.ui.Output("Error canceling job %q for app %q: %s", id, app,
+					clierrors.Humanize(err), terminal.WithErrorStyle())
+			}
+			continue
+		}
+
+		if c.ui != nil {
+			c.ui.Output("Canceled job %q for app %q.", id, app, terminal.WithWarningStyle())
+		}
+	}
+}
+
 // Init initializes the command by parsing flags, parsing the configuration,
 // setting up the project, etc. You can control what is done by using the
 // options.
@@ -149,6 +536,9 @@ func (c *baseCommand) Close() error {
 // Init should be called FIRST within the Run function implementation. Many
 // options will affect behavior of other functions that can be called later.
 func (c *baseCommand) Init(opts ...Option) error {
+	c.metrics.start = time.Now()
+	defer func() { c.metrics.initDuration = time.Since(c.metrics.start) }()
+
 	baseCfg := baseConfig{
 		Config: true,
 		Client: true,
@@ -164,6 +554,8 @@ func (c *baseCommand) Init(opts ...Option) error {
 
 	// Set some basic internal fields
 	c.autoServer = !baseCfg.NoAutoServer
+	c.serverAppCheck = baseCfg.ServerAppCheck
+	c.httpClient = baseCfg.HTTPClient
 
 	// Init our UI first so we can write output to the user immediately.
 	ui := baseCfg.UI
@@ -180,16 +572,124 @@ func (c *baseCommand) Init(opts ...Option) error {
 	}
 	c.args = baseCfg.Flags.Args()
 
+	// Note if the command outputs its own JSON so that we don't mix any
+	// other output into it.
+	baseCfg.Flags.Visit(func(f *stdflag.Flag) {
+		if f.Name == "json" && f.Value.String() == "true" {
+			c.commandJSON = true
+		}
+	})
+
+	// Apply the job spec file, if any, to the flags that weren't set.
+	if err := c.initSpec(baseCfg.Flags); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+
+	// Handle the explicit -local flag and warn on the deprecated
+	// "-remote=false" form.
+	if c.flagLocal {
+		if c.flagRemote {
+			err := errors.New("The -local and -remote flags can't both be set.")
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return err
+		}
+	} else if baseCfg.Flags.IsSet("remote") && !c.flagRemote {
+		c.ui.Output(warnRemoteFalseDeprecated, terminal.WithWarningStyle())
+	}
+
+	// Require remote execution if local runners are forbidden.
+	if baseCfg.Flags.Defined("no-local-runner") {
+		if err := c.initNoLocalRunner(baseCfg.Flags.IsSet("no-local-runner")); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return err
+		}
+	}
+
+	// Merge the labels from any label files.
+	if err := c.initLabelFiles(); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+
+	// Load the connection from a directory of files if requested. This
+	// must be before TLS is resolved since it sets the TLS flags.
+	if err := c.initServerConfigDir(baseCfg.Flags); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+
+	// Resolve whether TLS was explicitly disabled for flag connections.
+	if err := c.initServerTLS(baseCfg.Flags.IsSet("server-tls")); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+
+	// Validate the phase selection if it was given.
+	if baseCfg.Flags.IsSet("only") {
+		if err := c.initPhases(&baseCfg); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return err
+		}
+	}
+
+	// A repeated -project flag targets multiple projects. The first one is
+	// our primary target and DoApp will iterate over all of them.
+	if len(c.flagProjects) > 0 {
+		c.flagProject = c.flagProjects[0]
+	}
+	if c.flagProjectFilter != "" && len(c.flagProjects) > 0 {
+		err := errors.New("The -project-filter and -project flags can't be combined.")
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+	if len(c.flagProjects) > 1 || c.flagProjectFilter != "" {
+		// Apps are resolved from the server record of each project so
+		// a local configuration file isn't required.
+		baseCfg.ConfigOptional = true
+	}
+
 	// Check for flags after args
 	if err := checkFlagsAfterArgs(c.args, baseCfg.Flags); err != nil {
 		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
 		return err
 	}
 
-	// Reset the UI to plain if that was set
-	if c.flagPlain {
+	// Reset the UI to plain if that was set. Printing jobs also forces
+	// plain mode so that the JSON isn't mixed with interactive output.
+	if c.flagPlain || c.flagPlainProgress || c.flagPrintJob {
 		c.ui = terminal.NonInteractiveUI(c.Ctx)
 	}
+	if c.flagPlainProgress && !c.flagPrintJob {
+		if c.flagProgressInterval <= 0 {
+			err := errors.New("The -progress-interval flag must be a positive duration, such as \"30s\".")
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return err
+		}
+
+		c.ui = newProgressUI(c.ui, c.flagProgressInterval)
+	}
+
+	// Check the output directory before any operation runs.
+	if err := c.initOutputDir(); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+
+	// Determine how much informational output we show
+	v, err := parseVerbosity(c.flagVerbosity)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+	c.verbosity = v
+
+	// Forward logs and output to syslog if requested. This is after the
+	// UI is finalized so that the output to the UI is forwarded.
+	if err := c.initSyslog(); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
 
 	// If we're parsing the connection from the arg, then use that.
 	if baseCfg.ConnArg && len(c.args) > 0 {
@@ -211,6 +711,9 @@ func (c *baseCommand) Init(opts ...Option) error {
 	c.homeConfigPath = homeConfigPath
 	c.Log.Debug("home configuration directory", "path", homeConfigPath)
 
+	// Check for a newer version in the background.
+	c.startVersionCheck()
+
 	// Setup our base directory for context management
 	contextStorage, err := clicontext.NewStorage(
 		clicontext.WithDir(filepath.Join(homeConfigPath, "context")))
@@ -220,170 +723,397 @@ func (c *baseCommand) Init(opts ...Option) error {
 	}
 	c.contextStorage = contextStorage
 
-	// load workspace from cli/env/storage
+	// Apply any session context values before we read them back below.
+	if len(c.flagContextSet) > 0 {
+		if err := setSessionContext(c.homeConfigPath, c.flagContextSet); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return err
+		}
+	}
+
+	// load workspace from cli/env/session/storage
 	workspace, err := c.workspace()
 	if err != nil {
 		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
 		return err
 	}
 
+	// A fallback chain starts with its first workspace until we can check
+	// which exist once we have a client.
+	if chain := splitWorkspaceChain(workspace); len(chain) > 1 {
+		c.workspaceChain = chain
+		workspace = chain[0]
+	}
+
 	c.refWorkspace = &pb.Ref_Workspace{Workspace: workspace}
+	if c.atVerbosity(verbosityDebug) {
+		c.ui.Output("Workspace: %s", workspace, terminal.WithInfoStyle())
+	}
+
+	// Read any -var values that reference Vault before they're used.
+	if err := c.initVaultVars(); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+
+	// Split out app-scoped -var values, which are only set on the jobs
+	// for the app they're scoped to.
+	flagVars, appVars, err := splitAppVars(c.flagVars)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+
+	// Collect variable values from -var and -varfile flags,
+	// and env vars set with WP_VAR_* and set them on the job. These are
+	// loaded before the configuration so that they're also available as
+	// "var.<name>" while evaluating it, for example in the runner block.
+	//
+	// Auto files from any -auto-var-dir are loaded as var files with a
+	// lower precedence than the -var-file files.
+	autoVarFiles, err := c.autoVarDirFiles()
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+	stopTiming := c.timePhase("variables")
+	varFiles := append(autoVarFiles, c.flagVarFile...)
+	precedence := variables.Precedence(c.flagVarPrecedence)
+	vars, readFiles, diags := variables.LoadVariableValuesPrecedence(
+		flagVars, varFiles, precedence)
+	if !diags.HasErrors() {
+		var appDiags hcl.Diagnostics
+		c.appVariables, appDiags = loadAppVariables(flagVars, appVars, varFiles, precedence)
+		if appDiags.HasErrors() {
+			diags = appDiags
+		}
+	}
+	stopTiming()
+	if diags.HasErrors() {
+		// we only return errors for file parsing, so we are specific
+		// in the error log here
+		c.logError(c.Log, "failed to load wpvars file", errors.New(diags.Error()))
+		return diags
+	}
+	if err := c.checkVarWarnings(diags); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+	c.variables = vars
+	c.autoVarFiles = autoVarFiles
+	c.varFiles = readFiles[len(autoVarFiles):]
+
+	// Replay the variable values from a lockfile if requested. App-scoped
+	// values are -var values too, so those can't be combined with it.
+	if c.flagVarLock != "" {
+		if err := c.initVarLock(c.flagVars); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return err
+		}
+	}
 
 	// Parse the configuration
 	c.cfg = &config.Config{}
 
-	// If we have an app target requirement, we have to get it from the args
-	// or the config.
-	if baseCfg.AppTargetRequired {
-		// If we have args, attempt to extract there first.
-		if len(c.args) > 0 {
-			match := reAppTarget.FindStringSubmatch(c.args[0])
-			if match != nil {
-				// Set our refs
-				c.refProject = &pb.Ref_Project{Project: match[1]}
-				c.refApp = &pb.Ref_Application{
-					Project:     match[1],
-					Application: match[2],
-				}
+	// Determine our targets from the args and flags. If that isn't enough,
+	// we need to load the config to determine them.
+	refs := &refFlags{
+		Project:               c.flagProject,
+		Projects:              c.flagProjects,
+		App:                   c.flagApp,
+		AppTargetRequired:     baseCfg.AppTargetRequired,
+		AppOptional:           baseCfg.AppOptional,
+		ProjectTargetRequired: baseCfg.ProjectTargetRequired,
+	}
+	resolved, err := resolveRefs(c.args, refs, nil)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+	if resolved.NeedConfig {
+		baseCfg.Config = true
+	}
+
+	// If we're loading the config, then get it.
+	if baseCfg.Config {
+		stopTiming := c.timePhase("config load")
+		cfg, err := c.initConfig("")
+		stopTiming()
+		if err != nil {
+			// A missing configuration is tolerated if it is optional,
+			// but a configuration that fails to parse is always an error.
+			if !baseCfg.ConfigOptional || !errors.Is(err, ErrConfigNotFound) {
+				c.logError(c.Log, "failed to load config", err)
+				return err
+			}
 
-				// Shift the args
-				c.args = c.args[1:]
+			c.Log.Debug("no configuration found, continuing since it is optional")
+		}
 
-				// Explicitly set remote
-				c.flagRemote = true
+		c.cfg = cfg
+		if cfg != nil {
+			if resolved, err = resolveRefs(c.args, refs, cfg); err != nil {
+				c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+				return err
 			}
 		}
+	}
 
-		// If we didn't get our ref, then we need to load config
-		if c.refApp == nil {
-			baseCfg.Config = true
+	// Set our refs. A target given as an argument implies remote since we
+	// don't have the local configuration for it.
+	if resolved.Project != nil {
+		c.refProject = resolved.Project
+	}
+	if resolved.App != nil {
+		c.refApp = resolved.App
+	}
+	c.args = resolved.Args
+	if resolved.Remote {
+		c.flagRemote = true
+	}
+
+	// Forward any runner env vars now that we know if we're remote.
+	if err := c.initRunnerEnv(); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+	if err := c.initRunnerLabels(); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
+
+	// Annotate the jobs with build provenance. This is only done for
+	// operation commands, since those are what produce deployments.
+	if baseCfg.Flags.Defined("annotation") {
+		if err := c.initAnnotations(); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return err
 		}
 	}
 
-	// Some CLIs don't explicitly need an app, but sometimes need to load a config
-	// and setup the project client with the proper project refs.
-	if baseCfg.AppOptional || baseCfg.ProjectTargetRequired {
-		// If we have args, attempt to extract there first.
-		if len(c.args) > 0 {
-			match := reAppTarget.FindStringSubmatch(c.args[0])
-			if match != nil {
-				// Set our refs
-				c.refProject = &pb.Ref_Project{Project: match[1]}
-				c.refApp = &pb.Ref_Application{
-					Project:     match[1],
-					Application: match[2],
-				}
+	// Forward the config profile so that remote runners select it too.
+	if c.flagConfigProfile != "" {
+		if c.flagLabels == nil {
+			c.flagLabels = map[string]string{}
+		}
+		c.flagLabels[runnerpkg.JobConfigProfileLabel] = c.flagConfigProfile
+	}
 
-				// Shift the args
-				c.args = c.args[1:]
+	// Require a clean git working tree if requested. This checks the
+	// directory of the configuration, which is the project being operated on.
+	if c.flagRequireClean && !c.flagAllowDirty {
+		dir := "."
+		if c.configPath != "" {
+			dir = filepath.Dir(c.configPath)
+		}
 
-				// Explicitly set remote
-				c.flagRemote = true
+		if err := c.checkCleanTree(dir); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return ErrSentinel
+		}
+	}
 
-				// the below should only be used for commands that don't accept
-				// other arguments
-			} else if !baseCfg.ProjectTargetRequired {
-				// Assume the target is just project
-				p := c.args[0]
-				c.refProject = &pb.Ref_Project{Project: p}
-				// We don't explicitly set the app because there was none requested,
-				// and we might or might not be working on an app later.
+	// If an app was targeted with -app, make sure it exists so that a typo
+	// doesn't get all the way to a runner before failing.
+	if err := c.checkConfigApp(); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return ErrSentinel
+	}
 
-				// Shift the args
-				c.args = c.args[1:]
+	// Make sure the env vars the configuration requires are set so that
+	// a misconfigured environment fails before we talk to the server.
+	if err := c.checkRequiredEnv(); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
 
-				// Explicitly set remote
-				c.flagRemote = true
-			}
+	// Operations on a protected workspace require confirmation.
+	if baseCfg.ProtectedWorkspaceCheck {
+		if err := c.checkProtectedWorkspace(); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return ErrSentinel
 		}
+	}
 
-		// If we didn't get our ref, then we need to load config
-		if c.refApp == nil && c.refProject == nil {
-			// We look at both app and project for the case where no target was specified
-			// i.e. already in a project directory
-			baseCfg.Config = true
+	// Variables read from Vault are secrets, regardless of their declaration.
+	c.markVaultVarsSensitive()
+
+	// Snapshot the resolved variable values if requested.
+	if c.flagWriteVarLock {
+		if err := writeVarLock(varLockFile, newVarLock(c.variables, c.inputVariables())); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return err
 		}
+
+		c.ui.Output("Wrote the resolved variable values to %s.", varLockFile,
+			terminal.WithInfoStyle())
 	}
 
-	// If we're loading the config, then get it.
-	if baseCfg.Config {
-		cfg, err := c.initConfig("", baseCfg.ConfigOptional)
-		if err != nil {
-			c.logError(c.Log, "failed to load config", err)
+	// Export the resolved variable values for other tools if requested.
+	if c.flagVarExport != "" {
+		if err := writeVarExport(c.flagVarExport, c.variables,
+			c.inputVariables(), c.flagVarExportSensitive); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
 			return err
 		}
 
-		c.cfg = cfg
-		if cfg != nil {
-			project := &pb.Ref_Project{Project: cfg.Project}
+		c.Log.Debug("wrote the resolved variable values", "path", c.flagVarExport)
+	} else if c.flagVarExportSensitive {
+		err := errors.New("The -var-export-include-sensitive flag requires -var-export.")
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
+	}
 
-			// If we're loading config, we'll have a project, and we set it now.
-			// If they didn't provide a value via flag, we default to
-			// the project from initConfig.
-			if c.flagProject != "" {
-				project = &pb.Ref_Project{Project: c.flagProject}
-			}
-			if c.refProject == nil {
-				c.refProject = project
-			}
+	// If we're targeting multiple projects without a local config, then
+	// the primary project comes from the flag.
+	if c.refProject == nil && len(c.flagProjects) > 1 {
+		c.refProject = &pb.Ref_Project{Project: c.flagProject}
+	}
 
-			// If we require an app target and we still haven't set it,
-			// and the user provided it via the CLI, set it now. This code
-			// path is only reached if it wasn't set via the args either
-			// above.
-			if baseCfg.AppTargetRequired &&
-				c.refApp == nil &&
-				c.flagApp != "" {
-				c.refApp = &pb.Ref_Application{
-					Project:     project.Project,
-					Application: c.flagApp,
-				}
-			}
+	// If the context has a default data source ref and it wasn't overridden,
+	// make sure we have an overrides map to set it on once we know the
+	// project's data source below. This only applies to remote operations.
+	var dataSourceRef string
+	if c.flagRemote {
+		dataSourceRef, err = c.contextDataSourceRef()
+		if err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return err
 		}
 	}
+	if _, ok := c.flagRemoteSource["ref"]; ok {
+		dataSourceRef = ""
+	}
+	if dataSourceRef != "" && c.flagRemoteSource == nil {
+		c.flagRemoteSource = map[string]string{}
+	}
 
-	// Collect variable values from -var and -varfile flags,
-	// and env vars set with WP_VAR_* and set them on the job
-	vars, diags := variables.LoadVariableValues(c.flagVars, c.flagVarFile)
-	if diags.HasErrors() {
-		// we only return errors for file parsing, so we are specific
-		// in the error log here
-		c.logError(c.Log, "failed to load wpvars file", errors.New(diags.Error()))
-		return diags
+	// Open the file to export jobs to, if requested.
+	if c.flagExportJob != "" && !c.flagPrintJob {
+		if c.exportJobFile, err = os.Create(c.flagExportJob); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return err
+		}
+	}
+
+	// Create the directory for the auto server's state, if set.
+	if err := c.initAutoServerDir(); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return err
 	}
-	c.variables = vars
 
 	// Create our client
 	if baseCfg.Client {
+		stopTiming := c.timePhase("client connect")
 		c.project, err = c.initClient(nil)
+		stopTiming()
 		if err != nil {
 			c.logError(c.Log, "failed to create client", err)
 			return err
 		}
-	}
 
-	// Validate remote vs. local operations.
-	if !baseCfg.AppOptional {
-		if c.flagRemote && c.refApp == nil {
-			if c.cfg == nil || c.cfg.Runner == nil || !c.cfg.Runner.Enabled {
-				err := errors.New(
-					"The `-remote` flag was specified but remote operations are not supported\n" +
-						"for this project.\n\n" +
-						"Remote operations must be manually enabled by using setting the 'runner.enabled'\n" +
-						"setting in your Waypoint configuration file. Please see the documentation\n" +
-						"on this setting for more information.")
-				c.logError(c.Log, "", err)
+		// Select the workspace from a fallback chain. Otherwise, warn if
+		// the workspace isn't one we've seen on this server.
+		if len(c.workspaceChain) > 1 {
+			if err := c.resolveWorkspaceChain(); err != nil {
+				c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
 				return err
 			}
+		} else {
+			c.checkWorkspaceCache()
 		}
-	}
 
-	// If this is a single app mode then make sure that we only have
-	// one app or that we have an app target.
+		// Warn if this context was last used with a different project,
+		// which is often a sign of the wrong context. This is only done
+		// for operation commands, since those are what cause harm.
+		if baseCfg.Flags.Defined("remote") {
+			c.checkLastProject()
+		}
+
+		// Resolve the projects matching -project-filter. DoApp iterates
+		// over these the same as a repeated -project flag.
+		if c.flagProjectFilter != "" {
+			c.flagProjects, err = c.projectsBySelector(c.Ctx, c.flagProjectFilter)
+			if err != nil {
+				c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+				return err
+			}
+			c.flagProject = c.flagProjects[0]
+		}
+
+		// Make sure a remote runner can use the project's data source,
+		// falling back to a local runner if it can't.
+		if !baseCfg.NoRemoteCheck {
+			stopTiming := c.timePhase("remote check")
+			err := c.initRemoteDataSource()
+			stopTiming()
+			if err != nil {
+				c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+				return err
+			}
+		}
+
+		// Track our local runner so we can detect it if we exit uncleanly.
+		if id, ok := c.project.LocalRunnerId(); ok && c.homeConfigPath != "" {
+			c.initLocalRunnerTracking(id)
+		}
+
+		if dataSourceRef != "" {
+			c.initDataSourceRef(dataSourceRef)
+		}
+
+		// Don't let a remote operation use the default ref if requested.
+		if err := c.checkExplicitRef(); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return err
+		}
+
+		// Now that we know the connection works, save it if requested.
+		if c.flagContextCreate != "" {
+			if err := c.initContextCreate(c.flagContextCreate); err != nil {
+				c.logError(c.Log, "failed to create context", err)
+				return err
+			}
+		}
+	}
+
+	// Validate remote vs. local operations.
+	if !baseCfg.AppOptional && !baseCfg.NoRemoteCheck {
+		if c.flagRemote && c.refApp == nil {
+			if c.cfg == nil || c.cfg.Runner == nil || !c.cfg.Runner.Enabled {
+				err := errors.New(
+					"The `-remote` flag was specified but remote operations are not supported\n" +
+						"for this project.\n\n" +
+						"Remote operations must be manually enabled by using setting the 'runner.enabled'\n" +
+						"setting in your Waypoint configuration file. Please see the documentation\n" +
+						"on this setting for more information.")
+				c.logError(c.Log, "", err)
+				return err
+			}
+		}
+	}
+
+	// If this is a single app mode then make sure that we only have
+	// one app or that we have an app target.
 	if baseCfg.AppTargetRequired {
+		// In a multi-app layout, target the app we're within, if any.
 		if c.refApp == nil {
-			if len(c.cfg.Apps()) != 1 {
-				c.ui.Output(errAppModeSingle, terminal.WithErrorStyle())
+			if name := c.appFromWorkingDir(); name != "" {
+				c.refApp = &pb.Ref_Application{
+					Project:     c.cfg.Project,
+					Application: name,
+				}
+			}
+		}
+
+		if c.refApp == nil {
+			if c.cfg == nil || len(c.cfg.Apps()) != 1 {
+				msg := errAppModeSingle
+				if c.cfg != nil {
+					msg += "\n\n" + configAppList(c.cfg)
+				}
+
+				c.ui.Output(msg, terminal.WithErrorStyle())
 				return ErrSentinel
 			}
 
@@ -397,6 +1127,90 @@ func (c *baseCommand) Init(opts ...Option) error {
 	return nil
 }
 
+// runApp calls the callback for a single app with doAppResult, recording
+// the output and result of the app to the -output-dir, if set.
+func (c *baseCommand) runApp(
+	ctx context.Context,
+	app *clientpkg.App,
+	f func(context.Context, *clientpkg.App) (interface{}, error),
+) AppResult {
+	out, err := c.startAppOutputDir(app)
+	if err != nil {
+		return AppResult{
+			Project: app.Ref().Project,
+			App:     app.Ref().Application,
+			Status:  AppResultError,
+			Err:     fmt.Errorf("Error creating the -output-dir for the app: %s", err),
+			Failure: AppFailureOther,
+		}
+	}
+
+	// The app is part of the targeting recorded with the jobs.
+	ctx = grpcmetadata.AddTargeting(ctx, map[string]string{
+		"app": app.Ref().Application,
+	})
+
+	result := doAppResult(ctx, app, c.flagAppTimeout, f)
+	if err := out.Finish(result); err != nil {
+		c.Log.Warn("error recording the app output", "app", result.App, "error", err)
+	}
+
+	return result
+}
+
+// targeting returns how this command resolved the target of its
+// operations, which is sent to the server with grpcmetadata.AddTargeting
+// so that it is recorded with each job. This must never include secrets,
+// so the server address is stripped of any credentials.
+func (c *baseCommand) targeting() map[string]string {
+	result := map[string]string{}
+	if c.refProject != nil {
+		result["project"] = c.refProject.Project
+	}
+	if c.refWorkspace != nil {
+		result["workspace"] = c.refWorkspace.Workspace
+	}
+	if c.project != nil {
+		result["remote"] = strconv.FormatBool(!c.project.Local())
+	}
+	if c.clientContext != nil {
+		addr := c.clientContext.Server.Address
+		if idx := strings.LastIndex(addr, "@"); idx != -1 {
+			addr = addr[idx+1:]
+		}
+		if addr != "" {
+			result["server"] = addr
+		}
+	}
+
+	return result
+}
+
+// appFromWorkingDir returns the app whose path contains the working
+// directory if there are multiple apps, so that "cd" into an app and
+// running a command targets that app. This returns an empty string if no
+// app or more than one app matches. See config.AppForDir.
+func (c *baseCommand) appFromWorkingDir() string {
+	if c.cfg == nil || len(c.cfg.Apps()) < 2 {
+		return ""
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	name := c.cfg.AppForDir(wd)
+	if name != "" {
+		c.Log.Debug("targeting app from the working directory", "app", name, "dir", wd)
+		if c.atVerbosity(verbosityNormal) {
+			c.ui.Output("Targeting app %q from the current directory.", name, terminal.WithInfoStyle())
+		}
+	}
+
+	return name
+}
+
 // DoApp calls the callback for each app. This lets you execute logic
 // in an app-specific context safely. This automatically handles any
 // parallelization, waiting, and error handling. Your code should be
@@ -409,30 +1223,68 @@ func (c *baseCommand) Init(opts ...Option) error {
 // the callback closure properties to cancel the passed in context. This
 // will stop any remaining callbacks and exit early.
 func (c *baseCommand) DoApp(ctx context.Context, f func(context.Context, *clientpkg.App) error) error {
+	_, err := c.DoAppResults(ctx, func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+		return nil, f(ctx, app)
+	})
+	return err
+}
+
+// DoAppResults is the same as DoApp except the callback can return a
+// payload for each app. The result for every app that the callback was
+// called for is returned along with the aggregated error, so that callers
+// can inspect the outcome of each app programmatically.
+func (c *baseCommand) DoAppResults(
+	ctx context.Context,
+	f func(context.Context, *clientpkg.App) (interface{}, error),
+) ([]AppResult, error) {
+	// If we're targeting multiple projects, then iterate over each.
+	if len(c.flagProjects) > 1 || c.flagProjectFilter != "" {
+		return c.DoProjects(ctx, f)
+	}
+
+	// Never execute locally if local runners are forbidden. Init already
+	// requires remote execution, so this only guards against a fallback.
+	if c.flagNoLocalRunner && c.project != nil && c.project.Local() {
+		c.ui.Output(errNoLocalRunner, terminal.WithErrorStyle())
+		return nil, ErrSentinel
+	}
+
+	start := time.Now()
+	defer func() { c.metrics.doAppDuration += time.Since(start) }()
+
 	var appTargets []string
 
 	// If the user specified a project flag, we want only the apps
 	// that are assigned to that project
 	if c.flagProject != "" {
-		client := c.project.Client()
-		projectTarget := &pb.Ref_Project{Project: c.flagProject}
-		resp, err := client.GetProject(c.Ctx, &pb.GetProjectRequest{
-			Project: &pb.Ref_Project{
-				Project: projectTarget.Project,
-			},
-		})
+		project, err := c.getProject(ctx)
 		if err != nil {
 			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-			return ErrSentinel
+			return nil, ErrSentinel
 		}
-		project := resp.Project
 
 		for _, a := range project.Applications {
 			appTargets = append(appTargets, a.Name)
 		}
 	}
 
-	if c.flagApp != "" {
+	// A -app value with glob metacharacters expands to all matching apps.
+	appGlob := false
+	if c.flagApp != "" && isAppGlob(c.flagApp) {
+		known := appTargets
+		if c.cfg != nil && len(known) == 0 {
+			known = c.cfg.Apps()
+		}
+
+		matched, err := matchApps(c.flagApp, known)
+		if err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return nil, ErrSentinel
+		}
+
+		appTargets = matched
+		appGlob = true
+	} else if c.flagApp != "" {
 		c.refApp = &pb.Ref_Application{
 			Application: c.flagApp,
 		}
@@ -440,52 +1292,899 @@ func (c *baseCommand) DoApp(ctx context.Context, f func(context.Context, *client
 
 	// if we specifically target an app, we no longer care about the rest
 	// of the apps in the project that we set above
-	if c.refApp != nil {
-		appTargets = []string{c.refApp.Application}
-	} else if c.cfg != nil && len(appTargets) == 0 {
-		appTargets = append(appTargets, c.cfg.Apps()...)
+	if !appGlob {
+		if c.refApp != nil {
+			appTargets = []string{c.refApp.Application}
+		} else if c.cfg != nil && len(appTargets) == 0 {
+			appTargets = append(appTargets, c.cfg.Apps()...)
+		}
+	}
+
+	// Apply -retry-failed, -app-selector and the app workspaces.
+	appTargets, err := c.filterApps(c.refProject.GetProject(), true, appTargets)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return nil, ErrSentinel
+	}
+
+	// Resolving to no apps is usually a configuration error, so in
+	// automation we fail rather than succeed without doing anything.
+	if len(appTargets) == 0 && c.flagFailOnNoApps {
+		c.ui.Output(ErrNoApps.Error(), terminal.WithErrorStyle())
+		return nil, ErrNoApps
+	}
+
+	// Make sure the apps exist on the server so that a remote operation
+	// doesn't fail confusingly once it reaches a runner.
+	if c.serverAppCheck {
+		if err := c.checkServerApps(ctx, appTargets); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return nil, ErrSentinel
+		}
+	}
+
+	// Any app-scoped variables must target an app we know about.
+	if len(c.appVariables) > 0 {
+		known := appTargets
+		if c.cfg != nil {
+			known = c.cfg.Apps()
+		} else if c.flagProject != "" {
+			known = nil
+			project, err := c.getProject(ctx)
+			if err != nil {
+				c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+				return nil, ErrSentinel
+			}
+			for _, a := range project.Applications {
+				known = append(known, a.Name)
+			}
+		}
+
+		if err := c.validateAppVars(known); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return nil, ErrSentinel
+		}
 	}
 
 	var apps []*clientpkg.App
 	for _, appName := range appTargets {
 		app := c.project.App(appName)
 		c.Log.Debug("will operate on app", "name", appName)
+		if c.atVerbosity(verbosityVerbose) {
+			c.ui.Output("Operating on app: %s", appName, terminal.WithInfoStyle())
+		}
 		apps = append(apps, app)
 	}
+	c.metrics.appsTargeted += len(apps)
 
 	// Inject the metadata about the client, such as the runner id if it is running
 	// a local runner.
 	if id, ok := c.project.LocalRunnerId(); ok {
 		ctx = grpcmetadata.AddRunner(ctx, id)
 	}
+	ctx = grpcmetadata.AddTargeting(ctx, c.targeting())
+
+	// If we're only checking, we stop now that the targets are resolved.
+	if c.flagCheckOnly {
+		return nil, c.checkOnly(ctx, appTargets)
+	}
+
+	// Load the checkpoint if we're resuming or creating one.
+	checkpoint, err := c.initCheckpoint()
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return nil, ErrSentinel
+	}
 
-	// Just a serialize loop for now, one day we'll parallelize.
+	// Just a serialize loop for now, one day we'll parallelize. The
+	// outcome of each app is output as soon as its callback returns.
+	stream := c.streamAppResults(len(apps))
+	var results []AppResult
 	var finalErr error
 	var didErrSentinel bool
 	for _, app := range apps {
 		// Support cancellation
 		if err := ctx.Err(); err != nil {
-			return err
+			return results, err
+		}
+
+		if checkpoint != nil && checkpoint.Done(app.Ref().Application) {
+			c.ui.Output("Skipping app %q, it completed in run %s.",
+				app.Ref().Application, checkpoint.RunId, terminal.WithInfoStyle())
+			continue
+		}
+
+		result := c.runApp(ctx, app, f)
+		results = append(results, result)
+		c.recordTiming("app "+result.App, result.Duration)
+		if stream {
+			c.outputAppResult(result)
+		}
+		if err := result.Err; err != nil {
+			if !errors.Is(err, ErrSentinel) {
+				finalErr = multierror.Append(finalErr, err)
+			} else {
+				didErrSentinel = true
+			}
+		} else if checkpoint != nil {
+			if err := checkpoint.Complete(result.App); err != nil {
+				c.Log.Warn("error writing checkpoint", "error", err)
+			}
+		}
+	}
+	if checkpoint != nil {
+		if finalErr == nil && !didErrSentinel {
+			if err := checkpoint.Remove(); err != nil {
+				c.Log.Warn("error removing checkpoint", "error", err)
+			}
+		} else {
+			c.ui.Output(infoResumeCheckpoint, checkpoint.RunId, terminal.WithInfoStyle())
+		}
+	}
+	if c.outputSummary() {
+		c.outputRunSummary(results)
+		c.outputFailureSummary(results)
+	}
+	if c.outputSummaryJSON() {
+		c.outputResultsSummaryJSON(results)
+	}
+	if !c.flagPrintJob {
+		c.recordFailedApps(c.refProject.GetProject(), results)
+	}
+	if finalErr == nil && didErrSentinel {
+		finalErr = ErrSentinel
+	}
+
+	return results, finalErr
+}
+
+// httpClientOrDefault returns the client for HTTP requests that aren't to
+// the Waypoint server, as set with WithHTTPClient.
+func (c *baseCommand) httpClientOrDefault() *http.Client {
+	if c.httpClient != nil {
+		return c.httpClient
+	}
+
+	return http.DefaultClient
+}
+
+// checkVarWarnings handles the warnings from loading the variable values.
+// These are only logged unless "-strict-vars" is set, in which case any
+// warning is an error with the full diagnostic text.
+func (c *baseCommand) checkVarWarnings(diags hcl.Diagnostics) error {
+	if len(diags) == 0 {
+		return nil
+	}
+
+	if c.flagStrictVars {
+		return fmt.Errorf(
+			"Loading the variable values produced warnings, which are errors\n"+
+				"with -strict-vars:\n\n%s", diags.Error())
+	}
+
+	for _, diag := range diags {
+		c.Log.Warn("warning loading variable values",
+			"summary", diag.Summary, "detail", diag.Detail)
+	}
+
+	return nil
+}
+
+// initRunnerEnv validates the "-runner-env" and "-runner-env-sensitive"
+// flags and forwards them to the jobs for this command as labels so that
+// the runner sets them while executing. Sensitive values are never sent,
+// only the name of the runner env var to read them from, since labels are
+// stored with the job. Local runners execute within the CLI and share its
+// environment, so these are only valid for remote operations.
+func (c *baseCommand) initRunnerEnv() error {
+	if len(c.flagRunnerEnv) == 0 && len(c.flagRunnerEnvSensitive) == 0 {
+		return nil
+	}
+
+	if !c.flagRemote {
+		return errors.New(
+			"The -runner-env and -runner-env-sensitive flags require a remote runner.\n" +
+				"Local operations use the environment of the CLI, so set the env vars\n" +
+				"directly or use -remote to execute on a remote runner.")
+	}
+
+	if c.flagLabels == nil {
+		c.flagLabels = map[string]string{}
+	}
+	for k, v := range c.flagRunnerEnv {
+		if k == "" {
+			return errors.New("The -runner-env flag requires a KEY=VALUE pair with a non-empty KEY.")
+		}
+		if _, ok := c.flagRunnerEnvSensitive[k]; ok {
+			return fmt.Errorf(
+				"The env var %q was set with both -runner-env and -runner-env-sensitive.", k)
+		}
+
+		c.flagLabels[runnerpkg.JobEnvLabelPrefix+k] = v
+	}
+	for k, v := range c.flagRunnerEnvSensitive {
+		if k == "" || v == "" {
+			return errors.New(
+				"The -runner-env-sensitive flag requires a KEY=NAME pair, where NAME is\n" +
+					"the env var of the runner to read the value from.")
+		}
+
+		c.flagLabels[runnerpkg.JobEnvSensitiveLabelPrefix+k] = v
+	}
+
+	return nil
+}
+
+// initRunnerLabels validates the "-runner-label" flags and forwards them to
+// the jobs for this command as labels so that only runners with matching
+// labels execute them. Local runners always execute the jobs of the CLI,
+// so these are only valid for remote operations.
+//
+// This can't check that a matching runner exists. Runners don't register
+// their labels with the server and there is no RPC to list runners, so a
+// runner only compares the labels when it is assigned the job. A job that
+// no runner matches stays queued until it expires.
+func (c *baseCommand) initRunnerLabels() error {
+	if len(c.flagRunnerLabels) == 0 {
+		return nil
+	}
+
+	if !c.flagRemote {
+		return errors.New(
+			"The -runner-label flag requires a remote runner. Local operations\n" +
+				"always execute on the local runner of the CLI.")
+	}
+
+	if c.flagLabels == nil {
+		c.flagLabels = map[string]string{}
+	}
+	for k, v := range c.flagRunnerLabels {
+		if k == "" {
+			return errors.New("The -runner-label flag requires a KEY=VALUE pair with a non-empty KEY.")
+		}
+
+		c.flagLabels[runnerpkg.JobRunnerLabelPrefix+k] = v
+	}
+
+	return nil
+}
+
+// checkConfigApp verifies that the app targeted with "-app" is defined in
+// the local configuration. This is skipped if the operation may use another
+// configuration, such as for remote operations or another project.
+func (c *baseCommand) checkConfigApp() error {
+	if c.cfg == nil || c.flagApp == "" || isAppGlob(c.flagApp) || c.flagRemote {
+		return nil
+	}
+	if len(c.flagProjects) > 1 || c.flagProjectFilter != "" ||
+		(c.flagProject != "" && c.flagProject != c.cfg.Project) {
+		return nil
+	}
+
+	for _, name := range c.cfg.Apps() {
+		if name == c.flagApp {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("The app %q is not defined in the configuration.\n\n%s",
+		c.flagApp, configAppList(c.cfg))
+}
+
+// initVarLock replaces the variable values with those from the lockfile
+// set with "-var-lock". flagVars are the -var values, which can't be
+// combined with a lockfile since the replay wouldn't be identical.
+func (c *baseCommand) initVarLock(flagVars map[string]string) error {
+	if len(flagVars) > 0 || len(c.flagVarFile) > 0 {
+		return errors.New(
+			"The -var-lock flag can't be combined with -var or -var-file since\n" +
+				"the variable values are replayed from the lockfile.")
+	}
+
+	lock, err := readVarLock(c.flagVarLock)
+	if err != nil {
+		return err
+	}
+
+	vars, missing, err := replayVarLock(c.flagVarLock, lock, c.variables)
+	if err != nil {
+		return err
+	}
+	c.variables = vars
+
+	if len(missing) > 0 {
+		c.ui.Output(warnVarLockSensitiveMissing, strings.Join(missing, ", "),
+			terminal.WithWarningStyle())
+	}
+
+	return nil
+}
+
+// checkRequiredEnv verifies that the env vars listed in the "required_env"
+// setting of the configuration are set. This only checks the local env, so
+// it is skipped for remote operations which use the env of the runner.
+func (c *baseCommand) checkRequiredEnv() error {
+	if !c.envCheck || c.flagSkipEnvCheck || c.flagRemote || c.cfg == nil {
+		return nil
+	}
+
+	missing := c.cfg.MissingEnv()
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"The configuration requires the following env vars, which aren't set:\n\n"+
+			"  %s\n\n"+
+			"Set them and try again, or use -skip-env-check to skip this check.",
+		strings.Join(missing, "\n  "))
+}
+
+// initServerTLS applies "-server-insecure" and warns if TLS was explicitly
+// disabled for the flag-based connection. tlsSet is whether "-server-tls"
+// was set.
+func (c *baseCommand) initServerTLS(tlsSet bool) error {
+	if c.flagServerInsecure {
+		if tlsSet && c.flagConnection.Server.Tls {
+			return errors.New(
+				"The -server-insecure and -server-tls flags conflict. Please set only one.")
+		}
+
+		c.flagConnection.Server.Tls = false
+	}
+
+	c.serverTLSDisabled = c.flagServerInsecure || (tlsSet && !c.flagConnection.Server.Tls)
+
+	// The flags only have an effect if we're connecting using the flags.
+	if c.serverTLSDisabled && c.flagConnection.Server.Address != "" {
+		c.ui.Output(warnServerInsecure, c.flagConnection.Server.Address,
+			terminal.WithWarningStyle())
+	}
+
+	return nil
+}
+
+// initNoLocalRunner applies "-no-local-runner", or EnvNoLocalRunner if the
+// flag wasn't set. When local runners are forbidden, operations default to
+// executing remotely and an explicit -local is an error. flagSet is whether
+// "-no-local-runner" was set.
+func (c *baseCommand) initNoLocalRunner(flagSet bool) error {
+	if !flagSet {
+		v, err := env.GetBool(EnvNoLocalRunner, false)
+		if err != nil {
+			return fmt.Errorf("Invalid value for %s: %s", EnvNoLocalRunner, err)
+		}
+
+		c.flagNoLocalRunner = v
+	}
+
+	if !c.flagNoLocalRunner || !c.autoServer {
+		return nil
+	}
+
+	if c.flagLocal {
+		return errors.New(
+			"The -local flag can't be used since local runners are forbidden by\n" +
+				"-no-local-runner or the " + EnvNoLocalRunner + " env var.")
+	}
+
+	c.flagRemote = true
+	return nil
+}
+
+// initPhases validates the "-only" flag against the phases supported by
+// the command and records the selected phases.
+func (c *baseCommand) initPhases(baseCfg *baseConfig) error {
+	if len(baseCfg.Phases) == 0 {
+		return errors.New(
+			"The -only flag is only supported by commands that perform multiple\n" +
+				"phases, such as \"waypoint up\".")
+	}
+
+	supported := map[string]bool{}
+	for _, p := range baseCfg.Phases {
+		supported[p] = true
+	}
+
+	c.phases = map[string]bool{}
+	for _, p := range c.flagOnly {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if !supported[p] {
+			return fmt.Errorf(
+				"Invalid phase %q for -only. Valid phases are: %s",
+				p, strings.Join(baseCfg.Phases, ", "))
 		}
 
-		if err := f(ctx, app); err != nil {
-			if err != ErrSentinel {
+		c.phases[p] = true
+	}
+
+	return nil
+}
+
+// phaseEnabled returns true if the phase should be performed. All phases
+// are enabled unless a subset was selected with "-only".
+func (c *baseCommand) phaseEnabled(phase string) bool {
+	return c.phases == nil || c.phases[phase]
+}
+
+// getProject returns the server record for the targeted project. The
+// project is only fetched once per invocation and cached for subsequent
+// calls, so this should be preferred over calling GetProject directly.
+// If the project doesn't exist on the server, a user-friendly error is
+// returned that suggests any projects with a similar name.
+func (c *baseCommand) getProject(ctx context.Context) (*pb.Project, error) {
+	if c.projectRecord != nil {
+		return c.projectRecord, nil
+	}
+
+	if c.refProject == nil {
+		return nil, errors.New("No project is targeted. Specify a project with the `-project` flag.")
+	}
+
+	resp, err := c.project.Client().GetProject(ctx, &pb.GetProjectRequest{
+		Project: c.refProject,
+	})
+	if status.Code(err) == codes.NotFound {
+		return nil, c.projectNotFoundError(ctx, c.refProject.Project)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.projectRecord = resp.Project
+	return c.projectRecord, nil
+}
+
+// DoProjects calls the callback for each app in each of the projects
+// targeted with a repeated -project flag. The apps of each project are
+// resolved from the project record on the server rather than from the local
+// configuration, and the operations execute on remote runners.
+//
+// The error handling is the same as DoApp: errors for each project are
+// aggregated and a summary of the result for each project is output once
+// all projects have been processed.
+func (c *baseCommand) DoProjects(
+	ctx context.Context,
+	f func(context.Context, *clientpkg.App) (interface{}, error),
+) ([]AppResult, error) {
+	start := time.Now()
+	defer func() { c.metrics.doAppDuration += time.Since(start) }()
+
+	tbl := terminal.NewTable("Project", "Apps", "Result")
+
+	var results []AppResult
+	var finalErr error
+	var didErrSentinel bool
+	for _, name := range c.flagProjects {
+		// Support cancellation
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		if !c.commandJSON && !c.flagResultsJSON {
+			c.ui.Output("Project: %s", name, terminal.WithHeaderStyle())
+		}
+		apps, projectResults, err := c.doProject(ctx, name, f)
+		results = append(results, projectResults...)
+
+		result, color := "success", terminal.Green
+		if err != nil {
+			result, color = "failed", terminal.Red
+			if !errors.Is(err, ErrSentinel) {
+				c.logError(c.Log, fmt.Sprintf("project %q", name), err)
 				finalErr = multierror.Append(finalErr, err)
 			} else {
 				didErrSentinel = true
 			}
 		}
+
+		tbl.Rich([]string{
+			name,
+			strings.Join(apps, ", "),
+			result,
+		}, []string{
+			"",
+			"",
+			color,
+		})
 	}
+
+	if c.outputSummary() {
+		c.ui.Output("")
+		c.ui.Table(tbl)
+		c.outputFailureSummary(results)
+	}
+	if c.outputSummaryJSON() {
+		c.outputResultsSummaryJSON(results)
+	}
+
 	if finalErr == nil && didErrSentinel {
 		finalErr = ErrSentinel
 	}
+	if finalErr == nil && len(results) == 0 && c.flagFailOnNoApps {
+		c.ui.Output(ErrNoApps.Error(), terminal.WithErrorStyle())
+		finalErr = ErrNoApps
+	}
+
+	return results, finalErr
+}
+
+// doProject calls the callback for each app in a single project as part
+// of DoProjects. This returns the names of the apps that were targeted and
+// the result for each.
+func (c *baseCommand) doProject(
+	ctx context.Context,
+	name string,
+	f func(context.Context, *clientpkg.App) (interface{}, error),
+) ([]string, []AppResult, error) {
+	ref := &pb.Ref_Project{Project: name}
+	resp, err := c.project.Client().GetProject(ctx, &pb.GetProjectRequest{
+		Project: ref,
+	})
+	if status.Code(err) == codes.NotFound {
+		err = c.projectNotFoundError(ctx, name)
+	}
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return nil, nil, ErrSentinel
+	}
+
+	var appTargets []string
+	for _, a := range resp.Project.Applications {
+		appTargets = append(appTargets, a.Name)
+	}
+	if c.flagApp != "" {
+		// A pattern that matches nothing in this project is fine since
+		// it may match apps in the other projects.
+		appTargets, _ = matchApps(c.flagApp, appTargets)
+	}
+	// With -retry-failed, a project with no failed apps has nothing to
+	// retry. That isn't an error since other projects may have failures.
+	if c.flagRetryFailed && len(c.recordedFailedApps(name)) == 0 {
+		c.ui.Output("No failed apps to retry in project %q.", name, terminal.WithInfoStyle())
+		return nil, nil, nil
+	}
+
+	// Apply the same filters as for a single project. App labels and
+	// workspaces are only known for the project of the local configuration.
+	local := c.cfg != nil && c.cfg.Project == name
+	appTargets, err = c.filterApps(name, local, appTargets)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return nil, nil, ErrSentinel
+	}
+	if len(appTargets) == 0 {
+		c.ui.Output("No apps to operate on in project %q.", name, terminal.WithWarningStyle())
+		return nil, nil, nil
+	}
+
+	// Retried apps may have been removed from the project since they failed.
+	if c.serverAppCheck {
+		if err := checkProjectApps(resp.Project, appTargets); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return nil, nil, ErrSentinel
+		}
+	}
+
+	// Fetching the project already checked the connection and targets.
+	if c.flagCheckOnly {
+		return appTargets, nil, nil
+	}
+
+	// Build a client for this project. We reuse our existing connection
+	// and always execute remotely since we don't have local data for
+	// any of these projects.
+	opts := []clientpkg.Option{
+		clientpkg.WithClient(c.project.Client()),
+		clientpkg.WithLogger(c.Log),
+		clientpkg.WithProjectRef(ref),
+		clientpkg.WithWorkspaceRef(c.refWorkspace),
+		clientpkg.WithVariables(c.variables),
+		clientpkg.WithAppVariables(c.appVariables),
+		clientpkg.WithLabels(c.flagLabels),
+		clientpkg.WithSourceOverrides(c.flagRemoteSource),
+		clientpkg.WithUI(c.ui),
+	}
+	if c.flagPrintJob {
+		opts = append(opts, clientpkg.WithPrintJob(os.Stdout))
+	}
+	if c.exportJobFile != nil {
+		opts = append(opts, clientpkg.WithExportJob(c.exportJobFile))
+	}
+	project, err := clientpkg.New(ctx, opts...)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return nil, nil, ErrSentinel
+	}
+	defer project.Close()
+	c.projectClients = append(c.projectClients, project)
+
+	var results []AppResult
+	var finalErr error
+	var didErrSentinel bool
+	for _, appName := range appTargets {
+		// Support cancellation
+		if err := ctx.Err(); err != nil {
+			return appTargets, results, err
+		}
+
+		c.Log.Debug("will operate on app", "project", name, "name", appName)
+		c.metrics.appsTargeted++
+		result := c.runApp(ctx, project.App(appName), f)
+		results = append(results, result)
+		c.recordTiming("app "+name+"/"+appName, result.Duration)
+		if !c.flagPrintJob {
+			c.outputAppResult(result)
+		}
+		if err := result.Err; err != nil {
+			if !errors.Is(err, ErrSentinel) {
+				finalErr = multierror.Append(finalErr, err)
+			} else {
+				didErrSentinel = true
+			}
+		}
+	}
+	if finalErr == nil && didErrSentinel {
+		finalErr = ErrSentinel
+	}
+	if !c.flagPrintJob {
+		c.recordFailedApps(name, results)
+	}
+
+	return appTargets, results, finalErr
+}
+
+// isAppGlob returns true if the app name contains glob metacharacters.
+func isAppGlob(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// matchApps returns the names of the apps that match the pattern. The
+// pattern syntax is the same as path.Match. If an app name is exactly
+// equal to the pattern, only that app is returned even if the pattern
+// contains glob metacharacters. An error is returned if nothing matches
+// so that typos are caught.
+func matchApps(pattern string, names []string) ([]string, error) {
+	for _, name := range names {
+		if name == pattern {
+			return []string{name}, nil
+		}
+	}
+
+	var result []string
+	for _, name := range names {
+		ok, err := path.Match(pattern, name)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid app pattern %q: %s", pattern, err)
+		}
+		if ok {
+			result = append(result, name)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("No apps match the pattern %q.", pattern)
+	}
+
+	return result, nil
+}
+
+// validateAppVars verifies that every app-scoped variable targets one of
+// the known apps so that typos don't silently skip the override.
+func (c *baseCommand) validateAppVars(known []string) error {
+	knownMap := map[string]struct{}{}
+	for _, name := range known {
+		knownMap[name] = struct{}{}
+	}
+
+	var unknown []string
+	for name := range c.appVariables {
+		if _, ok := knownMap[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	// Copy known before sorting since it may be the list of app targets.
+	known = append([]string(nil), known...)
+	sort.Strings(known)
+	sort.Strings(unknown)
+	return fmt.Errorf(
+		"The -var flag was scoped to unknown app(s): %s\n\n"+
+			"Known apps: %s",
+		strings.Join(unknown, ", "),
+		strings.Join(known, ", "))
+}
+
+// splitAppVars splits the "-var" values into those that apply to all apps
+// and those scoped to a single app with the "app:key=value" syntax. Variable
+// names can't contain ":" so the two are never ambiguous.
+func splitAppVars(vars map[string]string) (map[string]string, map[string]map[string]string, error) {
+	global := map[string]string{}
+	byApp := map[string]map[string]string{}
+	for k, v := range vars {
+		idx := strings.Index(k, ":")
+		if idx == -1 {
+			global[k] = v
+			continue
+		}
+
+		app, name := k[:idx], k[idx+1:]
+		if app == "" || name == "" {
+			return nil, nil, fmt.Errorf(
+				"Invalid app-scoped variable %q. The format must be \"app:key=value\".", k)
+		}
+
+		if byApp[app] == nil {
+			byApp[app] = map[string]string{}
+		}
+		byApp[app][name] = v
+	}
 
-	return finalErr
+	return global, byApp, nil
+}
+
+// loadAppVariables loads the variable values of each app with app-scoped
+// "-var" values. This is the same as the values for all apps, with the
+// app-scoped values replacing the global -var values of the same name, so
+// that -var-precedence applies to them the same way.
+func loadAppVariables(
+	flagVars map[string]string,
+	appVars map[string]map[string]string,
+	files []string,
+	p variables.Precedence,
+) (map[string][]*pb.Variable, hcl.Diagnostics) {
+	if len(appVars) == 0 {
+		return nil, nil
+	}
+
+	result := map[string][]*pb.Variable{}
+	for app, vars := range appVars {
+		merged := map[string]string{}
+		for k, v := range flagVars {
+			merged[k] = v
+		}
+		for k, v := range vars {
+			merged[k] = v
+		}
+
+		pbv, _, diags := variables.LoadVariableValuesPrecedence(merged, files, p)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		result[app] = pbv
+	}
+
+	return result, nil
+}
+
+// filterApps applies the app filters shared by DoAppResults and doProject
+// to the apps targeted in the project: -retry-failed, -app-selector, and
+// the workspaces of each app. local is true if the project is the one of
+// the local configuration, which is where app labels and workspaces are
+// read from.
+func (c *baseCommand) filterApps(project string, local bool, appTargets []string) ([]string, error) {
+	// If we're retrying, the targets are the apps that failed last time.
+	if c.flagRetryFailed {
+		var err error
+		appTargets, err = c.retryFailedApps(project)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// If we have a label selector, only keep the apps that match it.
+	if c.flagAppSelector != "" {
+		if !local {
+			return nil, fmt.Errorf(
+				"The app selector %q requires the local Waypoint configuration of\n"+
+					"project %q since app labels are read from the configuration.",
+				c.flagAppSelector, project)
+		}
+
+		var err error
+		appTargets, err = c.appsBySelector(appTargets, c.flagAppSelector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Skip any apps that aren't available in the targeted workspace.
+	if local && c.cfg != nil {
+		var err error
+		appTargets, err = c.appsByWorkspace(appTargets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return appTargets, nil
+}
+
+// appsBySelector filters the given app names to only those with labels
+// matching the label selector. The selector syntax is the same as
+// Kubernetes label selectors, supporting both equality-based
+// ("tier=backend", "tier!=backend") and set-based ("tier in (a,b)",
+// "!canary") requirements. App labels come from the local configuration
+// since the server doesn't store them.
+func (c *baseCommand) appsBySelector(names []string, selector string) ([]string, error) {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid app selector %q: %s", selector, err)
+	}
+
+	if c.cfg == nil || len(c.cfg.Apps()) == 0 {
+		return nil, fmt.Errorf(
+			"The app selector %q requires a local Waypoint configuration\n"+
+				"file since app labels are read from the configuration.", selector)
+	}
+
+	var result []string
+	for _, name := range names {
+		app, err := c.cfg.App(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		if app == nil {
+			// The app isn't in our local config so we can't know its labels.
+			c.Log.Debug("app not in local config, skipping selector match", "app", name)
+			continue
+		}
+
+		if sel.Matches(labels.Set(app.Labels)) {
+			result = append(result, name)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("No apps matched the app selector %q.", selector)
+	}
+
+	return result, nil
+}
+
+// appsByWorkspace filters the given app names to only those available in
+// the targeted workspace, as set with "workspaces" in the app
+// configuration. Skipped apps are reported to the UI, or are an error with
+// -strict-workspace.
+func (c *baseCommand) appsByWorkspace(names []string) ([]string, error) {
+	ws := c.refWorkspace.GetWorkspace()
+	if ws == "" {
+		ws = "default"
+	}
+
+	var result []string
+	for _, name := range names {
+		app, err := c.cfg.App(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		if app == nil || app.InWorkspace(ws) {
+			result = append(result, name)
+			continue
+		}
+
+		if c.flagStrictWorkspace {
+			return nil, fmt.Errorf(
+				"The app %q is only deployed to the workspaces %s, not %q.\n"+
+					"Target one of those workspaces with -workspace, or remove\n"+
+					"-strict-workspace to skip the app.",
+				name, strings.Join(app.Workspaces, ", "), ws)
+		}
+
+		c.Log.Info("skipping app not in the workspace",
+			"app", name, "workspace", ws, "workspaces", app.Workspaces)
+		c.ui.Output("Skipping app %q: it is only deployed to the workspaces %s, not %q.",
+			name, strings.Join(app.Workspaces, ", "), ws, terminal.WithInfoStyle())
+	}
+
+	return result, nil
 }
 
 // logError logs an error and outputs it to the UI.
 func (c *baseCommand) logError(log hclog.Logger, prefix string, err error) {
-	if err == ErrSentinel {
+	if errors.Is(err, ErrSentinel) {
 		return
 	}
 
@@ -497,6 +2196,20 @@ func (c *baseCommand) logError(log hclog.Logger, prefix string, err error) {
 	c.ui.Output("%s%s", prefix, err, terminal.WithErrorStyle())
 }
 
+// appOpError outputs the error of an operation on the app and returns an
+// error that matches ErrSentinel, as the DoApp callbacks do for errors. The
+// cause is kept so that the failure can still be categorized. ErrJobPrinted
+// is returned as is without output, since printing the job with -print-job
+// is the expected outcome and DoApp treats it as success.
+func appOpError(ui terminal.UI, err error) error {
+	if errors.Is(err, clientpkg.ErrJobPrinted) {
+		return err
+	}
+
+	ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+	return &outputError{Err: err}
+}
+
 // flagSet creates the flags for this command. The callback should be used
 // to configure the set with your own custom options.
 func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
@@ -505,10 +2218,97 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 		f := set.NewSet("Global Options")
 
 		f.BoolVar(&flag.BoolVar{
-			Name:    "plain",
-			Target:  &c.flagPlain,
-			Default: false,
-			Usage:   "Plain output: no colors, no animation.",
+			Name:   "timings",
+			Target: &c.flagTimings,
+			Usage: "Output how long each phase of the command took at the end, " +
+				"such as loading the configuration, connecting to the server, and " +
+				"the operation on each app. With -results-json, this is output " +
+				"as a JSON object.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "plain",
+			Target:  &c.flagPlain,
+			Default: false,
+			Usage:   "Plain output: no colors, no animation.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "plain-progress",
+			Target: &c.flagPlainProgress,
+			Usage: "Plain output, but with a timestamped status line printed " +
+				"periodically while a step is running, such as \"still building, " +
+				"2m0s elapsed\". This keeps CI logs informative for long operations.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:    "progress-interval",
+			Target:  &c.flagProgressInterval,
+			Default: 30 * time.Second,
+			Usage:   "How often to print a status line with -plain-progress.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "no-version-check",
+			Target: &c.flagNoVersionCheck,
+			Usage: "Don't check for a newer version of the CLI. This can also be " +
+				"disabled with the " + EnvDisableVersionCheck + " environment variable.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "no-checkpoint",
+			Target: &c.flagNoCheckpoint,
+			Usage: "Don't make any network requests other than to the Waypoint server, " +
+				"such as the version check, for air-gapped environments. This can also " +
+				"be disabled with the " + EnvDisableCheckpoint + " or " +
+				EnvCheckpointDisable + " environment variables. This is unrelated to " +
+				"the -checkpoint flag of operations.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "verbosity",
+			Target:  &c.flagVerbosity,
+			Default: verbosityNormal.String(),
+			EnvVar:  EnvVerbosity,
+			Usage: "Amount of informational output to show: quiet, normal, " +
+				"verbose, or debug (or 0 to 3). This is separate from the log " +
+				"level which is controlled with -v, -vv, and -vvv.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "syslog",
+			Target: &c.flagSyslog,
+			Usage: "Also send logs to the local syslog daemon. Logs are sent at " +
+				"the info level and above, or the level set with -v, -vv, or -vvv.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "syslog-addr",
+			Target: &c.flagSyslogAddr,
+			Usage: "Send logs to the syslog server at this address instead of the " +
+				"local daemon, such as \"udp://host:514\" or \"tcp://host:601\". " +
+				"This implies -syslog.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "syslog-facility",
+			Target:  &c.flagSyslogFacility,
+			Default: "local0",
+			Usage:   "Syslog facility for messages, such as \"user\" or \"local0\" to \"local7\".",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:    "syslog-tag",
+			Target:  &c.flagSyslogTag,
+			Default: "waypoint",
+			Usage:   "Tag for syslog messages.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "syslog-output",
+			Target: &c.flagSyslogOutput,
+			Usage: "Also send the command output to syslog. Errors and warnings " +
+				"are sent with the matching severity.",
 		})
 
 		f.StringVar(&flag.StringVar{
@@ -518,26 +2318,99 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 			Default: "",
 			Usage: "App to target. Certain commands require a single app target for " +
 				"Waypoint configurations with multiple apps. If you have a single app, " +
-				"then this can be ignored.",
+				"then this can be ignored. For commands that operate on multiple apps, " +
+				"this can be a glob pattern such as \"svc-*\" to target every matching app. " +
+				"If a \"project/app\" target argument is also given, it must match.",
 		})
 
 		f.StringVar(&flag.StringVar{
+			Name:   "app-selector",
+			Target: &c.flagAppSelector,
+			Usage: "Label selector to filter the targeted apps by, for example " +
+				"\"team=payments,tier in (backend,worker)\". App labels are read " +
+				"from the local Waypoint configuration.",
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
 			Name:    "project",
-			Target:  &c.flagProject,
+			Target:  &c.flagProjects,
 			Aliases: []string{"p"},
-			Default: "",
-			Usage:   "Project to target.",
+			Usage: "Project to target. This can be specified multiple times to " +
+				"operate on multiple projects, in which case the apps are read " +
+				"from each project on the server. If a \"project/app\" target " +
+				"argument is also given, it must be for the same project.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "project-filter",
+			Target: &c.flagProjectFilter,
+			Usage: "Label selector for the projects to target, for example " +
+				"\"team=payments\". This is the project-level version of " +
+				"-app-selector and can't be combined with -project. Project " +
+				"labels are read from the waypoint.hcl stored with each project " +
+				"on the server.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "project-config",
+			Target: &c.flagProjectConfig,
+			Usage: "Path to a file with project-level settings, such as the runner, " +
+				"that is merged with the waypoint.hcl. Setting the same value in " +
+				"both files is an error.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "config-filename",
+			Target: &c.flagConfigFilename,
+			Usage: "File name of the Waypoint configuration to search for in the " +
+				"current directory and its parents, instead of \"" + config.Filename +
+				"\". A JSON configuration with the same name and a \".json\" " +
+				"extension is also found.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "config-profile",
+			Target: &c.flagConfigProfile,
+			Usage: "Name of a profile block in the configuration, such as \"prod\", " +
+				"that overrides the runner, labels, and app stages of the base " +
+				"configuration. Remote runners select the same profile.",
 		})
 
 		f.StringVar(&flag.StringVar{
 			Name:    "workspace",
 			Target:  &c.flagWorkspace,
 			Aliases: []string{"w"},
-			Usage:   "Workspace to operate in.",
+			Usage: "Workspace to operate in. This can be an alias from " +
+				"\"workspace_aliases\" in the configuration. This can also be a " +
+				"comma-separated fallback chain such as \"feature-123,staging\" " +
+				"to use the first workspace that exists on the server.",
+			Completion: predictWorkspaces(),
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "workspace-create",
+			Target: &c.flagWorkspaceCreate,
+			Usage: "Use the first workspace of a -workspace fallback chain if none " +
+				"of them exist, creating it. Without this, it is an error if none " +
+				"of the workspaces in the chain exist. Workspaces that aren't a " +
+				"chain are always created on first use.",
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "context-set",
+			Target: &c.flagContextSet,
+			Usage: "Set a value such as \"workspace=dev\" in the session context " +
+				"rather than the stored CLI context. The session is identified by " +
+				"the WAYPOINT_SESSION environment variable and the values apply to " +
+				"every command run with the same session.",
 		})
 	}
 
 	if bit&flagSetOperation != 0 {
+		// Operations check the env required by the configuration.
+		c.envCheck = true
+		c.operation = true
+
 		f := set.NewSet("Operation Options")
 		f.StringMapVar(&flag.StringMapVar{
 			Name:   "label",
@@ -545,6 +2418,41 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 			Usage:  "Labels to set for this operation. Can be specified multiple times.",
 		})
 
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "label-file",
+			Target: &c.flagLabelFiles,
+			Usage: "Path to an HCL or JSON file with a \"labels\" map to set for " +
+				"this operation, in the same format as in the waypoint.hcl. Can be " +
+				"specified multiple times, in which case later files take " +
+				"precedence. Labels set with -label take precedence over files.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "spec",
+			Target: &c.flagSpec,
+			Usage: "Path to an HCL or JSON job spec file that declares the operation " +
+				"instead of flags. The spec can set \"project\", \"app\", " +
+				"\"workspace\", \"remote\", and the \"variables\", \"labels\", " +
+				"\"remote_source\", and \"runner_labels\" maps. Flags take " +
+				"precedence over the values in the spec.",
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "annotation",
+			Target: &c.flagAnnotations,
+			Usage: "Annotation, such as \"git-commit=abc123\", to store with this " +
+				"operation for provenance. Unlike labels, annotations aren't meant " +
+				"for filtering. In GitHub Actions and GitLab CI, the commit, ref, " +
+				"actor, and job URL are annotated automatically unless set with " +
+				"this flag. Can be specified multiple times.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "no-ci-annotations",
+			Target: &c.flagNoCIAnnotations,
+			Usage:  "Don't annotate the operation with values detected from the CI system.",
+		})
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "remote",
 			Target:  &c.flagRemote,
@@ -553,6 +2461,157 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 				"unless 'runner.default' is set in your configuration.",
 		})
 
+		f.BoolVar(&flag.BoolVar{
+			Name:    "local",
+			Target:  &c.flagLocal,
+			Default: false,
+			Usage: "True to execute using a local runner. This is the default " +
+				"unless 'runner.default' is set in your configuration, and replaces " +
+				"the deprecated \"-remote=false\".",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "no-local-runner",
+			Target: &c.flagNoLocalRunner,
+			Usage: "Forbid executing on a local runner. Operations execute on a " +
+				"remote runner and fail if that isn't possible, rather than " +
+				"falling back to a local runner. This can also be set with the " +
+				EnvNoLocalRunner + " env var.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "auto-server-dir",
+			Target: &c.flagAutoServerDir,
+			Usage: "Directory for the state of the server that is started " +
+				"automatically for local operations when no server is configured. " +
+				"The directory is created if it doesn't exist. Use a separate " +
+				"directory for parallel runs so they don't share state. This has " +
+				"no effect when connecting to a server. Defaults to the \"" +
+				autoServerDir + "\" directory in the Waypoint config directory.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "results-json",
+			Target:  &c.flagResultsJSON,
+			Default: false,
+			Usage: "Output the outcome of each app as a JSON object on its own " +
+				"line as soon as the app completes, followed by a line with the " +
+				"summary as a \"summary\" object.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "no-summary",
+			Target: &c.flagNoSummary,
+			Usage: "Don't output the summary tables after operating on multiple " +
+				"apps or projects. Single-app operations never output a summary. " +
+				"This doesn't affect -results-json, which always outputs the " +
+				"outcome of each app and the summary.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "write-var-lock",
+			Target:  &c.flagWriteVarLock,
+			Default: false,
+			Usage: "Write the resolved variable values and their sources to " +
+				"\"" + varLockFile + "\" in the current directory. The values of " +
+				"variables declared as sensitive aren't written.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "var-lock",
+			Target: &c.flagVarLock,
+			Usage: "Path to a variable lockfile written with -write-var-lock to " +
+				"replay the same variable values. This can't be combined with " +
+				"-var or -var-file.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "var-export",
+			Target: &c.flagVarExport,
+			Usage: "Path to write the resolved variable values and their sources to " +
+				"as JSON, so that other tools can use the same values. An existing " +
+				"file at this path is overwritten. The values of variables declared " +
+				"as sensitive aren't written unless -var-export-include-sensitive " +
+				"is set.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "var-export-include-sensitive",
+			Target: &c.flagVarExportSensitive,
+			Usage: "Include the values of sensitive variables in the -var-export " +
+				"file. The file is then only readable by the current user.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "checkpoint",
+			Target:  &c.flagCheckpoint,
+			Default: false,
+			Usage: "Record each app that succeeds in an operation on multiple apps " +
+				"so that, if any app fails, the operation can be resumed with " +
+				"-resume without redoing the apps that succeeded.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "resume",
+			Target: &c.flagResume,
+			Usage: "Run ID of an operation started with -checkpoint to resume. " +
+				"Apps that succeeded in an earlier attempt of the run are skipped.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "retry-failed",
+			Target:  &c.flagRetryFailed,
+			Default: false,
+			Usage: "Target only the apps that failed in the last operation on " +
+				"multiple apps for this project and workspace. The failed apps " +
+				"are cleared once an operation succeeds for every app.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "skip-env-check",
+			Target:  &c.flagSkipEnvCheck,
+			Default: false,
+			Usage: "Don't check that the env vars listed in the \"required_env\" " +
+				"setting of the configuration are set.",
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "runner-env",
+			Target: &c.flagRunnerEnv,
+			Usage: "Env var to set on the remote runner while it executes this " +
+				"operation, as KEY=VALUE. Can be specified multiple times. " +
+				"This requires a remote runner.",
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "runner-env-sensitive",
+			Target: &c.flagRunnerEnvSensitive,
+			Usage: "Env var to set on the remote runner to the value of another " +
+				"env var of the runner, as KEY=NAME, such as one set with " +
+				"\"waypoint config set -runner\". The value is never sent with " +
+				"the job or logged, so use this for credentials and other " +
+				"secrets. Can be specified multiple times.",
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "runner-label",
+			Target: &c.flagRunnerLabels,
+			Usage: "Label, such as \"gpu=true\", that the remote runner executing " +
+				"this operation must have. Runners are labeled with the -label flag " +
+				"of \"waypoint runner agent\" and runners without every requested " +
+				"label leave the job for another runner. Whether a matching runner " +
+				"exists can't be checked, so a job that no runner matches stays " +
+				"queued. Can be specified multiple times.",
+		})
+
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "only",
+			Target: &c.flagOnly,
+			Usage: "Only perform the given phases, such as \"build,deploy\". " +
+				"This is only supported by commands that perform multiple " +
+				"phases, such as \"waypoint up\". Defaults to all phases.",
+		})
+
 		f.StringMapVar(&flag.StringMapVar{
 			Name:   "remote-source",
 			Target: &c.flagRemoteSource,
@@ -561,10 +2620,117 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 				"This is used for example to set a specific Git ref to run against.",
 		})
 
+		f.BoolVar(&flag.BoolVar{
+			Name:   "require-explicit-ref",
+			Target: &c.flagRequireExplicitRef,
+			Usage: "Refuse to execute remotely for a project with a Git data source " +
+				"unless a ref is set with \"-remote-source=ref=<ref>\" or the " +
+				"\"datasource_ref\" of the CLI context, rather than using the " +
+				"default ref of the data source. This makes deployments deterministic.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "print-job",
+			Target:  &c.flagPrintJob,
+			Default: false,
+			Usage: "Print the job that would be submitted to the server as JSON " +
+				"instead of executing it. Variable values are redacted.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "fail-on-no-apps",
+			Target: &c.flagFailOnNoApps,
+			Usage: "Fail if no apps are targeted, rather than succeeding without " +
+				"doing anything. This is recommended in automation so that a " +
+				"configuration or targeting mistake isn't mistaken for success.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "output-dir",
+			Target: &c.flagOutputDir,
+			Usage: "Directory to record the output of each app to, in a " +
+				"subdirectory per app with the log of the operation and its " +
+				"result. The directory must be empty or not exist.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "output-dir-force",
+			Target: &c.flagOutputDirForce,
+			Usage: "Allow an -output-dir that isn't empty, replacing the output " +
+				"of the apps in this run.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "require-clean",
+			Target: &c.flagRequireClean,
+			Usage: "Fail if the git working tree of the project has uncommitted " +
+				"changes, such as to prevent releasing from a modified checkout. " +
+				"The check is skipped with a warning outside of a git repository.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "allow-dirty",
+			Target: &c.flagAllowDirty,
+			Usage:  "Allow uncommitted changes even if -require-clean is set.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "app-timeout",
+			Target: &c.flagAppTimeout,
+			Usage: "Fail the operation on an app if it doesn't complete within this " +
+				"duration, such as \"15m\", and continue with the next app. This " +
+				"bounds each app separately. Defaults to no timeout.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "i-know-what-im-doing",
+			Target: &c.flagProtectedOverride,
+			Usage: "Operate on a workspace listed in \"protected_workspaces\" in the " +
+				"configuration without confirmation. This is required to operate on a " +
+				"protected workspace when the CLI isn't interactive.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "strict-workspace",
+			Target: &c.flagStrictWorkspace,
+			Usage: "Fail if a targeted app is restricted to other workspaces " +
+				"with \"workspaces\" in its configuration, rather than skipping it.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "check-only",
+			Target:  &c.flagCheckOnly,
+			Aliases: []string{"config-check-only"},
+			Default: false,
+			Usage: "Load the configuration and variables, connect to the server, " +
+				"and resolve the targeted apps, then exit without executing the " +
+				"operation. Exits with status 0 if every check passes.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "export-job",
+			Target: &c.flagExportJob,
+			Usage: "Write the job for each app as JSON to the given file before it " +
+				"is executed. Variable values and sensitive runner env values are " +
+				"redacted. Use -print-job to print the jobs without executing them.",
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "config-var",
+			Target: &c.flagConfigVars,
+			Usage: "Variable value to use only while evaluating the configuration in the " +
+				"CLI, such as for the runner data source, and not for the operation. " +
+				"This takes precedence over -var. Can be specified multiple times.",
+		})
+
 		f.StringMapVar(&flag.StringMapVar{
 			Name:   "var",
 			Target: &c.flagVars,
-			Usage:  "Variable value to set for this operation. Can be specified multiple times.",
+			Usage: "Variable value to set for this operation. Can be specified multiple times. " +
+				"Prefix the name with an app name, such as \"-var web:replicas=3\", " +
+				"to only set the value for that app. A value such as " +
+				"\"vault://secret/data/app#password\" is read from Vault using the " +
+				"VAULT_ADDR and VAULT_TOKEN environment variables, and is sensitive.",
 		})
 
 		f.StringSliceVar(&flag.StringSliceVar{
@@ -572,7 +2738,30 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 			Target: &c.flagVarFile,
 			Usage: "HCL or JSON file containing variable values to set for this " +
 				"operation. If any \"*.auto.wpvars\" or \"*.auto.wpvars.json\" " +
-				"files are present, they will be automatically loaded.",
+				"files are present in the project directory, they will be " +
+				"automatically loaded.",
+		})
+
+		c.addAutoVarDirFlag(f)
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "strict-vars",
+			Target:  &c.flagStrictVars,
+			Default: false,
+			Usage: "Fail if loading the variable values produces any warnings, " +
+				"rather than only logging them. This is useful in CI to catch " +
+				"problems with variable values early.",
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "var-precedence",
+			Target:  &c.flagVarPrecedence,
+			Values:  []string{string(variables.PrecedenceFlags), string(variables.PrecedenceFiles)},
+			Default: string(variables.PrecedenceFlags),
+			Usage: "Whether values from -var (\"flags\") or -var-file (\"files\") " +
+				"take precedence when both set the same variable. Both take " +
+				"precedence over WP_VAR_* environment variables, \"*.auto.wpvars\" " +
+				"files, and values set on the server",
 		})
 	}
 
@@ -581,7 +2770,21 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 		f.StringVar(&flag.StringVar{
 			Name:   "server-addr",
 			Target: &c.flagConnection.Server.Address,
-			Usage:  "Address for the server.",
+			Usage: "Address for the server. This can also be a DNS SRV record in the " +
+				"form \"srv://_waypoint._tcp.example.com\" to connect to the first " +
+				"available target by priority and weight. The placeholders " +
+				"\"{workspace}\" and \"{project}\" are replaced with the targeted " +
+				"workspace and project, and \"{{\" and \"}}\" are literal braces.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "server-config-dir",
+			Target: &c.flagServerConfigDir,
+			Usage: "Directory with a file for each connection setting, such as " +
+				"secrets mounted by Docker or Kubernetes. The \"server-addr\" file " +
+				"is required, and the optional files are \"server-tls\", " +
+				"\"server-tls-skip-verify\", and \"token\". This can't be " +
+				"combined with -server-addr.",
 		})
 
 		f.BoolVar(&flag.BoolVar{
@@ -591,12 +2794,128 @@ func (c *baseCommand) flagSet(bit flagSetBit, f func(*flag.Sets)) *flag.Sets {
 			Usage:   "True if the server should be connected to via TLS.",
 		})
 
+		f.BoolVar(&flag.BoolVar{
+			Name:   "server-insecure",
+			Target: &c.flagServerInsecure,
+			Usage: "Connect to the server without TLS. The connection is unencrypted, " +
+				"so this should only be used for local development servers. This is " +
+				"the same as \"-server-tls=false\".",
+		})
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "server-tls-skip-verify",
 			Target:  &c.flagConnection.Server.TlsSkipVerify,
 			Default: false,
 			Usage:   "True to skip verification of the TLS certificate advertised by the server.",
 		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "server-tls-pkcs12",
+			Target: &c.flagServerTLSPKCS12,
+			Usage: "Path to a PKCS#12 (.p12) file with a client certificate and key " +
+				"to authenticate to servers that require mutual TLS. CA certificates " +
+				"in the file are also trusted to verify the server.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "server-tls-pkcs12-passphrase-file",
+			Target: &c.flagServerTLSPKCS12PassFile,
+			Usage: "Path to a file with the passphrase of the -server-tls-pkcs12 file. " +
+				"If this isn't set, the passphrase is read from the " +
+				"WAYPOINT_SERVER_TLS_PKCS12_PASSPHRASE environment variable.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "server-tls-ca-path",
+			Target: &c.flagConnection.Server.TlsCaPath,
+			Usage: "Directory of CA certificates in \"*.pem\" or \"*.crt\" files to " +
+				"trust to verify the server, in addition to the system roots. This " +
+				"is saved with the context when creating one from these flags.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "server-tls-ca-only",
+			Target: &c.flagConnection.Server.TlsCaOnly,
+			Usage: "Trust only the CA certificates in -server-tls-ca-path to verify " +
+				"the server, and not the system roots.",
+		})
+
+		f.EnumSingleVar(&flag.EnumSingleVar{
+			Name:    "server-ip-version",
+			Target:  &c.flagServerIPVersion,
+			Values:  []string{"4", "6", "auto"},
+			Default: "auto",
+			Usage: "IP version to use when connecting to the server: 4, 6, or auto. " +
+				"This is useful on dual-stack hosts where one address family is " +
+				"unreachable.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:   "server-keepalive",
+			Target: &c.flagServerKeepalive,
+			Usage: "Interval of the keepalive pings sent on an idle connection to " +
+				"the server, such as \"1m\". Lower this if a proxy in front of the " +
+				"server drops idle connections. The minimum is 20s and the default " +
+				"is 30s. A read request that fails because the connection was " +
+				"dropped is retried once after reconnecting.",
+		})
+
+		f.Float64Var(&flag.Float64Var{
+			Name:   "rps",
+			Target: &c.flagRPS,
+			Usage: "Maximum requests per second to send to the server, such as \"20\". " +
+				"This is shared by every app and project that is targeted, so " +
+				"that large batch operations stay within the limits of the " +
+				"server. Defaults to unlimited.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "server-ssh-bastion",
+			Target: &c.flagServerSSHBastion,
+			Usage: "Connect to the server through an SSH tunnel to this bastion host, " +
+				"in the form \"[user@]host[:port]\". Keys from the SSH agent and " +
+				"-server-ssh-key are used to authenticate, and the host key of the " +
+				"bastion must be in \"~/.ssh/known_hosts\".",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "server-ssh-key",
+			Target: &c.flagServerSSHKey,
+			Usage: "Path to a private key to authenticate with the -server-ssh-bastion " +
+				"host. Keys with a passphrase must be added to the SSH agent instead.",
+		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "header",
+			Target: &c.flagHeaders,
+			Usage: "Header, such as \"X-Team-Id=payments\", to send with every request " +
+				"to the server, for example for an authenticating proxy. Can be " +
+				"specified multiple times. Headers can also be set with " +
+				"WAYPOINT_HEADER_* environment variables, such as WAYPOINT_HEADER_X_TEAM_ID.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "context-create-if-missing",
+			Target: &c.flagContextCreate,
+			Usage: "Save the connection from the -server-* flags as a CLI context " +
+				"with this name after successfully connecting, so that later commands " +
+				"don't need the flags. An existing context with this name is left " +
+				"unchanged unless -context-create-force is set.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "context-create-default",
+			Target: &c.flagContextCreateDefault,
+			Usage: "Set the context saved with -context-create-if-missing as the " +
+				"default CLI context.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "context-create-force",
+			Target: &c.flagContextCreateForce,
+			Usage: "Overwrite an existing context with the name given to " +
+				"-context-create-if-missing.",
+		})
 	}
 
 	if f != nil {
@@ -684,18 +3003,31 @@ func checkFlagsAfterArgs(args []string, set *flag.Sets) error {
 // precedence (last value wins):
 //
 // - value stored in the CLI context
+// - value stored in the session context (see WAYPOINT_SESSION)
 // - value from the environment variable WAYPOINT_WORKSPACE
 // - value set in the CLI flag -workspace
 //
-// The default value is "default"
+// The default value is "default". The value may be an alias from
+// "workspace_aliases" in the configuration, which is resolved once the
+// configuration is loaded, regardless of where the value came from. See
+// initConfigLoad.
 func (c *baseCommand) workspace() (string, error) {
 	// load env for workspace
 	workspaceENV := os.Getenv(defaultWorkspaceEnvName)
+
+	// load the session context, if any
+	session, err := sessionContext(c.homeConfigPath)
+	if err != nil {
+		return "", err
+	}
+
 	switch {
 	case c.flagWorkspace != "":
 		return c.flagWorkspace, nil
 	case workspaceENV != "":
 		return workspaceENV, nil
+	case session != nil && session.Workspace != "":
+		return session.Workspace, nil
 	default:
 		// attempt to load from CLI context storage
 		defaultName, err := c.contextStorage.Default()
@@ -720,6 +3052,87 @@ func (c *baseCommand) workspace() (string, error) {
 	}
 }
 
+// contextDataSourceRef returns the default data source ref stored in the
+// context used to connect, if any.
+func (c *baseCommand) contextDataSourceRef() (string, error) {
+	name := c.contextName()
+	if name == "" {
+		return "", nil
+	}
+
+	cfg, err := c.contextStorage.Load(name)
+	if err != nil {
+		return "", err
+	}
+
+	return cfg.DataSourceRef, nil
+}
+
+// initDataSourceRef sets the data source ref from the context as an
+// override for remote operations if the project uses a Git data source.
+// The client holds a reference to flagRemoteSource, so this must be
+// non-nil before the client is initialized.
+func (c *baseCommand) initDataSourceRef(ref string) {
+	if c.refProject == nil {
+		return
+	}
+
+	project, err := c.getProject(c.Ctx)
+	if err != nil {
+		// The project may not be registered yet, in which case there
+		// is no data source to apply the ref to.
+		c.Log.Debug("not applying context data source ref", "error", err)
+		return
+	}
+
+	if _, ok := project.DataSource.GetSource().(*pb.Job_DataSource_Git); !ok {
+		c.ui.Output(warnDataSourceRefNotGit, ref, terminal.WithWarningStyle())
+		return
+	}
+
+	c.Log.Debug("using data source ref from context", "ref", ref)
+	c.flagRemoteSource["ref"] = ref
+}
+
+// snapshotDefault returns the default value for the "-snapshot" flag of
+// commands that take a server snapshot. Snapshots are enabled unless they
+// were disabled globally with the WAYPOINT_DISABLE_SNAPSHOT env var, which
+// is useful when working with older servers that don't support snapshots.
+func (c *baseCommand) snapshotDefault() bool {
+	disabled, err := env.GetBool(EnvDisableSnapshot, false)
+	if err != nil && c.Log != nil {
+		c.Log.Warn(err.Error())
+	}
+
+	return !disabled
+}
+
+// noAppsError is the type of ErrNoApps.
+type noAppsError struct{}
+
+func (e *noAppsError) Error() string {
+	return "No apps were targeted. Check the -app, -app-selector, and -project\n" +
+		"flags and the apps defined in the configuration."
+}
+
+// Is makes ErrNoApps match ErrSentinel, since it was already output.
+func (e *noAppsError) Is(target error) bool {
+	return target == ErrSentinel
+}
+
+// outputError is an error that was already output to the user. This matches
+// ErrSentinel with errors.Is, but unlike ErrSentinel it keeps the cause.
+type outputError struct {
+	Err error
+}
+
+func (e *outputError) Error() string { return e.Err.Error() }
+func (e *outputError) Unwrap() error { return e.Err }
+
+func (e *outputError) Is(target error) bool {
+	return target == ErrSentinel
+}
+
 // flagSetBit is used with baseCommand.flagSet
 type flagSetBit uint
 
@@ -733,6 +3146,25 @@ var (
 	// ErrSentinel is a sentinel value that we can return from Init to force an exit.
 	ErrSentinel = errors.New("error sentinel")
 
+	// ErrNoApps is returned by DoApp with "-fail-on-no-apps" if no apps
+	// are targeted. The error is output before it is returned, so it also
+	// matches ErrSentinel with errors.Is.
+	ErrNoApps error = &noAppsError{}
+
+	// ErrConfigNotFound is returned when a Waypoint configuration file is
+	// required but wasn't found.
+	ErrConfigNotFound = errors.New(
+		"A Waypoint configuration file (waypoint.hcl) is required but wasn't found.\n" +
+			"The command can use configuration stored on the server by adding project/app\n" +
+			"as a final argument on the command line.\n" +
+			"For instance: `waypoint deployment list webapp/web` for the app 'web' in\n" +
+			"the project 'webapp'",
+	)
+
+	// ErrConfigParse is matched (using errors.Is) by errors for a Waypoint
+	// configuration file that was found but failed to load or validate.
+	ErrConfigParse = errors.New("failed to parse the Waypoint configuration")
+
 	errFlagAfterArgs = errors.New(strings.TrimSpace(`
 Flags must be specified before positional arguments in the CLI command.
 For example "waypoint up -example project" not "waypoint up project -example".
@@ -746,7 +3178,90 @@ enforces this requirement. Sorry!
 
 	errAppModeSingle = strings.TrimSpace(`
 This command requires a single targeted app. You have multiple apps defined
-so you can specify the app to target using the "-app" flag.
+so you can specify the app to target using the "-app" flag, or run the
+command from within the directory of the app's "path".
+`)
+
+	warnRemoteFalseDeprecated = strings.TrimSpace(`
+The "-remote=false" flag is deprecated. Please use "-local" instead to
+execute using a local runner. This will continue to work for now.
+`)
+
+	warnServerInsecure = strings.TrimSpace(`
+WARNING: Connecting to the server at %q without TLS. The connection,
+including any authentication token, is unencrypted. Only do this for local
+development servers.
+`)
+
+	warnProjectChanged = strings.TrimSpace(`
+The project %q is targeted, but the CLI context %q was last used
+with the project %q. Make sure you're using the right context. This
+warning is only shown once after switching projects, and can be disabled
+by setting the %s environment variable to "1".
+`)
+
+	warnProtectedWorkspace = strings.TrimSpace(`
+The workspace %q is protected in the configuration. Make sure you intend
+to operate on it.
+`)
+
+	errProtectedWorkspace = strings.TrimSpace(`
+The workspace %q is protected in the configuration, so operations on it
+must be confirmed. Since the CLI isn't interactive, rerun the command with
+-i-know-what-im-doing to confirm the operation.
+`)
+
+	warnWorkspaceNotCached = strings.TrimSpace(`
+The workspace %q isn't in the list of known workspaces for this server
+as of %s. It will be created if it doesn't exist. Run "waypoint workspace
+list" to refresh the list of known workspaces.
+`)
+
+	warnWorkspaceCacheStale = strings.TrimSpace(`
+The list of known workspaces is stale and may be out of date.
+`)
+
+	warnContextCreateNoFlags = strings.TrimSpace(`
+The "-context-create-if-missing" flag has no effect without "-server-addr".
+Only connections configured with the "-server-*" flags are saved.
+`)
+
+	warnOrphanedLocalRunners = strings.TrimSpace(`
+Found %d local runner(s) left behind by previous Waypoint commands that
+exited without cleaning up. Run "waypoint runner cleanup-local" to clean
+them up.
+`)
+
+	warnVarLockSensitiveMissing = strings.TrimSpace(`
+The variable lockfile references sensitive variables that aren't set: %s.
+Sensitive values aren't stored in the lockfile, so set them from their
+original source, such as WP_VAR_* env vars, for an identical replay.
+`)
+
+	infoResumeCheckpoint = strings.TrimSpace(`
+Not every app succeeded. To resume this run without redoing the apps that
+succeeded, rerun the command with "-resume=%s".
+`)
+
+	warnGitDataSourceMisconfigured = strings.TrimSpace(`
+The Git data source of project %q is misconfigured: %s.
+Remote runners can't clone the project until a valid Git URL is set.
+`)
+
+	errNoLocalRunner = strings.TrimSpace(`
+Remote execution is required since local runners are forbidden by
+-no-local-runner or the ` + EnvNoLocalRunner + ` env var, but this operation
+would execute on a local runner. Make sure a server is configured and
+'runner.enabled' is set in the configuration.
+`)
+
+	infoRemoteFallbackLocal = strings.TrimSpace(`
+Falling back to a local runner using the local Waypoint configuration.
+`)
+
+	warnDataSourceRefNotGit = strings.TrimSpace(`
+The current context sets the data source ref %q, but this project doesn't
+use a Git data source so the ref is ignored.
 `)
 
 	// matches either "project" or "project/app"
@@ -755,6 +3270,7 @@ so you can specify the app to target using the "-app" flag.
 	snapshotUnimplementedErr = strings.TrimSpace(`
 The current Waypoint server does not support snapshots. Rerunning the command
 with '-snapshot=false' is required, and there will be no automatic data backups
-for the server.
+for the server. To disable snapshots by default for all commands, set the
+WAYPOINT_DISABLE_SNAPSHOT environment variable to "1".
 `)
 )
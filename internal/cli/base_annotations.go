@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/hashicorp/waypoint/internal/config"
+)
+
+// annotationLabelPrefix is the prefix of the job labels that carry the
+// annotations set with "-annotation". The rest of the label key is the
+// annotation key. These are stored with the resulting operations like any
+// other label, but the prefix keeps them apart from the labels that are
+// meant for filtering. This can't use the "waypoint/" prefix since that is
+// reserved and the labels would be rejected.
+const annotationLabelPrefix = "annotation.waypointproject.io/"
+
+// ciAnnotations returns the build provenance annotations from the env vars
+// of a known CI system, or nil if we aren't running in one. getenv is used
+// to read env vars so that this can be tested.
+func ciAnnotations(getenv func(string) string) map[string]string {
+	var result map[string]string
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		result = map[string]string{
+			"ci":         "github-actions",
+			"git-commit": getenv("GITHUB_SHA"),
+			"git-ref":    getenv("GITHUB_REF"),
+			"actor":      getenv("GITHUB_ACTOR"),
+		}
+
+		server, repo, run := getenv("GITHUB_SERVER_URL"), getenv("GITHUB_REPOSITORY"), getenv("GITHUB_RUN_ID")
+		if server != "" && repo != "" && run != "" {
+			result["ci-job-url"] = strings.TrimRight(server, "/") + "/" + repo + "/actions/runs/" + run
+		}
+
+	case getenv("GITLAB_CI") == "true":
+		result = map[string]string{
+			"ci":         "gitlab-ci",
+			"git-commit": getenv("CI_COMMIT_SHA"),
+			"git-ref":    getenv("CI_COMMIT_REF_NAME"),
+			"actor":      getenv("GITLAB_USER_LOGIN"),
+			"ci-job-url": getenv("CI_JOB_URL"),
+		}
+
+	default:
+		return nil
+	}
+
+	// Don't annotate with values the CI system didn't set.
+	for k, v := range result {
+		if v == "" {
+			delete(result, k)
+		}
+	}
+
+	return result
+}
+
+// initAnnotations forwards the "-annotation" flags to the jobs for this
+// command as labels, along with the annotations detected from the CI
+// system. Annotations set with the flag take precedence over detected ones.
+func (c *baseCommand) initAnnotations() error {
+	annotations := map[string]string{}
+	if !c.flagNoCIAnnotations {
+		for k, v := range ciAnnotations(os.Getenv) {
+			annotations[k] = v
+		}
+	}
+	for k, v := range c.flagAnnotations {
+		if k == "" {
+			return errors.New("The -annotation flag requires a KEY=VALUE pair with a non-empty KEY.")
+		}
+
+		annotations[k] = v
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	labels := map[string]string{}
+	for k, v := range annotations {
+		labels[annotationLabelPrefix+k] = v
+	}
+
+	// Validate here so that an invalid annotation is reported before any
+	// jobs are queued, rather than by the runner.
+	if errs := config.ValidateLabels(labels); len(errs) > 0 {
+		return multierror.Append(nil, errs...)
+	}
+
+	if c.flagLabels == nil {
+		c.flagLabels = map[string]string{}
+	}
+	for k, v := range labels {
+		c.flagLabels[k] = v
+	}
+
+	return nil
+}
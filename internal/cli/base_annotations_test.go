@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/config"
+)
+
+func TestCIAnnotations(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Env      map[string]string
+		Expected map[string]string
+	}{
+		{
+			"none",
+			nil,
+			nil,
+		},
+		{
+			"github actions",
+			map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_SHA":        "abc123",
+				"GITHUB_REF":        "refs/heads/main",
+				"GITHUB_ACTOR":      "octocat",
+				"GITHUB_SERVER_URL": "https://github.com",
+				"GITHUB_REPOSITORY": "hashicorp/waypoint",
+				"GITHUB_RUN_ID":     "42",
+			},
+			map[string]string{
+				"ci":         "github-actions",
+				"git-commit": "abc123",
+				"git-ref":    "refs/heads/main",
+				"actor":      "octocat",
+				"ci-job-url": "https://github.com/hashicorp/waypoint/actions/runs/42",
+			},
+		},
+		{
+			"gitlab ci without a user",
+			map[string]string{
+				"GITLAB_CI":          "true",
+				"CI_COMMIT_SHA":      "abc123",
+				"CI_COMMIT_REF_NAME": "main",
+				"CI_JOB_URL":         "https://gitlab.com/p/-/jobs/1",
+			},
+			map[string]string{
+				"ci":         "gitlab-ci",
+				"git-commit": "abc123",
+				"git-ref":    "main",
+				"ci-job-url": "https://gitlab.com/p/-/jobs/1",
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			getenv := func(k string) string { return tt.Env[k] }
+			require.Equal(t, tt.Expected, ciAnnotations(getenv))
+		})
+	}
+}
+
+func TestInitAnnotations(t *testing.T) {
+	require := require.New(t)
+
+	os.Setenv("GITHUB_ACTIONS", "true")
+	os.Setenv("GITHUB_SHA", "abc123")
+	defer os.Unsetenv("GITHUB_ACTIONS")
+	defer os.Unsetenv("GITHUB_SHA")
+
+	// Flags take precedence over the detected values.
+	c := &baseCommand{
+		flagLabels:      map[string]string{"team": "web"},
+		flagAnnotations: map[string]string{"git-commit": "def456", "ticket": "OPS-1"},
+	}
+	require.NoError(c.initAnnotations())
+	require.Equal("web", c.flagLabels["team"])
+	require.Equal("def456", c.flagLabels[annotationLabelPrefix+"git-commit"])
+	require.Equal("OPS-1", c.flagLabels[annotationLabelPrefix+"ticket"])
+	require.Equal("github-actions", c.flagLabels[annotationLabelPrefix+"ci"])
+
+	// The labels must be accepted by the runner when it creates the project.
+	require.Empty(config.ValidateLabels(c.flagLabels))
+
+	// Detection can be disabled.
+	c = &baseCommand{flagNoCIAnnotations: true}
+	require.NoError(c.initAnnotations())
+	require.Empty(c.flagLabels)
+
+	// Keys are required.
+	c = &baseCommand{flagAnnotations: map[string]string{"": "x"}}
+	require.Error(c.initAnnotations())
+
+	// Values are validated like labels.
+	c = &baseCommand{
+		flagNoCIAnnotations: true,
+		flagAnnotations:     map[string]string{"note": strings.Repeat("x", 256)},
+	}
+	require.Error(c.initAnnotations())
+}
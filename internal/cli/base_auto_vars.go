@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/waypoint/internal/config/variables"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+)
+
+// autoVarDirFiles returns the "*.auto.wpvars" files in the directories set
+// with -auto-var-dir. By default, auto files are only loaded from the
+// project directory with the waypoint.hcl, by the configuration and the
+// runner, so that files in unrelated directories are never picked up. The
+// project directory is skipped here since its files are already loaded.
+func (c *baseCommand) autoVarDirFiles() ([]string, error) {
+	if len(c.flagAutoVarDirs) == 0 && !c.Log.IsDebug() {
+		return nil, nil
+	}
+
+	var projectDir string
+	if path, err := c.initConfigPath(""); err == nil && path != "" {
+		projectDir, _ = filepath.Abs(filepath.Dir(path))
+		for _, f := range variables.AutoVarFiles(projectDir) {
+			c.Log.Debug("auto var file", "path", f, "dir", projectDir, "source", "project")
+		}
+	}
+
+	var result []string
+	seen := map[string]struct{}{projectDir: {}}
+	for _, dir := range c.flagAutoVarDirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[abs]; ok {
+			continue
+		}
+		seen[abs] = struct{}{}
+
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading the -auto-var-dir %q: %s", dir, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("The -auto-var-dir %q is not a directory.", dir)
+		}
+
+		for _, f := range variables.AutoVarFiles(abs) {
+			c.Log.Debug("auto var file", "path", f, "dir", abs, "source", "auto-var-dir")
+			result = append(result, f)
+		}
+	}
+
+	return result, nil
+}
+
+// addAutoVarDirFlag adds the -auto-var-dir flag to the given set.
+func (c *baseCommand) addAutoVarDirFlag(f *flag.Set) {
+	f.StringSliceVar(&flag.StringSliceVar{
+		Name:   "auto-var-dir",
+		Target: &c.flagAutoVarDirs,
+		Usage: "Additional directory to load \"*.auto.wpvars\" and " +
+			"\"*.auto.wpvars.json\" files from, such as \".\" for the current " +
+			"directory. By default, only the project directory with the " +
+			"waypoint.hcl is searched. Values from these files take precedence " +
+			"over those from the project directory, but not over -var-file. " +
+			"Can be specified multiple times.",
+	})
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoVarDirFiles(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	path := filepath.Join(td, "dev.auto.wpvars")
+	require.NoError(ioutil.WriteFile(path, []byte(`region = "us-east-1"`), 0644))
+	require.NoError(ioutil.WriteFile(filepath.Join(td, "other.wpvars"), nil, 0644))
+
+	// No directories are searched by default.
+	c := &baseCommand{Log: hclog.NewNullLogger()}
+	files, err := c.autoVarDirFiles()
+	require.NoError(err)
+	require.Empty(files)
+
+	// Each directory is only searched once.
+	c.flagAutoVarDirs = []string{td, td}
+	files, err = c.autoVarDirFiles()
+	require.NoError(err)
+	require.Equal([]string{path}, files)
+
+	// The directory must exist.
+	c.flagAutoVarDirs = []string{filepath.Join(td, "nope")}
+	_, err = c.autoVarDirFiles()
+	require.Error(err)
+
+	c.flagAutoVarDirs = []string{path}
+	_, err = c.autoVarDirFiles()
+	require.Error(err)
+	require.Contains(err.Error(), "not a directory")
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+)
+
+// checkOnly finishes a "-check-only" invocation once Init has succeeded
+// and the apps are resolved. Init doesn't necessarily make a request to
+// the server, so this verifies the connection before reporting success.
+func (c *baseCommand) checkOnly(ctx context.Context, apps []string) error {
+	if len(apps) == 0 {
+		c.ui.Output("No apps to operate on.", terminal.WithErrorStyle())
+		return ErrSentinel
+	}
+
+	if _, err := c.project.Client().GetVersionInfo(ctx, &empty.Empty{}); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return ErrSentinel
+	}
+
+	c.ui.Output("Checks passed for apps: %s", strings.Join(apps, ", "),
+		terminal.WithSuccessStyle())
+	return nil
+}
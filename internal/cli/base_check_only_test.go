@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	clientpkg "github.com/hashicorp/waypoint/internal/client"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess"
+)
+
+func TestDoAppResults_checkOnly(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	project := clientpkg.TestProject(t, clientpkg.WithClient(singleprocess.TestServer(t)))
+
+	c := baseCommand{
+		Log:           hclog.L(),
+		ui:            terminal.ConsoleUI(ctx),
+		project:       project,
+		refProject:    project.Ref(),
+		flagApp:       "web",
+		flagCheckOnly: true,
+	}
+
+	// The operation isn't executed.
+	var called bool
+	results, err := c.DoAppResults(ctx, func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+	require.NoError(err)
+	require.Empty(results)
+	require.False(called)
+}
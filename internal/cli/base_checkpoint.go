@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/hashicorp/waypoint/internal/server"
+)
+
+// checkpointDir is the directory in the home config directory that stores
+// the checkpoint of each multi-app operation started with "-checkpoint".
+const checkpointDir = "checkpoints"
+
+// reRunId matches the run IDs we generate so that a -resume value can't be
+// used to read files outside of the checkpoint directory.
+var reRunId = regexp.MustCompile(`^[0-9A-Z]+$`)
+
+// appCheckpoint records the progress of a multi-app operation so that it
+// can be resumed with "-resume" without redoing the apps that succeeded.
+type appCheckpoint struct {
+	RunId     string   `json:"run_id"`
+	Project   string   `json:"project"`
+	Workspace string   `json:"workspace"`
+	Completed []string `json:"completed"`
+
+	// path is where the checkpoint is stored.
+	path string
+}
+
+// checkpointPath returns the path of the checkpoint for the run.
+func checkpointPath(homeConfigPath, runId string) string {
+	return filepath.Join(homeConfigPath, checkpointDir, runId+".json")
+}
+
+// newCheckpoint creates the checkpoint for a new run with a generated ID.
+// The checkpoint is written right away so that the run can be resumed even
+// if the first app fails.
+func newCheckpoint(homeConfigPath, project, workspace string) (*appCheckpoint, error) {
+	id, err := server.Id()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &appCheckpoint{
+		RunId:     id,
+		Project:   project,
+		Workspace: workspace,
+		path:      checkpointPath(homeConfigPath, id),
+	}
+	if err := result.save(); err != nil {
+		return nil, fmt.Errorf("Error writing the checkpoint for the run: %s", err)
+	}
+
+	return result, nil
+}
+
+// readCheckpoint reads the checkpoint for the run and verifies that it is
+// for the given project and workspace.
+func readCheckpoint(homeConfigPath, runId, project, workspace string) (*appCheckpoint, error) {
+	if !reRunId.MatchString(runId) {
+		return nil, fmt.Errorf("Invalid run ID %q for -resume.", runId)
+	}
+
+	path := checkpointPath(homeConfigPath, runId)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf(
+			"There is no checkpoint for the run %q. The checkpoint is removed once\n"+
+				"the run succeeds for every app, so there may be nothing to resume.",
+			runId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result appCheckpoint
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("Error parsing the checkpoint for the run %q: %s", runId, err)
+	}
+	if result.Project != project || result.Workspace != workspace {
+		return nil, fmt.Errorf(
+			"The run %q is for project %q in workspace %q, but this operation\n"+
+				"is for project %q in workspace %q.",
+			runId, result.Project, result.Workspace, project, workspace)
+	}
+
+	result.path = path
+	return &result, nil
+}
+
+// Done returns true if the app completed in an earlier attempt of the run.
+func (cp *appCheckpoint) Done(app string) bool {
+	for _, v := range cp.Completed {
+		if v == app {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Complete records that the app succeeded and writes the checkpoint.
+func (cp *appCheckpoint) Complete(app string) error {
+	if !cp.Done(app) {
+		cp.Completed = append(cp.Completed, app)
+	}
+
+	return cp.save()
+}
+
+// save writes the checkpoint.
+func (cp *appCheckpoint) save() error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cp.path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(cp.path, data, 0644)
+}
+
+// Remove deletes the checkpoint once the run has succeeded for every app.
+func (cp *appCheckpoint) Remove() error {
+	if err := os.Remove(cp.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// initCheckpoint returns the checkpoint to use for DoAppResults, or nil if
+// neither "-checkpoint" nor "-resume" was set.
+func (c *baseCommand) initCheckpoint() (*appCheckpoint, error) {
+	if !c.flagCheckpoint && c.flagResume == "" {
+		return nil, nil
+	}
+
+	if c.homeConfigPath == "" || c.refProject == nil || c.refWorkspace == nil {
+		return nil, errors.New(
+			"A checkpoint requires the project, workspace, and home configuration\n" +
+				"directory to be known.")
+	}
+
+	if c.flagResume != "" {
+		return readCheckpoint(c.homeConfigPath, c.flagResume,
+			c.refProject.Project, c.refWorkspace.Workspace)
+	}
+
+	return newCheckpoint(c.homeConfigPath, c.refProject.Project, c.refWorkspace.Workspace)
+}
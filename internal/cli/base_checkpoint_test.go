@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestCheckpoint(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	c := &baseCommand{
+		homeConfigPath: td,
+		refProject:     &pb.Ref_Project{Project: "p"},
+		refWorkspace:   &pb.Ref_Workspace{Workspace: "default"},
+	}
+
+	// Not requested
+	cp, err := c.initCheckpoint()
+	require.NoError(err)
+	require.Nil(cp)
+
+	// New run. This can be resumed even if no app completed.
+	c.flagCheckpoint = true
+	cp, err = c.initCheckpoint()
+	require.NoError(err)
+	require.NotEmpty(cp.RunId)
+	c.flagResume = cp.RunId
+	resumed, err := c.initCheckpoint()
+	require.NoError(err)
+	require.Empty(resumed.Completed)
+	c.flagResume = ""
+	require.NoError(cp.Complete("web"))
+
+	// Resume skips the completed apps
+	c.flagResume = cp.RunId
+	resumed, err = c.initCheckpoint()
+	require.NoError(err)
+	require.True(resumed.Done("web"))
+	require.False(resumed.Done("api"))
+
+	// Resuming in another workspace is an error
+	c.refWorkspace = &pb.Ref_Workspace{Workspace: "prod"}
+	_, err = c.initCheckpoint()
+	require.Error(err)
+	require.Contains(err.Error(), "workspace")
+	c.refWorkspace = &pb.Ref_Workspace{Workspace: "default"}
+
+	// Run IDs can't be paths
+	c.flagResume = "../failed-apps"
+	_, err = c.initCheckpoint()
+	require.Error(err)
+
+	// Once removed, there's nothing to resume
+	require.NoError(resumed.Remove())
+	c.flagResume = cp.RunId
+	_, err = c.initCheckpoint()
+	require.Error(err)
+	require.Contains(err.Error(), "no checkpoint")
+}
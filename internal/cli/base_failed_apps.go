@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// failedAppsFile is the name of the file in the home config directory that
+// records the apps that failed in the last multi-app operation of each
+// project and workspace. This is read by "-retry-failed".
+const failedAppsFile = "failed-apps.json"
+
+// failedApps is the record of the apps that failed in the last operation
+// for a single project and workspace.
+type failedApps struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	Apps      []string  `json:"apps"`
+}
+
+// failedAppsKey is the key of the record for the project and workspace.
+func failedAppsKey(project, workspace string) string {
+	return project + "/" + workspace
+}
+
+// readFailedApps reads the failed app records, keyed by failedAppsKey. A
+// missing or unreadable file is treated as empty.
+func readFailedApps(homeConfigPath string) map[string]*failedApps {
+	data, err := ioutil.ReadFile(filepath.Join(homeConfigPath, failedAppsFile))
+	if err != nil {
+		return nil
+	}
+
+	var result map[string]*failedApps
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil
+	}
+
+	return result
+}
+
+// writeFailedApps records the apps that failed for the key. If apps is
+// empty, the record for the key is removed.
+func writeFailedApps(homeConfigPath, key string, apps []string) error {
+	records := readFailedApps(homeConfigPath)
+	if records == nil {
+		records = map[string]*failedApps{}
+	}
+
+	if len(apps) == 0 {
+		if _, ok := records[key]; !ok {
+			return nil
+		}
+
+		delete(records, key)
+	} else {
+		apps = append([]string(nil), apps...)
+		sort.Strings(apps)
+		records[key] = &failedApps{
+			UpdatedAt: time.Now(),
+			Apps:      apps,
+		}
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(homeConfigPath, 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(homeConfigPath, failedAppsFile), data, 0644)
+}
+
+// failedAppsRecordKey returns the record key for the project in the current
+// workspace, or an empty string if either isn't known.
+func (c *baseCommand) failedAppsRecordKey(project string) string {
+	if c.homeConfigPath == "" || project == "" || c.refWorkspace == nil {
+		return ""
+	}
+
+	return failedAppsKey(project, c.refWorkspace.Workspace)
+}
+
+// recordedFailedApps returns the apps that failed in the last operation for
+// the project in the current workspace, if any are recorded.
+func (c *baseCommand) recordedFailedApps(project string) []string {
+	key := c.failedAppsRecordKey(project)
+	if key == "" {
+		return nil
+	}
+
+	record, ok := readFailedApps(c.homeConfigPath)[key]
+	if !ok {
+		return nil
+	}
+
+	return record.Apps
+}
+
+// recordFailedApps records the apps of the project that failed in results so
+// that they can be retried with "-retry-failed". A run with no failures
+// clears the record. This is best-effort, so failures are only logged.
+//
+// Only operations are recorded, since those are what "-retry-failed"
+// retries. Otherwise a read-only command such as listing deployments would
+// clear the record of the operation that failed.
+func (c *baseCommand) recordFailedApps(project string, results []AppResult) {
+	if !c.operation {
+		return
+	}
+
+	key := c.failedAppsRecordKey(project)
+	if key == "" {
+		return
+	}
+
+	var failed []string
+	for _, r := range results {
+		if r.Status == AppResultError {
+			failed = append(failed, r.App)
+		}
+	}
+
+	if err := writeFailedApps(c.homeConfigPath, key, failed); err != nil {
+		c.Log.Warn("error recording failed apps", "error", err)
+	}
+}
+
+// retryFailedApps returns the apps that failed in the last operation for
+// the project and current workspace, for "-retry-failed".
+func (c *baseCommand) retryFailedApps(project string) ([]string, error) {
+	if c.flagApp != "" {
+		return nil, errors.New(
+			"The -retry-failed flag can't be combined with -app, since the apps\n" +
+				"to target are the ones that failed in the last operation.")
+	}
+
+	apps := c.recordedFailedApps(project)
+	if len(apps) == 0 {
+		return nil, fmt.Errorf(
+			"There are no failed apps recorded for project %q in workspace %q.\n"+
+				"Failed apps are recorded by operations on multiple apps and cleared\n"+
+				"once an operation succeeds for every app.",
+			project, c.refWorkspace.GetWorkspace())
+	}
+
+	return apps, nil
+}
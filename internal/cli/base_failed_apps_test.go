@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestFailedApps(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	c := &baseCommand{
+		Log:             hclog.L(),
+		homeConfigPath:  td,
+		refProject:      &pb.Ref_Project{Project: "p"},
+		refWorkspace:    &pb.Ref_Workspace{Workspace: "default"},
+		flagRetryFailed: true,
+		operation:       true,
+	}
+
+	// Nothing recorded
+	_, err = c.retryFailedApps("p")
+	require.Error(err)
+	require.Contains(err.Error(), "no failed apps recorded")
+
+	// Only the failures are recorded
+	c.recordFailedApps("p", []AppResult{
+		{App: "web", Status: AppResultSuccess},
+		{App: "worker", Status: AppResultError},
+		{App: "api", Status: AppResultError},
+	})
+	apps, err := c.retryFailedApps("p")
+	require.NoError(err)
+	require.Equal([]string{"api", "worker"}, apps)
+
+	// Other workspaces have their own record
+	require.NoError(writeFailedApps(td, failedAppsKey("p", "prod"), []string{"web"}))
+	apps, err = c.retryFailedApps("p")
+	require.NoError(err)
+	require.Equal([]string{"api", "worker"}, apps)
+
+	// Can't combine with -app
+	c.flagApp = "web"
+	_, err = c.retryFailedApps("p")
+	require.Error(err)
+	c.flagApp = ""
+
+	// Commands that aren't operations, such as listing deployments, don't
+	// change the record even if they succeed.
+	c.operation = false
+	c.recordFailedApps("p", []AppResult{
+		{App: "api", Status: AppResultSuccess},
+		{App: "worker", Status: AppResultSuccess},
+	})
+	apps, err = c.retryFailedApps("p")
+	require.NoError(err)
+	require.Equal([]string{"api", "worker"}, apps)
+	c.operation = true
+
+	// A fully successful run clears the record
+	c.recordFailedApps("p", []AppResult{
+		{App: "api", Status: AppResultSuccess},
+		{App: "worker", Status: AppResultSuccess},
+	})
+	_, err = c.retryFailedApps("p")
+	require.Error(err)
+	require.Contains(readFailedApps(td), failedAppsKey("p", "prod"))
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+)
+
+// idleTimer cancels the command context if no activity is reported with
+// Reset within the duration given with -idle-timeout. This is used by
+// attached streaming commands such as logs and exec so that sessions left
+// open don't hold server resources forever.
+//
+// A nil *idleTimer is valid and never expires, which is the behavior when
+// -idle-timeout is not set.
+type idleTimer struct {
+	d       time.Duration
+	timer   *time.Timer
+	expired int32
+}
+
+// startIdleTimer starts the idle timer for the command. c.Ctx is replaced
+// with a context that is canceled when the timer expires, so this must be
+// called after Init and before the context is used for the stream. This
+// returns nil if no idle timeout is configured.
+func (c *baseCommand) startIdleTimer() *idleTimer {
+	if c.flagIdleTimeout <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(c.Ctx)
+	c.Ctx = ctx
+
+	t := &idleTimer{d: c.flagIdleTimeout}
+	t.timer = time.AfterFunc(t.d, func() {
+		atomic.StoreInt32(&t.expired, 1)
+		c.Log.Info("idle timeout reached, canceling", "timeout", t.d)
+		cancel()
+	})
+
+	return t
+}
+
+// Reset records activity and restarts the idle period.
+func (t *idleTimer) Reset() {
+	if t == nil || t.Expired() {
+		return
+	}
+
+	t.timer.Reset(t.d)
+}
+
+// Stop stops the timer. This should be called once the stream is done.
+func (t *idleTimer) Stop() {
+	if t == nil {
+		return
+	}
+
+	t.timer.Stop()
+}
+
+// Expired returns true if the context was canceled due to the timer.
+func (t *idleTimer) Expired() bool {
+	return t != nil && atomic.LoadInt32(&t.expired) == 1
+}
+
+// Message returns the message explaining to the user why the session was
+// closed.
+func (t *idleTimer) Message() string {
+	return fmt.Sprintf(
+		"No data received for %s, closing the session. The idle timeout "+
+			"can be changed with -idle-timeout.", t.d)
+}
+
+// Writer wraps w so that each write counts as activity.
+func (t *idleTimer) Writer(w io.Writer) io.Writer {
+	if t == nil {
+		return w
+	}
+
+	return &idleWriter{w: w, t: t}
+}
+
+type idleWriter struct {
+	w io.Writer
+	t *idleTimer
+}
+
+func (w *idleWriter) Write(p []byte) (int, error) {
+	w.t.Reset()
+	return w.w.Write(p)
+}
+
+// addIdleTimeoutFlag adds the -idle-timeout flag to the given set.
+func (c *baseCommand) addIdleTimeoutFlag(f *flag.Set) {
+	f.DurationVar(&flag.DurationVar{
+		Name:   "idle-timeout",
+		Target: &c.flagIdleTimeout,
+		Usage: "Close the session if no data is received for this duration, " +
+			"such as \"30m\". Defaults to no idle timeout.",
+	})
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdleTimer(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		require := require.New(t)
+
+		c := baseCommand{Ctx: context.Background(), Log: hclog.L()}
+		idle := c.startIdleTimer()
+		require.Nil(idle)
+
+		// A nil timer must be safe to use
+		idle.Reset()
+		idle.Stop()
+		require.False(idle.Expired())
+		require.NoError(c.Ctx.Err())
+	})
+
+	t.Run("reset on activity and expire when idle", func(t *testing.T) {
+		require := require.New(t)
+
+		c := baseCommand{
+			Ctx:             context.Background(),
+			Log:             hclog.L(),
+			flagIdleTimeout: 50 * time.Millisecond,
+		}
+		idle := c.startIdleTimer()
+		defer idle.Stop()
+
+		// Keep the session active for longer than the timeout
+		w := idle.Writer(ioutil.Discard)
+		for i := 0; i < 5; i++ {
+			time.Sleep(20 * time.Millisecond)
+			_, err := w.Write([]byte("data"))
+			require.NoError(err)
+		}
+		require.False(idle.Expired())
+		require.NoError(c.Ctx.Err())
+
+		select {
+		case <-c.Ctx.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("context should be canceled")
+		}
+		require.True(idle.Expired())
+	})
+}
@@ -4,11 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
 	"github.com/hashicorp/waypoint/internal/clicontext"
 	clientpkg "github.com/hashicorp/waypoint/internal/client"
 	configpkg "github.com/hashicorp/waypoint/internal/config"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
 	"github.com/hashicorp/waypoint/internal/serverclient"
 )
 
@@ -20,33 +28,35 @@ import (
 // other commands will succeed as well.
 
 // initConfig initializes the configuration with the specified filename from the CLI.
-// If filename is empty, it will default to configpkg.Filename.
-func (c *baseCommand) initConfig(filename string, optional bool) (*configpkg.Config, error) {
+// If filename is empty, it will default to configFilename.
+//
+// If no configuration file is found, ErrConfigNotFound is returned. If the
+// configuration file fails to load or validate, the returned error will
+// match ErrConfigParse with errors.Is.
+func (c *baseCommand) initConfig(filename string) (*configpkg.Config, error) {
 	path, err := c.initConfigPath(filename)
 	if err != nil {
 		return nil, err
 	}
 
 	if path == "" {
-		if optional {
-			return nil, nil
-		}
-
-		return nil, errors.New(
-			"A Waypoint configuration file (waypoint.hcl) is required but wasn't found.\n" +
-				"The command can use configuration stored on the server by adding project/app\n" +
-				"as a final argument on the command line.\n" +
-				"For instance: `waypoint deployment list webapp/web` for the app 'web' in\n" +
-				"the project 'webapp'",
-		)
+		return nil, ErrConfigNotFound
 	}
 
 	return c.initConfigLoad(path)
 }
 
 // initConfigPath returns the path for the configuration file with the
-// specified filename.
+// specified filename. If filename is empty, it will default to
+// configFilename.
 func (c *baseCommand) initConfigPath(filename string) (string, error) {
+	if filename == "" {
+		var err error
+		if filename, err = c.configFilename(); err != nil {
+			return "", err
+		}
+	}
+
 	path, err := configpkg.FindPath("", filename, true)
 	if err != nil {
 		return "", fmt.Errorf("Error looking for a Waypoint configuration: %s", err)
@@ -55,24 +65,173 @@ func (c *baseCommand) initConfigPath(filename string) (string, error) {
 	return path, nil
 }
 
+// configFilename returns the file name of the configuration to search for,
+// which is set with "-config-filename" and defaults to configpkg.Filename.
+// This is only a name, since the configuration is searched for in the
+// current directory and its parents.
+func (c *baseCommand) configFilename() (string, error) {
+	name := c.flagConfigFilename
+	if name == "" {
+		return configpkg.Filename, nil
+	}
+
+	if name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf(
+			"The -config-filename value %q must be a file name without a directory.", name)
+	}
+
+	return name, nil
+}
+
 // initConfigLoad loads the configuration at the given path.
 func (c *baseCommand) initConfigLoad(path string) (*configpkg.Config, error) {
-	cfg, err := configpkg.Load(path, &configpkg.LoadOptions{
-		Pwd:       filepath.Dir(path),
-		Workspace: c.refWorkspace.Workspace,
-	})
+	cfg, err := configpkg.Load(path, c.configLoadOptions(path))
 	if err != nil {
-		return nil, err
+		return nil, &configParseError{Path: path, Err: err}
 	}
 
 	// Validate
 	if err := cfg.Validate(); err != nil {
-		return nil, err
+		return nil, &configParseError{Path: path, Err: err}
 	}
 
+	// The workspace may be an alias from the configuration, which we can
+	// only resolve now. If it is, we load the configuration again so that
+	// it's evaluated with the workspace that the alias refers to.
+	if c.refWorkspace != nil {
+		alias := c.refWorkspace.Workspace
+		if ws := cfg.WorkspaceAlias(alias); ws != alias {
+			c.Log.Debug("resolved workspace alias", "alias", alias, "workspace", ws)
+			c.refWorkspace.Workspace = ws
+
+			if cfg, err = configpkg.Load(path, c.configLoadOptions(path)); err != nil {
+				return nil, &configParseError{Path: path, Err: err}
+			}
+			if err := cfg.Validate(); err != nil {
+				return nil, &configParseError{Path: path, Err: err}
+			}
+		}
+	}
+
+	if c.atVerbosity(verbosityDebug) {
+		c.ui.Output(configAppList(cfg), terminal.WithInfoStyle())
+	}
+
+	c.configPath = path
 	return cfg, nil
 }
 
+// configLoadOptions returns the options to load the configuration at path
+// with the current flags.
+func (c *baseCommand) configLoadOptions(path string) *configpkg.LoadOptions {
+	return &configpkg.LoadOptions{
+		Pwd:         filepath.Dir(path),
+		Workspace:   c.refWorkspace.GetWorkspace(),
+		Variables:   c.configVariables(),
+		ProjectPath: c.flagProjectConfig,
+		Profile:     c.flagConfigProfile,
+	}
+}
+
+// validateConfig loads and validates the configuration at path the same
+// as initConfigLoad, and then also loads and validates each app. Unlike
+// initConfigLoad, this doesn't stop at the first error in the structure
+// of the configuration, so that every problem can be reported at once.
+// This doesn't require a server.
+func (c *baseCommand) validateConfig(path string) []*configDiagnostic {
+	cfg, err := configpkg.Load(path, c.configLoadOptions(path))
+	if err != nil {
+		return configDiagnostics(err)
+	}
+
+	// If the structure is invalid, the apps can't be loaded.
+	if err := cfg.Validate(); err != nil {
+		return configDiagnostics(err)
+	}
+
+	var result []*configDiagnostic
+	for _, name := range cfg.Apps() {
+		app, err := cfg.App(name, nil)
+		if err == nil {
+			err = app.Validate()
+		}
+
+		for _, d := range configDiagnostics(err) {
+			if d.Filename == "" {
+				d.Summary = fmt.Sprintf("app %q: %s", name, d.Summary)
+			}
+
+			result = append(result, d)
+		}
+	}
+
+	return result
+}
+
+// configAppList returns a list of the apps defined in the configuration
+// and where they're defined, to help users target the right app.
+func configAppList(cfg *configpkg.Config) string {
+	decls := cfg.AppDecls()
+	if len(decls) == 0 {
+		return "No apps are defined in the configuration."
+	}
+
+	wd, _ := os.Getwd()
+
+	var b strings.Builder
+	b.WriteString("Apps defined in the configuration:\n")
+	for _, d := range decls {
+		filename := d.Range.Filename
+		if rel, err := filepath.Rel(wd, filename); err == nil && wd != "" {
+			filename = rel
+		}
+
+		fmt.Fprintf(&b, "\n  %s (%s:%d)", d.Name, filename, d.Range.Start.Line)
+	}
+
+	return b.String()
+}
+
+// configVariables returns the variable values that are available while
+// evaluating the configuration. This is the operation values from -var,
+// -var-file, and WP_VAR_* with the config-only -config-var values taking
+// precedence.
+func (c *baseCommand) configVariables() []*pb.Variable {
+	result := make([]*pb.Variable, 0, len(c.variables)+len(c.flagConfigVars))
+	result = append(result, c.variables...)
+
+	// Sort so that the resulting variables are in a stable order.
+	keys := make([]string, 0, len(c.flagConfigVars))
+	for k := range c.flagConfigVars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		result = append(result, &pb.Variable{
+			Name:   k,
+			Value:  &pb.Variable_Str{Str: c.flagConfigVars[k]},
+			Source: &pb.Variable_Cli{},
+		})
+	}
+
+	return result
+}
+
+// configParseError is returned when a configuration file was found but
+// failed to load or validate. This matches ErrConfigParse with errors.Is.
+// The error message is the underlying error so that diagnostics are
+// shown to the user unchanged.
+type configParseError struct {
+	Path string
+	Err  error
+}
+
+func (e *configParseError) Error() string { return e.Err.Error() }
+func (e *configParseError) Unwrap() error { return e.Err }
+
+// Is implements the interface used by errors.Is.
+func (e *configParseError) Is(target error) bool { return target == ErrConfigParse }
+
 // initClient initializes the client.
 //
 // If ctx is nil, c.Ctx will be used. If ctx is non-nil, that context will be
@@ -87,16 +246,34 @@ func (c *baseCommand) initClient(
 		flagConnection = &v
 	}
 
+	pkcs12Passphrase, err := c.pkcs12Passphrase()
+	if err != nil {
+		return nil, err
+	}
+
 	// Get the context we'll use. The ordering here is purposeful and creates
 	// the following precedence: (1) context (2) env (3) flags where the
 	// later values override the former.
-	var err error
 	connectOpts = append([]serverclient.ConnectOption{
 		serverclient.FromContext(c.contextStorage, ""),
 		serverclient.FromEnv(),
 		serverclient.FromContextConfig(flagConnection),
+		serverclient.IPVersion(c.flagServerIPVersion),
+		serverclient.SSHBastion(c.flagServerSSHBastion, c.flagServerSSHKey),
+		serverclient.TLSPKCS12(c.flagServerTLSPKCS12, pkcs12Passphrase),
+		serverclient.TLSCAPath(c.flagConnection.Server.TlsCaPath, c.flagConnection.Server.TlsCaOnly),
+		serverclient.Headers(c.flagHeaders),
+		serverclient.Keepalive(c.flagServerKeepalive),
+		serverclient.RateLimit(c.flagRPS),
 		serverclient.Logger(c.Log.Named("serverclient")),
 	}, connectOpts...)
+
+	// The address may have placeholders such as "{workspace}". This must
+	// be last so it expands the address from any of the options above.
+	values := c.serverAddrValues()
+	connectOpts = append(connectOpts, serverclient.ExpandAddr(func(addr string) (string, error) {
+		return expandServerAddr(addr, values)
+	}))
 	c.clientContext, err = serverclient.ContextConfig(connectOpts...)
 	if err != nil {
 		return nil, err
@@ -109,11 +286,21 @@ func (c *baseCommand) initClient(
 		clientpkg.WithProjectRef(c.refProject),
 		clientpkg.WithWorkspaceRef(c.refWorkspace),
 		clientpkg.WithVariables(c.variables),
+		clientpkg.WithAppVariables(c.appVariables),
 		clientpkg.WithLabels(c.flagLabels),
 		clientpkg.WithSourceOverrides(c.flagRemoteSource),
 	}
+	if c.flagPrintJob {
+		opts = append(opts, clientpkg.WithPrintJob(os.Stdout))
+	}
+	if c.exportJobFile != nil {
+		opts = append(opts, clientpkg.WithExportJob(c.exportJobFile))
+	}
 	if !c.flagRemote && c.autoServer {
 		opts = append(opts, clientpkg.WithLocal())
+		if c.flagAutoServerDir != "" {
+			opts = append(opts, clientpkg.WithLocalServerDir(c.flagAutoServerDir))
+		}
 	}
 
 	if c.ui != nil {
@@ -127,3 +314,174 @@ func (c *baseCommand) initClient(
 	// Create our client
 	return clientpkg.New(ctx, opts...)
 }
+
+// initContextCreate saves the connection that was configured with flags as
+// a CLI context with the given name. This should only be called after
+// initClient succeeds so that we only ever save working connections.
+func (c *baseCommand) initContextCreate(name string) error {
+	// We only save connections that came from flags. Other sources are
+	// either already a context or the environment, which persists anyways.
+	if c.flagConnection.Server.Address == "" || c.clientContext == nil {
+		c.ui.Output(warnContextCreateNoFlags, terminal.WithWarningStyle())
+		return nil
+	}
+
+	names, err := c.contextStorage.List()
+	if err != nil {
+		return err
+	}
+	for _, n := range names {
+		if n == name && !c.flagContextCreateForce {
+			c.Log.Debug("context already exists, not overwriting", "name", name)
+			return nil
+		}
+	}
+
+	// Copy the context so we don't modify the one we're using. We save the
+	// address as given so that any placeholders are expanded on each use.
+	config := *c.clientContext
+	config.Server.Address = c.flagConnection.Server.Address
+	if err := c.contextStorage.Set(name, &config); err != nil {
+		return err
+	}
+	if c.flagContextCreateDefault {
+		if err := c.contextStorage.SetDefault(name); err != nil {
+			return err
+		}
+	}
+
+	c.ui.Output("Context %q created.", name, terminal.WithSuccessStyle())
+	return nil
+}
+
+// waitForServer blocks until a client can be initialized, which requires
+// that the server is reachable and responds to API version negotiation,
+// or until the timeout elapses. Attempts are made every interval and
+// onRetry, if non-nil, is called after each failed attempt.
+//
+// Automatic in-memory servers should be disabled (see WithNoAutoServer)
+// when using this, otherwise the first attempt will always succeed.
+func (c *baseCommand) waitForServer(
+	ctx context.Context,
+	timeout time.Duration,
+	interval time.Duration,
+	onRetry func(attempt int, err error),
+) (*clientpkg.Project, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		project, err := c.initClient(ctx, serverclient.Timeout(interval))
+		if err == nil {
+			return project, nil
+		}
+
+		// If we have no server configuration then retrying won't help.
+		if err == serverclient.ErrNoServerConfig {
+			return nil, err
+		}
+
+		if onRetry != nil {
+			onRetry(attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf(
+				"Timed out after %s waiting for the server. Last error: %s", timeout, err)
+
+		case <-time.After(interval):
+		}
+	}
+}
+
+// autoServerDir is the directory in the home config directory that stores
+// the state of the automatically started server if "-auto-server-dir"
+// isn't set.
+const autoServerDir = "server"
+
+// initAutoServerDir validates the "-auto-server-dir" flag and creates the
+// directory if it doesn't exist. If the flag isn't set, this defaults to
+// autoServerDir in the home config directory for local operations.
+func (c *baseCommand) initAutoServerDir() error {
+	if c.flagAutoServerDir == "" {
+		if !c.autoServer || c.flagRemote || c.homeConfigPath == "" {
+			return nil
+		}
+
+		c.flagAutoServerDir = filepath.Join(c.homeConfigPath, autoServerDir)
+	} else if !c.autoServer {
+		return errors.New(
+			"The -auto-server-dir flag is only supported by commands that can\n" +
+				"start a server automatically for local operations.")
+	}
+
+	fi, err := os.Stat(c.flagAutoServerDir)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(c.flagAutoServerDir, 0700)
+	}
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf(
+			"The -auto-server-dir path %q exists but isn't a directory.",
+			c.flagAutoServerDir)
+	}
+
+	return nil
+}
+
+// initServerConfigDir loads the connection from the files in the
+// "-server-config-dir" directory, such as mounted secrets. This replaces
+// "-server-addr", but explicitly set TLS flags take precedence over the
+// values in the directory. set is the parsed flags.
+func (c *baseCommand) initServerConfigDir(set *flag.Sets) error {
+	if c.flagServerConfigDir == "" {
+		return nil
+	}
+
+	if set.IsSet("server-addr") {
+		return errors.New(
+			"The -server-config-dir and -server-addr flags can't both be set.")
+	}
+
+	cfg, err := clicontext.LoadDir(c.flagServerConfigDir)
+	if err != nil {
+		return err
+	}
+
+	c.flagConnection.Server.Address = cfg.Server.Address
+	c.flagConnection.Server.RequireAuth = cfg.Server.RequireAuth
+	c.flagConnection.Server.AuthToken = cfg.Server.AuthToken
+	if !set.IsSet("server-tls") {
+		c.flagConnection.Server.Tls = cfg.Server.Tls
+	}
+	if !set.IsSet("server-tls-skip-verify") {
+		c.flagConnection.Server.TlsSkipVerify = cfg.Server.TlsSkipVerify
+	}
+
+	return nil
+}
+
+// pkcs12Passphrase returns the passphrase of the "-server-tls-pkcs12" file
+// from "-server-tls-pkcs12-passphrase-file". This returns an empty string
+// if the file isn't set, so that the passphrase is read from the
+// environment instead.
+func (c *baseCommand) pkcs12Passphrase() (string, error) {
+	if c.flagServerTLSPKCS12PassFile == "" {
+		return "", nil
+	}
+	if c.flagServerTLSPKCS12 == "" {
+		return "", errors.New(
+			"The -server-tls-pkcs12-passphrase-file flag requires -server-tls-pkcs12.")
+	}
+
+	data, err := ioutil.ReadFile(c.flagServerTLSPKCS12PassFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading the PKCS#12 passphrase file: %w", err)
+	}
+
+	// Trailing newlines are common in secret files but never intended.
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,472 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clicontext"
+	"github.com/hashicorp/waypoint/internal/config"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/serverclient"
+)
+
+func TestValidateConfig(t *testing.T) {
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	write := func(t *testing.T, src string) string {
+		path := filepath.Join(td, "waypoint.hcl")
+		require.NoError(t, ioutil.WriteFile(path, []byte(src), 0644))
+		return path
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		path := write(t, `
+project = "test"
+
+variable "region" {
+  type = string
+}
+
+app "web" {
+  build {
+    use "docker" {}
+  }
+
+  deploy {
+    use "docker" {}
+  }
+}
+`)
+
+		c := &baseCommand{}
+		require.Empty(t, c.validateConfig(path))
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		require := require.New(t)
+
+		path := write(t, `project = "test`)
+
+		c := &baseCommand{}
+		diags := c.validateConfig(path)
+		require.NotEmpty(diags)
+		require.Equal("error", diags[0].Severity)
+		require.Equal(path, diags[0].Filename)
+		require.Equal(1, diags[0].Line)
+	})
+
+	t.Run("all problems are reported", func(t *testing.T) {
+		require := require.New(t)
+
+		path := write(t, `
+labels = { "waypoint/reserved" = "x" }
+
+app "web" {
+  build {
+    use "docker" {}
+  }
+}
+
+app "api" {
+  deploy {
+    use "docker" {}
+  }
+}
+`)
+
+		c := &baseCommand{}
+		diags := c.validateConfig(path)
+		require.Len(diags, 4)
+
+		var summaries []string
+		for _, d := range diags {
+			summaries = append(summaries, d.Summary)
+		}
+		require.Contains(summaries, "'project' attribute is required")
+		require.Contains(summaries, "'deploy' stanza required")
+		require.Contains(summaries, "'build' stanza required")
+	})
+
+	t.Run("app problems", func(t *testing.T) {
+		require := require.New(t)
+
+		path := write(t, `
+project = "test"
+
+app "web" {
+  labels = { "waypoint/reserved" = "x" }
+
+  build {
+    use "docker" {}
+  }
+
+  deploy {
+    use "docker" {}
+  }
+}
+`)
+
+		c := &baseCommand{}
+		diags := c.validateConfig(path)
+		require.Len(diags, 1)
+		require.Contains(diags[0].Summary, `app "web": `)
+	})
+}
+
+func TestInitConfigPath_configFilename(t *testing.T) {
+	td, err := ioutil.TempDir("", "waypoint-cli")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+	td, err = filepath.EvalSymlinks(td)
+	require.NoError(t, err)
+
+	nested := filepath.Join(td, "services", "web")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(td, "waypoint-app.hcl"), nil, 0644))
+
+	// Search from a nested directory
+	pwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(nested))
+	defer os.Chdir(pwd)
+
+	t.Run("custom filename", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{flagConfigFilename: "waypoint-app.hcl"}
+		path, err := c.initConfigPath("")
+		require.NoError(err)
+		require.Equal(filepath.Join(td, "waypoint-app.hcl"), path)
+	})
+
+	t.Run("default filename", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{}
+		path, err := c.initConfigPath("")
+		require.NoError(err)
+		require.Empty(path)
+	})
+
+	t.Run("filename with a directory", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{flagConfigFilename: filepath.Join("config", "waypoint.hcl")}
+		_, err := c.initConfigPath("")
+		require.Error(err)
+	})
+}
+
+func TestInitConfigErrors(t *testing.T) {
+	c := baseCommand{
+		refWorkspace: &pb.Ref_Workspace{Workspace: defaultWorkspace},
+	}
+
+	// Move into a temporary directory so we control the config
+	td, err := ioutil.TempDir("", "waypoint-cli")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	pwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(td))
+	defer os.Chdir(pwd)
+
+	t.Run("not found", func(t *testing.T) {
+		require := require.New(t)
+
+		_, err := c.initConfig("")
+		require.True(errors.Is(err, ErrConfigNotFound))
+		require.False(errors.Is(err, ErrConfigParse))
+	})
+
+	t.Run("parse failure", func(t *testing.T) {
+		require := require.New(t)
+
+		path := filepath.Join(td, config.Filename)
+		require.NoError(ioutil.WriteFile(path, []byte(`project = `), 0644))
+		defer os.Remove(path)
+
+		_, err := c.initConfig("")
+		require.True(errors.Is(err, ErrConfigParse))
+		require.False(errors.Is(err, ErrConfigNotFound))
+	})
+}
+
+func TestInitContextCreate(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	st, err := clicontext.NewStorage(clicontext.WithDir(td))
+	require.NoError(err)
+
+	c := &baseCommand{
+		Log:            hclog.L(),
+		ui:             terminal.ConsoleUI(context.Background()),
+		contextStorage: st,
+	}
+	c.flagConnection.Server.Address = "example.com:9701"
+	c.clientContext = &c.flagConnection
+
+	// Creates the context if it doesn't exist
+	require.NoError(c.initContextCreate("bootstrap"))
+	cfg, err := st.Load("bootstrap")
+	require.NoError(err)
+	require.Equal("example.com:9701", cfg.Server.Address)
+
+	// Doesn't overwrite an existing context
+	c.flagConnection.Server.Address = "other.com:9701"
+	require.NoError(c.initContextCreate("bootstrap"))
+	cfg, err = st.Load("bootstrap")
+	require.NoError(err)
+	require.Equal("example.com:9701", cfg.Server.Address)
+
+	// Overwrites if forced
+	c.flagContextCreateForce = true
+	require.NoError(c.initContextCreate("bootstrap"))
+	cfg, err = st.Load("bootstrap")
+	require.NoError(err)
+	require.Equal("other.com:9701", cfg.Server.Address)
+}
+
+func TestWaitForServer(t *testing.T) {
+	defer os.Setenv(serverclient.EnvServerAddr, os.Getenv(serverclient.EnvServerAddr))
+	require.NoError(t, os.Unsetenv(serverclient.EnvServerAddr))
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	st, err := clicontext.NewStorage(clicontext.WithDir(td))
+	require.NoError(t, err)
+
+	t.Run("no server config", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{Log: hclog.L(), contextStorage: st}
+
+		// Retrying can't help, so this returns without waiting.
+		var attempts int
+		_, err := c.waitForServer(context.Background(), time.Minute, time.Minute,
+			func(int, error) { attempts++ })
+		require.Equal(serverclient.ErrNoServerConfig, err)
+		require.Equal(0, attempts)
+	})
+
+	t.Run("server unavailable", func(t *testing.T) {
+		require := require.New(t)
+
+		// Get an address that nothing is listening on.
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(err)
+		addr := ln.Addr().String()
+		require.NoError(ln.Close())
+
+		c := &baseCommand{Log: hclog.L(), contextStorage: st}
+		c.flagConnection.Server.Address = addr
+
+		var attempts int
+		_, err = c.waitForServer(context.Background(),
+			500*time.Millisecond, 50*time.Millisecond,
+			func(attempt int, err error) {
+				attempts++
+				require.Equal(attempts, attempt)
+				require.Error(err)
+			})
+		require.Error(err)
+		require.Contains(err.Error(), "Timed out")
+		require.Greater(attempts, 1)
+	})
+}
+
+func TestInitServerConfigDir(t *testing.T) {
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	for name, v := range map[string]string{
+		"server-addr": "waypoint.example.com:9701\n",
+		"server-tls":  "true\n",
+		"token":       "secret\n",
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(td, name), []byte(v), 0600))
+	}
+
+	t.Run("from the directory", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{}
+		set := c.flagSet(flagSetConnection, nil)
+		require.NoError(set.Parse([]string{
+			"-server-config-dir", td,
+			"-server-tls-skip-verify",
+		}))
+		require.NoError(c.initServerConfigDir(set))
+		require.Equal("waypoint.example.com:9701", c.flagConnection.Server.Address)
+		require.True(c.flagConnection.Server.Tls)
+		require.True(c.flagConnection.Server.TlsSkipVerify)
+		require.True(c.flagConnection.Server.RequireAuth)
+		require.Equal("secret", c.flagConnection.Server.AuthToken)
+	})
+
+	t.Run("with -server-addr", func(t *testing.T) {
+		c := &baseCommand{}
+		set := c.flagSet(flagSetConnection, nil)
+		require.NoError(t, set.Parse([]string{
+			"-server-config-dir", td,
+			"-server-addr", "other.example.com:9701",
+		}))
+		require.Error(t, c.initServerConfigDir(set))
+	})
+}
+
+func TestInitConfigLoad_workspaceAlias(t *testing.T) {
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	path := filepath.Join(td, "waypoint.hcl")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+project = "test"
+
+workspace_aliases = {
+  prod = "production-us-east-1"
+}
+
+app "web" {
+  labels = {
+    workspace = workspace.name
+  }
+
+  build {}
+  deploy {}
+}
+`), 0644))
+
+	t.Run("alias", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{
+			Log:          hclog.L(),
+			refWorkspace: &pb.Ref_Workspace{Workspace: "prod"},
+		}
+		cfg, err := c.initConfigLoad(path)
+		require.NoError(err)
+		require.Equal("production-us-east-1", c.refWorkspace.Workspace)
+
+		// The configuration is evaluated with the resolved workspace.
+		app, err := cfg.App("web", nil)
+		require.NoError(err)
+		require.Equal("production-us-east-1", app.Labels["workspace"])
+	})
+
+	t.Run("not an alias", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{
+			Log:          hclog.L(),
+			refWorkspace: &pb.Ref_Workspace{Workspace: "staging"},
+		}
+		_, err := c.initConfigLoad(path)
+		require.NoError(err)
+		require.Equal("staging", c.refWorkspace.Workspace)
+	})
+}
+
+func TestPKCS12Passphrase(t *testing.T) {
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	path := filepath.Join(td, "passphrase")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hunter2\n"), 0600))
+
+	t.Run("unset", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{flagServerTLSPKCS12: "client.p12"}
+		v, err := c.pkcs12Passphrase()
+		require.NoError(err)
+		require.Empty(v)
+	})
+
+	t.Run("from a file", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{
+			flagServerTLSPKCS12:         "client.p12",
+			flagServerTLSPKCS12PassFile: path,
+		}
+		v, err := c.pkcs12Passphrase()
+		require.NoError(err)
+		require.Equal("hunter2", v)
+	})
+
+	t.Run("without a PKCS#12 file", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{flagServerTLSPKCS12PassFile: path}
+		_, err := c.pkcs12Passphrase()
+		require.Error(err)
+	})
+}
+
+func TestInitAutoServerDir(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	// Unset is fine.
+	c := &baseCommand{autoServer: true}
+	require.NoError(c.initAutoServerDir())
+	require.Empty(c.flagAutoServerDir)
+
+	// Unset defaults to a directory in the home config directory.
+	c = &baseCommand{autoServer: true, homeConfigPath: td}
+	require.NoError(c.initAutoServerDir())
+	require.Equal(filepath.Join(td, autoServerDir), c.flagAutoServerDir)
+	fi, err := os.Stat(c.flagAutoServerDir)
+	require.NoError(err)
+	require.True(fi.IsDir())
+
+	// There is no default for remote operations.
+	c = &baseCommand{autoServer: true, homeConfigPath: td, flagRemote: true}
+	require.NoError(c.initAutoServerDir())
+	require.Empty(c.flagAutoServerDir)
+
+	// The directory is created.
+	c.flagAutoServerDir = filepath.Join(td, "a", "b")
+	require.NoError(c.initAutoServerDir())
+	fi, err = os.Stat(c.flagAutoServerDir)
+	require.NoError(err)
+	require.True(fi.IsDir())
+
+	// An existing directory is fine too.
+	require.NoError(c.initAutoServerDir())
+
+	// A file isn't.
+	c.flagAutoServerDir = filepath.Join(td, "file")
+	require.NoError(ioutil.WriteFile(c.flagAutoServerDir, nil, 0644))
+	require.Error(c.initAutoServerDir())
+
+	// Commands without an auto server don't support it.
+	c = &baseCommand{flagAutoServerDir: td}
+	require.Error(c.initAutoServerDir())
+}
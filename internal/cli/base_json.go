@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/hashicorp/waypoint/internal/serverclient"
+)
+
+// jsonTarget is what a command acted upon, as resolved by Init. This is
+// included in the JSON output of commands so that automation can confirm
+// the target regardless of the command.
+type jsonTarget struct {
+	Project    string   `json:"project,omitempty"`
+	Apps       []string `json:"apps,omitempty"`
+	Workspace  string   `json:"workspace,omitempty"`
+	Remote     bool     `json:"remote"`
+	ConfigPath string   `json:"config_path,omitempty"`
+	Context    string   `json:"context,omitempty"`
+}
+
+// jsonEnvelope is the top-level JSON output of commands with "-json".
+type jsonEnvelope struct {
+	Target *jsonTarget `json:"target"`
+	Result interface{} `json:"result"`
+}
+
+// jsonTarget returns the resolved target for JSON output. apps are the
+// apps the output is for. If apps is empty, the targeted app is used if
+// there is one.
+func (c *baseCommand) jsonTarget(apps []string) *jsonTarget {
+	if len(apps) == 0 && c.refApp != nil {
+		apps = []string{c.refApp.Application}
+	}
+
+	return &jsonTarget{
+		Project:    c.refProject.GetProject(),
+		Apps:       apps,
+		Workspace:  c.refWorkspace.GetWorkspace(),
+		Remote:     c.flagRemote,
+		ConfigPath: c.configPath,
+		Context:    c.contextName(),
+	}
+}
+
+// contextName returns the name of the CLI context used to connect, or
+// an empty string if the connection wasn't from a stored context. This
+// follows the precedence of initClient, where the server flags and env
+// vars override the context.
+func (c *baseCommand) contextName() string {
+	if c.flagConnection.Server.Address != "" || os.Getenv(serverclient.EnvServerAddr) != "" {
+		return ""
+	}
+	if v := os.Getenv(serverclient.EnvContext); v != "" {
+		return v
+	}
+	if c.contextStorage == nil {
+		return ""
+	}
+
+	name, err := c.contextStorage.Default()
+	if err != nil || name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+// outputJSON outputs the result of a command with "-json" wrapped in the
+// envelope with the resolved target. apps are the apps the result is for.
+// Every command with "-json" outputs through this so that the shape of the
+// output is the same for all of them.
+func (c *baseCommand) outputJSON(apps []string, result interface{}) error {
+	data, err := json.MarshalIndent(&jsonEnvelope{
+		Target: c.jsonTarget(apps),
+		Result: result,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	c.ui.Output(string(data))
+	return nil
+}
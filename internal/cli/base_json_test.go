@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/clicontext"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestJSONTarget(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	st, err := clicontext.NewStorage(clicontext.WithDir(td))
+	require.NoError(err)
+	require.NoError(st.Set("prod", &clicontext.Config{}))
+
+	c := &baseCommand{
+		contextStorage: st,
+		configPath:     "/tmp/waypoint.hcl",
+		flagRemote:     true,
+		refProject:     &pb.Ref_Project{Project: "p"},
+		refApp:         &pb.Ref_Application{Application: "web", Project: "p"},
+		refWorkspace:   &pb.Ref_Workspace{Workspace: "dev"},
+	}
+
+	// The targeted app is used if no apps are given.
+	require.Equal(&jsonTarget{
+		Project:    "p",
+		Apps:       []string{"web"},
+		Workspace:  "dev",
+		Remote:     true,
+		ConfigPath: "/tmp/waypoint.hcl",
+		Context:    "prod",
+	}, c.jsonTarget(nil))
+	require.Equal([]string{"api"}, c.jsonTarget([]string{"api"}).Apps)
+
+	// A connection from flags isn't from a context.
+	c.flagConnection.Server.Address = "localhost:9701"
+	require.Empty(c.jsonTarget(nil).Context)
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+
+	"github.com/hashicorp/waypoint/internal/config"
+)
+
+// labelFile is the format of a file given with "-label-file". This is the
+// same as the "labels" attribute in the waypoint.hcl so that keys can
+// contain characters such as "/".
+type labelFile struct {
+	Labels map[string]string `hcl:"labels"`
+}
+
+// readLabelFile reads and validates the labels in the file at path. Files
+// ending in ".json" are parsed as JSON and all others as HCL.
+func readLabelFile(path string) (map[string]string, error) {
+	parser := hclparse.NewParser()
+
+	var f *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(path, ".json") {
+		f, diags = parser.ParseJSONFile(path)
+	} else {
+		f, diags = parser.ParseHCLFile(path)
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var result labelFile
+	if diags := gohcl.DecodeBody(f.Body, nil, &result); diags.HasErrors() {
+		return nil, diags
+	}
+
+	if errs := config.ValidateLabels(result.Labels); len(errs) > 0 {
+		return nil, fmt.Errorf("Invalid labels in %q: %s", path,
+			multierror.Append(nil, errs...).Error())
+	}
+
+	return result.Labels, nil
+}
+
+// initLabelFiles merges the labels from the "-label-file" flags into the
+// labels for this command. Files are merged in the order they're given, so
+// later files take precedence, and labels set with "-label" take precedence
+// over all files. The labels from a "-spec" file have the lowest precedence.
+func (c *baseCommand) initLabelFiles() error {
+	if len(c.flagLabelFiles) == 0 && len(c.specLabels) == 0 {
+		return nil
+	}
+
+	labels := map[string]string{}
+	for k, v := range c.specLabels {
+		labels[k] = v
+	}
+	for _, path := range c.flagLabelFiles {
+		fileLabels, err := readLabelFile(path)
+		if err != nil {
+			return err
+		}
+
+		for k, v := range fileLabels {
+			labels[k] = v
+		}
+	}
+
+	for k, v := range c.flagLabels {
+		labels[k] = v
+	}
+	c.flagLabels = labels
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitLabelFiles(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	base := filepath.Join(td, "base.hcl")
+	require.NoError(ioutil.WriteFile(base, []byte(`
+labels = {
+  "compliance.example.com/tier" = "1"
+  team                          = "web"
+  env                           = "dev"
+}
+`), 0644))
+	prod := filepath.Join(td, "prod.json")
+	require.NoError(ioutil.WriteFile(prod, []byte(`{"labels": {"env": "prod"}}`), 0644))
+
+	// Later files take precedence and -label takes precedence over files.
+	c := &baseCommand{
+		flagLabelFiles: []string{base, prod},
+		flagLabels:     map[string]string{"team": "api"},
+	}
+	require.NoError(c.initLabelFiles())
+	require.Equal(map[string]string{
+		"compliance.example.com/tier": "1",
+		"team":                        "api",
+		"env":                         "prod",
+	}, c.flagLabels)
+
+	// Labels are validated.
+	invalid := filepath.Join(td, "invalid.hcl")
+	require.NoError(ioutil.WriteFile(invalid, []byte(`
+labels = {
+  "waypoint/reserved" = "x"
+}
+`), 0644))
+	c = &baseCommand{flagLabelFiles: []string{invalid}}
+	err = c.initLabelFiles()
+	require.Error(err)
+	require.Contains(err.Error(), "reserved")
+
+	// Missing files are an error.
+	c = &baseCommand{flagLabelFiles: []string{filepath.Join(td, "nope.hcl")}}
+	require.Error(c.initLabelFiles())
+}
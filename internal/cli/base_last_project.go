@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/env"
+)
+
+// lastProjectFile is the name of the file in the home config directory
+// that records the last project targeted with each CLI context.
+const lastProjectFile = "last-projects.json"
+
+// lastProject is the last project targeted with a single CLI context.
+type lastProject struct {
+	Project   string    `json:"project"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// readLastProjects reads the last project targeted with each context, keyed
+// by context name. A missing or unreadable file is treated as empty since
+// this is only used for a warning.
+func readLastProjects(homeConfigPath string) map[string]*lastProject {
+	data, err := ioutil.ReadFile(filepath.Join(homeConfigPath, lastProjectFile))
+	if err != nil {
+		return nil
+	}
+
+	var result map[string]*lastProject
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil
+	}
+
+	return result
+}
+
+// writeLastProject records project as the last project targeted with the
+// context with the given name.
+func writeLastProject(homeConfigPath, contextName, project string) error {
+	projects := readLastProjects(homeConfigPath)
+	if projects == nil {
+		projects = map[string]*lastProject{}
+	}
+
+	projects[contextName] = &lastProject{
+		Project:   project,
+		UpdatedAt: time.Now(),
+	}
+
+	data, err := json.MarshalIndent(projects, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(homeConfigPath, 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(homeConfigPath, lastProjectFile), data, 0644)
+}
+
+// checkLastProject warns if the targeted project differs from the last
+// project targeted with the current context, which is often a sign that
+// the wrong context is in use. The targeted project is then recorded, so
+// the warning is only shown once after switching. This never fails the
+// command and is disabled with EnvNoProjectWarning.
+func (c *baseCommand) checkLastProject() {
+	if c.homeConfigPath == "" || c.refProject == nil || c.refProject.Project == "" {
+		return
+	}
+	if disabled, _ := env.GetBool(EnvNoProjectWarning, false); disabled {
+		return
+	}
+
+	ctxName := c.contextName()
+	if ctxName == "" {
+		return
+	}
+
+	project := c.refProject.Project
+	last, ok := readLastProjects(c.homeConfigPath)[ctxName]
+	if ok && last.Project == project {
+		return
+	}
+	if ok && last.Project != "" {
+		c.ui.Output(warnProjectChanged, project, ctxName, last.Project,
+			EnvNoProjectWarning, terminal.WithWarningStyle())
+	}
+
+	if err := writeLastProject(c.homeConfigPath, ctxName, project); err != nil {
+		c.Log.Warn("error recording the last project", "error", err)
+	}
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/serverclient"
+)
+
+func TestCheckLastProject(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	// Nothing is recorded without a context.
+	c := &baseCommand{
+		Log:            hclog.L(),
+		ui:             terminal.ConsoleUI(ctx),
+		homeConfigPath: td,
+		refProject:     &pb.Ref_Project{Project: "web"},
+	}
+	c.checkLastProject()
+	require.Empty(readLastProjects(td))
+
+	os.Setenv(serverclient.EnvContext, "prod")
+	defer os.Unsetenv(serverclient.EnvContext)
+
+	c.checkLastProject()
+	require.Equal("web", readLastProjects(td)["prod"].Project)
+
+	// Switching projects records the new project.
+	c.refProject = &pb.Ref_Project{Project: "api"}
+	c.checkLastProject()
+	require.Equal("api", readLastProjects(td)["prod"].Project)
+
+	// Nothing is recorded when the warning is disabled.
+	os.Setenv(EnvNoProjectWarning, "1")
+	defer os.Unsetenv(EnvNoProjectWarning)
+	c.refProject = &pb.Ref_Project{Project: "web"}
+	c.checkLastProject()
+	require.Equal("api", readLastProjects(td)["prod"].Project)
+}
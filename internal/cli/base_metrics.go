@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// EnvMetricsFile is the env var that can be set to a path to write
+	// metrics about the command execution to in the Prometheus text format.
+	EnvMetricsFile = "WAYPOINT_METRICS_FILE"
+
+	// EnvMetricsPushgateway is the env var that can be set to the URL of a
+	// Prometheus pushgateway to push metrics about the command execution to.
+	EnvMetricsPushgateway = "WAYPOINT_METRICS_PUSHGATEWAY"
+
+	// metricsJobName is the job name metrics are grouped under when pushed.
+	metricsJobName = "waypoint_cli"
+
+	// metricsPushTimeout bounds how long we'll wait on the pushgateway so
+	// that an unavailable gateway doesn't hang the CLI.
+	metricsPushTimeout = 5 * time.Second
+)
+
+// commandMetrics tracks metrics about a single command execution. These
+// are only ever emitted if one of the WAYPOINT_METRICS_* env vars is set.
+type commandMetrics struct {
+	// command is the name of the subcommand that was executed.
+	command string
+
+	// start is the time Init was called and initDuration is how long it took.
+	start        time.Time
+	initDuration time.Duration
+
+	// doAppDuration is the total time spent in DoApp and appsTargeted
+	// is the number of apps that DoApp operated on.
+	doAppDuration time.Duration
+	appsTargeted  int
+}
+
+// emitMetrics writes and pushes the metrics for this command execution to
+// the destinations configured with the WAYPOINT_METRICS_* env vars. This is
+// best-effort: any failure is only logged and never affects the outcome of
+// the command.
+func (c *baseCommand) emitMetrics(exitCode int) {
+	path := os.Getenv(EnvMetricsFile)
+	gateway := os.Getenv(EnvMetricsPushgateway)
+	if path == "" && gateway == "" {
+		return
+	}
+
+	// If Init was never called, then there is nothing worth reporting.
+	m := c.metrics
+	if m.start.IsZero() {
+		return
+	}
+
+	labels := map[string]string{
+		"command": m.command,
+	}
+	if c.refProject != nil {
+		labels["project"] = c.refProject.Project
+	}
+	if c.refWorkspace != nil {
+		labels["workspace"] = c.refWorkspace.Workspace
+	}
+
+	success := 0
+	if exitCode == 0 {
+		success = 1
+	}
+
+	var buf bytes.Buffer
+	writeMetric(&buf, "waypoint_cli_command_duration_seconds",
+		"Total duration of the command.", labels, time.Since(m.start).Seconds())
+	writeMetric(&buf, "waypoint_cli_command_init_duration_seconds",
+		"Duration of the command initialization.", labels, m.initDuration.Seconds())
+	writeMetric(&buf, "waypoint_cli_command_apps_duration_seconds",
+		"Duration of the operations on all targeted apps.", labels, m.doAppDuration.Seconds())
+	writeMetric(&buf, "waypoint_cli_command_apps_targeted",
+		"Number of apps targeted by the command.", labels, float64(m.appsTargeted))
+	writeMetric(&buf, "waypoint_cli_command_success",
+		"1 if the command succeeded, 0 otherwise.", labels, float64(success))
+
+	if path != "" {
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			c.Log.Warn("error writing metrics file", "path", path, "error", err)
+		}
+	}
+
+	if gateway != "" {
+		if err := pushMetrics(c.Ctx, c.httpClientOrDefault(), gateway, buf.Bytes()); err != nil {
+			c.Log.Warn("error pushing metrics", "url", gateway, "error", err)
+		}
+	}
+}
+
+// pushMetrics pushes the metrics in the Prometheus text format to the
+// pushgateway at the given address.
+func pushMetrics(ctx context.Context, client *http.Client, gateway string, data []byte) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, metricsPushTimeout)
+	defer cancel()
+
+	u := strings.TrimRight(gateway, "/") + "/metrics/job/" + url.PathEscape(metricsJobName)
+	req, err := http.NewRequest("PUT", u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status from pushgateway: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// writeMetric writes a single gauge in the Prometheus text format.
+func writeMetric(buf *bytes.Buffer, name, help string, labels map[string]string, v float64) {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, k, metricsLabelEscaper.Replace(labels[k])))
+	}
+
+	fmt.Fprintf(buf, "# HELP %s %s\n", name, metricsHelpEscaper.Replace(help))
+	fmt.Fprintf(buf, "# TYPE %s gauge\n", name)
+	fmt.Fprintf(buf, "%s{%s} %g\n", name, strings.Join(pairs, ","), v)
+}
+
+var (
+	// metricsLabelEscaper escapes label values for the Prometheus text
+	// format, which only allows escaping a backslash, double quote, and
+	// line feed. Every other character is written as is.
+	metricsLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	// metricsHelpEscaper escapes HELP text, which only allows escaping a
+	// backslash and line feed.
+	metricsHelpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
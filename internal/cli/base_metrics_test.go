@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestWriteMetric(t *testing.T) {
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	writeMetric(&buf, "waypoint_test", "Help with a \\ and\na line feed.", map[string]string{
+		"workspace": "default",
+		"project":   "a \"quoted\" \\path\\ with\nlines\tand ünïcode",
+	}, 1.5)
+
+	require.Equal(`# HELP waypoint_test Help with a \\ and\na line feed.
+# TYPE waypoint_test gauge
+waypoint_test{project="a \"quoted\" \\path\\ with\nlines`+"\t"+`and ünïcode",workspace="default"} 1.5
+`, buf.String())
+}
+
+func TestEmitMetrics(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	path := filepath.Join(td, "metrics.prom")
+	defer os.Unsetenv(EnvMetricsFile)
+	require.NoError(os.Setenv(EnvMetricsFile, path))
+
+	c := &baseCommand{
+		Log:        hclog.L(),
+		refProject: &pb.Ref_Project{Project: "p"},
+		metrics: commandMetrics{
+			command:      "up",
+			start:        time.Now(),
+			appsTargeted: 2,
+		},
+	}
+	c.emitMetrics(0)
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(err)
+	require.Contains(string(data), `waypoint_cli_command_apps_targeted{command="up",project="p"} 2`+"\n")
+	require.Contains(string(data), `waypoint_cli_command_success{command="up",project="p"} 1`+"\n")
+}
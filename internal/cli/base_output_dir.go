@@ -0,0 +1,401 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	clientpkg "github.com/hashicorp/waypoint/internal/client"
+)
+
+const (
+	// outputDirLog and outputDirResult are the files written to the
+	// directory of each app with -output-dir.
+	outputDirLog    = "output.log"
+	outputDirResult = "result.json"
+)
+
+// initOutputDir checks the -output-dir. An existing directory must be
+// empty unless -output-dir-force is set, so that we never mix the output
+// of separate runs.
+func (c *baseCommand) initOutputDir() error {
+	if c.flagOutputDir == "" {
+		return nil
+	}
+
+	entries, err := ioutil.ReadDir(c.flagOutputDir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Error reading the -output-dir: %s", err)
+	}
+	if len(entries) > 0 && !c.flagOutputDirForce {
+		return fmt.Errorf(
+			"The -output-dir %q already exists and isn't empty. Use an empty "+
+				"directory, or set -output-dir-force to replace the output of "+
+				"the apps in this run.", c.flagOutputDir)
+	}
+
+	return nil
+}
+
+// appOutputDir is the directory that the output of a single app is
+// recorded to with -output-dir.
+type appOutputDir struct {
+	path string
+	log  *lazyFile
+}
+
+// startAppOutputDir creates the -output-dir directory for the app and
+// sets the app UI to also record the output there. This returns nil if
+// -output-dir isn't set. The directory is named after the app, within a
+// directory for the project when targeting multiple projects.
+func (c *baseCommand) startAppOutputDir(app *clientpkg.App) (*appOutputDir, error) {
+	if c.flagOutputDir == "" {
+		return nil, nil
+	}
+
+	path := filepath.Join(c.flagOutputDir, app.Ref().Application)
+	if len(c.flagProjects) > 1 || c.flagProjectFilter != "" {
+		path = filepath.Join(c.flagOutputDir, app.Ref().Project, app.Ref().Application)
+	}
+
+	// initOutputDir only allows existing output with -output-dir-force.
+	if err := os.RemoveAll(path); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+
+	d := &appOutputDir{
+		path: path,
+		log:  &lazyFile{path: filepath.Join(path, outputDirLog)},
+	}
+	app.UI = &teeUI{UIs: []terminal.UI{app.UI, &writerUI{w: d.log}}}
+
+	return d, nil
+}
+
+// Finish records the result of the app and closes the log. If the app
+// failed without any output, the directory is removed so that only apps
+// with a record remain.
+func (d *appOutputDir) Finish(result AppResult) error {
+	if d == nil {
+		return nil
+	}
+
+	if err := d.log.Close(); err != nil {
+		return err
+	}
+
+	if result.Status != AppResultSuccess && !d.log.Opened() {
+		return os.Remove(d.path)
+	}
+
+	data, err := marshalAppResult(result)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(d.path, outputDirResult), append(data, '\n'), 0644)
+}
+
+// lazyFile is a writer that only creates the file on the first write.
+type lazyFile struct {
+	path string
+
+	mu  sync.Mutex
+	f   *os.File
+	err error
+}
+
+func (w *lazyFile) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil && w.err == nil {
+		w.f, w.err = os.Create(w.path)
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	return w.f.Write(p)
+}
+
+// Opened returns true if the file was created.
+func (w *lazyFile) Opened() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.f != nil
+}
+
+func (w *lazyFile) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return nil
+	}
+
+	return w.f.Close()
+}
+
+// writerUI is a non-interactive UI that writes plain text to a writer,
+// such as a log file. Styles and animation are dropped.
+type writerUI struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (u *writerUI) Input(input *terminal.Input) (string, error) {
+	return "", terminal.ErrNonInteractive
+}
+
+func (u *writerUI) Interactive() bool {
+	return false
+}
+
+func (u *writerUI) Output(msg string, raw ...interface{}) {
+	msg, _, _ = terminal.Interpret(msg, raw...)
+	u.writeLine(msg)
+}
+
+func (u *writerUI) NamedValues(tvalues []terminal.NamedValue, _ ...terminal.Option) {
+	for _, nv := range tvalues {
+		u.writeLine(fmt.Sprintf("%s: %s", nv.Name, nv.Value))
+	}
+}
+
+func (u *writerUI) OutputWriters() (stdout io.Writer, stderr io.Writer, err error) {
+	return u.w, u.w, nil
+}
+
+func (u *writerUI) Status() terminal.Status {
+	return &writerUIStatus{u}
+}
+
+func (u *writerUI) Table(tbl *terminal.Table, opts ...terminal.Option) {
+	u.writeLine(strings.Join(tbl.Headers, "\t"))
+	for _, row := range tbl.Rows {
+		values := make([]string, 0, len(row))
+		for _, ent := range row {
+			values = append(values, ent.Value)
+		}
+
+		u.writeLine(strings.Join(values, "\t"))
+	}
+}
+
+func (u *writerUI) StepGroup() terminal.StepGroup {
+	return &writerUIStepGroup{u}
+}
+
+func (u *writerUI) writeLine(msg string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	fmt.Fprintln(u.w, msg)
+}
+
+type writerUIStatus struct {
+	ui *writerUI
+}
+
+func (s *writerUIStatus) Update(msg string) {
+	s.ui.writeLine(msg)
+}
+
+func (s *writerUIStatus) Step(status, msg string) {
+	s.ui.writeLine(msg)
+}
+
+func (s *writerUIStatus) Close() error {
+	return nil
+}
+
+type writerUIStepGroup struct {
+	ui *writerUI
+}
+
+func (g *writerUIStepGroup) Add(str string, args ...interface{}) terminal.Step {
+	g.ui.writeLine(progressMsg(str, args...))
+	return &writerUIStep{g.ui}
+}
+
+func (g *writerUIStepGroup) Wait() {}
+
+type writerUIStep struct {
+	ui *writerUI
+}
+
+func (s *writerUIStep) TermOutput() io.Writer {
+	return s.ui.w
+}
+
+func (s *writerUIStep) Update(str string, args ...interface{}) {
+	s.ui.writeLine(progressMsg(str, args...))
+}
+
+func (s *writerUIStep) Status(status string) {}
+
+func (s *writerUIStep) Done() {}
+
+func (s *writerUIStep) Abort() {
+	s.ui.writeLine("Aborted.")
+}
+
+// teeUI mirrors output to multiple UIs. Input is only read from the first.
+type teeUI struct {
+	UIs []terminal.UI
+}
+
+func (u *teeUI) Input(input *terminal.Input) (string, error) {
+	return u.UIs[0].Input(input)
+}
+
+func (u *teeUI) Interactive() bool {
+	return u.UIs[0].Interactive()
+}
+
+func (u *teeUI) Output(msg string, raw ...interface{}) {
+	for _, u := range u.UIs {
+		u.Output(msg, raw...)
+	}
+}
+
+func (u *teeUI) NamedValues(tvalues []terminal.NamedValue, opts ...terminal.Option) {
+	for _, u := range u.UIs {
+		u.NamedValues(tvalues, opts...)
+	}
+}
+
+func (u *teeUI) OutputWriters() (stdout io.Writer, stderr io.Writer, err error) {
+	var outs, errs []io.Writer
+	for _, u := range u.UIs {
+		stdout, stderr, err := u.OutputWriters()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		outs = append(outs, stdout)
+		errs = append(errs, stderr)
+	}
+
+	return io.MultiWriter(outs...), io.MultiWriter(errs...), nil
+}
+
+func (u *teeUI) Table(tbl *terminal.Table, opts ...terminal.Option) {
+	for _, u := range u.UIs {
+		u.Table(tbl, opts...)
+	}
+}
+
+func (u *teeUI) Status() terminal.Status {
+	var s []terminal.Status
+	for _, u := range u.UIs {
+		s = append(s, u.Status())
+	}
+
+	return &teeUIStatus{s}
+}
+
+func (u *teeUI) StepGroup() terminal.StepGroup {
+	var sgs []terminal.StepGroup
+	for _, u := range u.UIs {
+		sgs = append(sgs, u.StepGroup())
+	}
+
+	return &teeUIStepGroup{sgs}
+}
+
+type teeUIStatus struct {
+	s []terminal.Status
+}
+
+func (u *teeUIStatus) Update(msg string) {
+	for _, s := range u.s {
+		s.Update(msg)
+	}
+}
+
+func (u *teeUIStatus) Step(status string, msg string) {
+	for _, s := range u.s {
+		s.Step(status, msg)
+	}
+}
+
+func (u *teeUIStatus) Close() error {
+	for _, s := range u.s {
+		s.Close()
+	}
+
+	return nil
+}
+
+type teeUIStepGroup struct {
+	sgs []terminal.StepGroup
+}
+
+func (u *teeUIStepGroup) Add(str string, args ...interface{}) terminal.Step {
+	var steps []terminal.Step
+	for _, sg := range u.sgs {
+		steps = append(steps, sg.Add(str, args...))
+	}
+
+	return &teeUIStep{steps}
+}
+
+func (u *teeUIStepGroup) Wait() {
+	for _, sg := range u.sgs {
+		sg.Wait()
+	}
+}
+
+type teeUIStep struct {
+	steps []terminal.Step
+}
+
+func (u *teeUIStep) TermOutput() io.Writer {
+	var ws []io.Writer
+	for _, s := range u.steps {
+		ws = append(ws, s.TermOutput())
+	}
+
+	return io.MultiWriter(ws...)
+}
+
+func (u *teeUIStep) Update(str string, args ...interface{}) {
+	for _, s := range u.steps {
+		s.Update(str, args...)
+	}
+}
+
+func (u *teeUIStep) Status(status string) {
+	for _, s := range u.steps {
+		s.Status(status)
+	}
+}
+
+func (u *teeUIStep) Done() {
+	for _, s := range u.steps {
+		s.Done()
+	}
+}
+
+func (u *teeUIStep) Abort() {
+	for _, s := range u.steps {
+		s.Abort()
+	}
+}
+
+var (
+	_ terminal.UI = (*writerUI)(nil)
+	_ terminal.UI = (*teeUI)(nil)
+)
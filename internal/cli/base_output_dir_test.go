@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	clientpkg "github.com/hashicorp/waypoint/internal/client"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess"
+)
+
+func TestDoAppResults_outputDir(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	td, err := ioutil.TempDir("", "waypoint")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	project := clientpkg.TestProject(t, clientpkg.WithClient(singleprocess.TestServer(t)))
+
+	c := baseCommand{
+		Log:           hclog.L(),
+		ui:            terminal.ConsoleUI(ctx),
+		project:       project,
+		refProject:    project.Ref(),
+		flagApp:       "web",
+		flagOutputDir: td,
+	}
+	require.NoError(c.initOutputDir())
+
+	// The output of the app and its result are recorded.
+	_, err = c.DoAppResults(ctx, func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+		app.UI.Output("Deploying %s", app.Ref().Application, terminal.WithInfoStyle())
+		return nil, nil
+	})
+	require.NoError(err)
+
+	data, err := ioutil.ReadFile(filepath.Join(td, "web", outputDirLog))
+	require.NoError(err)
+	require.Equal("Deploying web\n", string(data))
+
+	data, err = ioutil.ReadFile(filepath.Join(td, "web", outputDirResult))
+	require.NoError(err)
+	require.Contains(string(data), `"status":"success"`)
+
+	// The directory isn't empty anymore, so it can't be reused.
+	require.Error(c.initOutputDir())
+	c.flagOutputDirForce = true
+	require.NoError(c.initOutputDir())
+
+	// A failure without any output removes the directory of the app.
+	_, err = c.DoAppResults(ctx, func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	require.Error(err)
+	_, err = os.Stat(filepath.Join(td, "web"))
+	require.True(os.IsNotExist(err))
+}
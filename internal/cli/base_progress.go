@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+)
+
+// progressUI wraps a non-interactive UI for -plain-progress. Plain output
+// has no spinners, so a long step looks hung. This prints a timestamped
+// status line every interval while a status or step is open, naming the
+// step and how long it has been running.
+type progressUI struct {
+	terminal.UI
+
+	interval time.Duration
+}
+
+func newProgressUI(ui terminal.UI, interval time.Duration) *progressUI {
+	return &progressUI{UI: ui, interval: interval}
+}
+
+func (u *progressUI) Status() terminal.Status {
+	return &progressStatus{Status: u.UI.Status(), ui: u}
+}
+
+func (u *progressUI) StepGroup() terminal.StepGroup {
+	return &progressStepGroup{StepGroup: u.UI.StepGroup(), ui: u}
+}
+
+// heartbeat prints the status line for a single status or step until it
+// is stopped.
+type heartbeat struct {
+	ui    terminal.UI
+	start time.Time
+	done  chan struct{}
+	once  sync.Once
+
+	mu  sync.Mutex
+	msg string
+}
+
+// startHeartbeat starts printing status lines for msg every interval.
+func (u *progressUI) startHeartbeat(msg string) *heartbeat {
+	h := &heartbeat{
+		ui:    u.UI,
+		start: time.Now(),
+		done:  make(chan struct{}),
+		msg:   msg,
+	}
+	go h.run(u.interval)
+
+	return h
+}
+
+func (h *heartbeat) run(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-h.done:
+			return
+
+		case now := <-t.C:
+			h.mu.Lock()
+			msg := h.msg
+			h.mu.Unlock()
+
+			h.ui.Output("[%s] still running: %s (%s elapsed)",
+				now.Format("15:04:05"), msg, now.Sub(h.start).Round(time.Second))
+		}
+	}
+}
+
+// Update changes the message in the status lines.
+func (h *heartbeat) Update(msg string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.msg = msg
+}
+
+// Stop stops printing status lines. This is safe to call more than once.
+func (h *heartbeat) Stop() {
+	h.once.Do(func() { close(h.done) })
+}
+
+type progressStatus struct {
+	terminal.Status
+
+	ui *progressUI
+	hb *heartbeat
+}
+
+func (s *progressStatus) Update(msg string) {
+	s.Status.Update(msg)
+	s.beat(msg)
+}
+
+func (s *progressStatus) Step(status, msg string) {
+	s.Status.Step(status, msg)
+
+	// A step status means the step finished, so there's nothing running
+	// until the next update.
+	if s.hb != nil {
+		s.hb.Stop()
+		s.hb = nil
+	}
+}
+
+func (s *progressStatus) Close() error {
+	if s.hb != nil {
+		s.hb.Stop()
+		s.hb = nil
+	}
+
+	return s.Status.Close()
+}
+
+func (s *progressStatus) beat(msg string) {
+	if s.hb == nil {
+		s.hb = s.ui.startHeartbeat(msg)
+		return
+	}
+
+	s.hb.Update(msg)
+}
+
+type progressStepGroup struct {
+	terminal.StepGroup
+
+	ui *progressUI
+}
+
+func (g *progressStepGroup) Add(str string, args ...interface{}) terminal.Step {
+	step := g.StepGroup.Add(str, args...)
+	return &progressStep{
+		Step: step,
+		hb:   g.ui.startHeartbeat(progressMsg(str, args...)),
+	}
+}
+
+type progressStep struct {
+	terminal.Step
+
+	hb *heartbeat
+}
+
+func (s *progressStep) Update(str string, args ...interface{}) {
+	s.Step.Update(str, args...)
+	s.hb.Update(progressMsg(str, args...))
+}
+
+func (s *progressStep) Done() {
+	s.hb.Stop()
+	s.Step.Done()
+}
+
+func (s *progressStep) Abort() {
+	s.hb.Stop()
+	s.Step.Abort()
+}
+
+// progressMsg formats a step message the way the step does.
+func progressMsg(str string, args ...interface{}) string {
+	if len(args) == 0 {
+		return str
+	}
+
+	return fmt.Sprintf(str, args...)
+}
+
+var (
+	_ terminal.UI        = (*progressUI)(nil)
+	_ terminal.Status    = (*progressStatus)(nil)
+	_ terminal.StepGroup = (*progressStepGroup)(nil)
+	_ terminal.Step      = (*progressStep)(nil)
+)
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+)
+
+func TestProgressUI(t *testing.T) {
+	require := require.New(t)
+
+	rec := &recordUI{UI: terminal.NonInteractiveUI(context.Background())}
+	ui := newProgressUI(rec, 10*time.Millisecond)
+
+	// An open step prints status lines with the latest message
+	sg := ui.StepGroup()
+	step := sg.Add("Building image")
+	step.Update("Building image %s", "web")
+	require.Eventually(func() bool {
+		for _, msg := range rec.Messages() {
+			if strings.Contains(msg, "still running: Building image web") {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 5*time.Millisecond)
+
+	// Once the step is done, no more status lines are printed
+	step.Done()
+	sg.Wait()
+	time.Sleep(20 * time.Millisecond)
+	n := len(rec.Messages())
+	time.Sleep(50 * time.Millisecond)
+	require.Len(rec.Messages(), n)
+
+	// Statuses print status lines until closed
+	s := ui.Status()
+	s.Update("Deploying")
+	require.Eventually(func() bool {
+		return len(rec.Messages()) > n
+	}, time.Second, 5*time.Millisecond)
+	require.NoError(s.Close())
+	time.Sleep(20 * time.Millisecond)
+	n = len(rec.Messages())
+	time.Sleep(50 * time.Millisecond)
+	require.Len(rec.Messages(), n)
+}
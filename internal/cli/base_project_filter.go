@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"k8s.io/apimachinery/pkg/labels"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// projectsBySelector returns the names of the projects on the server with
+// labels matching the label selector, sorted by name. The selector syntax
+// is the same as for appsBySelector.
+//
+// The server doesn't store labels for projects directly, so the labels
+// are the top-level "labels" of the waypoint.hcl stored with each project.
+// Projects without a stored waypoint.hcl, or whose labels can't be read
+// without evaluating the configuration, never match.
+func (c *baseCommand) projectsBySelector(ctx context.Context, selector string) ([]string, error) {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid project filter %q: %s", selector, err)
+	}
+
+	client := c.project.Client()
+	resp, err := client.ListProjects(ctx, &empty.Empty{})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	labeled := 0
+	for _, ref := range resp.Projects {
+		presp, err := client.GetProject(ctx, &pb.GetProjectRequest{Project: ref})
+		if err != nil {
+			return nil, err
+		}
+
+		projectLabels, err := projectLabels(presp.Project)
+		if err != nil {
+			c.Log.Debug("error reading project labels, skipping selector match",
+				"project", ref.Project, "error", err)
+			continue
+		}
+		if projectLabels == nil {
+			continue
+		}
+
+		labeled++
+		if sel.Matches(labels.Set(projectLabels)) {
+			result = append(result, ref.Project)
+		}
+	}
+
+	if labeled == 0 {
+		return nil, fmt.Errorf(
+			"The project filter %q can't be used because none of the projects on\n"+
+				"the server have labels. Project labels are read from the top-level\n"+
+				"\"labels\" of the waypoint.hcl stored with each project, which is set\n"+
+				"with \"waypoint project apply -from-waypoint-hcl\".", selector)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf(
+			"The project filter %q didn't match any of the %d labeled projects\n"+
+				"on the server.", selector, labeled)
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+// projectLabels returns the labels of the project from the top-level
+// "labels" of its stored waypoint.hcl. This returns nil with no error if
+// the project has no stored waypoint.hcl or it doesn't set labels.
+func projectLabels(p *pb.Project) (map[string]string, error) {
+	if len(p.WaypointHcl) == 0 {
+		return nil, nil
+	}
+
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	parser := hclparse.NewParser()
+	if p.WaypointHclFormat == pb.Project_JSON {
+		file, diags = parser.ParseJSON(p.WaypointHcl, "waypoint.hcl.json")
+	} else {
+		file, diags = parser.ParseHCL(p.WaypointHcl, "waypoint.hcl")
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	content, _, diags := file.Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "labels"}},
+	})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	attr, ok := content.Attributes["labels"]
+	if !ok {
+		return nil, nil
+	}
+
+	// We decode without an eval context since only literal labels can be
+	// known without the full configuration.
+	var result map[string]string
+	if diags := gohcl.DecodeExpression(attr.Expr, nil, &result); diags.HasErrors() {
+		return nil, diags
+	}
+
+	return result, nil
+}
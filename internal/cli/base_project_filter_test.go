@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	clientpkg "github.com/hashicorp/waypoint/internal/client"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess"
+)
+
+func TestProjectsBySelector(t *testing.T) {
+	ctx := context.Background()
+
+	client := singleprocess.TestServer(t)
+	project := clientpkg.TestProject(t, clientpkg.WithClient(client))
+	c := baseCommand{Log: hclog.L(), project: project}
+
+	// With no labeled projects, filtering is an error
+	_, err := c.projectsBySelector(ctx, "team=payments")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "have labels")
+
+	for name, hcl := range map[string]string{
+		"billing":  `project = "billing"` + "\n" + `labels = { team = "payments" }`,
+		"checkout": `project = "checkout"` + "\n" + `labels = { team = "payments", tier = "web" }`,
+		"search":   `project = "search"` + "\n" + `labels = { team = "discovery" }`,
+		"dynamic":  `project = "dynamic"` + "\n" + `labels = { team = var.team }`,
+	} {
+		_, err := client.UpsertProject(ctx, &pb.UpsertProjectRequest{
+			Project: &pb.Project{Name: name, WaypointHcl: []byte(hcl)},
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("matches", func(t *testing.T) {
+		require := require.New(t)
+
+		result, err := c.projectsBySelector(ctx, "team=payments")
+		require.NoError(err)
+		require.Equal([]string{"billing", "checkout"}, result)
+
+		result, err = c.projectsBySelector(ctx, "team=payments,tier=web")
+		require.NoError(err)
+		require.Equal([]string{"checkout"}, result)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		require := require.New(t)
+
+		_, err := c.projectsBySelector(ctx, "team=nope")
+		require.Error(err)
+		require.Contains(err.Error(), "didn't match")
+	})
+
+	t.Run("invalid selector", func(t *testing.T) {
+		require := require.New(t)
+
+		_, err := c.projectsBySelector(ctx, "team in (")
+		require.Error(err)
+	})
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+// maxProjectSuggestions is the most project names suggested when a targeted
+// project doesn't exist.
+const maxProjectSuggestions = 3
+
+// projectNotFoundError returns the error for a targeted project that doesn't
+// exist on the server. If any projects on the server have a similar name,
+// they're suggested in the error since a typo is the most common cause.
+func (c *baseCommand) projectNotFoundError(ctx context.Context, name string) error {
+	msg := fmt.Sprintf(
+		"Project %q does not exist on the server. Run `waypoint project apply`\n"+
+			"or `waypoint init` to register it, or check the name.", name)
+
+	// Suggestions are best effort, so we ignore errors listing projects.
+	resp, err := c.project.Client().ListProjects(ctx, &empty.Empty{})
+	if err != nil {
+		c.Log.Debug("error listing projects for suggestions", "error", err)
+		return fmt.Errorf("%s", msg)
+	}
+
+	var names []string
+	for _, ref := range resp.Projects {
+		names = append(names, ref.Project)
+	}
+	if similar := similarNames(name, names); len(similar) > 0 {
+		msg += "\n\nDid you mean: " + strings.Join(similar, ", ")
+	}
+
+	return fmt.Errorf("%s", msg)
+}
+
+// similarNames returns the names from candidates that are close to name,
+// closest first. A candidate is close if it differs from name by only a few
+// edits or if one contains the other, ignoring case.
+func similarNames(name string, candidates []string) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+
+	lower := strings.ToLower(name)
+	maxDistance := len(lower) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	var matches []match
+	for _, candidate := range candidates {
+		if candidate == name {
+			continue
+		}
+
+		cl := strings.ToLower(candidate)
+		d := editDistance(lower, cl)
+		if d > maxDistance && !strings.Contains(cl, lower) && !strings.Contains(lower, cl) {
+			continue
+		}
+
+		matches = append(matches, match{name: candidate, distance: d})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+
+		return matches[i].name < matches[j].name
+	})
+	if len(matches) > maxProjectSuggestions {
+		matches = matches[:maxProjectSuggestions]
+	}
+
+	var result []string
+	for _, m := range matches {
+		result = append(result, m.name)
+	}
+
+	return result
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(br)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
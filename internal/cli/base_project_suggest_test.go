@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimilarNames(t *testing.T) {
+	candidates := []string{"billing", "billing-api", "checkout", "search", "Checkout-v2"}
+
+	cases := []struct {
+		Name     string
+		Expected []string
+	}{
+		{"biling", []string{"billing"}},
+		{"bill", []string{"billing", "billing-api"}},
+		{"checkot", []string{"checkout"}},
+		{"CHECKOUT", []string{"checkout", "Checkout-v2"}},
+		{"search", nil},
+		{"payments", nil},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			require.Equal(t, tt.Expected, similarNames(tt.Name, candidates))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+)
+
+// checkProtectedWorkspace returns an error if the targeted workspace is
+// listed in "protected_workspaces" in the configuration and the operation
+// wasn't confirmed. Interactive sessions are prompted for confirmation, and
+// other sessions must set "-i-know-what-im-doing". Commands that don't
+// execute the operation, such as with "-print-job", aren't checked.
+func (c *baseCommand) checkProtectedWorkspace() error {
+	if c.cfg == nil || c.flagProtectedOverride || c.flagPrintJob || c.flagCheckOnly {
+		return nil
+	}
+
+	ws := c.refWorkspace.GetWorkspace()
+	if !c.cfg.WorkspaceProtected(ws) {
+		return nil
+	}
+
+	if !c.ui.Interactive() {
+		return fmt.Errorf(errProtectedWorkspace, ws)
+	}
+
+	c.ui.Output(warnProtectedWorkspace, ws, terminal.WithWarningStyle())
+	ok, err := c.confirm("Continue? [y/n]")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("Canceled the operation on the protected workspace %q.", ws)
+	}
+
+	return nil
+}
+
+// confirm prompts the user until they answer "y" or "n", and returns true
+// if they answered "y". This must only be called if the UI is interactive.
+func (c *baseCommand) confirm(prompt string) (bool, error) {
+	for {
+		result, err := c.ui.Input(&terminal.Input{
+			Prompt: prompt,
+			Style:  terminal.WarningStyle,
+		})
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToLower(strings.TrimSpace(result)) {
+		case "y":
+			return true, nil
+		case "n":
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/config"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestCheckProtectedWorkspace(t *testing.T) {
+	cfg := config.TestConfig(t, `
+project = "test"
+
+protected_workspaces = ["production"]
+`)
+
+	newCommand := func(ws string) *baseCommand {
+		return &baseCommand{
+			Log:          hclog.L(),
+			ui:           terminal.ConsoleUI(context.Background()),
+			cfg:          cfg,
+			refWorkspace: &pb.Ref_Workspace{Workspace: ws},
+		}
+	}
+
+	t.Run("not protected", func(t *testing.T) {
+		require.NoError(t, newCommand("staging").checkProtectedWorkspace())
+	})
+
+	t.Run("protected without confirmation", func(t *testing.T) {
+		// The tests aren't interactive, so this can't prompt.
+		err := newCommand("production").checkProtectedWorkspace()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "-i-know-what-im-doing")
+	})
+
+	t.Run("protected with the override", func(t *testing.T) {
+		c := newCommand("production")
+		c.flagProtectedOverride = true
+		require.NoError(t, c.checkProtectedWorkspace())
+	})
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/hashicorp/waypoint/internal/config"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// refFlags are the inputs to resolveRefs other than the arguments and
+// configuration: the targeting flags and the command's requirements.
+type refFlags struct {
+	// Project and App are the values of the -project and -app flags.
+	// Projects is every -project value if it was repeated.
+	Project  string
+	Projects []string
+	App      string
+
+	// These are copied from the baseConfig of the command.
+	AppTargetRequired     bool
+	AppOptional           bool
+	ProjectTargetRequired bool
+}
+
+// refResolution is the result of resolveRefs.
+type refResolution struct {
+	// Project and App are the targeted project and app. Either may be nil
+	// if it couldn't be determined.
+	Project *pb.Ref_Project
+	App     *pb.Ref_Application
+
+	// Args are the remaining arguments once any target argument is removed.
+	Args []string
+
+	// Remote is true if the target was given as an argument. Since there is
+	// no local configuration for such targets, the operation requires a
+	// remote runner.
+	Remote bool
+
+	// NeedConfig is true if the arguments didn't fully determine the target
+	// so the local configuration should be loaded and given to resolveRefs.
+	NeedConfig bool
+}
+
+// resolveRefs determines the targeted project and app from the arguments,
+// flags, and configuration. This has no side effects so that the targeting
+// rules can be tested directly. The precedence is:
+//
+//   - A "project/app" or, for commands that don't require a project
+//     target, "project" first argument.
+//   - The -project and -app flags.
+//   - The project of the configuration, if cfg is non-nil.
+//
+// A target argument may be combined with the -project and -app flags only
+// if they agree. A conflict is an error rather than silently ignoring one of
+// them, since either could be what the user meant.
+//
+// cfg may be nil, such as when it hasn't been loaded yet. Callers should
+// load the configuration and call this again with it if NeedConfig is true
+// or the command otherwise loads the configuration.
+func resolveRefs(args []string, flags *refFlags, cfg *config.Config) (*refResolution, error) {
+	result := &refResolution{Args: args}
+
+	// Commands with an app or project target can take it as the first
+	// argument. We only ever consume one argument for the target.
+	if (flags.AppTargetRequired || flags.AppOptional || flags.ProjectTargetRequired) &&
+		len(args) > 0 {
+		if match := reAppTarget.FindStringSubmatch(args[0]); match != nil {
+			result.Project = &pb.Ref_Project{Project: match[1]}
+			result.App = &pb.Ref_Application{
+				Project:     match[1],
+				Application: match[2],
+			}
+		} else if flags.AppOptional && !flags.ProjectTargetRequired {
+			// Assume the target is just a project. We don't set the app
+			// because none was requested and we might or might not be
+			// working on an app later.
+			result.Project = &pb.Ref_Project{Project: args[0]}
+		}
+
+		if result.Project != nil {
+			result.Args = args[1:]
+			result.Remote = true
+
+			if len(flags.Projects) > 1 {
+				return nil, fmt.Errorf(
+					"The target %q can't be combined with multiple -project flags.\n"+
+						"Remove the target argument or the -project flags.",
+					args[0])
+			}
+			if flags.Project != "" && flags.Project != result.Project.Project {
+				return nil, fmt.Errorf(
+					"The target %q is for project %q, but -project is %q.\n"+
+						"Remove the -project flag or make them match.",
+					args[0], result.Project.Project, flags.Project)
+			}
+			if flags.App != "" && result.App != nil && !appMatches(flags.App, result.App.Application) {
+				return nil, fmt.Errorf(
+					"The target %q is for app %q, but -app is %q.\n"+
+						"Remove the -app flag or make them match.",
+					args[0], result.App.Application, flags.App)
+			}
+		}
+	}
+
+	// If we didn't get our target from the args, we need the config. Commands
+	// that require an app need the config for the app, and the rest need it
+	// for the project.
+	switch {
+	case flags.AppTargetRequired:
+		result.NeedConfig = result.App == nil
+
+	case flags.AppOptional || flags.ProjectTargetRequired:
+		result.NeedConfig = result.App == nil && result.Project == nil
+	}
+
+	if cfg == nil {
+		return result, nil
+	}
+
+	// The -project flag overrides the project of the configuration.
+	project := &pb.Ref_Project{Project: cfg.Project}
+	if flags.Project != "" {
+		project = &pb.Ref_Project{Project: flags.Project}
+	}
+	if result.Project == nil {
+		result.Project = project
+	}
+
+	// If we require an app target, use the -app flag. If that isn't set
+	// either, Init will default to the only app in the configuration.
+	if flags.AppTargetRequired && result.App == nil && flags.App != "" {
+		result.App = &pb.Ref_Application{
+			Project:     project.Project,
+			Application: flags.App,
+		}
+	}
+
+	return result, nil
+}
+
+// appMatches returns true if the -app value, which may be a glob, matches
+// the app name.
+func appMatches(flag, name string) bool {
+	matched, err := path.Match(flag, name)
+	return err == nil && matched
+}
@@ -0,0 +1,263 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/config"
+)
+
+func TestResolveRefs(t *testing.T) {
+	cfg := config.TestConfig(t, `
+project = "cfgproject"
+
+app "web" {}
+`)
+
+	single := refFlags{AppTargetRequired: true}
+	multiple := refFlags{ProjectTargetRequired: true}
+	optional := refFlags{AppOptional: true}
+
+	cases := []struct {
+		Name       string
+		Args       []string
+		Flags      refFlags
+		Cfg        *config.Config
+		Project    string
+		App        string
+		Rest       []string
+		Remote     bool
+		NeedConfig bool
+		Err        string
+	}{
+		{
+			Name:       "single app with no args needs config",
+			Flags:      single,
+			NeedConfig: true,
+		},
+		{
+			Name:    "single app from project/app arg",
+			Args:    []string{"p/a", "rest"},
+			Flags:   single,
+			Project: "p",
+			App:     "a",
+			Rest:    []string{"rest"},
+			Remote:  true,
+		},
+		{
+			Name:       "single app doesn't consume a project arg",
+			Args:       []string{"p"},
+			Flags:      single,
+			Rest:       []string{"p"},
+			NeedConfig: true,
+		},
+		{
+			Name:       "single app from config",
+			Flags:      single,
+			Cfg:        cfg,
+			Project:    "cfgproject",
+			NeedConfig: true,
+		},
+		{
+			Name: "single app from -app flag and config",
+			Flags: refFlags{
+				App:               "web",
+				AppTargetRequired: true,
+			},
+			Cfg:        cfg,
+			Project:    "cfgproject",
+			App:        "web",
+			NeedConfig: true,
+		},
+		{
+			Name: "-project flag overrides config",
+			Flags: refFlags{
+				Project:           "flagproject",
+				App:               "web",
+				AppTargetRequired: true,
+			},
+			Cfg:        cfg,
+			Project:    "flagproject",
+			App:        "web",
+			NeedConfig: true,
+		},
+		{
+			Name:    "arg overrides config",
+			Args:    []string{"p/a"},
+			Flags:   single,
+			Cfg:     cfg,
+			Project: "p",
+			App:     "a",
+			Rest:    []string{},
+			Remote:  true,
+		},
+		{
+			Name: "matching flags and arg",
+			Args: []string{"p/a"},
+			Flags: refFlags{
+				Project:           "p",
+				App:               "a",
+				AppTargetRequired: true,
+			},
+			Project: "p",
+			App:     "a",
+			Rest:    []string{},
+			Remote:  true,
+		},
+		{
+			Name: "-project conflicts with arg",
+			Args: []string{"p/a"},
+			Flags: refFlags{
+				Project:           "other",
+				AppTargetRequired: true,
+			},
+			Err: "-project",
+		},
+		{
+			Name: "-app conflicts with arg",
+			Args: []string{"p/a"},
+			Flags: refFlags{
+				App:                   "other",
+				ProjectTargetRequired: true,
+			},
+			Err: "-app",
+		},
+		{
+			Name: "-project conflicts with project arg",
+			Args: []string{"a"},
+			Flags: refFlags{
+				Project:     "b",
+				AppOptional: true,
+			},
+			Err: `is for project "a", but -project is "b"`,
+		},
+		{
+			Name: "-project matches project arg",
+			Args: []string{"a"},
+			Flags: refFlags{
+				Project:     "a",
+				AppOptional: true,
+			},
+			Project: "a",
+			Rest:    []string{},
+			Remote:  true,
+		},
+		{
+			Name: "arg with multiple -project flags",
+			Args: []string{"a/web"},
+			Flags: refFlags{
+				Project:               "a",
+				Projects:              []string{"a", "b"},
+				ProjectTargetRequired: true,
+			},
+			Err: "multiple -project flags",
+		},
+		{
+			Name: "-app glob matches arg",
+			Args: []string{"a/web"},
+			Flags: refFlags{
+				App:                   "w*",
+				ProjectTargetRequired: true,
+			},
+			Project: "a",
+			App:     "web",
+			Rest:    []string{},
+			Remote:  true,
+		},
+		{
+			Name: "-app glob conflicts with arg",
+			Args: []string{"a/web"},
+			Flags: refFlags{
+				App:                   "api-*",
+				ProjectTargetRequired: true,
+			},
+			Err: `is for app "web", but -app is "api-*"`,
+		},
+		{
+			Name:       "multiple apps doesn't consume a project arg",
+			Args:       []string{"p"},
+			Flags:      multiple,
+			Rest:       []string{"p"},
+			NeedConfig: true,
+		},
+		{
+			Name:    "multiple apps from project/app arg",
+			Args:    []string{"p/a"},
+			Flags:   multiple,
+			Project: "p",
+			App:     "a",
+			Rest:    []string{},
+			Remote:  true,
+		},
+		{
+			Name:    "optional app from project arg",
+			Args:    []string{"p", "rest"},
+			Flags:   optional,
+			Project: "p",
+			Rest:    []string{"rest"},
+			Remote:  true,
+		},
+		{
+			Name:       "optional app from config",
+			Flags:      optional,
+			Cfg:        cfg,
+			Project:    "cfgproject",
+			NeedConfig: true,
+		},
+		{
+			Name: "only one arg is consumed with multiple requirements",
+			Args: []string{"p/a", "q/b"},
+			Flags: refFlags{
+				AppTargetRequired: true,
+				AppOptional:       true,
+			},
+			Project: "p",
+			App:     "a",
+			Rest:    []string{"q/b"},
+			Remote:  true,
+		},
+		{
+			Name:  "no requirements ignores args",
+			Args:  []string{"p/a"},
+			Flags: refFlags{},
+			Rest:  []string{"p/a"},
+		},
+		{
+			Name:    "no requirements uses config project",
+			Flags:   refFlags{},
+			Cfg:     cfg,
+			Project: "cfgproject",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			require := require.New(t)
+
+			flags := tt.Flags
+			result, err := resolveRefs(tt.Args, &flags, tt.Cfg)
+			if tt.Err != "" {
+				require.Error(err)
+				require.Contains(err.Error(), tt.Err)
+				return
+			}
+			require.NoError(err)
+
+			var project, app string
+			if result.Project != nil {
+				project = result.Project.Project
+			}
+			if result.App != nil {
+				app = result.App.Application
+				require.Equal(project, result.App.Project)
+			}
+
+			require.Equal(tt.Project, project)
+			require.Equal(tt.App, app)
+			require.Equal(tt.Rest, result.Args)
+			require.Equal(tt.Remote, result.Remote)
+			require.Equal(tt.NeedConfig, result.NeedConfig)
+		})
+	}
+}
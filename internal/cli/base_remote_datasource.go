@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// reGitSCPURL matches the scp-like syntax for Git URLs, such as
+// "git@github.com:hashicorp/waypoint.git", which net/url can't parse.
+var reGitSCPURL = regexp.MustCompile(`^[-0-9A-Za-z_.]+@[-0-9A-Za-z_.]+:.+$`)
+
+// gitDataSourceError returns an error describing why the data source can't
+// be used by a remote runner, or nil if it can. Only Git data sources are
+// checked, since a Git data source without a usable URL can't be cloned.
+func gitDataSourceError(ds *pb.Job_DataSource) error {
+	git, ok := ds.GetSource().(*pb.Job_DataSource_Git)
+	if !ok {
+		return nil
+	}
+
+	u := strings.TrimSpace(git.Git.GetUrl())
+	if u == "" {
+		return errors.New("the Git URL is empty")
+	}
+	if reGitSCPURL.MatchString(u) {
+		return nil
+	}
+
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("the Git URL %q is invalid: %s", u, err)
+	}
+	if parsed.Scheme == "" || (parsed.Host == "" && parsed.Scheme != "file") {
+		return fmt.Errorf("the Git URL %q is invalid, it must include a scheme and host", u)
+	}
+
+	return nil
+}
+
+// remoteIsPossible returns true if a remote runner can use the data source
+// of the project. If it can't, the misconfiguration is logged and output
+// as a warning.
+func (c *baseCommand) remoteIsPossible(project *pb.Project) bool {
+	err := gitDataSourceError(project.DataSource)
+	if err == nil {
+		return true
+	}
+
+	c.Log.Warn("project Git data source is misconfigured",
+		"project", project.Name, "error", err)
+	c.ui.Output(warnGitDataSourceMisconfigured, project.Name, err.Error(),
+		terminal.WithWarningStyle())
+	return false
+}
+
+// initRemoteDataSource checks that a remote operation is possible with the
+// data source of the targeted project. If it isn't, this falls back to a
+// local runner when the local configuration is for the same project, and
+// otherwise returns an error. This catches the misconfiguration before the
+// runner fails to clone the project.
+func (c *baseCommand) initRemoteDataSource() error {
+	if !c.flagRemote || c.refProject == nil {
+		return nil
+	}
+
+	project, err := c.getProject(c.Ctx)
+	if err != nil {
+		// The project may not be registered yet, in which case the server
+		// will report that when the job is queued.
+		c.Log.Debug("not checking the project data source", "error", err)
+		return nil
+	}
+
+	if c.remoteIsPossible(project) {
+		return nil
+	}
+
+	if !c.localFallbackPossible() {
+		return fmt.Errorf(
+			"Remote operations aren't possible for project %q since its Git data\n"+
+				"source is misconfigured. Set a valid Git URL for the project with\n"+
+				"\"waypoint project apply -data-source=git -git-url=<url> %s\".",
+			c.refProject.Project, c.refProject.Project)
+	}
+
+	c.ui.Output(infoRemoteFallbackLocal, terminal.WithInfoStyle())
+	c.flagRemote = false
+	c.project.Close()
+	c.project, err = c.initClient(nil)
+	return err
+}
+
+// localFallbackPossible returns true if a remote operation on the targeted
+// project can fall back to a local runner. We can only use a local runner
+// if we have the local configuration for the project and local runners
+// aren't forbidden. The runner env vars and labels are only valid remotely.
+func (c *baseCommand) localFallbackPossible() bool {
+	return c.autoServer && !c.flagNoLocalRunner && c.cfg != nil && c.refProject != nil &&
+		c.cfg.Project == c.refProject.Project &&
+		len(c.flagRunnerEnv) == 0 && len(c.flagRunnerEnvSensitive) == 0 &&
+		len(c.flagRunnerLabels) == 0
+}
+
+// checkExplicitRef returns an error if "-require-explicit-ref" is set for a
+// remote operation on a project with a Git data source but no ref was set,
+// in which case the runner would use the default ref of the data source.
+// This must be called after the ref from the context is applied.
+func (c *baseCommand) checkExplicitRef() error {
+	if !c.flagRequireExplicitRef || !c.flagRemote || c.refProject == nil {
+		return nil
+	}
+	if c.flagRemoteSource["ref"] != "" {
+		return nil
+	}
+
+	project, err := c.getProject(c.Ctx)
+	if err != nil {
+		// The project may not be registered yet, in which case the server
+		// will report that when the job is queued.
+		c.Log.Debug("not checking for an explicit ref", "error", err)
+		return nil
+	}
+
+	git, ok := project.DataSource.GetSource().(*pb.Job_DataSource_Git)
+	if !ok {
+		return nil
+	}
+
+	ref := git.Git.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	return fmt.Errorf(
+		"The -require-explicit-ref flag is set, but no Git ref was given for\n"+
+			"project %q, so the remote runner would use the default ref %q of\n"+
+			"its data source. Set the ref with \"-remote-source=ref=<ref>\".",
+		c.refProject.Project, ref)
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestGitDataSourceError(t *testing.T) {
+	git := func(url string) *pb.Job_DataSource {
+		return &pb.Job_DataSource{
+			Source: &pb.Job_DataSource_Git{Git: &pb.Job_Git{Url: url}},
+		}
+	}
+
+	cases := []struct {
+		Name string
+		DS   *pb.Job_DataSource
+		Err  string
+	}{
+		{"no data source", nil, ""},
+		{"local", &pb.Job_DataSource{
+			Source: &pb.Job_DataSource_Local{Local: &pb.Job_Local{}},
+		}, ""},
+		{"https", git("https://github.com/hashicorp/waypoint.git"), ""},
+		{"scp-like", git("git@github.com:hashicorp/waypoint.git"), ""},
+		{"file", git("file:///tmp/repo"), ""},
+		{"empty", git(""), "empty"},
+		{"whitespace", git("  "), "empty"},
+		{"no scheme", git("github.com/hashicorp/waypoint"), "invalid"},
+		{"no host", git("https:///waypoint.git"), "invalid"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			require := require.New(t)
+
+			err := gitDataSourceError(tt.DS)
+			if tt.Err == "" {
+				require.NoError(err)
+				return
+			}
+
+			require.Error(err)
+			require.Contains(err.Error(), tt.Err)
+		})
+	}
+}
+
+func TestCheckExplicitRef(t *testing.T) {
+	gitProject := &pb.Project{
+		Name: "p",
+		DataSource: &pb.Job_DataSource{Source: &pb.Job_DataSource_Git{
+			Git: &pb.Job_Git{Url: "https://github.com/hashicorp/waypoint.git", Ref: "main"},
+		}},
+	}
+
+	newCommand := func(project *pb.Project) *baseCommand {
+		return &baseCommand{
+			Log:                    hclog.L(),
+			refProject:             &pb.Ref_Project{Project: "p"},
+			projectRecord:          project,
+			flagRemote:             true,
+			flagRequireExplicitRef: true,
+		}
+	}
+
+	t.Run("no ref", func(t *testing.T) {
+		require := require.New(t)
+
+		err := newCommand(gitProject).checkExplicitRef()
+		require.Error(err)
+		require.Contains(err.Error(), `"main"`)
+	})
+
+	t.Run("explicit ref", func(t *testing.T) {
+		c := newCommand(gitProject)
+		c.flagRemoteSource = map[string]string{"ref": "v1.2.3"}
+		require.NoError(t, c.checkExplicitRef())
+	})
+
+	t.Run("local", func(t *testing.T) {
+		c := newCommand(gitProject)
+		c.flagRemote = false
+		require.NoError(t, c.checkExplicitRef())
+	})
+
+	t.Run("not git", func(t *testing.T) {
+		c := newCommand(&pb.Project{
+			Name: "p",
+			DataSource: &pb.Job_DataSource{Source: &pb.Job_DataSource_Local{
+				Local: &pb.Job_Local{},
+			}},
+		})
+		require.NoError(t, c.checkExplicitRef())
+	})
+
+	t.Run("not required", func(t *testing.T) {
+		c := newCommand(gitProject)
+		c.flagRequireExplicitRef = false
+		require.NoError(t, c.checkExplicitRef())
+	})
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+)
+
+// checkCleanTree returns an error listing the uncommitted changes in the
+// git working tree at dir, for -require-clean. If dir isn't in a git
+// working tree or git isn't installed, the check is skipped with a warning
+// rather than failing.
+func (c *baseCommand) checkCleanTree(dir string) error {
+	cmd := exec.Command("git", "status", "--porcelain")
+	cmd.Dir = dir
+	cmd.Stderr = ioutil.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		c.Log.Debug("error checking the git working tree", "dir", dir, "error", err)
+		c.ui.Output(
+			"The -require-clean check was skipped since %q isn't in a git working "+
+				"tree, or git isn't installed.", dir, terminal.WithWarningStyle())
+		return nil
+	}
+
+	paths := dirtyPaths(string(out))
+	if len(paths) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"The git working tree has uncommitted changes and -require-clean is set. "+
+			"Commit or stash the changes, or set -allow-dirty to operate anyway. "+
+			"The changed paths are:\n\n  %s", strings.Join(paths, "\n  "))
+}
+
+// dirtyPaths returns the paths from the output of "git status --porcelain".
+// Each line is a two character status, a space, and the path.
+func dirtyPaths(out string) []string {
+	var result []string
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+
+		result = append(result, line[3:])
+	}
+
+	return result
+}
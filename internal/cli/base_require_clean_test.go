@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+)
+
+func TestBaseCommand_checkCleanTree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	require := require.New(t)
+	ctx := context.Background()
+
+	td, err := ioutil.TempDir("", "waypoint")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	c := baseCommand{
+		Log: hclog.L(),
+		ui:  terminal.ConsoleUI(ctx),
+	}
+
+	// Outside of a git repository the check is skipped.
+	require.NoError(c.checkCleanTree(td))
+
+	cmd := exec.Command("git", "init")
+	cmd.Dir = td
+	require.NoError(cmd.Run())
+	require.NoError(c.checkCleanTree(td))
+
+	// Uncommitted changes are listed.
+	require.NoError(ioutil.WriteFile(filepath.Join(td, "waypoint.hcl"), nil, 0644))
+	err = c.checkCleanTree(td)
+	require.Error(err)
+	require.Contains(err.Error(), "waypoint.hcl")
+}
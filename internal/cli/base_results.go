@@ -0,0 +1,405 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	clientpkg "github.com/hashicorp/waypoint/internal/client"
+)
+
+// AppResultStatus is the outcome of the operation on a single app.
+type AppResultStatus string
+
+const (
+	AppResultSuccess AppResultStatus = "success"
+	AppResultError   AppResultStatus = "error"
+)
+
+// AppResult is the result of calling the DoAppResults callback for a
+// single app.
+type AppResult struct {
+	// Project and App are the names of the project and app.
+	Project string
+	App     string
+
+	// Status is whether the callback succeeded and Err is the error it
+	// returned, if any. Err matches ErrSentinel with errors.Is if the error
+	// was already output to the user.
+	Status AppResultStatus
+	Err    error
+
+	// Failure is the category of Err. This is empty on success.
+	Failure AppFailure
+
+	// Duration is how long the callback took.
+	Duration time.Duration
+
+	// Payload is the arbitrary value returned by the callback.
+	Payload interface{}
+}
+
+// doAppResult calls the callback for a single app and builds the result.
+// If timeout is non-zero, the callback's context has its own deadline so
+// that one slow app fails with an appTimeoutError rather than using up the
+// deadline of ctx, if any, which still applies to every app.
+func doAppResult(
+	ctx context.Context,
+	app *clientpkg.App,
+	timeout time.Duration,
+	f func(context.Context, *clientpkg.App) (interface{}, error),
+) AppResult {
+	appCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		appCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	payload, err := f(appCtx, app)
+
+	// Printing the job with -print-job rather than executing it is the
+	// expected outcome, so the app succeeded.
+	if errors.Is(err, clientpkg.ErrJobPrinted) {
+		err = nil
+	}
+
+	// If the app deadline was reached but not the deadline of ctx, this
+	// app timed out. The callback may have already output the error, so
+	// we replace it in either case to report the timeout consistently.
+	if err != nil && timeout > 0 && ctx.Err() == nil &&
+		appCtx.Err() == context.DeadlineExceeded {
+		err = &appTimeoutError{App: app.Ref().Application, Timeout: timeout}
+	}
+
+	result := AppResult{
+		Project:  app.Ref().Project,
+		App:      app.Ref().Application,
+		Status:   AppResultSuccess,
+		Err:      err,
+		Duration: time.Since(start),
+		Payload:  payload,
+	}
+	if err != nil {
+		result.Status = AppResultError
+		result.Failure = categorizeAppError(err)
+	}
+
+	return result
+}
+
+// appTimeoutError is the error for an app whose operation didn't complete
+// within the -app-timeout. This is categorized as AppFailureTimeout.
+type appTimeoutError struct {
+	App     string
+	Timeout time.Duration
+}
+
+func (e *appTimeoutError) Error() string {
+	return fmt.Sprintf("The operation on app %q didn't complete within the "+
+		"-app-timeout of %s.", e.App, e.Timeout)
+}
+
+func (e *appTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// AppFailure is the category of an error from an operation on an app,
+// used to group failures when operating on many apps.
+type AppFailure string
+
+const (
+	AppFailureTimeout     AppFailure = "timeout"
+	AppFailureCanceled    AppFailure = "canceled"
+	AppFailureValidation  AppFailure = "validation"
+	AppFailureUnavailable AppFailure = "server or runner unavailable"
+	AppFailureServer      AppFailure = "server error"
+	AppFailureOther       AppFailure = "other"
+)
+
+// categorizeAppError returns the category of an error from an operation on
+// an app. This uses the gRPC status code if there is one, including when it
+// is wrapped such as by appOpError. A bare ErrSentinel has no details left
+// to categorize by, so it is AppFailureOther.
+func categorizeAppError(err error) AppFailure {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return AppFailureTimeout
+	case errors.Is(err, context.Canceled):
+		return AppFailureCanceled
+	case errors.Is(err, ErrConfigParse):
+		return AppFailureValidation
+	}
+
+	var diags hcl.Diagnostics
+	if errors.As(err, &diags) {
+		return AppFailureValidation
+	}
+
+	var se interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &se) {
+		return AppFailureOther
+	}
+
+	switch se.GRPCStatus().Code() {
+	case codes.DeadlineExceeded:
+		return AppFailureTimeout
+	case codes.Canceled:
+		return AppFailureCanceled
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange,
+		codes.NotFound, codes.AlreadyExists:
+		return AppFailureValidation
+	case codes.Unavailable, codes.ResourceExhausted:
+		return AppFailureUnavailable
+	default:
+		return AppFailureServer
+	}
+}
+
+// appFailureGroup is the apps that failed with a single category of error.
+type appFailureGroup struct {
+	Failure AppFailure
+	Apps    []string
+}
+
+// groupAppFailures groups the failed results by category. The groups are
+// sorted with the most failures first. Apps are named "project/app".
+func groupAppFailures(results []AppResult) []*appFailureGroup {
+	byFailure := map[AppFailure]*appFailureGroup{}
+	var groups []*appFailureGroup
+	for _, r := range results {
+		if r.Status != AppResultError {
+			continue
+		}
+
+		g, ok := byFailure[r.Failure]
+		if !ok {
+			g = &appFailureGroup{Failure: r.Failure}
+			byFailure[r.Failure] = g
+			groups = append(groups, g)
+		}
+		g.Apps = append(g.Apps, r.Project+"/"+r.App)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].Apps) != len(groups[j].Apps) {
+			return len(groups[i].Apps) > len(groups[j].Apps)
+		}
+
+		return groups[i].Failure < groups[j].Failure
+	})
+
+	return groups
+}
+
+// outputSummary returns true if the summary tables should be output after
+// operating on apps. These are text, so they're never output with JSON.
+// See outputSummaryJSON for the summary with -results-json.
+func (c *baseCommand) outputSummary() bool {
+	return c.operation && !c.commandJSON && !c.flagPrintJob &&
+		!c.flagResultsJSON && !c.flagNoSummary
+}
+
+// outputSummaryJSON returns true if the summary should be output as JSON
+// after operating on apps. With -results-json, the summary is always part
+// of the output regardless of -no-summary, which only affects text.
+func (c *baseCommand) outputSummaryJSON() bool {
+	return c.operation && c.flagResultsJSON && !c.flagPrintJob
+}
+
+// outputRunSummary outputs the outcome of each app in a table, so that the
+// result of a long operation on many apps can be read at a glance. This
+// outputs nothing if fewer than two apps were targeted.
+func (c *baseCommand) outputRunSummary(results []AppResult) {
+	if len(results) < 2 {
+		return
+	}
+
+	succeeded := 0
+	tbl := terminal.NewTable("App", "Result", "Duration")
+	for _, r := range results {
+		result, color := string(r.Status), terminal.Green
+		if r.Status == AppResultSuccess {
+			succeeded++
+		} else {
+			result, color = fmt.Sprintf("%s (%s)", r.Status, r.Failure), terminal.Red
+		}
+
+		tbl.Rich([]string{
+			r.App,
+			result,
+			r.Duration.Round(time.Second).String(),
+		}, []string{
+			"",
+			color,
+			"",
+		})
+	}
+
+	c.ui.Output("")
+	c.ui.Output("Summary", terminal.WithHeaderStyle())
+	c.ui.Table(tbl)
+	c.ui.Output("%d of %d apps succeeded.", succeeded, len(results))
+}
+
+// outputFailureSummary outputs the failed apps grouped by the category of
+// error, to help triage operations on many apps. This outputs nothing if
+// fewer than two apps were targeted or nothing failed.
+func (c *baseCommand) outputFailureSummary(results []AppResult) {
+	if len(results) < 2 {
+		return
+	}
+
+	groups := groupAppFailures(results)
+	if len(groups) == 0 {
+		return
+	}
+
+	c.ui.Output("")
+	c.ui.Output("Failures by category", terminal.WithHeaderStyle())
+	tbl := terminal.NewTable("Category", "Count", "Apps")
+	for _, g := range groups {
+		tbl.Rich([]string{
+			string(g.Failure),
+			strconv.Itoa(len(g.Apps)),
+			strings.Join(g.Apps, ", "),
+		}, []string{
+			terminal.Red,
+			"",
+			"",
+		})
+	}
+	c.ui.Table(tbl)
+}
+
+// appResultJSON is the JSON format of an AppResult for -results-json.
+type appResultJSON struct {
+	Project    string          `json:"project"`
+	App        string          `json:"app"`
+	Status     AppResultStatus `json:"status"`
+	DurationMs int64           `json:"duration_ms"`
+	Failure    AppFailure      `json:"failure,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// marshalAppResult encodes the result as a single line of JSON.
+func marshalAppResult(r AppResult) ([]byte, error) {
+	v := appResultJSON{
+		Project:    r.Project,
+		App:        r.App,
+		Status:     r.Status,
+		DurationMs: r.Duration.Milliseconds(),
+		Failure:    r.Failure,
+	}
+	if r.Err != nil && r.Err != ErrSentinel {
+		v.Error = r.Err.Error()
+	}
+
+	return json.Marshal(v)
+}
+
+// resultsSummaryJSON is the JSON format of the summary for -results-json.
+// This is output as the "summary" field of the last line.
+type resultsSummaryJSON struct {
+	Apps      int                   `json:"apps"`
+	Succeeded int                   `json:"succeeded"`
+	Failed    int                   `json:"failed"`
+	Failures  []*resultsFailureJSON `json:"failures"`
+}
+
+// resultsFailureJSON is the apps that failed with one category of error.
+type resultsFailureJSON struct {
+	Failure AppFailure `json:"failure"`
+	Apps    []string   `json:"apps"`
+}
+
+// marshalResultsSummary encodes the summary of the results as a single
+// line of JSON.
+func marshalResultsSummary(results []AppResult) ([]byte, error) {
+	summary := resultsSummaryJSON{
+		Apps:     len(results),
+		Failures: []*resultsFailureJSON{},
+	}
+	for _, r := range results {
+		if r.Status == AppResultSuccess {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+	}
+	for _, g := range groupAppFailures(results) {
+		summary.Failures = append(summary.Failures, &resultsFailureJSON{
+			Failure: g.Failure,
+			Apps:    g.Apps,
+		})
+	}
+
+	return json.Marshal(map[string]interface{}{"summary": summary})
+}
+
+// outputResultsSummaryJSON outputs the summary of the results as a line of
+// JSON, after the line for each app.
+func (c *baseCommand) outputResultsSummaryJSON(results []AppResult) {
+	c.resultsLock.Lock()
+	defer c.resultsLock.Unlock()
+
+	data, err := marshalResultsSummary(results)
+	if err != nil {
+		c.Log.Warn("error encoding results summary", "error", err)
+		return
+	}
+
+	c.ui.Output(string(data))
+}
+
+// outputAppResult outputs the outcome of a single app as soon as its
+// callback returns, as a text line or, with -results-json, a JSON object
+// per line. This is safe to call concurrently so that the output of each
+// app is never interleaved.
+func (c *baseCommand) outputAppResult(r AppResult) {
+	c.resultsLock.Lock()
+	defer c.resultsLock.Unlock()
+
+	if c.flagResultsJSON {
+		data, err := marshalAppResult(r)
+		if err != nil {
+			c.Log.Warn("error encoding app result", "error", err)
+			return
+		}
+
+		c.ui.Output(string(data))
+		return
+	}
+
+	duration := r.Duration.Round(time.Second)
+	if r.Status == AppResultSuccess {
+		c.ui.Output("✓ %s (%s)", r.App, duration, terminal.WithSuccessStyle())
+		return
+	}
+
+	c.ui.Output("✗ %s (%s): %s", r.App, duration, r.Failure, terminal.WithErrorStyle())
+}
+
+// streamAppResults returns true if outputAppResult should be called for
+// each app when operating on n apps. Single-app operations only output
+// their results as JSON since the text line would repeat the operation's
+// own output. Commands without the operation flags never do, nor do
+// commands outputting their own JSON.
+func (c *baseCommand) streamAppResults(n int) bool {
+	if !c.operation || c.commandJSON || c.flagPrintJob {
+		return false
+	}
+
+	return n > 1 || c.flagResultsJSON
+}
@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	clientpkg "github.com/hashicorp/waypoint/internal/client"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess"
+)
+
+func TestDoAppResult_appTimeout(t *testing.T) {
+	project := clientpkg.TestProject(t, clientpkg.WithClient(singleprocess.TestServer(t)))
+	app := project.App("web")
+
+	// wait blocks until the context is done, like a stuck operation.
+	wait := func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	t.Run("app timeout", func(t *testing.T) {
+		require := require.New(t)
+
+		result := doAppResult(context.Background(), app, 10*time.Millisecond, wait)
+		require.Equal(AppResultError, result.Status)
+		require.Equal(AppFailureTimeout, result.Failure)
+
+		var terr *appTimeoutError
+		require.True(errors.As(result.Err, &terr))
+		require.Equal("web", terr.App)
+		require.Contains(result.Err.Error(), "-app-timeout")
+	})
+
+	t.Run("batch timeout first", func(t *testing.T) {
+		require := require.New(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		// The batch deadline wins, so this isn't reported as an app timeout.
+		result := doAppResult(ctx, app, time.Minute, wait)
+		require.Equal(AppFailureTimeout, result.Failure)
+		require.Equal(context.DeadlineExceeded, result.Err)
+	})
+
+	t.Run("app timeout first", func(t *testing.T) {
+		require := require.New(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		result := doAppResult(ctx, app, 10*time.Millisecond, wait)
+		var terr *appTimeoutError
+		require.True(errors.As(result.Err, &terr))
+		require.NoError(ctx.Err())
+	})
+
+	t.Run("errors other than the timeout are kept", func(t *testing.T) {
+		require := require.New(t)
+
+		result := doAppResult(context.Background(), app, time.Minute,
+			func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+				return nil, errors.New("failed")
+			})
+		require.EqualError(result.Err, "failed")
+	})
+}
+
+func TestDoAppResults_appTimeout(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	project := clientpkg.TestProject(t, clientpkg.WithClient(singleprocess.TestServer(t)))
+
+	c := baseCommand{
+		Log:            hclog.L(),
+		ui:             terminal.ConsoleUI(ctx),
+		project:        project,
+		refProject:     project.Ref(),
+		flagApp:        "web",
+		flagAppTimeout: 10 * time.Millisecond,
+	}
+
+	// The timeout is an error for the app, not the whole run.
+	results, err := c.DoAppResults(ctx, func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ErrSentinel
+	})
+	require.Error(err)
+	require.Len(results, 1)
+	require.Equal(AppFailureTimeout, results[0].Failure)
+	require.Contains(err.Error(), "-app-timeout")
+}
+
+func TestCategorizeAppError(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Err      error
+		Expected AppFailure
+	}{
+		{"deadline", fmt.Errorf("wrapped: %w", context.DeadlineExceeded), AppFailureTimeout},
+		{"deadline status", status.Error(codes.DeadlineExceeded, "slow"), AppFailureTimeout},
+		{"canceled", context.Canceled, AppFailureCanceled},
+		{"config", &configParseError{Err: errors.New("bad")}, AppFailureValidation},
+		{"invalid argument", status.Error(codes.InvalidArgument, "bad"), AppFailureValidation},
+		{"unavailable", status.Error(codes.Unavailable, "down"), AppFailureUnavailable},
+		{"internal", status.Error(codes.Internal, "oops"), AppFailureServer},
+		{"sentinel", ErrSentinel, AppFailureOther},
+		{"output", &outputError{Err: status.Error(codes.NotFound, "gone")}, AppFailureValidation},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			require.Equal(t, tt.Expected, categorizeAppError(tt.Err))
+		})
+	}
+}
+
+func TestAppOpError(t *testing.T) {
+	require := require.New(t)
+	ui := terminal.ConsoleUI(context.Background())
+
+	// The cause is kept for categorizing even though it was output.
+	err := appOpError(ui, status.Error(codes.Unavailable, "runner gone"))
+	require.True(errors.Is(err, ErrSentinel))
+	require.Equal(AppFailureUnavailable, categorizeAppError(err))
+	require.Equal("runner gone", status.Convert(errors.Unwrap(err)).Message())
+
+	// Printed jobs aren't errors, so they're returned without output.
+	require.Equal(clientpkg.ErrJobPrinted, appOpError(ui, clientpkg.ErrJobPrinted))
+}
+
+func TestStreamAppResults(t *testing.T) {
+	require := require.New(t)
+
+	c := &baseCommand{operation: true}
+	require.False(c.streamAppResults(1))
+	require.True(c.streamAppResults(2))
+
+	c = &baseCommand{operation: true, flagResultsJSON: true}
+	require.True(c.streamAppResults(1))
+
+	c = &baseCommand{operation: true, flagPrintJob: true}
+	require.False(c.streamAppResults(2))
+
+	// Commands that aren't operations, or that output their own JSON,
+	// have their own output.
+	c = &baseCommand{}
+	require.False(c.streamAppResults(2))
+
+	c = &baseCommand{operation: true, commandJSON: true}
+	require.False(c.streamAppResults(2))
+}
+
+func TestMarshalAppResult(t *testing.T) {
+	require := require.New(t)
+
+	data, err := marshalAppResult(AppResult{
+		Project:  "p",
+		App:      "web",
+		Status:   AppResultError,
+		Err:      status.Error(codes.DeadlineExceeded, "slow"),
+		Failure:  AppFailureTimeout,
+		Duration: 12 * time.Second,
+	})
+	require.NoError(err)
+	require.NotContains(string(data), "\n")
+	require.JSONEq(`{
+		"project": "p",
+		"app": "web",
+		"status": "error",
+		"duration_ms": 12000,
+		"failure": "timeout",
+		"error": "rpc error: code = DeadlineExceeded desc = slow"
+	}`, string(data))
+
+	// Errors that were already output aren't repeated
+	data, err = marshalAppResult(AppResult{App: "web", Status: AppResultError, Err: ErrSentinel})
+	require.NoError(err)
+	require.NotContains(string(data), "sentinel")
+}
+
+func TestGroupAppFailures(t *testing.T) {
+	require := require.New(t)
+
+	groups := groupAppFailures([]AppResult{
+		{Project: "p", App: "a", Status: AppResultSuccess},
+		{Project: "p", App: "b", Status: AppResultError, Failure: AppFailureServer},
+		{Project: "p", App: "c", Status: AppResultError, Failure: AppFailureTimeout},
+		{Project: "p", App: "d", Status: AppResultError, Failure: AppFailureTimeout},
+	})
+	require.Len(groups, 2)
+	require.Equal(AppFailureTimeout, groups[0].Failure)
+	require.Equal([]string{"p/c", "p/d"}, groups[0].Apps)
+	require.Equal(AppFailureServer, groups[1].Failure)
+	require.Equal([]string{"p/b"}, groups[1].Apps)
+
+	require.Empty(groupAppFailures([]AppResult{{Status: AppResultSuccess}}))
+}
+
+func TestBaseCommand_outputRunSummary(t *testing.T) {
+	require := require.New(t)
+
+	results := []AppResult{
+		{App: "web", Status: AppResultSuccess},
+		{App: "api", Status: AppResultError, Failure: AppFailureTimeout},
+	}
+
+	summarized := func(c *baseCommand, results []AppResult) bool {
+		rec := &recordUI{UI: terminal.NonInteractiveUI(context.Background())}
+		c.ui = rec
+		if c.outputSummary() {
+			c.outputRunSummary(results)
+		}
+
+		for _, msg := range rec.Messages() {
+			if strings.Contains(msg, "1 of 2 apps succeeded.") {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	// Multiple apps have a summary by default.
+	require.True(summarized(&baseCommand{operation: true}, results))
+
+	// A single app never does.
+	require.False(summarized(&baseCommand{operation: true}, results[:1]))
+
+	// The summary is suppressed with -no-summary, and is only text.
+	require.False(summarized(&baseCommand{operation: true, flagNoSummary: true}, results))
+	require.False(summarized(&baseCommand{operation: true, flagResultsJSON: true}, results))
+	require.False(summarized(&baseCommand{operation: true, commandJSON: true}, results))
+
+	// Commands that aren't operations have their own output.
+	require.False(summarized(&baseCommand{}, results))
+}
+
+func TestMarshalResultsSummary(t *testing.T) {
+	require := require.New(t)
+
+	data, err := marshalResultsSummary([]AppResult{
+		{Project: "p", App: "web", Status: AppResultSuccess},
+		{Project: "p", App: "api", Status: AppResultError, Failure: AppFailureTimeout},
+	})
+	require.NoError(err)
+	require.JSONEq(`{"summary": {
+		"apps": 2,
+		"succeeded": 1,
+		"failed": 1,
+		"failures": [{"failure": "timeout", "apps": ["p/api"]}]
+	}}`, string(data))
+
+	// -no-summary only affects the text summary.
+	c := &baseCommand{operation: true, flagResultsJSON: true, flagNoSummary: true}
+	require.True(c.outputSummaryJSON())
+	require.False((&baseCommand{operation: true}).outputSummaryJSON())
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/plugin"
+)
+
+// localRunnersDir is the directory under the home config directory where
+// we track the local runners started by the CLI.
+const localRunnersDir = "local-runners"
+
+// localRunnerRecord is the metadata we track for a local runner while the
+// CLI process that started it is running. The record is removed on Close,
+// so any record left behind whose process is gone belongs to a CLI that
+// exited without cleaning up, such as due to a crash.
+type localRunnerRecord struct {
+	Id        string    `json:"id"`
+	Pid       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	Dir       string    `json:"dir"`
+
+	// PluginPids are the plugin processes started by the runner that are
+	// still running. These outlive the CLI if it crashes.
+	PluginPids []int `json:"plugin_pids,omitempty"`
+
+	// path is the path to the record file. This isn't persisted.
+	path string
+}
+
+// localRunnerTracker keeps the record of a local runner up to date as the
+// runner starts and stops plugin processes.
+type localRunnerTracker struct {
+	mu     sync.Mutex
+	record *localRunnerRecord
+	closed bool
+}
+
+// trackLocalRunner records that this process started a local runner with
+// the given ID. Close should be called on the result once the runner is
+// closed to remove the record.
+func trackLocalRunner(homeConfigPath, id string) (*localRunnerTracker, error) {
+	dir := filepath.Join(homeConfigPath, localRunnersDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	wd, _ := os.Getwd()
+	t := &localRunnerTracker{
+		record: &localRunnerRecord{
+			Id:        id,
+			Pid:       os.Getpid(),
+			StartedAt: time.Now(),
+			Dir:       wd,
+			path:      filepath.Join(dir, id+".json"),
+		},
+	}
+	if err := t.write(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// pluginProcess records that a plugin process was started or stopped.
+// This has the signature of plugin.ProcessHook.
+func (t *localRunnerTracker) pluginProcess(pid int, started bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil
+	}
+
+	pids := t.record.PluginPids[:0:0]
+	for _, p := range t.record.PluginPids {
+		if p != pid {
+			pids = append(pids, p)
+		}
+	}
+	if started {
+		pids = append(pids, pid)
+	}
+	t.record.PluginPids = pids
+
+	return t.write()
+}
+
+// write writes the record. The lock must be held if other goroutines may
+// be using the tracker.
+func (t *localRunnerTracker) write() error {
+	data, err := json.Marshal(t.record)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(t.record.path, data, 0600)
+}
+
+// Close removes the record.
+func (t *localRunnerTracker) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+	os.Remove(t.record.path)
+}
+
+// initLocalRunnerTracking starts tracking the local runner with the given
+// ID and warns if there are orphaned local runners from previous commands.
+// This is best-effort, so failures are only logged.
+func (c *baseCommand) initLocalRunnerTracking(id string) {
+	orphans, err := orphanedLocalRunners(c.homeConfigPath)
+	if err != nil {
+		c.Log.Warn("error checking for orphaned local runners", "error", err)
+	}
+	if len(orphans) > 0 {
+		c.ui.Output(warnOrphanedLocalRunners, len(orphans), terminal.WithWarningStyle())
+	}
+
+	tracker, err := trackLocalRunner(c.homeConfigPath, id)
+	if err != nil {
+		c.Log.Warn("error tracking local runner", "error", err)
+		return
+	}
+	c.localRunnerCleanup = tracker.Close
+
+	// The local runner runs in this process, so every plugin process we
+	// launch belongs to it.
+	plugin.ProcessHook = func(pid int, started bool) {
+		if err := tracker.pluginProcess(pid, started); err != nil {
+			c.Log.Warn("error tracking local runner plugin", "pid", pid, "error", err)
+		}
+	}
+}
+
+// orphanedLocalRunners returns the records of local runners whose CLI
+// process is no longer running. Records that can't be read are returned
+// as well with only the ID set, since they can't belong to a healthy CLI.
+func orphanedLocalRunners(homeConfigPath string) ([]*localRunnerRecord, error) {
+	dir := filepath.Join(homeConfigPath, localRunnersDir)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var result []*localRunnerRecord
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		record := &localRunnerRecord{
+			Id:   strings.TrimSuffix(entry.Name(), ".json"),
+			path: path,
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(data, record)
+		}
+		if err == nil && processAlive(record.Pid) {
+			continue
+		}
+
+		result = append(result, record)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.Before(result[j].StartedAt)
+	})
+
+	return result, nil
+}
+
+// cleanupLocalRunner cleans up the resources left behind by an orphaned
+// local runner. The plugin processes started by the runner are stopped,
+// since those outlive the CLI if it crashes. Only those processes are
+// signaled, never the process group of the CLI, which may include other
+// processes such as the rest of a shell pipeline. This returns true if any
+// processes were signaled.
+func cleanupLocalRunner(record *localRunnerRecord) (bool, error) {
+	var signaled bool
+	for _, pid := range record.PluginPids {
+		ok, err := killProcess(pid)
+		if err != nil {
+			return signaled, err
+		}
+		signaled = signaled || ok
+	}
+
+	if err := os.Remove(record.path); err != nil && !os.IsNotExist(err) {
+		return signaled, err
+	}
+
+	return signaled, nil
+}
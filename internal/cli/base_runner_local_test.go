@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrphanedLocalRunners(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	// Our own runner is running, so it isn't orphaned.
+	tracker, err := trackLocalRunner(td, "alive")
+	require.NoError(err)
+
+	// A runner whose process is gone is orphaned.
+	require.NoError(ioutil.WriteFile(
+		filepath.Join(td, localRunnersDir, "dead.json"),
+		[]byte(`{"id": "dead", "pid": 999999999}`), 0600))
+
+	orphans, err := orphanedLocalRunners(td)
+	require.NoError(err)
+	require.Len(orphans, 1)
+	require.Equal("dead", orphans[0].Id)
+
+	// Cleaning up removes the record.
+	_, err = cleanupLocalRunner(orphans[0])
+	require.NoError(err)
+	orphans, err = orphanedLocalRunners(td)
+	require.NoError(err)
+	require.Empty(orphans)
+
+	// Closing our runner removes its record too.
+	tracker.Close()
+	_, err = os.Stat(filepath.Join(td, localRunnersDir, "alive.json"))
+	require.True(os.IsNotExist(err))
+}
+
+func TestCleanupLocalRunner_pluginPids(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not installed")
+	}
+
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	tracker, err := trackLocalRunner(td, "runner")
+	require.NoError(err)
+	defer tracker.Close()
+
+	// A plugin that is still running and one that was stopped.
+	cmd := exec.Command("sleep", "60")
+	require.NoError(cmd.Start())
+	defer cmd.Process.Kill()
+	require.NoError(tracker.pluginProcess(cmd.Process.Pid, true))
+	require.NoError(tracker.pluginProcess(999999999, true))
+	require.NoError(tracker.pluginProcess(999999999, false))
+
+	path := filepath.Join(td, localRunnersDir, "runner.json")
+	data, err := ioutil.ReadFile(path)
+	require.NoError(err)
+	record := &localRunnerRecord{path: path}
+	require.NoError(json.Unmarshal(data, record))
+	require.Equal([]int{cmd.Process.Pid}, record.PluginPids)
+
+	// Only the running plugin is signaled. If our process group was
+	// signaled, this test wouldn't survive to check it.
+	signaled, err := cleanupLocalRunner(record)
+	require.NoError(err)
+	require.True(signaled)
+	require.Error(cmd.Wait())
+}
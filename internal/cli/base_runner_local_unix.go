@@ -0,0 +1,37 @@
+// +build !windows
+
+package cli
+
+import (
+	"syscall"
+)
+
+// processAlive returns true if a process with the given PID is running.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	// Signal 0 only checks that we could signal the process. EPERM means
+	// it exists but is owned by someone else.
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// killProcess sends SIGTERM to the process with the given PID. This
+// returns false if the process is no longer running.
+func killProcess(pid int) (bool, error) {
+	if pid <= 0 {
+		return false, nil
+	}
+
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		if err == syscall.ESRCH {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,42 @@
+// +build windows
+
+package cli
+
+import (
+	"os"
+)
+
+// processAlive returns true if a process with the given PID is running.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+
+	return true
+}
+
+// killProcess kills the process with the given PID. This returns false if
+// the process is no longer running.
+func killProcess(pid int) (bool, error) {
+	if pid <= 0 || !processAlive(pid) {
+		return false, nil
+	}
+
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false, nil
+	}
+	defer p.Release()
+
+	if err := p.Kill(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// runnerProfileCacheTTL is how long the cached runner profiles are used
+// before they're listed again. This is short since the cache is only meant
+// to dedupe lookups within a single invocation, such as for every app of
+// a multi-app operation.
+const runnerProfileCacheTTL = 30 * time.Second
+
+// runnerProfileCache caches the runner profiles (on-demand runner configs)
+// listed from the server. The zero value is ready to use.
+type runnerProfileCache struct {
+	mu        sync.Mutex
+	configs   []*pb.OnDemandRunnerConfig
+	fetchedAt time.Time
+}
+
+// runnerProfileList returns the runner profiles on the server. The list is
+// fetched at most once per runnerProfileCacheTTL and shared by every caller,
+// so this is safe to call from DoApp callbacks. Commands that modify runner
+// profiles should call invalidateRunnerProfiles afterwards.
+func (c *baseCommand) runnerProfileList(ctx context.Context) ([]*pb.OnDemandRunnerConfig, error) {
+	cache := &c.runnerProfiles
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if !cache.fetchedAt.IsZero() && time.Since(cache.fetchedAt) < runnerProfileCacheTTL {
+		return cache.configs, nil
+	}
+
+	resp, err := c.project.Client().ListOnDemandRunnerConfigs(ctx, &empty.Empty{})
+	if err != nil {
+		return nil, err
+	}
+
+	cache.configs = resp.Configs
+	cache.fetchedAt = time.Now()
+	return cache.configs, nil
+}
+
+// runnerProfile returns the runner profile with the given name, or nil if
+// there is no such profile. This uses the cached list from runnerProfileList.
+func (c *baseCommand) runnerProfile(ctx context.Context, name string) (*pb.OnDemandRunnerConfig, error) {
+	configs, err := c.runnerProfileList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cfg := range configs {
+		if cfg.Name == name {
+			return cfg, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// invalidateRunnerProfiles clears the cached runner profiles so that the
+// next call to runnerProfileList lists them from the server again.
+func (c *baseCommand) invalidateRunnerProfiles() {
+	c.runnerProfiles.mu.Lock()
+	defer c.runnerProfiles.mu.Unlock()
+
+	c.runnerProfiles.configs = nil
+	c.runnerProfiles.fetchedAt = time.Time{}
+}
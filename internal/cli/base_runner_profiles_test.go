@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	clientpkg "github.com/hashicorp/waypoint/internal/client"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess"
+)
+
+func TestRunnerProfileList(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	client := &listRunnerProfilesCountingClient{
+		WaypointClient: singleprocess.TestServer(t),
+	}
+	project := clientpkg.TestProject(t, clientpkg.WithClient(client))
+	clientpkg.TestApp(t, project)
+
+	_, err := client.UpsertOnDemandRunnerConfig(ctx, &pb.UpsertOnDemandRunnerConfigRequest{
+		Config: &pb.OnDemandRunnerConfig{Name: "k8s", PluginType: "kubernetes"},
+	})
+	require.NoError(err)
+
+	c := baseCommand{
+		Log:        hclog.L(),
+		ui:         terminal.ConsoleUI(ctx),
+		project:    project,
+		refProject: project.Ref(),
+		flagApp:    "web",
+	}
+
+	// Every app in every DoApp call should share one list
+	for i := 0; i < 5; i++ {
+		require.NoError(c.DoApp(ctx, func(ctx context.Context, app *clientpkg.App) error {
+			od, err := c.runnerProfile(ctx, "k8s")
+			if err != nil {
+				return err
+			}
+			if od == nil {
+				return errors.New("runner profile not found")
+			}
+
+			return nil
+		}))
+	}
+	require.Equal(int32(1), atomic.LoadInt32(&client.calls))
+
+	// Missing profiles are nil rather than an error
+	od, err := c.runnerProfile(ctx, "nope")
+	require.NoError(err)
+	require.Nil(od)
+	require.Equal(int32(1), atomic.LoadInt32(&client.calls))
+
+	// Invalidating should list again
+	c.invalidateRunnerProfiles()
+	_, err = c.runnerProfileList(ctx)
+	require.NoError(err)
+	require.Equal(int32(2), atomic.LoadInt32(&client.calls))
+}
+
+// listRunnerProfilesCountingClient counts the number of calls to
+// ListOnDemandRunnerConfigs.
+type listRunnerProfilesCountingClient struct {
+	pb.WaypointClient
+
+	calls int32
+}
+
+func (c *listRunnerProfilesCountingClient) ListOnDemandRunnerConfigs(
+	ctx context.Context,
+	req *emptypb.Empty,
+	opts ...grpc.CallOption,
+) (*pb.ListOnDemandRunnerConfigsResponse, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return c.WaypointClient.ListOnDemandRunnerConfigs(ctx, req, opts...)
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// expandServerAddr replaces the placeholders in a server address, such as
+// "wp-{workspace}.internal", with their values. Literal braces are written
+// as "{{" and "}}". A placeholder that isn't in values, or whose value is
+// empty, is an error rather than a silently wrong address.
+func expandServerAddr(addr string, values map[string]string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(addr); i++ {
+		switch ch := addr[i]; {
+		case ch == '{' && strings.HasPrefix(addr[i:], "{{"):
+			b.WriteByte('{')
+			i++
+
+		case ch == '}' && strings.HasPrefix(addr[i:], "}}"):
+			b.WriteByte('}')
+			i++
+
+		case ch == '{':
+			end := strings.IndexByte(addr[i:], '}')
+			if end == -1 {
+				return "", fmt.Errorf(
+					"The server address %q has an unterminated placeholder. Use \"{{\"\n"+
+						"for a literal brace.", addr)
+			}
+
+			name := addr[i+1 : i+end]
+			value, ok := values[name]
+			if !ok {
+				names := make([]string, 0, len(values))
+				for k := range values {
+					names = append(names, "{"+k+"}")
+				}
+				sort.Strings(names)
+
+				return "", fmt.Errorf(
+					"The server address %q has an unknown placeholder {%s}.\n"+
+						"The available placeholders are: %s",
+					addr, name, strings.Join(names, ", "))
+			}
+			if value == "" {
+				return "", fmt.Errorf(
+					"The server address %q uses the placeholder {%s}, but the %s\n"+
+						"isn't known for this command.", addr, name, name)
+			}
+
+			b.WriteString(value)
+			i += end
+
+		case ch == '}':
+			return "", fmt.Errorf(
+				"The server address %q has an unmatched \"}\". Use \"}}\" for a\n"+
+					"literal brace.", addr)
+
+		default:
+			b.WriteByte(ch)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// serverAddrValues returns the values of the placeholders that can be
+// used in the server address.
+func (c *baseCommand) serverAddrValues() map[string]string {
+	values := map[string]string{
+		"workspace": "",
+		"project":   "",
+	}
+	if c.refWorkspace != nil {
+		values["workspace"] = c.refWorkspace.Workspace
+	}
+	if c.refProject != nil {
+		values["project"] = c.refProject.Project
+	}
+
+	return values
+}
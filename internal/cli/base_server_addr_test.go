@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandServerAddr(t *testing.T) {
+	values := map[string]string{
+		"workspace": "prod",
+		"project":   "",
+	}
+
+	cases := []struct {
+		Addr     string
+		Expected string
+		Err      string
+	}{
+		{"localhost:9701", "localhost:9701", ""},
+		{"wp-{workspace}.internal:9701", "wp-prod.internal:9701", ""},
+		{"srv://_waypoint._tcp.{workspace}.example.com", "srv://_waypoint._tcp.prod.example.com", ""},
+		{"{{literal}}.{workspace}", "{literal}.prod", ""},
+		{"wp-{project}.internal", "", "isn't known"},
+		{"wp-{region}.internal", "", "unknown placeholder {region}"},
+		{"wp-{workspace.internal", "", "unterminated"},
+		{"wp-}.internal", "", "unmatched"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Addr, func(t *testing.T) {
+			require := require.New(t)
+
+			addr, err := expandServerAddr(tt.Addr, values)
+			if tt.Err != "" {
+				require.Error(err)
+				require.Contains(err.Error(), tt.Err)
+				return
+			}
+
+			require.NoError(err)
+			require.Equal(tt.Expected, addr)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// checkServerApps returns an error if any of the targeted apps don't exist
+// in the project on the server. This is only checked for remote operations,
+// since local operations register new apps as they execute. If the project
+// isn't registered or has no apps yet, there is nothing to check against
+// and the server reports any problem when the job is queued.
+func (c *baseCommand) checkServerApps(ctx context.Context, apps []string) error {
+	if !c.flagRemote || c.refProject == nil || len(apps) == 0 {
+		return nil
+	}
+
+	project, err := c.getProject(ctx)
+	if err != nil {
+		c.Log.Debug("not checking the apps on the server", "error", err)
+		return nil
+	}
+
+	return checkProjectApps(project, apps)
+}
+
+// checkProjectApps returns an error if any of the apps don't exist in the
+// server record of the project. A project with no apps yet can't be checked.
+func checkProjectApps(project *pb.Project, apps []string) error {
+	if len(project.Applications) == 0 {
+		return nil
+	}
+
+	known := map[string]struct{}{}
+	var names []string
+	for _, a := range project.Applications {
+		known[a.Name] = struct{}{}
+		names = append(names, a.Name)
+	}
+	sort.Strings(names)
+
+	var missing []string
+	for _, app := range apps {
+		if _, ok := known[app]; !ok {
+			missing = append(missing, app)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf(
+		"The following apps don't exist in project %q on the server:\n\n"+
+			"  %s\n\n"+
+			"The apps on the server are:\n\n"+
+			"  %s\n\n"+
+			"Run `waypoint init` to register the apps in your configuration.",
+		project.Name,
+		strings.Join(missing, "\n  "),
+		strings.Join(names, "\n  "))
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	clientpkg "github.com/hashicorp/waypoint/internal/client"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess"
+)
+
+func TestCheckServerApps(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	project := clientpkg.TestProject(t, clientpkg.WithClient(singleprocess.TestServer(t)))
+	clientpkg.TestApp(t, project)
+
+	c := &baseCommand{
+		Log:        hclog.L(),
+		project:    project,
+		refProject: project.Ref(),
+	}
+
+	// Local operations register apps as they execute.
+	require.NoError(c.checkServerApps(ctx, []string{"nope"}))
+
+	c.flagRemote = true
+	require.NoError(c.checkServerApps(ctx, []string{"test_a"}))
+
+	err := c.checkServerApps(ctx, []string{"test_a", "nope"})
+	require.Error(err)
+	require.Contains(err.Error(), "nope")
+	require.Contains(err.Error(), "The apps on the server are:\n\n  test_a")
+
+	// A project that isn't registered can't be checked.
+	c = &baseCommand{
+		Log:        hclog.L(),
+		project:    project,
+		refProject: &pb.Ref_Project{Project: "unregistered"},
+		flagRemote: true,
+	}
+	require.NoError(c.checkServerApps(ctx, []string{"nope"}))
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/waypoint/internal/clicontext"
+)
+
+// EnvSession is the env var that identifies a CLI session. When set, the
+// "-context-set" flag stores overrides in an ephemeral session context that
+// is read by every command run with the same session value. This lets a
+// wrapper pin settings such as the workspace for a single shell, for
+// example with "export WAYPOINT_SESSION=$$", without modifying the
+// persisted CLI contexts.
+const EnvSession = "WAYPOINT_SESSION"
+
+// sessionDir is the directory in the home config directory that stores the
+// session contexts. This is only accessible by the current user so that
+// other users can't read or plant the settings of a session.
+const sessionDir = "sessions"
+
+// reSessionId restricts session IDs to values that are safe as filenames.
+var reSessionId = regexp.MustCompile(`^[-0-9A-Za-z_.]+$`)
+
+// sessionPath returns the path to the session context file for the current
+// session. This returns an empty string if no session is active or there
+// is no home config directory.
+func sessionPath(homeConfigPath string) (string, error) {
+	id := os.Getenv(EnvSession)
+	if id == "" || homeConfigPath == "" {
+		return "", nil
+	}
+
+	if !reSessionId.MatchString(id) {
+		return "", fmt.Errorf(
+			"%s value %q is invalid. It may only contain letters, numbers, "+
+				"'-', '_', and '.'.", EnvSession, id)
+	}
+
+	return filepath.Join(homeConfigPath, sessionDir, id+".hcl"), nil
+}
+
+// checkSessionPath returns an error if the file or directory at path isn't
+// one that the current user created for a session. This returns false if
+// path doesn't exist.
+func checkSessionPath(path string) (bool, error) {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if fi.Mode()&os.ModeSymlink != 0 {
+		return true, fmt.Errorf(
+			"The session context path %q is a symlink. Remove it to use the session.", path)
+	}
+	if !fileOwnedByUser(fi) {
+		return true, fmt.Errorf(
+			"The session context path %q isn't owned by the current user. "+
+				"Remove it to use the session.", path)
+	}
+
+	return true, nil
+}
+
+// sessionContext loads the session context for the current session. This
+// returns nil if there is no active session or nothing was set in it yet.
+func sessionContext(homeConfigPath string) (*clicontext.Config, error) {
+	path, err := sessionPath(homeConfigPath)
+	if err != nil || path == "" {
+		return nil, err
+	}
+
+	for _, p := range []string{filepath.Dir(path), path} {
+		exists, err := checkSessionPath(p)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, nil
+		}
+	}
+
+	return clicontext.LoadPath(path)
+}
+
+// setSessionContext applies the key/value pairs from "-context-set" to the
+// session context and saves it. Only keys that are safe to override per
+// session are supported.
+func setSessionContext(homeConfigPath string, values map[string]string) error {
+	path, err := sessionPath(homeConfigPath)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return fmt.Errorf(
+			"The -context-set flag requires the %s environment variable to be\n"+
+				"set to identify the session, for example: export %s=$$",
+			EnvSession, EnvSession)
+	}
+
+	cfg, err := sessionContext(homeConfigPath)
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		cfg = &clicontext.Config{}
+	}
+
+	// Apply in a stable order so errors are deterministic.
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		switch strings.ToLower(k) {
+		case "workspace":
+			cfg.Workspace = values[k]
+
+		default:
+			return fmt.Errorf(
+				"Unsupported key %q for -context-set. Supported keys: workspace", k)
+		}
+	}
+
+	// The directory may have been created before with other permissions,
+	// so make sure only we can access it.
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = cfg.WriteTo(f)
+	return err
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/clicontext"
+)
+
+func TestWorkspaceSession(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	st := clicontext.TestStorage(t)
+	require.NoError(st.Set("default", &clicontext.Config{Workspace: "lab"}))
+
+	c := baseCommand{contextStorage: st, homeConfigPath: td}
+
+	// Setting the session context without a session is an error
+	os.Unsetenv(EnvSession)
+	require.Error(setSessionContext(td, map[string]string{"workspace": "dev"}))
+
+	os.Setenv(EnvSession, "test-workspace-session")
+	defer os.Unsetenv(EnvSession)
+	path, err := sessionPath(td)
+	require.NoError(err)
+	require.Equal(filepath.Join(td, sessionDir, "test-workspace-session.hcl"), path)
+
+	// Without a session value we get the stored context
+	workspace, err := c.workspace()
+	require.NoError(err)
+	require.Equal("lab", workspace)
+
+	// The session value overrides the stored context
+	require.NoError(setSessionContext(td, map[string]string{"workspace": "dev"}))
+	workspace, err = c.workspace()
+	require.NoError(err)
+	require.Equal("dev", workspace)
+
+	// Only we can access the session directory
+	fi, err := os.Stat(filepath.Dir(path))
+	require.NoError(err)
+	require.Equal(os.FileMode(0700), fi.Mode().Perm())
+
+	// The stored context should not be modified
+	cfg, err := st.Load("default")
+	require.NoError(err)
+	require.Equal("lab", cfg.Workspace)
+
+	// The env var overrides the session value
+	os.Setenv(defaultWorkspaceEnvName, "test")
+	defer os.Unsetenv(defaultWorkspaceEnvName)
+	workspace, err = c.workspace()
+	require.NoError(err)
+	require.Equal("test", workspace)
+
+	// Unknown keys are an error
+	require.Error(setSessionContext(td, map[string]string{"nope": "dev"}))
+
+	// A session file that we didn't write is rejected
+	other := filepath.Join(td, "other.hcl")
+	require.NoError(ioutil.WriteFile(other, []byte(`workspace = "evil"`), 0644))
+	require.NoError(os.Remove(path))
+	require.NoError(os.Symlink(other, path))
+	_, err = c.workspace()
+	require.Error(err)
+}
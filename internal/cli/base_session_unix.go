@@ -0,0 +1,18 @@
+// +build !windows
+
+package cli
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileOwnedByUser returns true if the file is owned by the current user.
+func fileOwnedByUser(fi os.FileInfo) bool {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+
+	return int(st.Uid) == os.Getuid()
+}
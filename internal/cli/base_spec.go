@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+
+	"github.com/hashicorp/waypoint/internal/config"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+)
+
+// jobSpec is the format of a file given with "-spec". This declares the
+// same settings as the flags for an operation so that an operation can be
+// kept in version control rather than as a long list of flags. Unknown
+// keys are an error.
+type jobSpec struct {
+	Project      string            `hcl:"project,optional"`
+	App          string            `hcl:"app,optional"`
+	Workspace    string            `hcl:"workspace,optional"`
+	Remote       *bool             `hcl:"remote,optional"`
+	Variables    map[string]string `hcl:"variables,optional"`
+	Labels       map[string]string `hcl:"labels,optional"`
+	RemoteSource map[string]string `hcl:"remote_source,optional"`
+	RunnerLabels map[string]string `hcl:"runner_labels,optional"`
+}
+
+// readJobSpec reads and validates the job spec file at path. Files ending
+// in ".json" are parsed as JSON and all others as HCL.
+func readJobSpec(path string) (*jobSpec, error) {
+	parser := hclparse.NewParser()
+
+	var f *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(path, ".json") {
+		f, diags = parser.ParseJSONFile(path)
+	} else {
+		f, diags = parser.ParseHCLFile(path)
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var result jobSpec
+	if diags := gohcl.DecodeBody(f.Body, nil, &result); diags.HasErrors() {
+		return nil, diags
+	}
+
+	if errs := config.ValidateLabels(result.Labels); len(errs) > 0 {
+		return nil, fmt.Errorf("Invalid labels in %q: %s", path,
+			multierror.Append(nil, errs...).Error())
+	}
+
+	return &result, nil
+}
+
+// initSpec applies the job spec file given with "-spec" to the flags for
+// this command. Flags that were set explicitly take precedence over the
+// spec, and for maps, such as the variables, the keys set with flags take
+// precedence over the same keys in the spec. set is the parsed flags.
+func (c *baseCommand) initSpec(set *flag.Sets) error {
+	if c.flagSpec == "" {
+		return nil
+	}
+
+	spec, err := readJobSpec(c.flagSpec)
+	if err != nil {
+		return err
+	}
+
+	if spec.Project != "" && !set.IsSet("project") {
+		c.flagProjects = []string{spec.Project}
+	}
+	if spec.App != "" && !set.IsSet("app") {
+		c.flagApp = spec.App
+	}
+	if spec.Workspace != "" && !set.IsSet("workspace") {
+		c.flagWorkspace = spec.Workspace
+	}
+	if spec.Remote != nil && !set.IsSet("remote") && !set.IsSet("local") {
+		c.flagRemote = *spec.Remote
+		c.flagLocal = !*spec.Remote
+	}
+
+	c.flagVars = mergeSpecMap(spec.Variables, c.flagVars)
+	c.flagRemoteSource = mergeSpecMap(spec.RemoteSource, c.flagRemoteSource)
+	c.flagRunnerLabels = mergeSpecMap(spec.RunnerLabels, c.flagRunnerLabels)
+
+	// The labels are merged with any label files in initLabelFiles, since
+	// those take precedence over the spec.
+	c.specLabels = spec.Labels
+
+	return nil
+}
+
+// mergeSpecMap returns the values from a job spec merged with the values
+// from flags, which take precedence. This returns the flag values as-is
+// if the spec doesn't set any values.
+func mergeSpecMap(spec, flags map[string]string) map[string]string {
+	if len(spec) == 0 {
+		return flags
+	}
+
+	result := map[string]string{}
+	for k, v := range spec {
+		result[k] = v
+	}
+	for k, v := range flags {
+		result[k] = v
+	}
+
+	return result
+}
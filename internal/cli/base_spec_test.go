@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitSpec(t *testing.T) {
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	path := filepath.Join(td, "deploy.hcl")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+project   = "web"
+app       = "api"
+workspace = "prod"
+remote    = true
+
+variables = {
+  region   = "us-east-1"
+  replicas = 3
+}
+
+labels = {
+  team = "web"
+  env  = "prod"
+}
+
+remote_source = {
+  ref = "main"
+}
+
+runner_labels = {
+  gpu = "true"
+}
+`), 0644))
+
+	t.Run("spec values", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{}
+		set := c.flagSet(flagSetOperation, nil)
+		require.NoError(set.Parse([]string{"-spec", path}))
+		require.NoError(c.initSpec(set))
+		require.NoError(c.initLabelFiles())
+
+		require.Equal([]string{"web"}, c.flagProjects)
+		require.Equal("api", c.flagApp)
+		require.Equal("prod", c.flagWorkspace)
+		require.True(c.flagRemote)
+		require.False(c.flagLocal)
+		require.Equal(map[string]string{"region": "us-east-1", "replicas": "3"}, c.flagVars)
+		require.Equal(map[string]string{"team": "web", "env": "prod"}, c.flagLabels)
+		require.Equal(map[string]string{"ref": "main"}, c.flagRemoteSource)
+		require.Equal(map[string]string{"gpu": "true"}, c.flagRunnerLabels)
+	})
+
+	t.Run("flags take precedence", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{}
+		set := c.flagSet(flagSetOperation, nil)
+		require.NoError(set.Parse([]string{
+			"-spec", path,
+			"-app", "worker",
+			"-local",
+			"-var", "region=eu-west-1",
+			"-label", "env=staging",
+		}))
+		require.NoError(c.initSpec(set))
+		require.NoError(c.initLabelFiles())
+
+		require.Equal("worker", c.flagApp)
+		require.False(c.flagRemote)
+		require.True(c.flagLocal)
+		require.Equal("eu-west-1", c.flagVars["region"])
+		require.Equal("3", c.flagVars["replicas"])
+		require.Equal(map[string]string{"team": "web", "env": "staging"}, c.flagLabels)
+	})
+
+	t.Run("unknown keys", func(t *testing.T) {
+		require := require.New(t)
+
+		invalid := filepath.Join(td, "invalid.hcl")
+		require.NoError(ioutil.WriteFile(invalid, []byte(`
+app      = "api"
+replicas = 3
+`), 0644))
+
+		c := &baseCommand{}
+		set := c.flagSet(flagSetOperation, nil)
+		require.NoError(set.Parse([]string{"-spec", invalid}))
+		err := c.initSpec(set)
+		require.Error(err)
+		require.Contains(err.Error(), "replicas")
+	})
+}
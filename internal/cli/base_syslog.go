@@ -0,0 +1,248 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+)
+
+// syslogWriter is the subset of *syslog.Writer that we use. It is an
+// interface because log/syslog isn't available on Windows.
+type syslogWriter interface {
+	Debug(string) error
+	Info(string) error
+	Notice(string) error
+	Warning(string) error
+	Err(string) error
+	Close() error
+}
+
+// syslogSeverity is the severity of a syslog message.
+type syslogSeverity int
+
+const (
+	syslogDebug syslogSeverity = iota
+	syslogInfo
+	syslogNotice
+	syslogWarning
+	syslogErr
+)
+
+// syslogLevelSeverity maps a log level to the syslog severity.
+func syslogLevelSeverity(level hclog.Level) syslogSeverity {
+	switch {
+	case level >= hclog.Error:
+		return syslogErr
+	case level == hclog.Warn:
+		return syslogWarning
+	case level == hclog.Info:
+		return syslogInfo
+	default:
+		return syslogDebug
+	}
+}
+
+// syslogStyleSeverity maps the style of UI output to the syslog severity.
+func syslogStyleSeverity(style string) syslogSeverity {
+	switch style {
+	case terminal.ErrorStyle, terminal.ErrorBoldStyle:
+		return syslogErr
+	case terminal.WarningStyle, terminal.WarningBoldStyle:
+		return syslogWarning
+	case terminal.SuccessStyle, terminal.SuccessBoldStyle:
+		return syslogNotice
+	default:
+		return syslogInfo
+	}
+}
+
+// syslogSend writes msg to w with the given severity.
+func syslogSend(w syslogWriter, sev syslogSeverity, msg string) error {
+	switch sev {
+	case syslogErr:
+		return w.Err(msg)
+	case syslogWarning:
+		return w.Warning(msg)
+	case syslogNotice:
+		return w.Notice(msg)
+	case syslogInfo:
+		return w.Info(msg)
+	default:
+		return w.Debug(msg)
+	}
+}
+
+// syslogFormat formats a log message and its key/value pairs as a single
+// line for syslog. The time and level aren't included since syslog
+// records them itself.
+func syslogFormat(name, msg string, args ...interface{}) string {
+	var b strings.Builder
+	if name != "" {
+		b.WriteString(name)
+		b.WriteString(": ")
+	}
+	b.WriteString(msg)
+
+	for i := 0; i < len(args); i += 2 {
+		var value interface{} = "(missing)"
+		if i+1 < len(args) {
+			value = args[i+1]
+		}
+
+		v := fmt.Sprint(value)
+		if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+			v = fmt.Sprintf("%q", v)
+		}
+
+		fmt.Fprintf(&b, " %v=%s", args[i], v)
+	}
+
+	return b.String()
+}
+
+// parseSyslogAddr parses the -syslog-addr value into the network and
+// address to dial. An empty value is the local syslog daemon. The value is
+// a URL such as "udp://host:514" or "tcp://host:601", or a "host:port"
+// which uses UDP. The port defaults to 514.
+func parseSyslogAddr(v string) (string, string, error) {
+	if v == "" {
+		return "", "", nil
+	}
+
+	if !strings.Contains(v, "://") {
+		v = "udp://" + v
+	}
+
+	u, err := url.Parse(v)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid syslog address %q: %w", v, err)
+	}
+
+	switch u.Scheme {
+	case "udp", "tcp":
+	default:
+		return "", "", fmt.Errorf(
+			"invalid syslog address %q: the protocol must be \"udp\" or \"tcp\"", v)
+	}
+
+	if u.Hostname() == "" {
+		return "", "", fmt.Errorf("invalid syslog address %q: a host is required", v)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "514"
+	}
+
+	return u.Scheme, net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// initSyslog forwards the logs, and the UI output with -syslog-output, to
+// syslog if that is enabled. This is in addition to the logs on stderr.
+func (c *baseCommand) initSyslog() error {
+	if !c.flagSyslog && c.flagSyslogAddr == "" {
+		return nil
+	}
+
+	network, addr, err := parseSyslogAddr(c.flagSyslogAddr)
+	if err != nil {
+		return err
+	}
+
+	w, err := dialSyslog(network, addr, c.flagSyslogFacility, c.flagSyslogTag)
+	if err != nil {
+		return fmt.Errorf("Error connecting to syslog: %w", err)
+	}
+	c.syslog = w
+
+	if intercept, ok := c.Log.(hclog.InterceptLogger); ok {
+		// Logs are sent at info and above unless a more verbose level
+		// was requested with -v.
+		level := hclog.Info
+		if c.Log.IsTrace() {
+			level = hclog.Trace
+		} else if c.Log.IsDebug() {
+			level = hclog.Debug
+		}
+
+		c.syslogSink = &syslogSink{w: w, level: level}
+		intercept.RegisterSink(c.syslogSink)
+	} else {
+		c.Log.Warn("logger doesn't support sinks, logs won't be sent to syslog")
+	}
+
+	if c.flagSyslogOutput {
+		c.ui = &syslogUI{UI: c.ui, w: w}
+	}
+
+	return nil
+}
+
+// closeSyslog stops forwarding to syslog and closes the connection.
+func (c *baseCommand) closeSyslog() {
+	if c.syslogSink != nil {
+		if intercept, ok := c.Log.(hclog.InterceptLogger); ok {
+			intercept.DeregisterSink(c.syslogSink)
+		}
+		c.syslogSink = nil
+	}
+
+	if c.syslog != nil {
+		c.syslog.Close()
+		c.syslog = nil
+	}
+}
+
+// syslogSink is an hclog.SinkAdapter that writes logs to syslog.
+type syslogSink struct {
+	w     syslogWriter
+	level hclog.Level
+}
+
+func (s *syslogSink) Accept(name string, level hclog.Level, msg string, args ...interface{}) {
+	if level < s.level {
+		return
+	}
+
+	// Errors are ignored since there is nowhere to report them that
+	// wouldn't loop back to this sink.
+	_ = syslogSend(s.w, syslogLevelSeverity(level), syslogFormat(name, msg, args...))
+}
+
+// syslogUI is a terminal.UI that also writes Output to syslog. Other
+// output such as tables and status updates is only written to the UI.
+type syslogUI struct {
+	terminal.UI
+
+	w syslogWriter
+}
+
+func (u *syslogUI) Output(msg string, raw ...interface{}) {
+	u.UI.Output(msg, raw...)
+
+	msg, style, _ := terminal.Interpret(msg, raw...)
+	sev := syslogStyleSeverity(style)
+	for _, line := range strings.Split(msg, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		_ = syslogSend(u.w, sev, line)
+	}
+}
+
+// Close closes the wrapped UI if it implements io.Closer, such as the
+// glint-based UI.
+func (u *syslogUI) Close() error {
+	if closer, ok := u.UI.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
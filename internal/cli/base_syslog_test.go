@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSyslogAddr(t *testing.T) {
+	cases := []struct {
+		Input   string
+		Network string
+		Addr    string
+		Err     bool
+	}{
+		{"", "", "", false},
+		{"udp://logs.example.com:1514", "udp", "logs.example.com:1514", false},
+		{"tcp://logs.example.com", "tcp", "logs.example.com:514", false},
+		{"logs.example.com:1514", "udp", "logs.example.com:1514", false},
+		{"http://logs.example.com", "", "", true},
+		{"udp://", "", "", true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Input, func(t *testing.T) {
+			require := require.New(t)
+
+			network, addr, err := parseSyslogAddr(tt.Input)
+			if tt.Err {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(tt.Network, network)
+			require.Equal(tt.Addr, addr)
+		})
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	require := require.New(t)
+
+	var w testSyslogWriter
+	sink := &syslogSink{w: &w, level: hclog.Info}
+	sink.Accept("waypoint", hclog.Debug, "hidden")
+	sink.Accept("waypoint", hclog.Info, "starting", "app", "web")
+	sink.Accept("waypoint.runner", hclog.Warn, "slow", "message", "took a while")
+	sink.Accept("waypoint", hclog.Error, "failed", "odd")
+
+	require.Equal([]string{
+		"info: waypoint: starting app=web",
+		"warning: waypoint.runner: slow message=\"took a while\"",
+		"err: waypoint: failed odd=(missing)",
+	}, w.lines)
+}
+
+// testSyslogWriter is a syslogWriter that records the severity and message
+// of each line.
+type testSyslogWriter struct {
+	lines []string
+}
+
+func (w *testSyslogWriter) Debug(m string) error   { return w.write("debug", m) }
+func (w *testSyslogWriter) Info(m string) error    { return w.write("info", m) }
+func (w *testSyslogWriter) Notice(m string) error  { return w.write("notice", m) }
+func (w *testSyslogWriter) Warning(m string) error { return w.write("warning", m) }
+func (w *testSyslogWriter) Err(m string) error     { return w.write("err", m) }
+func (w *testSyslogWriter) Close() error           { return nil }
+
+func (w *testSyslogWriter) write(sev, m string) error {
+	w.lines = append(w.lines, sev+": "+m)
+	return nil
+}
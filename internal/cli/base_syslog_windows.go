@@ -0,0 +1,9 @@
+// +build windows
+
+package cli
+
+import "errors"
+
+func dialSyslog(network, addr, facility, tag string) (syslogWriter, error) {
+	return nil, errors.New("syslog isn't supported on Windows")
+}
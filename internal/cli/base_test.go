@@ -1,13 +1,30 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
 	"github.com/hashicorp/waypoint/internal/clicontext"
+	clientpkg "github.com/hashicorp/waypoint/internal/client"
+	"github.com/hashicorp/waypoint/internal/config"
+	"github.com/hashicorp/waypoint/internal/config/variables"
 	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	runnerpkg "github.com/hashicorp/waypoint/internal/runner"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess"
+	"github.com/hashicorp/waypoint/internal/serverclient"
 )
 
 func TestCheckFlagsAfterArgs(t *testing.T) {
@@ -201,3 +218,867 @@ func TestWorkspacePrecedence(t *testing.T) {
 		})
 	}
 }
+
+func TestAppsBySelector(t *testing.T) {
+	cfg := config.TestConfig(t, `
+project = "test"
+
+app "web" {
+  labels = {
+    team = "payments"
+    tier = "frontend"
+  }
+}
+
+app "api" {
+  labels = {
+    team = "payments"
+    tier = "backend"
+  }
+}
+
+app "worker" {
+  labels = {
+    team = "search"
+    tier = "backend"
+  }
+}
+`)
+
+	cases := []struct {
+		Name     string
+		Selector string
+		Expected []string
+		Err      bool
+	}{
+		{
+			"equality",
+			"team=payments",
+			[]string{"web", "api"},
+			false,
+		},
+		{
+			"multiple requirements",
+			"team=payments,tier=backend",
+			[]string{"api"},
+			false,
+		},
+		{
+			"inequality",
+			"tier!=backend",
+			[]string{"web"},
+			false,
+		},
+		{
+			"set based",
+			"team in (payments,search),tier notin (frontend)",
+			[]string{"api", "worker"},
+			false,
+		},
+		{
+			"no matches",
+			"team=nope",
+			nil,
+			true,
+		},
+		{
+			"invalid selector",
+			"team in (",
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			require := require.New(t)
+
+			c := baseCommand{
+				Log: hclog.L(),
+				cfg: cfg,
+			}
+
+			result, err := c.appsBySelector(cfg.Apps(), tt.Selector)
+			if tt.Err {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(tt.Expected, result)
+		})
+	}
+}
+
+func TestAppsByWorkspace(t *testing.T) {
+	cfg := config.TestConfig(t, `
+project = "test"
+
+app "web" {
+  workspaces = ["staging", "prod"]
+}
+
+app "api" {
+}
+`)
+
+	newCommand := func(ws string, strict bool) *baseCommand {
+		return &baseCommand{
+			Log:                 hclog.L(),
+			ui:                  terminal.ConsoleUI(context.Background()),
+			cfg:                 cfg,
+			refWorkspace:        &pb.Ref_Workspace{Workspace: ws},
+			flagStrictWorkspace: strict,
+		}
+	}
+
+	t.Run("listed workspace", func(t *testing.T) {
+		require := require.New(t)
+
+		result, err := newCommand("prod", false).appsByWorkspace([]string{"web", "api"})
+		require.NoError(err)
+		require.Equal([]string{"web", "api"}, result)
+	})
+
+	t.Run("other workspace skips", func(t *testing.T) {
+		require := require.New(t)
+
+		result, err := newCommand("dev", false).appsByWorkspace([]string{"web", "api"})
+		require.NoError(err)
+		require.Equal([]string{"api"}, result)
+	})
+
+	t.Run("other workspace strict", func(t *testing.T) {
+		require := require.New(t)
+
+		_, err := newCommand("dev", true).appsByWorkspace([]string{"web", "api"})
+		require.Error(err)
+		require.Contains(err.Error(), "staging, prod")
+	})
+
+	t.Run("apps not in the config are kept", func(t *testing.T) {
+		require := require.New(t)
+
+		result, err := newCommand("dev", true).appsByWorkspace([]string{"other"})
+		require.NoError(err)
+		require.Equal([]string{"other"}, result)
+	})
+}
+
+func TestMatchApps(t *testing.T) {
+	names := []string{"svc-web", "svc-api", "svc-worker", "db", "odd[1]"}
+
+	cases := []struct {
+		Pattern  string
+		Expected []string
+		Err      bool
+	}{
+		{"svc-*", []string{"svc-web", "svc-api", "svc-worker"}, false},
+		{"svc-?pi", []string{"svc-api"}, false},
+		{"db", []string{"db"}, false},
+		{"odd[1]", []string{"odd[1]"}, false},
+		{"web-*", nil, true},
+		{"svc-[", nil, true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Pattern, func(t *testing.T) {
+			require := require.New(t)
+
+			result, err := matchApps(tt.Pattern, names)
+			if tt.Err {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(tt.Expected, result)
+		})
+	}
+}
+
+func TestSplitAppVars(t *testing.T) {
+	t.Run("global and app-scoped", func(t *testing.T) {
+		require := require.New(t)
+
+		global, byApp, err := splitAppVars(map[string]string{
+			"replicas":     "1",
+			"web:replicas": "3",
+			"web:port":     "8080",
+			"api:replicas": "2",
+		})
+		require.NoError(err)
+		require.Equal(map[string]string{"replicas": "1"}, global)
+		require.Equal(map[string]map[string]string{
+			"web": {"replicas": "3", "port": "8080"},
+			"api": {"replicas": "2"},
+		}, byApp)
+
+		c := &baseCommand{appVariables: map[string][]*pb.Variable{"web": nil, "api": nil}}
+		require.NoError(c.validateAppVars([]string{"api", "web"}))
+		require.Error(c.validateAppVars([]string{"web"}))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := splitAppVars(map[string]string{":replicas": "3"})
+		require.Error(t, err)
+
+		_, _, err = splitAppVars(map[string]string{"web:": "3"})
+		require.Error(t, err)
+	})
+}
+
+func TestLoadAppVariables(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	file := filepath.Join(td, "values.wpvars")
+	require.NoError(ioutil.WriteFile(file, []byte(`replicas = "5"`), 0644))
+
+	// values returns the last value of each variable, which is the one
+	// that is used.
+	values := func(vs []*pb.Variable) map[string]string {
+		result := map[string]string{}
+		for _, v := range vs {
+			result[v.Name] = v.Value.(*pb.Variable_Str).Str
+		}
+		return result
+	}
+
+	// No app-scoped values
+	byApp, diags := loadAppVariables(nil, nil, []string{file}, variables.PrecedenceFlags)
+	require.False(diags.HasErrors())
+	require.Nil(byApp)
+
+	flagVars := map[string]string{"replicas": "1", "port": "80"}
+	appVars := map[string]map[string]string{"web": {"replicas": "3"}}
+
+	// The app-scoped value wins over the file by default, and replaces
+	// the global -var value.
+	byApp, diags = loadAppVariables(flagVars, appVars, []string{file}, variables.PrecedenceFlags)
+	require.False(diags.HasErrors())
+	require.Equal(map[string]string{"replicas": "3", "port": "80"}, values(byApp["web"]))
+
+	// With -var-precedence=files the file wins over the app-scoped value
+	// too.
+	byApp, diags = loadAppVariables(flagVars, appVars, []string{file}, variables.PrecedenceFiles)
+	require.False(diags.HasErrors())
+	require.Equal(map[string]string{"replicas": "5", "port": "80"}, values(byApp["web"]))
+}
+
+func TestInitPhases(t *testing.T) {
+	cfg := &baseConfig{Phases: []string{phaseBuild, phaseDeploy, phaseRelease}}
+
+	t.Run("all phases by default", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{}
+		require.True(c.phaseEnabled(phaseBuild))
+		require.True(c.phaseEnabled(phaseRelease))
+	})
+
+	t.Run("unsupported command", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{flagOnly: []string{"build"}}
+		require.Error(c.initPhases(&baseConfig{}))
+	})
+
+	t.Run("invalid phase", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{flagOnly: []string{"build", "nope"}}
+		err := c.initPhases(cfg)
+		require.Error(err)
+		require.Contains(err.Error(), "nope")
+	})
+
+	t.Run("subset", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{flagOnly: []string{"build", " Deploy"}}
+		require.NoError(c.initPhases(cfg))
+		require.True(c.phaseEnabled(phaseBuild))
+		require.True(c.phaseEnabled(phaseDeploy))
+		require.False(c.phaseEnabled(phaseRelease))
+	})
+}
+
+func TestInitRunnerEnv(t *testing.T) {
+	t.Run("unset", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{}
+		require.NoError(c.initRunnerEnv())
+		require.Nil(c.flagLabels)
+	})
+
+	t.Run("local", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{flagRunnerEnv: map[string]string{"A": "1"}}
+		err := c.initRunnerEnv()
+		require.Error(err)
+		require.Contains(err.Error(), "remote runner")
+	})
+
+	t.Run("set both ways", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{
+			flagRemote:             true,
+			flagRunnerEnv:          map[string]string{"A": "1"},
+			flagRunnerEnvSensitive: map[string]string{"A": "2"},
+		}
+		require.Error(c.initRunnerEnv())
+	})
+
+	t.Run("remote", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{
+			flagRemote:             true,
+			flagLabels:             map[string]string{"team": "platform"},
+			flagRunnerEnv:          map[string]string{"A": "1"},
+			flagRunnerEnvSensitive: map[string]string{"TOKEN": "DEPLOY_TOKEN"},
+		}
+		require.NoError(c.initRunnerEnv())
+		require.Equal(map[string]string{
+			"team":                            "platform",
+			runnerpkg.JobEnvLabelPrefix + "A": "1",
+			runnerpkg.JobEnvSensitiveLabelPrefix + "TOKEN": "DEPLOY_TOKEN",
+		}, c.flagLabels)
+	})
+
+	t.Run("sensitive without a runner env var", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{
+			flagRemote:             true,
+			flagRunnerEnvSensitive: map[string]string{"TOKEN": ""},
+		}
+		require.Error(c.initRunnerEnv())
+	})
+}
+
+func TestInitRunnerLabels(t *testing.T) {
+	require := require.New(t)
+
+	// Unset
+	c := &baseCommand{}
+	require.NoError(c.initRunnerLabels())
+	require.Nil(c.flagLabels)
+
+	// Local
+	c = &baseCommand{flagRunnerLabels: map[string]string{"gpu": "true"}}
+	err := c.initRunnerLabels()
+	require.Error(err)
+	require.Contains(err.Error(), "remote runner")
+
+	// Remote
+	c.flagRemote = true
+	require.NoError(c.initRunnerLabels())
+	require.Equal(map[string]string{
+		runnerpkg.JobRunnerLabelPrefix + "gpu": "true",
+	}, c.flagLabels)
+}
+
+func TestCheckRequiredEnv(t *testing.T) {
+	os.Unsetenv("WP_TEST_REQUIRED_ENV")
+	cfg := config.TestConfig(t, `
+project = "test"
+
+required_env = ["WP_TEST_REQUIRED_ENV"]
+`)
+
+	t.Run("missing", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{cfg: cfg, envCheck: true}
+		err := c.checkRequiredEnv()
+		require.Error(err)
+		require.Contains(err.Error(), "WP_TEST_REQUIRED_ENV")
+	})
+
+	t.Run("skipped", func(t *testing.T) {
+		require := require.New(t)
+
+		require.NoError((&baseCommand{cfg: cfg}).checkRequiredEnv())
+		require.NoError((&baseCommand{cfg: cfg, envCheck: true, flagSkipEnvCheck: true}).checkRequiredEnv())
+		require.NoError((&baseCommand{cfg: cfg, envCheck: true, flagRemote: true}).checkRequiredEnv())
+	})
+
+	t.Run("set", func(t *testing.T) {
+		require := require.New(t)
+
+		os.Setenv("WP_TEST_REQUIRED_ENV", "1")
+		defer os.Unsetenv("WP_TEST_REQUIRED_ENV")
+
+		c := &baseCommand{cfg: cfg, envCheck: true}
+		require.NoError(c.checkRequiredEnv())
+	})
+}
+
+func TestCheckConfigApp(t *testing.T) {
+	cfg := config.TestConfig(t, `
+project = "test"
+
+app "web" {}
+
+app "api" {}
+`)
+
+	t.Run("defined app", func(t *testing.T) {
+		c := &baseCommand{cfg: cfg, flagApp: "web"}
+		require.NoError(t, c.checkConfigApp())
+	})
+
+	t.Run("undefined app lists the defined apps", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{cfg: cfg, flagApp: "wbe"}
+		err := c.checkConfigApp()
+		require.Error(err)
+		require.Contains(err.Error(), "wbe")
+		require.Contains(err.Error(), "web (")
+		require.Contains(err.Error(), "api (")
+	})
+
+	t.Run("remote operations are skipped", func(t *testing.T) {
+		c := &baseCommand{cfg: cfg, flagApp: "wbe", flagRemote: true}
+		require.NoError(t, c.checkConfigApp())
+	})
+
+	t.Run("other projects are skipped", func(t *testing.T) {
+		c := &baseCommand{cfg: cfg, flagApp: "wbe", flagProject: "other"}
+		require.NoError(t, c.checkConfigApp())
+	})
+}
+
+func TestInitServerTLS(t *testing.T) {
+	newCommand := func(tls bool) *baseCommand {
+		c := &baseCommand{ui: terminal.ConsoleUI(context.Background())}
+		c.flagConnection.Server.Address = "example.com:9701"
+		c.flagConnection.Server.Tls = tls
+		return c
+	}
+
+	t.Run("defaulted", func(t *testing.T) {
+		require := require.New(t)
+
+		c := newCommand(true)
+		require.NoError(c.initServerTLS(false))
+		require.False(c.serverTLSDisabled)
+		require.True(c.flagConnection.Server.Tls)
+	})
+
+	t.Run("-server-tls=false", func(t *testing.T) {
+		require := require.New(t)
+
+		c := newCommand(false)
+		require.NoError(c.initServerTLS(true))
+		require.True(c.serverTLSDisabled)
+	})
+
+	t.Run("-server-insecure", func(t *testing.T) {
+		require := require.New(t)
+
+		c := newCommand(true)
+		c.flagServerInsecure = true
+		require.NoError(c.initServerTLS(false))
+		require.True(c.serverTLSDisabled)
+		require.False(c.flagConnection.Server.Tls)
+	})
+
+	t.Run("conflicting flags", func(t *testing.T) {
+		c := newCommand(true)
+		c.flagServerInsecure = true
+		require.Error(t, c.initServerTLS(true))
+	})
+}
+
+func TestCheckVarWarnings(t *testing.T) {
+	require := require.New(t)
+
+	c := &baseCommand{Log: hclog.L()}
+	warnings := hcl.Diagnostics{{
+		Severity: hcl.DiagWarning,
+		Summary:  "Deprecated value",
+		Detail:   "The value is deprecated.",
+	}}
+
+	// No diagnostics
+	require.NoError(c.checkVarWarnings(nil))
+
+	// Warnings are only logged by default
+	require.NoError(c.checkVarWarnings(warnings))
+
+	// Warnings are errors with -strict-vars
+	c.flagStrictVars = true
+	err := c.checkVarWarnings(warnings)
+	require.Error(err)
+	require.Contains(err.Error(), "-strict-vars")
+	require.Contains(err.Error(), "Deprecated value")
+}
+
+func TestContextDataSourceRef(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	st, err := clicontext.NewStorage(clicontext.WithDir(td))
+	require.NoError(err)
+
+	c := &baseCommand{contextStorage: st}
+
+	// No context
+	ref, err := c.contextDataSourceRef()
+	require.NoError(err)
+	require.Empty(ref)
+
+	// Context with a ref is the default so we should use it
+	require.NoError(st.Set("release", &clicontext.Config{
+		DataSourceRef: "release-1.0",
+	}))
+	ref, err = c.contextDataSourceRef()
+	require.NoError(err)
+	require.Equal("release-1.0", ref)
+
+	// The context from the env var is the one used to connect
+	require.NoError(st.Set("other", &clicontext.Config{
+		DataSourceRef: "release-2.0",
+	}))
+	require.NoError(st.SetDefault("release"))
+	defer os.Unsetenv(serverclient.EnvContext)
+	require.NoError(os.Setenv(serverclient.EnvContext, "other"))
+	ref, err = c.contextDataSourceRef()
+	require.NoError(err)
+	require.Equal("release-2.0", ref)
+
+	// Connecting with the server flags doesn't use a context
+	c.flagConnection.Server.Address = "localhost:9701"
+	ref, err = c.contextDataSourceRef()
+	require.NoError(err)
+	require.Empty(ref)
+}
+
+func TestDoAppResults(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	project := clientpkg.TestProject(t, clientpkg.WithClient(singleprocess.TestServer(t)))
+
+	c := baseCommand{
+		Log:        hclog.L(),
+		ui:         terminal.ConsoleUI(ctx),
+		project:    project,
+		refProject: project.Ref(),
+		flagApp:    "web",
+	}
+
+	results, err := c.DoAppResults(ctx, func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+		return "payload", nil
+	})
+	require.NoError(err)
+	require.Len(results, 1)
+	require.Equal(project.Ref().Project, results[0].Project)
+	require.Equal("web", results[0].App)
+	require.Equal(AppResultSuccess, results[0].Status)
+	require.Equal("payload", results[0].Payload)
+
+	results, err = c.DoAppResults(ctx, func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	require.Error(err)
+	require.Len(results, 1)
+	require.Equal(AppResultError, results[0].Status)
+	require.EqualError(results[0].Err, "failed")
+	require.Equal(AppFailureOther, results[0].Failure)
+}
+
+func TestDoAppResults_printJob(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	project := clientpkg.TestProject(t, clientpkg.WithClient(singleprocess.TestServer(t)))
+
+	c := baseCommand{
+		Log:          hclog.L(),
+		ui:           terminal.ConsoleUI(ctx),
+		project:      project,
+		refProject:   project.Ref(),
+		flagApp:      "web",
+		flagPrintJob: true,
+	}
+
+	// A printed job is the expected outcome, not an error for the app.
+	results, err := c.DoAppResults(ctx, func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+		return nil, appOpError(app.UI, clientpkg.ErrJobPrinted)
+	})
+	require.NoError(err)
+	require.Len(results, 1)
+	require.Equal(AppResultSuccess, results[0].Status)
+	require.NoError(results[0].Err)
+
+	// Other errors are output and still fail the app.
+	results, err = c.DoAppResults(ctx, func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+		return nil, appOpError(app.UI, errors.New("failed"))
+	})
+	require.Equal(ErrSentinel, err)
+	require.Len(results, 1)
+	require.Equal(AppResultError, results[0].Status)
+}
+
+func TestDoAppResults_failOnNoApps(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	project := clientpkg.TestProject(t, clientpkg.WithClient(singleprocess.TestServer(t)))
+
+	c := baseCommand{
+		Log:        hclog.L(),
+		ui:         terminal.ConsoleUI(ctx),
+		project:    project,
+		refProject: project.Ref(),
+	}
+	f := func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+		return nil, nil
+	}
+
+	// No apps is a no-op by default.
+	results, err := c.DoAppResults(ctx, f)
+	require.NoError(err)
+	require.Empty(results)
+
+	// With the flag, it is an error that was already output.
+	c.flagFailOnNoApps = true
+	_, err = c.DoAppResults(ctx, f)
+	require.Error(err)
+	require.True(errors.Is(err, ErrNoApps))
+	require.True(errors.Is(err, ErrSentinel))
+}
+
+func TestDoProjects_filters(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	project := clientpkg.TestProject(t, clientpkg.WithClient(singleprocess.TestServer(t)))
+	client := project.Client()
+	singleprocess.TestApp(t, client, &pb.Ref_Application{Project: "a", Application: "web"})
+	singleprocess.TestApp(t, client, &pb.Ref_Application{Project: "a", Application: "api"})
+	singleprocess.TestApp(t, client, &pb.Ref_Application{Project: "b", Application: "web"})
+
+	c := &baseCommand{
+		Log:             hclog.L(),
+		ui:              terminal.ConsoleUI(ctx),
+		project:         project,
+		refProject:      project.Ref(),
+		refWorkspace:    &pb.Ref_Workspace{Workspace: "default"},
+		homeConfigPath:  td,
+		operation:       true,
+		serverAppCheck:  true,
+		flagRemote:      true,
+		flagRetryFailed: true,
+		flagProjects:    []string{"a", "b"},
+	}
+
+	var called []string
+	f := func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+		called = append(called, app.Ref().Project+"/"+app.Ref().Application)
+		return nil, errors.New("failed")
+	}
+
+	// Only the failed apps of each project are retried, and a project with
+	// no failed apps is skipped.
+	require.NoError(writeFailedApps(td, failedAppsKey("a", "default"), []string{"api"}))
+	_, err = c.DoProjects(ctx, f)
+	require.Error(err)
+	require.Equal([]string{"a/api"}, called)
+	require.Equal([]string{"api"}, c.recordedFailedApps("a"))
+	require.Empty(c.recordedFailedApps("b"))
+
+	// The jobs of each project are canceled if we're interrupted.
+	require.Len(c.projectClients, 1)
+
+	// Apps that no longer exist on the server are an error.
+	called = nil
+	require.NoError(writeFailedApps(td, failedAppsKey("a", "default"), []string{"gone"}))
+	_, err = c.DoProjects(ctx, f)
+	require.Error(err)
+	require.Empty(called)
+
+	// App labels are only known for the project of the local configuration.
+	called = nil
+	c.flagRetryFailed = false
+	c.flagAppSelector = "tier=frontend"
+	_, err = c.DoProjects(ctx, f)
+	require.Error(err)
+	require.Empty(called)
+}
+
+func TestInitNoLocalRunner(t *testing.T) {
+	t.Run("flag", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{autoServer: true, flagNoLocalRunner: true}
+		require.NoError(c.initNoLocalRunner(true))
+		require.True(c.flagRemote)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		require := require.New(t)
+
+		os.Setenv(EnvNoLocalRunner, "1")
+		defer os.Unsetenv(EnvNoLocalRunner)
+
+		c := &baseCommand{autoServer: true}
+		require.NoError(c.initNoLocalRunner(false))
+		require.True(c.flagNoLocalRunner)
+		require.True(c.flagRemote)
+
+		// An explicit flag wins over the env var.
+		c = &baseCommand{autoServer: true}
+		require.NoError(c.initNoLocalRunner(true))
+		require.False(c.flagRemote)
+	})
+
+	t.Run("invalid env", func(t *testing.T) {
+		os.Setenv(EnvNoLocalRunner, "nope")
+		defer os.Unsetenv(EnvNoLocalRunner)
+
+		c := &baseCommand{autoServer: true}
+		require.Error(t, c.initNoLocalRunner(false))
+	})
+
+	t.Run("with -local", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{autoServer: true, flagNoLocalRunner: true, flagLocal: true}
+		err := c.initNoLocalRunner(true)
+		require.Error(err)
+		require.Contains(err.Error(), "-local")
+	})
+
+	t.Run("no local fallback", func(t *testing.T) {
+		require := require.New(t)
+
+		c := &baseCommand{
+			autoServer:        true,
+			flagNoLocalRunner: true,
+			cfg:               config.TestConfig(t, `project = "test"`),
+			refProject:        &pb.Ref_Project{Project: "test"},
+		}
+		require.NoError(c.initNoLocalRunner(true))
+		require.False(c.localFallbackPossible())
+
+		c.flagNoLocalRunner = false
+		require.True(c.localFallbackPossible())
+	})
+}
+
+func TestGetProject(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	client := &getProjectCountingClient{
+		WaypointClient: singleprocess.TestServer(t),
+	}
+	project := clientpkg.TestProject(t, clientpkg.WithClient(client))
+	clientpkg.TestApp(t, project)
+
+	c := baseCommand{
+		project:    project,
+		refProject: project.Ref(),
+	}
+
+	// Fetching multiple times should only hit the server once
+	for i := 0; i < 3; i++ {
+		result, err := c.getProject(ctx)
+		require.NoError(err)
+		require.Equal(project.Ref().Project, result.Name)
+	}
+	require.Equal(1, client.calls)
+
+	// A project that doesn't exist should error
+	c = baseCommand{
+		project:    project,
+		refProject: &pb.Ref_Project{Project: "nope"},
+	}
+	_, err := c.getProject(ctx)
+	require.Error(err)
+	require.Contains(err.Error(), "does not exist")
+	require.NotContains(err.Error(), "Did you mean")
+
+	// A typo in the project name should suggest the close match
+	c = baseCommand{
+		project:    project,
+		refProject: &pb.Ref_Project{Project: "test_q"},
+	}
+	_, err = c.getProject(ctx)
+	require.Error(err)
+	require.Contains(err.Error(), "does not exist")
+	require.Contains(err.Error(), "Did you mean: test_p")
+}
+
+// getProjectCountingClient counts the number of calls to GetProject.
+type getProjectCountingClient struct {
+	pb.WaypointClient
+
+	calls int
+}
+
+func (c *getProjectCountingClient) GetProject(
+	ctx context.Context,
+	req *pb.GetProjectRequest,
+	opts ...grpc.CallOption,
+) (*pb.GetProjectResponse, error) {
+	c.calls++
+	return c.WaypointClient.GetProject(ctx, req, opts...)
+}
+
+// recordUI is a non-interactive UI that records the messages output.
+type recordUI struct {
+	terminal.UI
+
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (u *recordUI) Output(msg string, raw ...interface{}) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.msgs = append(u.msgs, fmt.Sprintf(msg, raw...))
+}
+
+func (u *recordUI) Messages() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return append([]string(nil), u.msgs...)
+}
+
+func TestBaseCommand_targeting(t *testing.T) {
+	require := require.New(t)
+
+	cfg := &clicontext.Config{}
+	cfg.Server.Address = "user:secret@waypoint.example.com:9701"
+
+	c := baseCommand{
+		refProject:    &pb.Ref_Project{Project: "acme"},
+		refWorkspace:  &pb.Ref_Workspace{Workspace: "staging"},
+		clientContext: cfg,
+	}
+
+	// Credentials in the address are never sent.
+	require.Equal(map[string]string{
+		"project":   "acme",
+		"workspace": "staging",
+		"server":    "waypoint.example.com:9701",
+	}, c.targeting())
+}
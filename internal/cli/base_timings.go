@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+)
+
+// phaseTiming is the wall-clock duration of a single phase of the command,
+// such as loading the configuration or the operation on an app. These are
+// output at the end of the command with "-timings".
+type phaseTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+// phaseTimingJSON is the JSON format of a phaseTiming.
+type phaseTimingJSON struct {
+	Name       string `json:"name"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// timePhase starts timing the phase with the given name. The returned
+// function records the duration and should be called once the phase ends.
+func (c *baseCommand) timePhase(name string) func() {
+	start := time.Now()
+	return func() { c.recordTiming(name, time.Since(start)) }
+}
+
+// recordTiming records the duration of the phase with the given name.
+func (c *baseCommand) recordTiming(name string, d time.Duration) {
+	c.timingsLock.Lock()
+	defer c.timingsLock.Unlock()
+
+	c.timings = append(c.timings, &phaseTiming{Name: name, Duration: d})
+}
+
+// outputTimings outputs the duration of each phase if "-timings" was set,
+// as a table or, with -results-json, as a single JSON object.
+func (c *baseCommand) outputTimings() {
+	if !c.flagTimings || c.ui == nil || c.metrics.start.IsZero() {
+		return
+	}
+
+	c.timingsLock.Lock()
+	defer c.timingsLock.Unlock()
+
+	total := time.Since(c.metrics.start)
+	if c.flagResultsJSON {
+		var v struct {
+			Timings []*phaseTimingJSON `json:"timings"`
+			TotalMs int64              `json:"total_ms"`
+		}
+		for _, t := range c.timings {
+			v.Timings = append(v.Timings, &phaseTimingJSON{
+				Name:       t.Name,
+				DurationMs: t.Duration.Milliseconds(),
+			})
+		}
+		v.TotalMs = total.Milliseconds()
+
+		data, err := json.Marshal(&v)
+		if err != nil {
+			c.Log.Warn("error encoding timings", "error", err)
+			return
+		}
+
+		c.ui.Output(string(data))
+		return
+	}
+
+	c.ui.Output("Timings", terminal.WithHeaderStyle())
+	tbl := terminal.NewTable("Phase", "Duration")
+	for _, t := range c.timings {
+		tbl.Rich([]string{t.Name, t.Duration.Round(time.Millisecond).String()}, nil)
+	}
+	tbl.Rich([]string{"total", total.Round(time.Millisecond).String()}, nil)
+	c.ui.Table(tbl)
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	clientpkg "github.com/hashicorp/waypoint/internal/client"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess"
+)
+
+func TestTimings(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	project := clientpkg.TestProject(t, clientpkg.WithClient(singleprocess.TestServer(t)))
+
+	c := &baseCommand{
+		Log:        hclog.L(),
+		ui:         terminal.ConsoleUI(ctx),
+		project:    project,
+		refProject: project.Ref(),
+		flagApp:    "web",
+	}
+
+	stop := c.timePhase("config load")
+	stop()
+
+	_, err := c.DoAppResults(ctx, func(ctx context.Context, app *clientpkg.App) (interface{}, error) {
+		return nil, nil
+	})
+	require.NoError(err)
+
+	// Each phase and app is recorded in order.
+	require.Len(c.timings, 2)
+	require.Equal("config load", c.timings[0].Name)
+	require.Equal("app web", c.timings[1].Name)
+}
@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/hashicorp/waypoint/internal/config/variables"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+const (
+	// varLockFile is the name of the variable lockfile written with
+	// "-write-var-lock" in the current directory.
+	varLockFile = "waypoint.lock.json"
+
+	// varLockVersion is the version of the variable lockfile format.
+	varLockVersion = 1
+)
+
+// varLock is the format of the variable lockfile. This records the
+// resolved value of each variable set by the CLI so that an operation
+// can be reviewed and replayed with "-var-lock".
+type varLock struct {
+	Version   int                `json:"version"`
+	Variables []*varLockVariable `json:"variables"`
+}
+
+// varLockVariable is a single resolved variable in the lockfile.
+type varLockVariable struct {
+	Name string `json:"name"`
+
+	// Source is where the value was set, such as "cli", "file", or "env".
+	// File is the var file for the "file" and "vcs" sources.
+	Source string `json:"source"`
+	File   string `json:"file,omitempty"`
+
+	// Sensitive variables only record their source, never their value,
+	// so Type and Value are empty for them. The exception is a file
+	// written with "-var-export-include-sensitive", which isn't a lockfile.
+	Sensitive bool   `json:"sensitive,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Value     string `json:"value,omitempty"`
+}
+
+// inputVariables returns the variables declared in the configuration, or
+// nil if there is no configuration, such as for a -project target without
+// a waypoint.hcl.
+func (c *baseCommand) inputVariables() map[string]*variables.Variable {
+	if c.cfg == nil {
+		return nil
+	}
+
+	return c.cfg.InputVariables
+}
+
+// newVarLock builds the lockfile for the given variable values. The values
+// are in precedence order, so the last value for each name is the resolved
+// value. Variables declared as sensitive in inputs have their values
+// omitted.
+func newVarLock(vars []*pb.Variable, inputs map[string]*variables.Variable) *varLock {
+	return buildVarLock(vars, inputs, false)
+}
+
+// buildVarLock is newVarLock, except that the values of sensitive variables
+// are included if includeSensitive is true. They're still marked sensitive.
+func buildVarLock(
+	vars []*pb.Variable,
+	inputs map[string]*variables.Variable,
+	includeSensitive bool,
+) *varLock {
+	resolved := map[string]*pb.Variable{}
+	for _, v := range vars {
+		resolved[v.Name] = v
+	}
+
+	names := make([]string, 0, len(resolved))
+	for name := range resolved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := &varLock{Version: varLockVersion}
+	for _, name := range names {
+		v := resolved[name]
+		lv := &varLockVariable{Name: name}
+		switch s := v.Source.(type) {
+		case *pb.Variable_Cli:
+			lv.Source = "cli"
+		case *pb.Variable_Env:
+			lv.Source = "env"
+		case *pb.Variable_File_:
+			lv.Source = "file"
+			lv.File = s.File.FileName
+		case *pb.Variable_Vcs:
+			lv.Source = "vcs"
+			lv.File = s.Vcs.FileName
+		case *pb.Variable_Server:
+			lv.Source = "server"
+		}
+
+		if input, ok := inputs[name]; ok && input.Sensitive {
+			lv.Sensitive = true
+			if !includeSensitive {
+				result.Variables = append(result.Variables, lv)
+				continue
+			}
+		}
+
+		switch val := v.Value.(type) {
+		case *pb.Variable_Str:
+			lv.Type, lv.Value = "str", val.Str
+		case *pb.Variable_Bool:
+			lv.Type, lv.Value = "bool", fmt.Sprint(val.Bool)
+		case *pb.Variable_Num:
+			lv.Type, lv.Value = "num", fmt.Sprint(val.Num)
+		case *pb.Variable_Hcl:
+			lv.Type, lv.Value = "hcl", val.Hcl
+		}
+
+		result.Variables = append(result.Variables, lv)
+	}
+
+	return result
+}
+
+// writeVarLock writes the lockfile to path.
+func writeVarLock(path string, lock *varLock) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// readVarLock reads the lockfile at path.
+func readVarLock(path string) (*varLock, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock varLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("Error parsing variable lockfile %q: %s", path, err)
+	}
+	if lock.Version != varLockVersion {
+		return nil, fmt.Errorf(
+			"The variable lockfile %q has version %d, but only version %d is supported.",
+			path, lock.Version, varLockVersion)
+	}
+
+	return &lock, nil
+}
+
+// replayVarLock returns the variable values to use for an identical replay
+// of the lockfile. The non-sensitive values come from the lockfile. Since
+// sensitive values aren't in the lockfile, those are taken from current,
+// which are the values loaded as usual from the environment and flags. The
+// names of any sensitive variables that aren't set are also returned.
+func replayVarLock(path string, lock *varLock, current []*pb.Variable) ([]*pb.Variable, []string, error) {
+	sensitive := map[string]bool{}
+	for _, lv := range lock.Variables {
+		if lv.Sensitive {
+			sensitive[lv.Name] = false
+		}
+	}
+
+	var result []*pb.Variable
+	for _, v := range current {
+		if _, ok := sensitive[v.Name]; ok {
+			sensitive[v.Name] = true
+			result = append(result, v)
+		}
+	}
+
+	source := &pb.Variable_File_{File: &pb.Variable_File{FileName: path}}
+	for _, lv := range lock.Variables {
+		if lv.Sensitive {
+			continue
+		}
+
+		v := &pb.Variable{Name: lv.Name, Source: source}
+		switch lv.Type {
+		case "str":
+			v.Value = &pb.Variable_Str{Str: lv.Value}
+		case "bool":
+			v.Value = &pb.Variable_Bool{Bool: lv.Value == "true"}
+		case "num":
+			var n int64
+			if _, err := fmt.Sscan(lv.Value, &n); err != nil {
+				return nil, nil, fmt.Errorf(
+					"The variable %q in the lockfile has an invalid number: %q", lv.Name, lv.Value)
+			}
+			v.Value = &pb.Variable_Num{Num: n}
+		case "hcl":
+			v.Value = &pb.Variable_Hcl{Hcl: lv.Value}
+		default:
+			return nil, nil, fmt.Errorf(
+				"The variable %q in the lockfile has an unknown type %q.", lv.Name, lv.Type)
+		}
+
+		result = append(result, v)
+	}
+
+	var missing []string
+	for name, ok := range sensitive {
+		if !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+
+	return result, missing, nil
+}
+
+// writeVarExport writes the resolved variable values for other tools to
+// path for "-var-export". This is the same format as the lockfile, so it
+// can also be replayed with "-var-lock". If includeSensitive is true, the
+// values of sensitive variables are included and the file is only readable
+// by the current user.
+func writeVarExport(
+	path string,
+	vars []*pb.Variable,
+	inputs map[string]*variables.Variable,
+	includeSensitive bool,
+) error {
+	data, err := json.MarshalIndent(buildVarLock(vars, inputs, includeSensitive), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	perm := os.FileMode(0644)
+	if includeSensitive {
+		perm = 0600
+	}
+
+	return ioutil.WriteFile(path, append(data, '\n'), perm)
+}
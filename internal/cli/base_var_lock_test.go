@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/config"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestVarLock_noConfig(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+	path := filepath.Join(td, varLockFile)
+
+	// Without a configuration, such as for a -project target, there are
+	// no declarations so nothing is known to be sensitive.
+	c := &baseCommand{}
+	require.Nil(c.inputVariables())
+	require.NoError(writeVarLock(path, newVarLock([]*pb.Variable{
+		{Name: "region", Value: &pb.Variable_Str{Str: "us-east-1"}, Source: &pb.Variable_Cli{}},
+	}, c.inputVariables())))
+
+	lock, err := readVarLock(path)
+	require.NoError(err)
+	require.Len(lock.Variables, 1)
+	require.Equal("us-east-1", lock.Variables[0].Value)
+}
+
+func TestVarLock(t *testing.T) {
+	require := require.New(t)
+
+	cfg := config.TestConfig(t, `
+project = "test"
+
+variable "region" {
+  type = string
+}
+
+variable "replicas" {
+  type = number
+}
+
+variable "token" {
+  type      = string
+  sensitive = true
+}
+`)
+
+	td, err := ioutil.TempDir("", "waypoint")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+	path := filepath.Join(td, varLockFile)
+
+	// The last value for a variable is the resolved one.
+	require.NoError(writeVarLock(path, newVarLock([]*pb.Variable{
+		{Name: "region", Value: &pb.Variable_Str{Str: "us-west-2"}, Source: &pb.Variable_Env{}},
+		{Name: "region", Value: &pb.Variable_Str{Str: "us-east-1"}, Source: &pb.Variable_Cli{}},
+		{Name: "replicas", Value: &pb.Variable_Num{Num: 3}, Source: &pb.Variable_File_{
+			File: &pb.Variable_File{FileName: "prod.wpvars"},
+		}},
+		{Name: "token", Value: &pb.Variable_Str{Str: "secret"}, Source: &pb.Variable_Env{}},
+	}, cfg.InputVariables)))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(err)
+	require.NotContains(string(data), "secret")
+
+	lock, err := readVarLock(path)
+	require.NoError(err)
+	require.Len(lock.Variables, 3)
+	require.Equal("cli", lock.Variables[0].Source)
+	require.Equal("us-east-1", lock.Variables[0].Value)
+	require.Equal("prod.wpvars", lock.Variables[1].File)
+	require.True(lock.Variables[2].Sensitive)
+	require.Empty(lock.Variables[2].Value)
+
+	// Replaying uses the lockfile values and only the sensitive values
+	// from the current variables.
+	vars, missing, err := replayVarLock(path, lock, []*pb.Variable{
+		{Name: "region", Value: &pb.Variable_Str{Str: "eu-west-1"}, Source: &pb.Variable_Env{}},
+	})
+	require.NoError(err)
+	require.Equal([]string{"token"}, missing)
+	require.Len(vars, 2)
+	require.Equal("us-east-1", vars[0].GetStr())
+	require.Equal(int64(3), vars[1].GetNum())
+
+	vars, missing, err = replayVarLock(path, lock, []*pb.Variable{
+		{Name: "token", Value: &pb.Variable_Str{Str: "secret"}, Source: &pb.Variable_Env{}},
+	})
+	require.NoError(err)
+	require.Empty(missing)
+	require.Len(vars, 3)
+	require.Equal("secret", vars[0].GetStr())
+}
+
+func TestWriteVarExport(t *testing.T) {
+	cfg := config.TestConfig(t, `
+project = "test"
+
+variable "region" {
+  type = string
+}
+
+variable "token" {
+  type      = string
+  sensitive = true
+}
+`)
+
+	vars := []*pb.Variable{
+		{Name: "region", Value: &pb.Variable_Str{Str: "us-east-1"}, Source: &pb.Variable_Cli{}},
+		{Name: "token", Value: &pb.Variable_Str{Str: "secret"}, Source: &pb.Variable_Env{}},
+	}
+
+	td, err := ioutil.TempDir("", "waypoint")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	t.Run("without sensitive values", func(t *testing.T) {
+		require := require.New(t)
+
+		path := filepath.Join(td, "vars.json")
+		require.NoError(writeVarExport(path, vars, cfg.InputVariables, false))
+
+		data, err := ioutil.ReadFile(path)
+		require.NoError(err)
+		require.NotContains(string(data), "secret")
+
+		var result varLock
+		require.NoError(json.Unmarshal(data, &result))
+		require.Len(result.Variables, 2)
+		require.Equal("us-east-1", result.Variables[0].Value)
+		require.Equal("cli", result.Variables[0].Source)
+		require.True(result.Variables[1].Sensitive)
+		require.Equal("env", result.Variables[1].Source)
+	})
+
+	t.Run("with sensitive values", func(t *testing.T) {
+		require := require.New(t)
+
+		path := filepath.Join(td, "vars-sensitive.json")
+		require.NoError(writeVarExport(path, vars, cfg.InputVariables, true))
+
+		fi, err := os.Stat(path)
+		require.NoError(err)
+		require.Equal(os.FileMode(0600), fi.Mode().Perm())
+
+		data, err := ioutil.ReadFile(path)
+		require.NoError(err)
+
+		var result varLock
+		require.NoError(json.Unmarshal(data, &result))
+		require.Len(result.Variables, 2)
+		require.True(result.Variables[1].Sensitive)
+		require.Equal("secret", result.Variables[1].Value)
+		require.Equal("str", result.Variables[1].Type)
+	})
+}
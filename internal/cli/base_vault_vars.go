@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// vaultVarPrefix is the prefix of "-var" values that are read from Vault,
+// in the form "vault://PATH#KEY".
+const vaultVarPrefix = "vault://"
+
+// vaultVarRef is a reference to a value in Vault.
+type vaultVarRef struct {
+	Path string
+	Key  string
+}
+
+// parseVaultVarRef parses a "-var" value in the form "vault://PATH#KEY". ok
+// is false if the value isn't a Vault reference.
+func parseVaultVarRef(v string) (ref *vaultVarRef, ok bool, err error) {
+	if !strings.HasPrefix(v, vaultVarPrefix) {
+		return nil, false, nil
+	}
+
+	rest := strings.TrimPrefix(v, vaultVarPrefix)
+	idx := strings.LastIndex(rest, "#")
+	if idx == -1 {
+		return nil, true, fmt.Errorf(
+			"The Vault reference %q has no key. The format must be "+
+				"\"vault://PATH#KEY\", such as \"vault://secret/data/app#password\".", v)
+	}
+
+	ref = &vaultVarRef{
+		Path: strings.Trim(rest[:idx], "/"),
+		Key:  rest[idx+1:],
+	}
+	if ref.Path == "" || ref.Key == "" {
+		return nil, true, fmt.Errorf(
+			"The Vault reference %q must have both a path and a key, in the "+
+				"format \"vault://PATH#KEY\".", v)
+	}
+
+	return ref, true, nil
+}
+
+// initVaultVars replaces the "-var" values that reference Vault with the
+// values read from Vault. Vault is configured with the standard VAULT_*
+// env vars, such as VAULT_ADDR and VAULT_TOKEN. The names of the variables
+// are recorded in vaultVars so they can be marked sensitive once the
+// configuration is loaded. The values are never logged.
+func (c *baseCommand) initVaultVars() error {
+	var client *vaultapi.Client
+	for k, v := range c.flagVars {
+		ref, ok, err := parseVaultVarRef(v)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if client == nil {
+			if client, err = newVaultVarClient(); err != nil {
+				return err
+			}
+		}
+
+		value, err := readVaultVar(client, ref)
+		if err != nil {
+			return fmt.Errorf("Error reading variable %q from Vault: %s", k, err)
+		}
+
+		c.Log.Debug("read variable from Vault", "name", k, "path", ref.Path, "key", ref.Key)
+		c.flagVars[k] = value
+
+		// App-scoped variables are recorded by their variable name.
+		if idx := strings.Index(k, ":"); idx != -1 {
+			k = k[idx+1:]
+		}
+		if c.vaultVars == nil {
+			c.vaultVars = map[string]struct{}{}
+		}
+		c.vaultVars[k] = struct{}{}
+	}
+
+	return nil
+}
+
+// markVaultVarsSensitive marks the variables read from Vault as sensitive
+// in the configuration, so that their values are never written out, such
+// as to variable lockfiles.
+func (c *baseCommand) markVaultVarsSensitive() {
+	if c.cfg == nil {
+		return
+	}
+
+	for name := range c.vaultVars {
+		if v, ok := c.cfg.InputVariables[name]; ok {
+			v.Sensitive = true
+		}
+	}
+}
+
+// newVaultVarClient returns a Vault client configured from the environment.
+func newVaultVarClient() (*vaultapi.Client, error) {
+	client, err := vaultapi.NewClient(vaultapi.DefaultConfig())
+	if err != nil {
+		return nil, fmt.Errorf("Error configuring the Vault client: %s", err)
+	}
+	if client.Token() == "" {
+		return nil, errors.New(
+			"A -var value references Vault, but there is no Vault token. Set the " +
+				"VAULT_TOKEN environment variable, and VAULT_ADDR to the Vault address.")
+	}
+
+	return client, nil
+}
+
+// readVaultVar reads the value for ref from Vault. For the KV version 2
+// secrets engine, the path must include "data/", such as
+// "secret/data/app", and the key is read from the secret data.
+func readVaultVar(client *vaultapi.Client, ref *vaultVarRef) (string, error) {
+	secret, err := client.Logical().Read(ref.Path)
+	if err != nil {
+		if rerr, ok := err.(*vaultapi.ResponseError); ok {
+			switch rerr.StatusCode {
+			case http.StatusForbidden, http.StatusUnauthorized:
+				return "", fmt.Errorf(
+					"permission denied reading %q at %s. Check that VAULT_TOKEN is valid "+
+						"and that its policy allows reading the path.",
+					ref.Path, client.Address())
+			}
+		}
+
+		return "", fmt.Errorf("error reading %q: %s", ref.Path, err)
+	}
+	if secret == nil || secret.Data == nil {
+		return "", fmt.Errorf(
+			"no secret exists at %q. For the KV version 2 secrets engine, the "+
+				"path must include \"data/\", such as \"secret/data/app\".", ref.Path)
+	}
+
+	data := secret.Data
+
+	// KV version 2 nests the secret data along with its metadata.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	raw, ok := data[ref.Key]
+	if !ok {
+		keys := make([]string, 0, len(data))
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		return "", fmt.Errorf("the secret at %q has no key %q. The keys are: %s",
+			ref.Path, ref.Key, strings.Join(keys, ", "))
+	}
+
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "", nil
+	default:
+		// Non-string values such as numbers are used in their string form.
+		return fmt.Sprint(v), nil
+	}
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/config"
+)
+
+func TestParseVaultVarRef(t *testing.T) {
+	cases := []struct {
+		Value    string
+		Expected *vaultVarRef
+		Ref      bool
+		Err      bool
+	}{
+		{"plain", nil, false, false},
+		{"vault://secret/data/app#password", &vaultVarRef{Path: "secret/data/app", Key: "password"}, true, false},
+		{"vault:///secret/app/#a#b", &vaultVarRef{Path: "secret/app/#a", Key: "b"}, true, false},
+		{"vault://secret/data/app", nil, true, true},
+		{"vault://#password", nil, true, true},
+		{"vault://secret/data/app#", nil, true, true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Value, func(t *testing.T) {
+			require := require.New(t)
+
+			ref, ok, err := parseVaultVarRef(tt.Value)
+			require.Equal(tt.Ref, ok)
+			if tt.Err {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(tt.Expected, ref)
+		})
+	}
+}
+
+func TestMarkVaultVarsSensitive(t *testing.T) {
+	require := require.New(t)
+
+	cfg := config.TestConfig(t, `
+project = "test"
+
+variable "db_pass" {
+  type    = string
+  default = ""
+}
+
+variable "replicas" {
+  type    = number
+  default = 1
+}
+`)
+
+	c := &baseCommand{
+		cfg:       cfg,
+		vaultVars: map[string]struct{}{"db_pass": {}},
+	}
+	c.markVaultVarsSensitive()
+	require.True(cfg.InputVariables["db_pass"].Sensitive)
+	require.False(cfg.InputVariables["replicas"].Sensitive)
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// verbosity is the level of informational output that commands write to
+// the UI. This is separate from the log level controlled by "-v", "-vv", etc.
+// which only affects the logs written to stderr.
+type verbosity int
+
+const (
+	// verbosityQuiet only outputs errors and the primary command result.
+	verbosityQuiet verbosity = iota
+
+	// verbosityNormal is the default output.
+	verbosityNormal
+
+	// verbosityVerbose adds informational output such as which apps are
+	// being operated on.
+	verbosityVerbose
+
+	// verbosityDebug adds output useful for debugging the CLI itself, such
+	// as the resolved project and workspace targeting.
+	verbosityDebug
+)
+
+// EnvVerbosity is the env var that can be set to set the default verbosity.
+const EnvVerbosity = "WAYPOINT_VERBOSITY"
+
+// verbosityNames are the names of each verbosity tier, in order.
+var verbosityNames = []string{"quiet", "normal", "verbose", "debug"}
+
+func (v verbosity) String() string {
+	if v < 0 || int(v) >= len(verbosityNames) {
+		return strconv.Itoa(int(v))
+	}
+
+	return verbosityNames[v]
+}
+
+// parseVerbosity parses a verbosity tier from either its name or its
+// numeric value.
+func parseVerbosity(v string) (verbosity, error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" {
+		return verbosityNormal, nil
+	}
+
+	for i, name := range verbosityNames {
+		if v == name {
+			return verbosity(i), nil
+		}
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < int(verbosityQuiet) || n > int(verbosityDebug) {
+		return 0, fmt.Errorf(
+			"Invalid verbosity %q. Must be one of %s, or a number from 0 to %d.",
+			v, strings.Join(verbosityNames, ", "), verbosityDebug)
+	}
+
+	return verbosity(n), nil
+}
+
+// atVerbosity returns true if informational output at the given tier
+// should be shown. Commands should gate optional output lines with this.
+func (c *baseCommand) atVerbosity(v verbosity) bool {
+	return c.verbosity >= v
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVerbosity(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected verbosity
+		Err      bool
+	}{
+		{"", verbosityNormal, false},
+		{"quiet", verbosityQuiet, false},
+		{"Verbose", verbosityVerbose, false},
+		{"debug", verbosityDebug, false},
+		{"0", verbosityQuiet, false},
+		{"3", verbosityDebug, false},
+		{"4", 0, true},
+		{"loud", 0, true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Input, func(t *testing.T) {
+			require := require.New(t)
+
+			v, err := parseVerbosity(tt.Input)
+			if tt.Err {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(tt.Expected, v)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	goversion "github.com/hashicorp/go-version"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/env"
+	"github.com/hashicorp/waypoint/internal/version"
+)
+
+const (
+	// versionCheckURL is the endpoint that returns the latest version.
+	versionCheckURL = "https://checkpoint-api.hashicorp.com/v1/check/waypoint"
+
+	// versionCheckCacheFile is the name of the file in the home config
+	// directory that caches the result of the last check.
+	versionCheckCacheFile = "version-check.json"
+
+	// versionCheckTTL is how long a cached result is used before we check
+	// again, so that we check at most once a day.
+	versionCheckTTL = 24 * time.Hour
+
+	// versionCheckTimeout bounds how long a single check can take.
+	versionCheckTimeout = 5 * time.Second
+)
+
+// versionCheckCache is the cached result of the last version check.
+type versionCheckCache struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+}
+
+// checkpointDisabled returns true if the CLI must not make any network
+// requests other than to the server, such as the version check. This is
+// set with "-no-checkpoint" or the checkpoint env vars.
+func (c *baseCommand) checkpointDisabled() bool {
+	if c.flagNoCheckpoint || os.Getenv(EnvCheckpointDisable) != "" {
+		return true
+	}
+
+	disabled, err := env.GetBool(EnvDisableCheckpoint, false)
+	if err != nil {
+		// Fail closed, since this is used for compliance.
+		c.Log.Warn(err.Error())
+		return true
+	}
+
+	return disabled
+}
+
+// startVersionCheck starts checking for a newer CLI version in the
+// background. The result is read with versionCheckNotice once the command
+// completes. This is a no-op if the check is disabled with the
+// "-no-version-check" flag or the WAYPOINT_DISABLE_VERSION_CHECK env var,
+// or if checkpoint is disabled.
+func (c *baseCommand) startVersionCheck() {
+	if c.flagNoVersionCheck || c.homeConfigPath == "" {
+		return
+	}
+	if c.checkpointDisabled() {
+		c.Log.Debug("checkpoint is disabled, not checking for a newer version")
+		return
+	}
+
+	disabled, err := env.GetBool(EnvDisableVersionCheck, false)
+	if err != nil {
+		c.Log.Warn(err.Error())
+	}
+	if disabled {
+		return
+	}
+
+	ctx := c.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ch := make(chan string, 1)
+	c.versionCheckCh = ch
+	go func() {
+		latest, err := checkLatestVersion(ctx, c.httpClientOrDefault(),
+			filepath.Join(c.homeConfigPath, versionCheckCacheFile))
+		if err != nil {
+			c.Log.Debug("error checking for a newer version", "error", err)
+		}
+
+		ch <- latest
+	}()
+}
+
+// versionCheckNotice outputs a notice if the version check started by
+// startVersionCheck found a newer version. This never waits on the check:
+// if it hasn't completed yet, nothing is output.
+func (c *baseCommand) versionCheckNotice() {
+	if c.versionCheckCh == nil || c.ui == nil {
+		return
+	}
+
+	// Don't pollute the output if the user asked for less of it.
+	if c.flagPrintJob || !c.atVerbosity(verbosityNormal) {
+		return
+	}
+
+	var latest string
+	select {
+	case latest = <-c.versionCheckCh:
+	default:
+		return
+	}
+	if latest == "" {
+		return
+	}
+
+	current := version.GetVersion().Version
+	currentV, err := goversion.NewVersion(current)
+	if err != nil {
+		return
+	}
+	latestV, err := goversion.NewVersion(latest)
+	if err != nil || !latestV.GreaterThan(currentV) {
+		return
+	}
+
+	c.ui.Output("A newer version of Waypoint is available: %s (current: %s)",
+		latestV.String(), currentV.String(), terminal.WithInfoStyle())
+}
+
+// checkLatestVersion returns the latest available CLI version. The result
+// is cached at cachePath and the cached value is used for versionCheckTTL.
+func checkLatestVersion(ctx context.Context, client *http.Client, cachePath string) (string, error) {
+	var cache versionCheckCache
+	if data, err := ioutil.ReadFile(cachePath); err == nil {
+		if err := json.Unmarshal(data, &cache); err == nil &&
+			time.Since(cache.CheckedAt) < versionCheckTTL {
+			return cache.Latest, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, versionCheckTimeout)
+	defer cancel()
+
+	u := versionCheckURL + "?current_version=" +
+		url.QueryEscape(version.GetVersion().Version)
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status checking version: %s", resp.Status)
+	}
+
+	var result struct {
+		CurrentVersion string `json:"current_version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	// Cache the result. A failure here just means we check again next time.
+	cache = versionCheckCache{
+		CheckedAt: time.Now(),
+		Latest:    result.CurrentVersion,
+	}
+	if data, err := json.Marshal(&cache); err == nil {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+			ioutil.WriteFile(cachePath, data, 0644)
+		}
+	}
+
+	return result.CurrentVersion, nil
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLatestVersion_cached(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	// A fresh cache should be used without making a request.
+	path := filepath.Join(td, versionCheckCacheFile)
+	require.NoError(ioutil.WriteFile(path, []byte(
+		`{"checked_at": "`+time.Now().Format(time.RFC3339)+`", "latest": "9.9.9"}`), 0644))
+
+	// A canceled context would fail any request that we made.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	latest, err := checkLatestVersion(ctx, http.DefaultClient, path)
+	require.NoError(err)
+	require.Equal("9.9.9", latest)
+}
+
+func TestHTTPClient(t *testing.T) {
+	require := require.New(t)
+
+	// The default is the standard client
+	c := &baseCommand{}
+	require.Equal(http.DefaultClient, c.httpClientOrDefault())
+
+	// A custom client is used for requests such as the version check.
+	var requested string
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requested = req.URL.Host
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"current_version": "9.9.9"}`)),
+			Header:     http.Header{},
+		}, nil
+	})}
+
+	var cfg baseConfig
+	WithHTTPClient(client)(&cfg)
+	c = &baseCommand{httpClient: cfg.HTTPClient}
+	require.Equal(client, c.httpClientOrDefault())
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	latest, err := checkLatestVersion(context.Background(),
+		c.httpClientOrDefault(), filepath.Join(td, versionCheckCacheFile))
+	require.NoError(err)
+	require.Equal("9.9.9", latest)
+	require.NotEmpty(requested)
+}
+
+func TestStartVersionCheck_checkpointDisabled(t *testing.T) {
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	// Any request fails the test.
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Errorf("unexpected request to %s", req.URL)
+		return nil, errors.New("unexpected request")
+	})}
+
+	newCommand := func() *baseCommand {
+		return &baseCommand{
+			Log:            hclog.L(),
+			homeConfigPath: td,
+			httpClient:     client,
+		}
+	}
+
+	t.Run("flag", func(t *testing.T) {
+		c := newCommand()
+		c.flagNoCheckpoint = true
+		c.startVersionCheck()
+		require.Nil(t, c.versionCheckCh)
+	})
+
+	for _, name := range []string{EnvDisableCheckpoint, EnvCheckpointDisable} {
+		t.Run(name, func(t *testing.T) {
+			os.Setenv(name, "1")
+			defer os.Unsetenv(name)
+
+			c := newCommand()
+			c.startVersionCheck()
+			require.Nil(t, c.versionCheckCh)
+		})
+	}
+}
+
+// roundTripFunc is an http.RoundTripper from a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
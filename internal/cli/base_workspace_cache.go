@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+)
+
+const (
+	// workspaceCacheFile is the name of the file in the home config
+	// directory that caches the known workspaces of each server.
+	workspaceCacheFile = "workspaces.json"
+
+	// workspaceCacheTTL is how long the cached list for a server is
+	// considered current. Older lists are still used but marked stale.
+	workspaceCacheTTL = 7 * 24 * time.Hour
+)
+
+// workspaceCache is the list of known workspaces for a single server, as
+// of the last time the workspaces were successfully listed.
+type workspaceCache struct {
+	UpdatedAt  time.Time `json:"updated_at"`
+	Workspaces []string  `json:"workspaces"`
+}
+
+// Stale returns true if the cached list may be out of date.
+func (w *workspaceCache) Stale() bool {
+	return time.Since(w.UpdatedAt) > workspaceCacheTTL
+}
+
+// Has returns true if the cached list contains the workspace.
+func (w *workspaceCache) Has(name string) bool {
+	for _, v := range w.Workspaces {
+		if v == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readWorkspaceCaches reads the cached workspace lists, keyed by server
+// address. A missing or unreadable cache is treated as empty since this
+// is only an optimization.
+func readWorkspaceCaches(homeConfigPath string) map[string]*workspaceCache {
+	data, err := ioutil.ReadFile(filepath.Join(homeConfigPath, workspaceCacheFile))
+	if err != nil {
+		return nil
+	}
+
+	var result map[string]*workspaceCache
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil
+	}
+
+	return result
+}
+
+// writeWorkspaceCache records the workspaces known to the server at addr.
+func writeWorkspaceCache(homeConfigPath, addr string, names []string) error {
+	caches := readWorkspaceCaches(homeConfigPath)
+	if caches == nil {
+		caches = map[string]*workspaceCache{}
+	}
+
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+	caches[addr] = &workspaceCache{
+		UpdatedAt:  time.Now(),
+		Workspaces: names,
+	}
+
+	data, err := json.MarshalIndent(caches, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(homeConfigPath, 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(homeConfigPath, workspaceCacheFile), data, 0644)
+}
+
+// cacheWorkspaces caches the workspaces listed from the current server.
+// This is best-effort, so failures are only logged.
+func (c *baseCommand) cacheWorkspaces(names []string) {
+	if c.homeConfigPath == "" || c.clientContext == nil || c.clientContext.Server.Address == "" {
+		return
+	}
+
+	if err := writeWorkspaceCache(c.homeConfigPath, c.clientContext.Server.Address, names); err != nil {
+		c.Log.Warn("error caching workspaces", "error", err)
+	}
+}
+
+// checkWorkspaceCache warns if the targeted workspace isn't in the cached
+// list of workspaces for the current server. This is only a soft check
+// since workspaces are created on first use and the cache may be old.
+func (c *baseCommand) checkWorkspaceCache() {
+	if c.flagWorkspace == "" || c.clientContext == nil || c.clientContext.Server.Address == "" {
+		return
+	}
+
+	cache, ok := readWorkspaceCaches(c.homeConfigPath)[c.clientContext.Server.Address]
+	if !ok || cache.Has(c.flagWorkspace) {
+		return
+	}
+
+	msg := warnWorkspaceNotCached
+	if cache.Stale() {
+		msg += "\n\n" + warnWorkspaceCacheStale
+	}
+
+	c.ui.Output(msg, c.flagWorkspace,
+		cache.UpdatedAt.Format(time.RFC3339), terminal.WithWarningStyle())
+}
+
+// predictWorkspaces completes workspace names from the cached lists of all
+// servers. This doesn't require a connection to the server.
+func predictWorkspaces() complete.Predictor {
+	return complete.PredictFunc(func(complete.Args) []string {
+		path, err := xdg.ConfigFile("waypoint/.ignore")
+		if err != nil {
+			return nil
+		}
+
+		seen := map[string]struct{}{}
+		var result []string
+		for _, cache := range readWorkspaceCaches(filepath.Dir(path)) {
+			for _, name := range cache.Workspaces {
+				if _, ok := seen[name]; !ok {
+					seen[name] = struct{}{}
+					result = append(result, name)
+				}
+			}
+		}
+		sort.Strings(result)
+
+		return result
+	})
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceCache(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	// No cache is empty
+	require.Empty(readWorkspaceCaches(td))
+
+	require.NoError(writeWorkspaceCache(td, "a:9701", []string{"prod", "default"}))
+	require.NoError(writeWorkspaceCache(td, "b:9701", []string{"dev"}))
+
+	caches := readWorkspaceCaches(td)
+	require.Len(caches, 2)
+	require.Equal([]string{"default", "prod"}, caches["a:9701"].Workspaces)
+	require.True(caches["a:9701"].Has("prod"))
+	require.False(caches["a:9701"].Has("dev"))
+	require.False(caches["a:9701"].Stale())
+
+	caches["a:9701"].UpdatedAt = time.Now().Add(-2 * workspaceCacheTTL)
+	require.True(caches["a:9701"].Stale())
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// workspaceChainSep separates the workspaces of a fallback chain, such as
+// "-workspace=feature-123,staging".
+const workspaceChainSep = ","
+
+// splitWorkspaceChain splits a workspace value into its fallback chain.
+// A value without a separator is a chain of one.
+func splitWorkspaceChain(v string) []string {
+	var result []string
+	for _, name := range strings.Split(v, workspaceChainSep) {
+		if name = strings.TrimSpace(name); name != "" {
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
+
+// resolveWorkspaceChain selects the first workspace of the fallback chain
+// that exists on the server. Each name may be an alias. If none of them
+// exist, the first is used with -workspace-create, since workspaces are
+// created on first use, and otherwise this is an error. The configuration
+// is reloaded if the workspace changes, since it may depend on the
+// workspace.
+func (c *baseCommand) resolveWorkspaceChain() error {
+	if len(c.workspaceChain) < 2 {
+		return nil
+	}
+
+	names := make([]string, len(c.workspaceChain))
+	for i, name := range c.workspaceChain {
+		names[i] = name
+		if c.cfg != nil {
+			names[i] = c.cfg.WorkspaceAlias(name)
+		}
+	}
+
+	client := c.project.Client()
+	for _, name := range names {
+		_, err := client.GetWorkspace(c.Ctx, &pb.GetWorkspaceRequest{
+			Workspace: &pb.Ref_Workspace{Workspace: name},
+		})
+		if status.Code(err) == codes.NotFound {
+			c.Log.Debug("workspace in the chain doesn't exist", "workspace", name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		return c.useWorkspace(name)
+	}
+
+	if !c.flagWorkspaceCreate {
+		return fmt.Errorf(
+			"None of the workspaces %q exist. Set -workspace-create to create "+
+				"the first workspace, %q.", names, names[0])
+	}
+
+	return c.useWorkspace(names[0])
+}
+
+// useWorkspace switches to the workspace selected from the chain.
+func (c *baseCommand) useWorkspace(name string) error {
+	c.Log.Debug("selected workspace from the chain", "workspace", name)
+	if c.atVerbosity(verbosityNormal) {
+		c.ui.Output("Using workspace %q.", name, terminal.WithInfoStyle())
+	}
+
+	if name == c.refWorkspace.Workspace {
+		return nil
+	}
+	c.refWorkspace.Workspace = name
+
+	if c.cfg == nil {
+		return nil
+	}
+
+	cfg, err := c.initConfigLoad(c.configPath)
+	if err != nil {
+		return err
+	}
+	c.cfg = cfg
+	c.markVaultVarsSensitive()
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	clientpkg "github.com/hashicorp/waypoint/internal/client"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess"
+)
+
+func TestBaseCommand_resolveWorkspaceChain(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	require.Equal([]string{"feature-123", "staging"}, splitWorkspaceChain("feature-123, staging"))
+	require.Equal([]string{"dev"}, splitWorkspaceChain("dev"))
+
+	project := clientpkg.TestProject(t, clientpkg.WithClient(singleprocess.TestServer(t)))
+
+	// Workspaces are created on first use, such as by a build.
+	_, err := project.Client().UpsertBuild(ctx, &pb.UpsertBuildRequest{
+		Build: serverptypes.TestValidBuild(t, &pb.Build{
+			Workspace: &pb.Ref_Workspace{Workspace: "staging"},
+		}),
+	})
+	require.NoError(err)
+
+	newCommand := func(chain string) *baseCommand {
+		return &baseCommand{
+			Ctx:            ctx,
+			Log:            hclog.L(),
+			ui:             terminal.ConsoleUI(ctx),
+			project:        project,
+			refWorkspace:   &pb.Ref_Workspace{Workspace: splitWorkspaceChain(chain)[0]},
+			workspaceChain: splitWorkspaceChain(chain),
+		}
+	}
+
+	// The first workspace that exists is used.
+	c := newCommand("feature-123,staging")
+	require.NoError(c.resolveWorkspaceChain())
+	require.Equal("staging", c.refWorkspace.Workspace)
+
+	// If none exist, it's an error unless we create the first.
+	c = newCommand("feature-123,feature-456")
+	require.Error(c.resolveWorkspaceChain())
+
+	c = newCommand("feature-123,feature-456")
+	c.flagWorkspaceCreate = true
+	require.NoError(c.resolveWorkspaceChain())
+	require.Equal("feature-123", c.refWorkspace.Workspace)
+}
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	configpkg "github.com/hashicorp/waypoint/internal/config"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// configDiff is a setting that differs between the local configuration
+// and the configuration stored on the server. Local or Server is nil if
+// the setting is only in the other.
+type configDiff struct {
+	Name   string  `json:"name"`
+	Local  *string `json:"local"`
+	Server *string `json:"server"`
+}
+
+type ConfigDiffRemoteCommand struct {
+	*baseCommand
+
+	flagJson bool
+}
+
+func (c *ConfigDiffRemoteCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoAutoServer(),
+	); err != nil {
+		return 1
+	}
+
+	project, err := c.getProject(c.Ctx)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+	if len(project.WaypointHcl) == 0 {
+		c.ui.Output(
+			"The project %q has no waypoint.hcl stored on the server, so there is "+
+				"nothing to compare. Remote operations use the waypoint.hcl from the "+
+				"project's data source.", project.Name, terminal.WithErrorStyle())
+		return 1
+	}
+
+	remote, err := c.remoteConfig(project)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	localSettings, diags := c.cfg.Settings()
+	if diags.HasErrors() {
+		c.ui.Output(clierrors.Humanize(diags), terminal.WithErrorStyle())
+		return 1
+	}
+	remoteSettings, diags := remote.Settings()
+	if diags.HasErrors() {
+		c.ui.Output(clierrors.Humanize(diags), terminal.WithErrorStyle())
+		return 1
+	}
+
+	diffs := diffSettings(localSettings, remoteSettings)
+
+	if c.flagJson {
+		if diffs == nil {
+			diffs = []*configDiff{}
+		}
+
+		if err := c.outputJSON(nil, diffs); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+	} else if len(diffs) == 0 {
+		c.ui.Output("The local configuration matches the configuration on the server.",
+			terminal.WithSuccessStyle())
+	} else {
+		tbl := terminal.NewTable("Setting", "Local", "Server")
+		for _, d := range diffs {
+			tbl.Rich([]string{d.Name, diffValue(d.Local), diffValue(d.Server)}, []string{
+				"", terminal.Yellow, terminal.Yellow,
+			})
+		}
+		c.ui.Table(tbl)
+	}
+
+	if len(diffs) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// remoteConfig loads the waypoint.hcl stored on the server for the project
+// with the same options as the local configuration, so that only the
+// contents of the files differ.
+func (c *ConfigDiffRemoteCommand) remoteConfig(project *pb.Project) (*configpkg.Config, error) {
+	td, err := ioutil.TempDir("", "waypoint-config")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(td)
+
+	ext := ""
+	if project.WaypointHclFormat == pb.Project_JSON {
+		ext = ".json"
+	}
+
+	path := filepath.Join(td, configpkg.Filename+ext)
+	if err := ioutil.WriteFile(path, project.WaypointHcl, 0644); err != nil {
+		return nil, err
+	}
+
+	// The server configuration isn't merged with a local -project-config.
+	opts := c.configLoadOptions(c.configPath)
+	opts.ProjectPath = ""
+
+	cfg, err := configpkg.Load(path, opts)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading the waypoint.hcl stored on the server: %s", err)
+	}
+
+	return cfg, nil
+}
+
+// diffSettings returns the settings that differ between local and server
+// by name and value. Settings in local come first in their order, followed
+// by the settings that are only on the server.
+func diffSettings(local, server []*configpkg.Setting) []*configDiff {
+	serverByName := map[string]*configpkg.Setting{}
+	for _, s := range server {
+		serverByName[s.Name] = s
+	}
+
+	var result []*configDiff
+	seen := map[string]struct{}{}
+	for _, l := range local {
+		seen[l.Name] = struct{}{}
+
+		value := l.Value
+		s, ok := serverByName[l.Name]
+		if !ok {
+			result = append(result, &configDiff{Name: l.Name, Local: &value})
+			continue
+		}
+
+		if s.Value != l.Value {
+			serverValue := s.Value
+			result = append(result, &configDiff{Name: l.Name, Local: &value, Server: &serverValue})
+		}
+	}
+
+	for _, s := range server {
+		if _, ok := seen[s.Name]; ok {
+			continue
+		}
+
+		value := s.Value
+		result = append(result, &configDiff{Name: s.Name, Server: &value})
+	}
+
+	return result
+}
+
+// diffValue is the value of one side of a configDiff for output.
+func diffValue(v *string) string {
+	if v == nil {
+		return "(not set)"
+	}
+	if *v == "" {
+		return "(set)"
+	}
+
+	return *v
+}
+
+func (c *ConfigDiffRemoteCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.BoolVar(&flag.BoolVar{
+			Name:   "json",
+			Target: &c.flagJson,
+			Usage:  "Output the differences as JSON.",
+		})
+	})
+}
+
+func (c *ConfigDiffRemoteCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ConfigDiffRemoteCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ConfigDiffRemoteCommand) Synopsis() string {
+	return "Compare the local waypoint.hcl with the one stored on the server."
+}
+
+func (c *ConfigDiffRemoteCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint config diff-remote [options]
+
+  Compare the settings of the local waypoint.hcl with the waypoint.hcl
+  stored on the server for the project.
+
+  The server's waypoint.hcl is used by remote operations when the project's
+  data source doesn't have one, so a difference means that a remote
+  operation may not use the configuration that you edited locally. Both
+  files are loaded with the same workspace, variables, and -config-profile.
+
+  This exits with a non-zero status if the configurations differ.
+
+` + c.Flags().Help())
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/config"
+)
+
+func TestDiffSettings(t *testing.T) {
+	require := require.New(t)
+
+	local := []*config.Setting{
+		{Name: "project", Value: `"acme"`},
+		{Name: "labels.env", Value: `"dev"`},
+		{Name: "app.web"},
+	}
+	server := []*config.Setting{
+		{Name: "project", Value: `"acme"`},
+		{Name: "labels.env", Value: `"prod"`},
+		{Name: "app.api"},
+	}
+
+	diffs := diffSettings(local, server)
+	require.Len(diffs, 3)
+
+	require.Equal("labels.env", diffs[0].Name)
+	require.Equal(`"dev"`, *diffs[0].Local)
+	require.Equal(`"prod"`, *diffs[0].Server)
+
+	// Settings only on one side have no value on the other.
+	require.Equal("app.web", diffs[1].Name)
+	require.Nil(diffs[1].Server)
+	require.Equal("app.api", diffs[2].Name)
+	require.Nil(diffs[2].Local)
+
+	require.Empty(diffSettings(local, local))
+}
@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 
@@ -103,14 +102,6 @@ func (c *ConfigGetCommand) Run(args []string) int {
 	}
 
 	if c.json {
-		// Get our direct stdout handle cause we're going to be writing colors
-		// and want color detection to work.
-		out, _, err := c.project.UI.OutputWriters()
-		if err != nil {
-			c.project.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-			return 1
-		}
-
 		vars := map[string]string{}
 
 		for _, cv := range resp.Variables {
@@ -126,7 +117,11 @@ func (c *ConfigGetCommand) Run(args []string) int {
 			vars[cv.Name] = value
 		}
 
-		json.NewEncoder(out).Encode(vars)
+		if err := c.outputJSON(nil, vars); err != nil {
+			c.project.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+
 		return 0
 	}
 
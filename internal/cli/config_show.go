@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	configpkg "github.com/hashicorp/waypoint/internal/config"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+)
+
+// configSetting is the JSON format of a configpkg.Setting.
+type configSetting struct {
+	Name     string `json:"name"`
+	Value    string `json:"value,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+}
+
+type ConfigShowCommand struct {
+	*baseCommand
+
+	flagMerged bool
+	flagJson   bool
+}
+
+func (c *ConfigShowCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) > 1 {
+		c.ui.Output("At most one argument is expected.\n\n"+c.Help(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	var path string
+	if len(c.args) == 1 {
+		path = c.args[0]
+	} else {
+		var err error
+		path, err = c.initConfigPath("")
+		if err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+		if path == "" {
+			c.ui.Output(clierrors.Humanize(ErrConfigNotFound), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	// Without -merged, we show the file on its own.
+	opts := c.configLoadOptions(path)
+	if !c.flagMerged {
+		opts.ProjectPath = ""
+		opts.Profile = ""
+	}
+
+	cfg, err := configpkg.Load(path, opts)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(&configParseError{Path: path, Err: err}),
+			terminal.WithErrorStyle())
+		return 1
+	}
+
+	settings, diags := cfg.Settings()
+	if diags.HasErrors() {
+		c.ui.Output(clierrors.Humanize(&configParseError{Path: path, Err: diags}),
+			terminal.WithErrorStyle())
+		return 1
+	}
+
+	if c.flagJson {
+		result := make([]*configSetting, 0, len(settings))
+		for _, s := range settings {
+			result = append(result, &configSetting{
+				Name:     s.Name,
+				Value:    s.Value,
+				Filename: s.Range.Filename,
+				Line:     s.Range.Start.Line,
+				Profile:  s.Profile,
+			})
+		}
+
+		if err := c.outputJSON(nil, result); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+
+		return 0
+	}
+
+	tbl := terminal.NewTable("Setting", "Value", "Source")
+	for _, s := range settings {
+		tbl.Rich([]string{s.Name, s.Value, settingSource(s)}, nil)
+	}
+	c.ui.Table(tbl)
+
+	return 0
+}
+
+// settingSource is where a setting was set, for output. The file is
+// relative to the working directory if possible.
+func settingSource(s *configpkg.Setting) string {
+	if s.Range.Filename == "" {
+		return ""
+	}
+
+	filename := s.Range.Filename
+	if pwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(pwd, filename); err == nil {
+			filename = rel
+		}
+	}
+
+	result := fmt.Sprintf("%s:%d", filename, s.Range.Start.Line)
+	if s.Profile != "" {
+		result += fmt.Sprintf(" (profile %q)", s.Profile)
+	}
+
+	return result
+}
+
+func (c *ConfigShowCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.BoolVar(&flag.BoolVar{
+			Name:   "merged",
+			Target: &c.flagMerged,
+			Usage: "Show the configuration merged with the -project-config file " +
+				"and the -config-profile, the same as operations load it.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "json",
+			Target: &c.flagJson,
+			Usage:  "Output the settings as JSON.",
+		})
+	})
+}
+
+func (c *ConfigShowCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*.hcl")
+}
+
+func (c *ConfigShowCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ConfigShowCommand) Synopsis() string {
+	return "Show the settings of the waypoint.hcl and where each was set."
+}
+
+func (c *ConfigShowCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint config show [options] [FILE]
+
+  Show the top-level settings of the waypoint.hcl configuration along with
+  the file and line that set each of them.
+
+  With -merged, this shows the effective configuration after merging the
+  file set with -project-config and applying the profile selected with
+  -config-profile. Settings from a profile are annotated with the profile
+  name. This is useful to find which file contributed a setting.
+
+  If FILE is not specified, then the current directory will be searched
+  for a "waypoint.hcl" file. This doesn't require a server.
+
+` + c.Flags().Help())
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+)
+
+// configDiagnostic is a single problem found while validating the
+// configuration. The location is only set if the problem is in a known
+// place in the file.
+type configDiagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+// String returns the diagnostic in the "file:line,col: summary" format
+// of the HCL diagnostics.
+func (d *configDiagnostic) String() string {
+	result := d.Summary
+	if d.Detail != "" {
+		result += "; " + d.Detail
+	}
+	if d.Filename != "" {
+		result = fmt.Sprintf("%s:%d,%d: %s", d.Filename, d.Line, d.Column, result)
+	}
+
+	return result
+}
+
+// configDiagnostics flattens an error from loading or validating the
+// configuration into its individual diagnostics.
+func configDiagnostics(err error) []*configDiagnostic {
+	switch err := err.(type) {
+	case nil:
+		return nil
+
+	case hcl.Diagnostics:
+		var result []*configDiagnostic
+		for _, d := range err {
+			result = append(result, newConfigDiagnostic(d))
+		}
+
+		return result
+
+	case *hcl.Diagnostic:
+		return []*configDiagnostic{newConfigDiagnostic(err)}
+
+	case *multierror.Error:
+		var result []*configDiagnostic
+		for _, e := range err.Errors {
+			result = append(result, configDiagnostics(e)...)
+		}
+
+		return result
+
+	default:
+		return []*configDiagnostic{{
+			Severity: "error",
+			Summary:  err.Error(),
+		}}
+	}
+}
+
+func newConfigDiagnostic(d *hcl.Diagnostic) *configDiagnostic {
+	result := &configDiagnostic{
+		Severity: "error",
+		Summary:  d.Summary,
+		Detail:   d.Detail,
+	}
+	if d.Severity == hcl.DiagWarning {
+		result.Severity = "warning"
+	}
+	if d.Subject != nil {
+		result.Filename = d.Subject.Filename
+		result.Line = d.Subject.Start.Line
+		result.Column = d.Subject.Start.Column
+	}
+
+	return result
+}
+
+type ConfigValidateCommand struct {
+	*baseCommand
+
+	flagJson bool
+}
+
+func (c *ConfigValidateCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) > 1 {
+		c.ui.Output("At most one argument is expected.\n\n"+c.Help(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	var path string
+	if len(c.args) == 1 {
+		path = c.args[0]
+	} else {
+		var err error
+		path, err = c.initConfigPath("")
+		if err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+		if path == "" {
+			c.ui.Output(clierrors.Humanize(ErrConfigNotFound), terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	diags := c.validateConfig(path)
+	errored := false
+	for _, d := range diags {
+		if d.Severity == "error" {
+			errored = true
+		}
+	}
+
+	if c.flagJson {
+		var v struct {
+			Path        string              `json:"path"`
+			Valid       bool                `json:"valid"`
+			Diagnostics []*configDiagnostic `json:"diagnostics"`
+		}
+		v.Path = path
+		v.Valid = !errored
+		v.Diagnostics = diags
+		if v.Diagnostics == nil {
+			v.Diagnostics = []*configDiagnostic{}
+		}
+
+		if err := c.outputJSON(nil, &v); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+	} else {
+		for _, d := range diags {
+			style := terminal.WithErrorStyle()
+			if d.Severity == "warning" {
+				style = terminal.WithWarningStyle()
+			}
+
+			c.ui.Output(d.String(), style)
+		}
+
+		if !errored {
+			c.ui.Output("The configuration %s is valid.", path, terminal.WithSuccessStyle())
+		}
+	}
+
+	if errored {
+		return 1
+	}
+
+	return 0
+}
+
+func (c *ConfigValidateCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.BoolVar(&flag.BoolVar{
+			Name:   "json",
+			Target: &c.flagJson,
+			Usage:  "Output the diagnostics as JSON.",
+		})
+	})
+}
+
+func (c *ConfigValidateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*.hcl")
+}
+
+func (c *ConfigValidateCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ConfigValidateCommand) Synopsis() string {
+	return "Validate the waypoint.hcl configuration without a server."
+}
+
+func (c *ConfigValidateCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint config validate [options] [FILE]
+
+  Validate the waypoint.hcl configuration without connecting to a server.
+
+  This checks the HCL syntax, the required settings, the variable
+  declarations, and the definition of each app, and reports every problem
+  found. Plugin-specific settings are only validated when an operation
+  executes.
+
+  If FILE is not specified, then the current directory will be searched
+  for a "waypoint.hcl" file. This exits with a non-zero status if the
+  configuration is invalid.
+
+` + c.Flags().Help())
+}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"path/filepath"
+
 	"github.com/posener/complete"
 
 	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
@@ -37,6 +39,17 @@ func (c *ContextCreateCommand) Run(args []string) int {
 
 	name := args[0]
 
+	// The CA path must be absolute so the context works from any directory.
+	if v := c.flagConfig.Server.TlsCaPath; v != "" {
+		path, err := filepath.Abs(v)
+		if err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+
+		c.flagConfig.Server.TlsCaPath = path
+	}
+
 	// Set the context
 	if err := c.contextStorage.Set(name, &c.flagConfig); err != nil {
 		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
@@ -76,6 +89,17 @@ func (c *ContextCreateCommand) Flags() *flag.Sets {
 			Target: &c.flagConfig.Server.TlsSkipVerify,
 			Usage:  "If true, will not validate TLS cert presented by the server.",
 		})
+		f.StringVar(&flag.StringVar{
+			Name:   "server-tls-ca-path",
+			Target: &c.flagConfig.Server.TlsCaPath,
+			Usage: "Directory of CA certificates in \"*.pem\" or \"*.crt\" files to " +
+				"trust to verify the server, in addition to the system roots.",
+		})
+		f.BoolVar(&flag.BoolVar{
+			Name:   "server-tls-ca-only",
+			Target: &c.flagConfig.Server.TlsCaOnly,
+			Usage:  "If true, only the CA certificates in -server-tls-ca-path are trusted.",
+		})
 		f.BoolVar(&flag.BoolVar{
 			Name:   "server-require-auth",
 			Target: &c.flagConfig.Server.RequireAuth,
@@ -1,8 +1,6 @@
 package cli
 
 import (
-	"encoding/json"
-
 	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
 	"github.com/hashicorp/waypoint/internal/pkg/flag"
 	"github.com/posener/complete"
@@ -34,13 +32,11 @@ func (c *ContextInspectCommand) Run(args []string) int {
 		}
 
 		if c.flagJson {
-			data, err := json.MarshalIndent(cc.Server, "", "  ")
-			if err != nil {
+			if err := c.outputJSON(nil, cc.Server); err != nil {
 				c.ui.Output("Error rendering json: %s", err)
 				return 1
 			}
 
-			c.ui.Output(string(data))
 			return 0
 		}
 
@@ -79,6 +75,15 @@ func (c *ContextInspectCommand) Run(args []string) int {
 			},
 		}, terminal.WithInfoStyle())
 
+		if cc.DataSourceRef != "" {
+			c.ui.Output("Data Source Info:", terminal.WithHeaderStyle())
+			c.ui.NamedValues([]terminal.NamedValue{
+				{
+					Name: "ref", Value: cc.DataSourceRef,
+				},
+			}, terminal.WithInfoStyle())
+		}
+
 		return 0
 	}
 
@@ -88,16 +93,14 @@ func (c *ContextInspectCommand) Run(args []string) int {
 	}
 
 	if c.flagJson {
-		data, err := json.MarshalIndent(map[string]interface{}{
+		if err := c.outputJSON(nil, map[string]interface{}{
 			"config_path":     c.homeConfigPath,
 			"default_context": def,
-		}, "", "  ")
-		if err != nil {
+		}); err != nil {
 			c.ui.Output("Error rendering json: %s", err)
 			return 1
 		}
 
-		c.ui.Output(string(data))
 		return 0
 	}
 
@@ -12,6 +12,8 @@ import (
 
 type ContextSetCommand struct {
 	*baseCommand
+
+	flagDataSourceRef string
 }
 
 func (c *ContextSetCommand) Run(args []string) int {
@@ -26,8 +28,8 @@ func (c *ContextSetCommand) Run(args []string) int {
 		return 1
 	}
 
-	if c.flagWorkspace == "" {
-		// Require one argument
+	if c.flagWorkspace == "" && c.flagDataSourceRef == "" {
+		// Require one property to set
 		c.ui.Output(c.Help(), terminal.WithErrorStyle())
 		return 1
 	}
@@ -54,7 +56,16 @@ func (c *ContextSetCommand) Run(args []string) int {
 	}
 
 	// set new workspace
-	cfg.Workspace = c.flagWorkspace
+	if c.flagWorkspace != "" {
+		cfg.Workspace = c.flagWorkspace
+	}
+
+	// set new data source ref, "-" unsets it
+	if c.flagDataSourceRef == "-" {
+		cfg.DataSourceRef = ""
+	} else if c.flagDataSourceRef != "" {
+		cfg.DataSourceRef = c.flagDataSourceRef
+	}
 
 	// store updated context
 	if err := c.contextStorage.Set(contextName, cfg); err != nil {
@@ -62,12 +73,28 @@ func (c *ContextSetCommand) Run(args []string) int {
 		return 1
 	}
 
-	c.ui.Output("Context %q (%s) updated to use %s workspace.", contextName, cfg.Server.Address, cfg.Workspace, terminal.WithSuccessStyle())
+	if c.flagWorkspace != "" {
+		c.ui.Output("Context %q (%s) updated to use %s workspace.", contextName, cfg.Server.Address, cfg.Workspace, terminal.WithSuccessStyle())
+	}
+	if c.flagDataSourceRef == "-" {
+		c.ui.Output("Context %q (%s) updated to use the project's data source ref.", contextName, cfg.Server.Address, terminal.WithSuccessStyle())
+	} else if c.flagDataSourceRef != "" {
+		c.ui.Output("Context %q (%s) updated to use data source ref %q.", contextName, cfg.Server.Address, cfg.DataSourceRef, terminal.WithSuccessStyle())
+	}
 	return 0
 }
 
 func (c *ContextSetCommand) Flags() *flag.Sets {
-	return c.flagSet(0, nil)
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.StringVar(&flag.StringVar{
+			Name:   "datasource-ref",
+			Target: &c.flagDataSourceRef,
+			Usage: "Default ref, such as a branch or tag, to use for projects with a Git " +
+				"data source. This applies to remote operations unless overridden with " +
+				"\"-remote-source=ref=<value>\". Set to \"-\" to unset.",
+		})
+	})
 }
 
 func (c *ContextSetCommand) AutocompleteArgs() complete.Predictor {
@@ -86,8 +113,8 @@ func (c *ContextSetCommand) Help() string {
 	return formatHelp(`
 Usage: waypoint context set [options]
 
-  Sets a property of the current context. The only property supported at this
-  time is -workspace.
+  Sets a property of the current context. The properties supported at this
+  time are -workspace and -datasource-ref.
 
   To use this command, use the global -workspace flag to set the default
   workspace for the current context.
@@ -95,5 +122,9 @@ Usage: waypoint context set [options]
   To restore this CLI context to use the default workspace, use
   -workspace=default
 
+  Use -datasource-ref to pin remote operations for projects with a Git data
+  source to a specific ref, such as a release branch. Use -datasource-ref=-
+  to go back to the ref configured for the project.
+
 ` + c.Flags().Help())
 }
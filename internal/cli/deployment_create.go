@@ -8,7 +8,6 @@ import (
 
 	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
 	clientpkg "github.com/hashicorp/waypoint/internal/client"
-	"github.com/hashicorp/waypoint/internal/clierrors"
 	"github.com/hashicorp/waypoint/internal/pkg/flag"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 )
@@ -25,6 +24,8 @@ func (c *DeploymentCreateCommand) Run(args []string) int {
 		WithArgs(args),
 		WithFlags(c.Flags()),
 		WithMultipleApp(),
+		WithServerAppCheck(),
+		WithProtectedWorkspaceCheck(),
 	); err != nil {
 		return 1
 	}
@@ -38,18 +39,18 @@ func (c *DeploymentCreateCommand) Run(args []string) int {
 			Workspace:   c.project.WorkspaceRef(),
 		})
 		if err != nil {
-			app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-			return ErrSentinel
+			return appOpError(app.UI, err)
 		}
 
 		// Push it
-		app.UI.Output("Deploying %s...", app.Ref().Application, terminal.WithHeaderStyle())
+		if c.atVerbosity(verbosityNormal) {
+			app.UI.Output("Deploying %s...", app.Ref().Application, terminal.WithHeaderStyle())
+		}
 		result, err := app.Deploy(ctx, &pb.Job_DeployOp{
 			Artifact: push,
 		})
 		if err != nil {
-			app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-			return ErrSentinel
+			return appOpError(app.UI, err)
 		}
 		deployUrl := result.Deployment.Preload.DeployUrl
 		deployment := result.Deployment
@@ -78,8 +79,7 @@ func (c *DeploymentCreateCommand) Run(args []string) int {
 			},
 		})
 		if err != nil {
-			app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-			return ErrSentinel
+			return appOpError(app.UI, err)
 		}
 
 		// Release if we're releasing
@@ -92,8 +92,7 @@ func (c *DeploymentCreateCommand) Run(args []string) int {
 				Prune:      true,
 			})
 			if err != nil {
-				app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-				return ErrSentinel
+				return appOpError(app.UI, err)
 			}
 
 			releaseUrl = releaseResult.Release.Url
@@ -112,8 +111,7 @@ func (c *DeploymentCreateCommand) Run(args []string) int {
 					},
 				})
 				if err != nil {
-					app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-					return ErrSentinel
+					return appOpError(app.UI, err)
 				}
 			}
 		}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"strconv"
 
 	"github.com/posener/complete"
@@ -30,6 +31,7 @@ func (c *DeploymentDestroyCommand) Run(args []string) int {
 		WithArgs(args),
 		WithFlags(flags),
 		WithMultipleApp(),
+		WithProtectedWorkspaceCheck(),
 	); err != nil {
 		return 1
 	}
@@ -76,6 +78,10 @@ func (c *DeploymentDestroyCommand) Run(args []string) int {
 					Deployment: deployment,
 				},
 			}); err != nil {
+				if errors.Is(err, clientpkg.ErrJobPrinted) {
+					return err
+				}
+
 				c.ui.Output("Error destroying the deployment: %s", err.Error(), terminal.WithErrorStyle())
 				return ErrSentinel
 			}
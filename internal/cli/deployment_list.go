@@ -2,7 +2,6 @@ package cli
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -147,7 +146,7 @@ func (c *DeploymentListCommand) Run(args []string) int {
 		sort.Sort(serversort.DeploymentBundleCompleteDesc(resp.Deployments))
 
 		if c.flagJson {
-			return c.displayJson(resp.Deployments)
+			return c.displayJson(app.Ref().Application, resp.Deployments)
 		}
 
 		headers := []string{
@@ -363,7 +362,7 @@ func (c *DeploymentListCommand) Run(args []string) int {
 	return 0
 }
 
-func (c *DeploymentListCommand) displayJson(deployments []*pb.UI_DeploymentBundle) error {
+func (c *DeploymentListCommand) displayJson(app string, deployments []*pb.UI_DeploymentBundle) error {
 	var output []map[string]interface{}
 
 	for _, dep := range deployments {
@@ -384,14 +383,7 @@ func (c *DeploymentListCommand) displayJson(deployments []*pb.UI_DeploymentBundl
 		output = append(output, i)
 	}
 
-	data, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	c.ui.Output(string(data))
-
-	return nil
+	return c.outputJSON([]string{app}, output)
 }
 
 func (c *DeploymentListCommand) artifactJson(art *pb.PushedArtifact) interface{} {
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"errors"
 
 	"github.com/golang/protobuf/ptypes/empty"
 
@@ -24,6 +25,8 @@ func (c *DestroyCommand) Run(args []string) int {
 		WithArgs(args),
 		WithFlags(c.Flags()),
 		WithMultipleApp(),
+		WithServerAppCheck(),
+		WithProtectedWorkspaceCheck(),
 	); err != nil {
 		return 1
 	}
@@ -39,6 +42,10 @@ func (c *DestroyCommand) Run(args []string) int {
 				Workspace: &empty.Empty{},
 			},
 		}); err != nil {
+			if errors.Is(err, clientpkg.ErrJobPrinted) {
+				return err
+			}
+
 			c.ui.Output("Error destroying: %s", err.Error(), terminal.WithErrorStyle())
 			return ErrSentinel
 		}
@@ -47,7 +54,7 @@ func (c *DestroyCommand) Run(args []string) int {
 		return nil
 	})
 	if err != nil {
-		if err != ErrSentinel {
+		if !errors.Is(err, ErrSentinel) {
 			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
 		}
 
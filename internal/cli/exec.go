@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"fmt"
 	"os"
 
 	"github.com/posener/complete"
@@ -116,6 +117,9 @@ func (c *ExecCommand) Run(args []string) int {
 		return 1
 	}
 
+	idle := c.startIdleTimer()
+	defer idle.Stop()
+
 	var exitCode int
 	client := c.project.Client()
 	err := c.DoApp(c.Ctx, func(ctx context.Context, app *clientpkg.App) error {
@@ -126,8 +130,8 @@ func (c *ExecCommand) Run(args []string) int {
 			Client:  client,
 			Args:    args,
 			Stdin:   os.Stdin,
-			Stdout:  os.Stdout,
-			Stderr:  os.Stderr,
+			Stdout:  idle.Writer(os.Stdout),
+			Stderr:  idle.Writer(os.Stderr),
 		}
 
 		var (
@@ -145,6 +149,12 @@ func (c *ExecCommand) Run(args []string) int {
 		}
 
 		exitCode, err = ec.Run()
+		if idle.Expired() {
+			// The UI is closed once the session starts so we write
+			// directly to stderr.
+			fmt.Fprintf(os.Stderr, "\r\n%s\r\n", idle.Message())
+			return nil
+		}
 		if err != nil {
 			app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
 			return ErrSentinel
@@ -167,6 +177,8 @@ func (c *ExecCommand) Flags() *flag.Sets {
 			Usage:  "Start an exec session on this specific instance",
 			Target: &c.flagInstanceId,
 		})
+
+		c.addIdleTimeoutFlag(f)
 	})
 }
 
@@ -39,7 +39,13 @@ func (c *FmtCommand) Run(args []string) int {
 
 	// If we have no args, default to the filename
 	if len(c.args) == 0 {
-		c.args = []string{configpkg.Filename}
+		name, err := c.configFilename()
+		if err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+
+		c.args = []string{name}
 	}
 
 	// Read the input
@@ -194,7 +194,13 @@ func (c *InitCommand) initNew() bool {
 		panic(err)
 	}
 
-	if err := ioutil.WriteFile(configpkg.Filename, data, 0644); err != nil {
+	name, err := c.configFilename()
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return false
+	}
+
+	if err := ioutil.WriteFile(name, data, 0644); err != nil {
 		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
 		return false
 	}
@@ -203,12 +209,13 @@ func (c *InitCommand) initNew() bool {
 	c.ui.Output(strings.TrimSpace(`
 No Waypoint configuration was found in this directory.
 
-A sample configuration has been created in the file "waypoint.hcl". This
+A sample configuration has been created in the file %q. This
 file is heavily commented to help you get started.
 
 Once you've setup your initial configuration, run "waypoint init" again to
 validate the configuration and initialize your project.
 `),
+		name,
 		terminal.WithSuccessStyle(),
 	)
 
@@ -220,7 +227,7 @@ func (c *InitCommand) validateConfig() bool {
 	defer sg.Wait()
 
 	s := sg.Add("Validating configuration file...")
-	cfg, err := c.initConfig(c.fromProject, false)
+	cfg, err := c.initConfig(c.fromProject)
 	if err != nil {
 		c.stepError(s, initStepConfig, err)
 		return false
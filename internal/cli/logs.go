@@ -34,6 +34,9 @@ func (c *LogsCommand) Run(args []string) int {
 		return 1
 	}
 
+	idle := c.startIdleTimer()
+	defer idle.Stop()
+
 	err := c.DoApp(c.Ctx, func(ctx context.Context, app *clientpkg.App) error {
 		stream, err := app.Logs(ctx)
 		if err != nil {
@@ -46,6 +49,11 @@ func (c *LogsCommand) Run(args []string) int {
 		for {
 			batch, err := stream.Recv()
 			if err != nil {
+				if idle.Expired() {
+					app.UI.Output(idle.Message(), terminal.WithWarningStyle())
+					return nil
+				}
+
 				if !clierrors.IsCanceled(err) {
 					app.UI.Output("Error reading logs: %s", err, terminal.WithErrorStyle())
 				}
@@ -57,6 +65,8 @@ func (c *LogsCommand) Run(args []string) int {
 				break
 			}
 
+			idle.Reset()
+
 			for _, event := range batch.Lines {
 				event.Line = strings.TrimSuffix(event.Line, "\n")
 
@@ -100,7 +110,10 @@ func (c *LogsCommand) Run(args []string) int {
 }
 
 func (c *LogsCommand) Flags() *flag.Sets {
-	return c.flagSet(0, nil)
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		c.addIdleTimeoutFlag(f)
+	})
 }
 
 func (c *LogsCommand) AutocompleteArgs() complete.Predictor {
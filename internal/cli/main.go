@@ -29,6 +29,32 @@ const (
 
 	// EnvPlain is the env var that can be set to force plain output mode.
 	EnvPlain = "WAYPOINT_PLAIN"
+
+	// EnvDisableSnapshot is the env var that can be set to disable server
+	// snapshots by default for commands that take them, such as
+	// "server upgrade". An explicit "-snapshot" flag always wins.
+	EnvDisableSnapshot = "WAYPOINT_DISABLE_SNAPSHOT"
+
+	// EnvDisableVersionCheck is the env var that can be set to disable
+	// checking for a newer version of the CLI.
+	EnvDisableVersionCheck = "WAYPOINT_DISABLE_VERSION_CHECK"
+
+	// EnvDisableCheckpoint is the env var that can be set to disable all
+	// of the CLI's outbound requests other than to the server, the same as
+	// "-no-checkpoint". EnvCheckpointDisable is the equivalent env var
+	// shared by HashiCorp tools, which disables it if set to any value.
+	EnvDisableCheckpoint = "WAYPOINT_DISABLE_CHECKPOINT"
+	EnvCheckpointDisable = "CHECKPOINT_DISABLE"
+
+	// EnvNoLocalRunner is the env var that can be set to forbid operations
+	// from executing on a local runner, the same as "-no-local-runner".
+	// An explicit "-no-local-runner" flag always wins.
+	EnvNoLocalRunner = "WAYPOINT_NO_LOCAL_RUNNER"
+
+	// EnvNoProjectWarning is the env var that can be set to disable the
+	// warning when a CLI context is used with a different project than
+	// the last time it was used.
+	EnvNoProjectWarning = "WAYPOINT_NO_PROJECT_WARNING"
 )
 
 var (
@@ -121,6 +147,7 @@ func Main(args []string) int {
 		cli = cliFactory()
 		cli.Args = []string{"version"}
 	}
+	base.metrics.command = cli.Subcommand()
 
 	// Run the CLI
 	exitCode, err := cli.Run()
@@ -128,6 +155,15 @@ func Main(args []string) int {
 		panic(err)
 	}
 
+	// Emit metrics about this execution if requested. This is best-effort.
+	base.emitMetrics(exitCode)
+
+	// Output the timing breakdown if requested.
+	base.outputTimings()
+
+	// Let the user know if there is a newer version available.
+	base.versionCheckNotice()
+
 	return exitCode
 }
 
@@ -200,6 +236,11 @@ func Commands(
 				HelpText:     helpText["config"][1],
 			}, nil
 		},
+		"config diff-remote": func() (cli.Command, error) {
+			return &ConfigDiffRemoteCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 		"config get": func() (cli.Command, error) {
 			return &ConfigGetCommand{
 				baseCommand: baseCommand,
@@ -210,6 +251,11 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"config show": func() (cli.Command, error) {
+			return &ConfigShowCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 		"config source-get": func() (cli.Command, error) {
 			return &ConfigSourceGetCommand{
 				baseCommand: baseCommand,
@@ -225,6 +271,11 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"config validate": func() (cli.Command, error) {
+			return &ConfigValidateCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 		"logs": func() (cli.Command, error) {
 			return &LogsCommand{
 				baseCommand: baseCommand,
@@ -344,6 +395,11 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"server wait": func() (cli.Command, error) {
+			return &ServerWaitCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 		"status": func() (cli.Command, error) {
 			return &StatusCommand{
 				baseCommand: baseCommand,
@@ -405,6 +461,18 @@ func Commands(
 			}, nil
 		},
 
+		"operation": func() (cli.Command, error) {
+			return &helpCommand{
+				SynopsisText: helpText["operation"][0],
+				HelpText:     helpText["operation"][1],
+			}, nil
+		},
+		"operation logs": func() (cli.Command, error) {
+			return &OperationLogsCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+
 		"runner": func() (cli.Command, error) {
 			return &helpCommand{
 				SynopsisText: helpText["runner"][0],
@@ -416,6 +484,21 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"runner cleanup-local": func() (cli.Command, error) {
+			return &RunnerCleanupLocalCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner prune": func() (cli.Command, error) {
+			return &RunnerPruneCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+		"runner where": func() (cli.Command, error) {
+			return &RunnerWhereCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 
 		"context": func() (cli.Command, error) {
 			return &ContextHelpCommand{
@@ -510,6 +593,12 @@ func Commands(
 			}, nil
 		},
 
+		"var-files": func() (cli.Command, error) {
+			return &VarFilesCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
+
 		"auth-method": func() (cli.Command, error) {
 			return &helpCommand{
 				SynopsisText: helpText["auth-method"][0],
@@ -618,6 +707,11 @@ func Commands(
 				baseCommand: baseCommand,
 			}, nil
 		},
+		"workspace copy": func() (cli.Command, error) {
+			return &WorkspaceCopyCommand{
+				baseCommand: baseCommand,
+			}, nil
+		},
 		"workspace list": func() (cli.Command, error) {
 			return &WorkspaceListCommand{
 				baseCommand: baseCommand,
@@ -700,7 +794,9 @@ func logger(args []string) ([]string, hclog.Logger, io.Writer, error) {
 		color = hclog.AutoColor
 	}
 
-	logger := hclog.New(&hclog.LoggerOptions{
+	// The logger intercepts so that -syslog can register a sink once the
+	// flags are parsed.
+	logger := hclog.NewInterceptLogger(&hclog.LoggerOptions{
 		Name:   app,
 		Level:  level,
 		Color:  color,
@@ -921,6 +1017,17 @@ to a VCS repository (if one exists).
 `,
 	},
 
+	"operation": {
+		"Operation output",
+		`
+Operation output.
+
+The operation commands work with the operations, or jobs, queued on the
+server by other commands, such as showing their output after the command
+that queued them exited.
+`,
+	},
+
 	"runner": {
 		"Runner management",
 		`
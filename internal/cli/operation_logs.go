@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"github.com/posener/complete"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type OperationLogsCommand struct {
+	*baseCommand
+
+	flagFollow bool
+}
+
+func (c *OperationLogsCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoAutoServer(), // the job is on the server
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if len(c.args) != 1 {
+		c.ui.Output("A single job ID is required.\n\n"+c.Help(), terminal.WithErrorStyle())
+		return 1
+	}
+	id := c.args[0]
+
+	job, err := c.project.JobLogs(c.Ctx, id, c.ui, c.flagFollow)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	switch job.State {
+	case pb.Job_SUCCESS:
+		c.ui.Output("The job completed successfully.", terminal.WithSuccessStyle())
+
+	case pb.Job_ERROR:
+		msg := "The job failed."
+		if job.Error != nil {
+			msg = "The job failed: " + status.FromProto(job.Error).Message()
+		}
+
+		c.ui.Output(msg, terminal.WithErrorStyle())
+		return 1
+
+	default:
+		c.ui.Output("The job is %s. Use -follow to stream its output until it completes.",
+			jobStateName(job.State), terminal.WithInfoStyle())
+	}
+
+	return 0
+}
+
+// jobStateName is the human-readable name of a job state.
+func jobStateName(s pb.Job_State) string {
+	switch s {
+	case pb.Job_QUEUED:
+		return "queued"
+	case pb.Job_WAITING:
+		return "waiting for a runner"
+	case pb.Job_RUNNING:
+		return "still running"
+	default:
+		return "in an unknown state"
+	}
+}
+
+func (c *OperationLogsCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.BoolVar(&flag.BoolVar{
+			Name:    "follow",
+			Target:  &c.flagFollow,
+			Aliases: []string{"f"},
+			Usage: "Stream the output of a job that hasn't completed until it " +
+				"completes, rather than only showing the output so far.",
+		})
+	})
+}
+
+func (c *OperationLogsCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperationLogsCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperationLogsCommand) Synopsis() string {
+	return "Show the output of an operation by job ID."
+}
+
+func (c *OperationLogsCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint operation logs [options] JOB-ID
+
+  Show the output of an operation by its job ID.
+
+  This shows the output stored by the server for any operation, so that
+  you can re-attach to an operation after the command that queued it
+  exited. The output of a completed operation is replayed in full. For an
+  operation that is still running, this shows the output so far, or with
+  -follow, streams the output until the operation completes.
+
+  The server only keeps the output in memory, so the output of operations
+  from before a server restart isn't available. This exits with a non-zero
+  status if the operation failed.
+
+` + c.Flags().Help())
+}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"net/http"
+
 	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
 	"github.com/hashicorp/waypoint/internal/pkg/flag"
 )
@@ -106,6 +108,55 @@ func WithConnectionArg() Option {
 	}
 }
 
+// WithPhases configures the CLI to accept the "-only" flag to select a
+// subset of the given phases. The phases should be listed in the order the
+// command performs them. Use phaseEnabled to check if a phase was selected.
+func WithPhases(phases ...string) Option {
+	return func(c *baseConfig) {
+		c.Phases = phases
+	}
+}
+
+// WithHTTPClient configures the CLI to use the given client for HTTP
+// requests that aren't to the Waypoint server, such as checking for a newer
+// version. This lets a proxy, custom CA, or timeouts be applied to all of
+// the network activity of the CLI. If this isn't set, http.DefaultClient
+// is used.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *baseConfig) {
+		c.HTTPClient = client
+	}
+}
+
+// WithNoRemoteCheck configures the CLI to not check whether remote
+// operations are possible for the project, so that commands can report on
+// it rather than fail or fall back to a local runner.
+func WithNoRemoteCheck() Option {
+	return func(c *baseConfig) {
+		c.NoRemoteCheck = true
+	}
+}
+
+// WithServerAppCheck configures DoApp to check that each targeted app
+// exists in the project on the server before executing a remote operation.
+// This should only be set for operation commands, since other commands
+// don't need the apps to be registered.
+func WithServerAppCheck() Option {
+	return func(c *baseConfig) {
+		c.ServerAppCheck = true
+	}
+}
+
+// WithProtectedWorkspaceCheck configures Init to require confirmation to
+// operate on a workspace listed in "protected_workspaces" in the
+// configuration. This should be set for commands that change what is
+// deployed.
+func WithProtectedWorkspaceCheck() Option {
+	return func(c *baseConfig) {
+		c.ProtectedWorkspaceCheck = true
+	}
+}
+
 type baseConfig struct {
 	Args                  []string
 	Flags                 *flag.Sets
@@ -123,4 +174,24 @@ type baseConfig struct {
 	// ConnArg as true means we should parse the server address as an
 	// argument (the first argument).
 	ConnArg bool
+
+	// Phases are the phases of the command that can be selected with the
+	// "-only" flag. If this is empty, the flag isn't supported.
+	Phases []string
+
+	// HTTPClient is the client for HTTP requests that aren't to the
+	// Waypoint server. If this is nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+
+	// NoRemoteCheck is true if Init shouldn't check whether remote
+	// operations are possible.
+	NoRemoteCheck bool
+
+	// ServerAppCheck is true if DoApp should check that the targeted apps
+	// exist on the server for remote operations.
+	ServerAppCheck bool
+
+	// ProtectedWorkspaceCheck is true if Init should require confirmation
+	// to operate on a protected workspace.
+	ProtectedWorkspaceCheck bool
 }
@@ -372,9 +372,7 @@ func (c *ProjectApplyCommand) Run(args []string) int {
 		}
 
 		// Validate the ref is validate by looking up the runner.
-		_, err := c.project.Client().GetOnDemandRunnerConfig(ctx, &pb.GetOnDemandRunnerConfigRequest{
-			Config: ref,
-		})
+		od, err := c.runnerProfile(ctx, c.flagOndemandRunner)
 		if err != nil {
 			c.ui.Output(
 				"Error looking up ondemand runner: %s", clierrors.Humanize(err),
@@ -383,6 +381,15 @@ func (c *ProjectApplyCommand) Run(args []string) int {
 
 			return 1
 		}
+		if od == nil {
+			c.ui.Output(
+				"Error looking up ondemand runner: no runner profile named %q was found.",
+				c.flagOndemandRunner,
+				terminal.WithErrorStyle(),
+			)
+
+			return 1
+		}
 
 		proj.OndemandRunner = ref
 	}
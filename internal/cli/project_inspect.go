@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"strconv"
 	"strings"
 
@@ -85,7 +86,11 @@ func (c *ProjectInspectCommand) FormatProject(projectTarget string) error {
 			return err
 		}
 
-		c.ui.Output(str)
+		if err := c.outputJSON(nil, json.RawMessage(str)); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return err
+		}
+
 		return nil
 	}
 
@@ -11,7 +11,6 @@ import (
 
 	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
 	clientpkg "github.com/hashicorp/waypoint/internal/client"
-	"github.com/hashicorp/waypoint/internal/clierrors"
 	"github.com/hashicorp/waypoint/internal/pkg/flag"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 )
@@ -34,6 +33,8 @@ func (c *ReleaseCreateCommand) Run(args []string) int {
 		WithArgs(args),
 		WithFlags(c.Flags()),
 		WithMultipleApp(),
+		WithServerAppCheck(),
+		WithProtectedWorkspaceCheck(),
 	); err != nil {
 		return 1
 	}
@@ -42,7 +43,9 @@ func (c *ReleaseCreateCommand) Run(args []string) int {
 	err := c.DoApp(c.Ctx, func(ctx context.Context, app *clientpkg.App) error {
 		// UI -- this should happen at the top so that the app name shows clearly
 		// for any errors we may encounter prior to the actual release op
-		app.UI.Output("Releasing %s...", app.Ref().Application, terminal.WithHeaderStyle())
+		if c.atVerbosity(verbosityNormal) {
+			app.UI.Output("Releasing %s...", app.Ref().Application, terminal.WithHeaderStyle())
+		}
 
 		// Get the latest release
 		release, err := client.GetLatestRelease(ctx, &pb.GetLatestReleaseRequest{
@@ -54,8 +57,7 @@ func (c *ReleaseCreateCommand) Run(args []string) int {
 			release = nil
 		}
 		if err != nil {
-			app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-			return ErrSentinel
+			return appOpError(app.UI, err)
 		}
 
 		var deploy *pb.Deployment
@@ -73,8 +75,7 @@ func (c *ReleaseCreateCommand) Run(args []string) int {
 				},
 			})
 			if err != nil {
-				app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-				return ErrSentinel
+				return appOpError(app.UI, err)
 			}
 			if len(resp.Deployments) == 0 {
 				app.UI.Output(strings.TrimSpace(releaseNoDeploys), terminal.WithErrorStyle())
@@ -94,8 +95,7 @@ func (c *ReleaseCreateCommand) Run(args []string) int {
 			})
 
 			if err != nil {
-				app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-				return ErrSentinel
+				return appOpError(app.UI, err)
 			}
 		} else {
 			deploy, err = client.GetDeployment(ctx, &pb.GetDeploymentRequest{
@@ -107,8 +107,7 @@ func (c *ReleaseCreateCommand) Run(args []string) int {
 			})
 
 			if err != nil {
-				app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-				return ErrSentinel
+				return appOpError(app.UI, err)
 			}
 		}
 
@@ -140,8 +139,7 @@ func (c *ReleaseCreateCommand) Run(args []string) int {
 			PruneRetainOverride: c.flagPruneRetain >= 0,
 		})
 		if err != nil {
-			app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-			return ErrSentinel
+			return appOpError(app.UI, err)
 		}
 
 		// If the released deploy doesn't match what we requested, it is
@@ -164,8 +162,7 @@ func (c *ReleaseCreateCommand) Run(args []string) int {
 			},
 		})
 		if err != nil {
-			app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-			return ErrSentinel
+			return appOpError(app.UI, err)
 		}
 
 		if result.Release.Url == "" {
@@ -2,7 +2,6 @@ package cli
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -95,7 +94,7 @@ func (c *ReleaseListCommand) Run(args []string) int {
 		sort.Sort(serversort.ReleaseBundleCompleteDesc(resp.Releases))
 
 		if c.flagJson {
-			return c.displayJson(resp.Releases)
+			return c.displayJson(app.Ref().Application, resp.Releases)
 		}
 
 		headers := []string{
@@ -287,7 +286,7 @@ func (c *ReleaseListCommand) Run(args []string) int {
 	return 0
 }
 
-func (c *ReleaseListCommand) displayJson(releases []*pb.UI_ReleaseBundle) error {
+func (c *ReleaseListCommand) displayJson(app string, releases []*pb.UI_ReleaseBundle) error {
 	var output []map[string]interface{}
 
 	for _, rel := range releases {
@@ -311,14 +310,7 @@ func (c *ReleaseListCommand) displayJson(releases []*pb.UI_ReleaseBundle) error
 		output = append(output, i)
 	}
 
-	data, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	c.ui.Output(string(data))
-
-	return nil
+	return c.outputJSON([]string{app}, output)
 }
 
 func (c *ReleaseListCommand) statusJson(status *pb.Status) interface{} {
@@ -40,6 +40,10 @@ type RunnerAgentCommand struct {
 	// is made available to the plugins so they can alter their behavior for
 	// this unique context.
 	flagODR bool
+
+	// Labels of the runner. Jobs that require runner labels are only
+	// executed by runners with matching labels.
+	flagAgentLabels map[string]string
 }
 
 // This is how long a runner in ODR mode will wait for its job assignment before
@@ -125,6 +129,7 @@ func (c *RunnerAgentCommand) Run(args []string) int {
 		runnerpkg.WithClient(client),
 		runnerpkg.WithLogger(log.Named("runner")),
 		runnerpkg.WithDynamicConfig(c.flagDynConfig),
+		runnerpkg.WithLabels(c.flagAgentLabels),
 	}
 
 	if c.flagId != "" {
@@ -273,6 +278,14 @@ func (c *RunnerAgentCommand) Flags() *flag.Sets {
 			Target: &c.flagODR,
 			Usage:  "Indicates to the runner it's operating as an on-demand runner.",
 		})
+
+		f.StringMapVar(&flag.StringMapVar{
+			Name:   "label",
+			Target: &c.flagAgentLabels,
+			Usage: "Label for this runner, such as \"gpu=true\". Operations run " +
+				"with -runner-label are only executed by runners with all of the " +
+				"requested labels. Can be specified multiple times.",
+		})
 	})
 }
 
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+)
+
+type RunnerCleanupLocalCommand struct {
+	*baseCommand
+
+	flagAutoApprove bool
+}
+
+func (c *RunnerCleanupLocalCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		return 1
+	}
+
+	orphans, err := orphanedLocalRunners(c.homeConfigPath)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+	if len(orphans) == 0 {
+		c.ui.Output("No orphaned local runners found.", terminal.WithSuccessStyle())
+		return 0
+	}
+
+	c.ui.Output("Orphaned local runners", terminal.WithHeaderStyle())
+	tbl := terminal.NewTable("ID", "PID", "Started", "Directory")
+	for _, r := range orphans {
+		pid, started := "", ""
+		if r.Pid > 0 {
+			pid = strconv.Itoa(r.Pid)
+		}
+		if !r.StartedAt.IsZero() {
+			started = r.StartedAt.Format(time.RFC3339)
+		}
+
+		tbl.Rich([]string{r.Id, pid, started, r.Dir}, nil)
+	}
+	c.ui.Table(tbl)
+
+	if !c.flagAutoApprove {
+		if !c.ui.Interactive() {
+			c.ui.Output(strings.TrimSpace(runnerCleanupLocalAutoApprove), terminal.WithErrorStyle())
+			return 1
+		}
+
+		result, err := c.ui.Input(&terminal.Input{
+			Prompt: "Clean up these local runners? [y/n]",
+			Style:  terminal.WarningStyle,
+		})
+		if err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+		if strings.ToLower(result) != "y" {
+			return 0
+		}
+	}
+
+	var failed bool
+	for _, r := range orphans {
+		signaled, err := cleanupLocalRunner(r)
+		if err != nil {
+			c.ui.Output("Error cleaning up local runner %q: %s",
+				r.Id, clierrors.Humanize(err), terminal.WithErrorStyle())
+			failed = true
+			continue
+		}
+
+		if signaled {
+			c.ui.Output("Cleaned up local runner %q and stopped its leftover processes.",
+				r.Id, terminal.WithSuccessStyle())
+		} else {
+			c.ui.Output("Cleaned up local runner %q.", r.Id, terminal.WithSuccessStyle())
+		}
+	}
+	if failed {
+		return 1
+	}
+
+	return 0
+}
+
+func (c *RunnerCleanupLocalCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.BoolVar(&flag.BoolVar{
+			Name:    "auto-approve",
+			Target:  &c.flagAutoApprove,
+			Default: false,
+			Usage:   "Clean up orphaned local runners without asking for confirmation.",
+		})
+	})
+}
+
+func (c *RunnerCleanupLocalCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerCleanupLocalCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerCleanupLocalCommand) Synopsis() string {
+	return "Clean up local runners left behind by crashed commands."
+}
+
+func (c *RunnerCleanupLocalCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner cleanup-local [options]
+
+  Detect and clean up local runners left behind by previous commands.
+
+  Commands that execute operations locally start a local runner that is
+  cleaned up when the command exits. If a command crashes or is killed, the
+  runner's plugin processes may keep running. This command finds runners
+  whose command is no longer running, stops any leftover processes, and
+  removes them from tracking.
+
+` + c.Flags().Help())
+}
+
+const runnerCleanupLocalAutoApprove = `
+Cleaning up local runners requires confirmation. Rerun the command with
+'-auto-approve' to continue.
+`
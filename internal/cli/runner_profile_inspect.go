@@ -1,7 +1,7 @@
 package cli
 
 import (
-	"fmt"
+	"encoding/json"
 
 	"github.com/golang/protobuf/jsonpb"
 	"google.golang.org/grpc/codes"
@@ -75,7 +75,11 @@ func (c *RunnerProfileInspectCommand) Run(args []string) int {
 			return 1
 		}
 
-		fmt.Println(str)
+		if err := c.outputJSON(nil, json.RawMessage(str)); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+
 		return 0
 	}
 
@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/posener/complete"
 
 	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
@@ -23,13 +22,13 @@ func (c *RunnerProfileListCommand) Run(args []string) int {
 		return 1
 	}
 
-	resp, err := c.project.Client().ListOnDemandRunnerConfigs(c.Ctx, &empty.Empty{})
+	configs, err := c.runnerProfileList(c.Ctx)
 	if err != nil {
 		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
 		return 1
 	}
 
-	if len(resp.Configs) == 0 {
+	if len(configs) == 0 {
 		return 0
 	}
 
@@ -37,7 +36,7 @@ func (c *RunnerProfileListCommand) Run(args []string) int {
 
 	tbl := terminal.NewTable("Name", "Plugin Type", "OCI Url", "Default")
 
-	for _, p := range resp.Configs {
+	for _, p := range configs {
 		def := ""
 		if p.Default {
 			def = "yes"
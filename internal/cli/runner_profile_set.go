@@ -191,6 +191,7 @@ func (c *RunnerProfileSetCommand) Run(args []string) int {
 		)
 		return 1
 	}
+	c.invalidateRunnerProfiles()
 
 	if updated {
 		s.Update("Runner profile updated")
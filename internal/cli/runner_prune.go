@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+)
+
+// staleLocalState is local runner state in the home config directory that
+// is safe to remove.
+type staleLocalState struct {
+	// Kind is the type of state, such as "runner" or "checkpoint".
+	Kind   string
+	Path   string
+	Reason string
+
+	// record is set for orphaned local runners so that their leftover
+	// processes are stopped when pruning.
+	record *localRunnerRecord
+}
+
+// staleLocalRunnerState scans the home config directory for local runner
+// state left behind by interrupted commands. Runner records are stale once
+// their CLI process is gone. Checkpoints and unknown files in the runner
+// directory are stale once they haven't been modified for maxAge.
+func staleLocalRunnerState(homeConfigPath string, maxAge time.Duration, now time.Time) ([]*staleLocalState, error) {
+	orphans, err := orphanedLocalRunners(homeConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*staleLocalState
+	for _, r := range orphans {
+		reason := "process is no longer running"
+		if r.Pid == 0 {
+			reason = "record is unreadable"
+		}
+
+		result = append(result, &staleLocalState{
+			Kind:   "runner",
+			Path:   r.path,
+			Reason: reason,
+			record: r,
+		})
+	}
+
+	for _, scan := range []struct {
+		kind string
+		dir  string
+		skip func(os.FileInfo) bool
+	}{
+		{
+			kind: "checkpoint",
+			dir:  checkpointDir,
+		},
+		{
+			// Runner records are handled above, so only other files in the
+			// runner directory are checked by age.
+			kind: "file",
+			dir:  localRunnersDir,
+			skip: func(fi os.FileInfo) bool {
+				return !fi.IsDir() && filepath.Ext(fi.Name()) == ".json"
+			},
+		},
+	} {
+		entries, err := ioutil.ReadDir(filepath.Join(homeConfigPath, scan.dir))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if scan.skip != nil && scan.skip(entry) {
+				continue
+			}
+
+			age := now.Sub(entry.ModTime())
+			if age < maxAge {
+				continue
+			}
+
+			result = append(result, &staleLocalState{
+				Kind:   scan.kind,
+				Path:   filepath.Join(homeConfigPath, scan.dir, entry.Name()),
+				Reason: "not modified for " + age.Truncate(time.Hour).String(),
+			})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Kind > result[j].Kind
+	})
+
+	return result, nil
+}
+
+// pruneLocalState removes the stale state. This returns true if any
+// leftover processes of an orphaned runner were signaled.
+func pruneLocalState(s *staleLocalState) (bool, error) {
+	if s.record != nil {
+		return cleanupLocalRunner(s.record)
+	}
+
+	if err := os.RemoveAll(s.Path); err != nil {
+		return false, err
+	}
+
+	return false, nil
+}
+
+type RunnerPruneCommand struct {
+	*baseCommand
+
+	flagDryRun bool
+	flagMaxAge time.Duration
+}
+
+func (c *RunnerPruneCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		return 1
+	}
+
+	stale, err := staleLocalRunnerState(c.homeConfigPath, c.flagMaxAge, time.Now())
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+	if len(stale) == 0 {
+		c.ui.Output("No stale local runner state found.", terminal.WithSuccessStyle())
+		return 0
+	}
+
+	if c.flagDryRun {
+		c.ui.Output("Stale local runner state that would be removed", terminal.WithHeaderStyle())
+	} else {
+		c.ui.Output("Removing stale local runner state", terminal.WithHeaderStyle())
+	}
+
+	var failed bool
+	tbl := terminal.NewTable("Kind", "Path", "Reason", "Result")
+	for _, s := range stale {
+		result := "would remove"
+		if !c.flagDryRun {
+			signaled, err := pruneLocalState(s)
+			switch {
+			case err != nil:
+				result = "error: " + clierrors.Humanize(err)
+				failed = true
+			case signaled:
+				result = "removed, stopped processes"
+			default:
+				result = "removed"
+			}
+		}
+
+		tbl.Rich([]string{s.Kind, s.Path, s.Reason, result}, nil)
+	}
+	c.ui.Table(tbl)
+
+	if failed {
+		return 1
+	}
+
+	return 0
+}
+
+func (c *RunnerPruneCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.BoolVar(&flag.BoolVar{
+			Name:    "dry-run",
+			Target:  &c.flagDryRun,
+			Default: false,
+			Usage:   "Report the stale local runner state without removing it.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:    "max-age",
+			Target:  &c.flagMaxAge,
+			Default: 7 * 24 * time.Hour,
+			Usage: "Checkpoints and other leftover files that haven't been modified " +
+				"for this long are removed.",
+		})
+	})
+}
+
+func (c *RunnerPruneCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerPruneCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerPruneCommand) Synopsis() string {
+	return "Remove stale local runner state left behind by interrupted commands."
+}
+
+func (c *RunnerPruneCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner prune [options]
+
+  Remove stale local runner state from the home configuration directory.
+
+  Commands that execute operations locally track their runner and its
+  progress in the home configuration directory. Interrupted commands can
+  leave this state behind. This removes the records of runners whose
+  command is no longer running, stopping any leftover processes, and
+  removes checkpoints and other leftover files older than -max-age.
+
+  Use -dry-run to see what would be removed.
+
+` + c.Flags().Help())
+}
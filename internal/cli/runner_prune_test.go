@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleLocalRunnerState(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	// Nothing to prune without any state.
+	stale, err := staleLocalRunnerState(td, time.Hour, time.Now())
+	require.NoError(err)
+	require.Empty(stale)
+
+	tracker, err := trackLocalRunner(td, "alive")
+	require.NoError(err)
+	defer tracker.Close()
+	require.NoError(ioutil.WriteFile(
+		filepath.Join(td, localRunnersDir, "dead.json"),
+		[]byte(`{"id": "dead", "pid": 999999999}`), 0600))
+	require.NoError(os.MkdirAll(filepath.Join(td, checkpointDir), 0755))
+	require.NoError(ioutil.WriteFile(
+		filepath.Join(td, checkpointDir, "ABC.json"), []byte(`{}`), 0644))
+
+	// Only the dead runner is stale while the checkpoint is recent.
+	stale, err = staleLocalRunnerState(td, time.Hour, time.Now())
+	require.NoError(err)
+	require.Len(stale, 1)
+	require.Equal("runner", stale[0].Kind)
+	require.Equal(filepath.Join(td, localRunnersDir, "dead.json"), stale[0].Path)
+
+	// The checkpoint is stale once it is older than the max age.
+	stale, err = staleLocalRunnerState(td, time.Hour, time.Now().Add(2*time.Hour))
+	require.NoError(err)
+	require.Len(stale, 2)
+	require.Equal("checkpoint", stale[1].Kind)
+
+	// Pruning removes both, but not our own runner.
+	for _, s := range stale {
+		_, err := pruneLocalState(s)
+		require.NoError(err)
+	}
+	stale, err = staleLocalRunnerState(td, time.Hour, time.Now().Add(2*time.Hour))
+	require.NoError(err)
+	require.Empty(stale)
+	_, err = os.Stat(filepath.Join(td, localRunnersDir, "alive.json"))
+	require.NoError(err)
+}
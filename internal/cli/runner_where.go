@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+const (
+	// Where an operation executes, as reported by "waypoint runner where".
+	executionLocal  = "local"
+	executionRemote = "remote"
+	executionError  = "error"
+)
+
+// executionDecision is where an operation on an app would execute and why.
+type executionDecision struct {
+	App       string `json:"app"`
+	Execution string `json:"execution"`
+	Reason    string `json:"reason"`
+}
+
+// executionFor returns where an operation on the targeted project
+// would execute with the current flags, following the same checks as Init.
+// project is the server record of the project, or nil if the project isn't
+// registered.
+func (c *baseCommand) executionFor(project *pb.Project) (string, string) {
+	switch {
+	case c.flagLocal:
+		return executionLocal, "The -local flag was set."
+
+	case !c.autoServer:
+		return executionRemote, "The command doesn't support local runners."
+
+	case !c.flagRemote:
+		return executionLocal, "Operations execute locally unless -remote is set " +
+			"or a project is targeted without its local configuration."
+
+	case c.refApp == nil && (c.cfg == nil || c.cfg.Runner == nil || !c.cfg.Runner.Enabled):
+		return executionError, "Remote operations aren't enabled with " +
+			"'runner.enabled' in the configuration."
+
+	case project == nil:
+		return executionRemote, "The project isn't registered with the server, " +
+			"so its data source can't be checked."
+
+	case project.DataSource == nil:
+		return executionError, "The project has no data source for remote runners."
+	}
+
+	if err := gitDataSourceError(project.DataSource); err != nil {
+		if c.localFallbackPossible() {
+			return executionLocal, fmt.Sprintf(
+				"The Git data source is misconfigured (%s), so the local "+
+					"configuration is used instead.", err)
+		}
+
+		return executionError, fmt.Sprintf(
+			"The Git data source is misconfigured (%s).", err)
+	}
+
+	return executionRemote, "The -remote flag was set or the project was " +
+		"targeted without its local configuration."
+}
+
+type RunnerWhereCommand struct {
+	*baseCommand
+
+	flagJson bool
+}
+
+func (c *RunnerWhereCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithMultipleApp(),
+		WithNoRemoteCheck(),
+	); err != nil {
+		return 1
+	}
+
+	apps, project, err := c.whereApps(c.Ctx)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	execution, reason := c.executionFor(project)
+	var decisions []*executionDecision
+	for _, app := range apps {
+		decisions = append(decisions, &executionDecision{
+			App:       app,
+			Execution: execution,
+			Reason:    reason,
+		})
+	}
+
+	if c.flagJson {
+		if err := c.outputJSON(apps, decisions); err != nil {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+
+		return 0
+	}
+
+	tbl := terminal.NewTable("App", "Execution", "Reason")
+	for _, d := range decisions {
+		color := ""
+		if d.Execution == executionError {
+			color = terminal.Red
+		}
+
+		tbl.Rich([]string{d.App, d.Execution, d.Reason}, []string{"", color, ""})
+	}
+	c.ui.Table(tbl)
+
+	return 0
+}
+
+// whereApps returns the targeted apps along with the server record of the
+// project, which is nil if the project isn't registered.
+func (c *RunnerWhereCommand) whereApps(ctx context.Context) ([]string, *pb.Project, error) {
+	project, err := c.getProject(ctx)
+	if err != nil {
+		c.Log.Debug("error getting the project", "error", err)
+		project = nil
+	}
+
+	switch {
+	case c.refApp != nil:
+		return []string{c.refApp.Application}, project, nil
+
+	case c.flagApp != "":
+		return []string{c.flagApp}, project, nil
+
+	case c.cfg != nil && len(c.cfg.Apps()) > 0:
+		return c.cfg.Apps(), project, nil
+
+	case project != nil:
+		var apps []string
+		for _, a := range project.Applications {
+			apps = append(apps, a.Name)
+		}
+
+		return apps, project, nil
+	}
+
+	if err == nil {
+		err = errors.New("No apps are targeted. Specify an app with the `-app` flag.")
+	}
+
+	return nil, nil, err
+}
+
+func (c *RunnerWhereCommand) Flags() *flag.Sets {
+	return c.flagSet(flagSetOperation, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.BoolVar(&flag.BoolVar{
+			Name:   "json",
+			Target: &c.flagJson,
+			Usage:  "Output where each app would execute as JSON.",
+		})
+	})
+}
+
+func (c *RunnerWhereCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *RunnerWhereCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *RunnerWhereCommand) Synopsis() string {
+	return "Show whether operations would execute locally or on a remote runner."
+}
+
+func (c *RunnerWhereCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint runner where [options]
+
+  Show whether operations would execute locally or on a remote runner.
+
+  For each targeted app, this reports where an operation with the same
+  flags would execute and why, such as the -local flag being set or the
+  project's Git data source being misconfigured. Nothing is executed.
+
+` + c.Flags().Help())
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/config"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestExecutionFor(t *testing.T) {
+	git := func(url string) *pb.Job_DataSource {
+		return &pb.Job_DataSource{Source: &pb.Job_DataSource_Git{
+			Git: &pb.Job_Git{Url: url},
+		}}
+	}
+	enabled := &config.Config{}
+	enabled.Project = "p"
+	enabled.Runner = &config.Runner{Enabled: true}
+
+	cases := []struct {
+		Name     string
+		Cmd      *baseCommand
+		Project  *pb.Project
+		Expected string
+	}{
+		{
+			"local flag",
+			&baseCommand{autoServer: true, flagLocal: true},
+			nil,
+			executionLocal,
+		},
+		{
+			"default",
+			&baseCommand{autoServer: true},
+			nil,
+			executionLocal,
+		},
+		{
+			"no auto server",
+			&baseCommand{},
+			nil,
+			executionRemote,
+		},
+		{
+			"runner not enabled",
+			&baseCommand{autoServer: true, flagRemote: true},
+			nil,
+			executionError,
+		},
+		{
+			"remote",
+			&baseCommand{autoServer: true, flagRemote: true, cfg: enabled},
+			&pb.Project{DataSource: git("https://github.com/hashicorp/waypoint.git")},
+			executionRemote,
+		},
+		{
+			"no data source",
+			&baseCommand{autoServer: true, flagRemote: true, cfg: enabled},
+			&pb.Project{},
+			executionError,
+		},
+		{
+			"misconfigured git falls back",
+			&baseCommand{
+				autoServer: true,
+				flagRemote: true,
+				cfg:        enabled,
+				refProject: &pb.Ref_Project{Project: "p"},
+			},
+			&pb.Project{DataSource: git("")},
+			executionLocal,
+		},
+		{
+			"misconfigured git without local config",
+			&baseCommand{
+				autoServer: true,
+				flagRemote: true,
+				cfg:        enabled,
+				refProject: &pb.Ref_Project{Project: "other"},
+			},
+			&pb.Project{DataSource: git("")},
+			executionError,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			execution, reason := tt.Cmd.executionFor(tt.Project)
+			require.Equal(t, tt.Expected, execution)
+			require.NotEmpty(t, reason)
+		})
+	}
+}
@@ -72,6 +72,7 @@ type ServerRunCommand struct {
 	flagAcceptTOS              bool
 	flagTLSCertFile            string
 	flagTLSKeyFile             string
+	flagTLSWatchInterval       time.Duration
 
 	flagTelemetryOpenCensusAgentAddr     string
 	flagTelemetryOpenCensusAgentInsecure bool
@@ -444,6 +445,16 @@ func (c *ServerRunCommand) Flags() *flag.Sets {
 			Default: "",
 		})
 
+		f.DurationVar(&flag.DurationVar{
+			Name:   "watch-interval",
+			Target: &c.flagTLSWatchInterval,
+			Usage: "Poll the -tls-cert-file and -tls-key-file for changes at this " +
+				"interval, such as \"30s\", rather than using filesystem events. " +
+				"Use this where events are unreliable, such as on network " +
+				"filesystems or Docker for Mac bind mounts. Polling is also used " +
+				"automatically if filesystem events are unavailable.",
+		})
+
 		f.BoolVar(&flag.BoolVar{
 			Name:    "disable-ui",
 			Target:  &c.flagDisableUI,
@@ -605,7 +616,8 @@ func (c *ServerRunCommand) listenerForConfig(log hclog.Logger, cfg *serverconfig
 
 	var tlsConfig *tls.Config
 	if certFile != "" {
-		w, err := cert.New(log, certFile, keyFile)
+		w, err := cert.New(log, certFile, keyFile,
+			cert.WithPollInterval(c.flagTLSWatchInterval))
 		if err != nil {
 			return nil, err
 		}
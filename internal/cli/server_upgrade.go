@@ -413,8 +413,9 @@ func (c *ServerUpgradeCommand) Flags() *flag.Sets {
 		f.BoolVar(&flag.BoolVar{
 			Name:    "snapshot",
 			Target:  &c.flagSnapshot,
-			Default: true,
-			Usage:   "Enable or disable taking a snapshot of Waypoint server prior to upgrades.",
+			Default: c.snapshotDefault(),
+			Usage: "Enable or disable taking a snapshot of Waypoint server prior to upgrades. " +
+				"This defaults to false if WAYPOINT_DISABLE_SNAPSHOT is set.",
 		})
 
 		// Add platforms in alphabetical order. A consistent order is important for repeatable doc generation.
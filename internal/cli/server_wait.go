@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+)
+
+type ServerWaitCommand struct {
+	*baseCommand
+
+	flagTimeout  time.Duration
+	flagInterval time.Duration
+}
+
+func (c *ServerWaitCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI. We
+	// create the client ourselves below since the server may not be up yet.
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(c.Flags()),
+		WithNoConfig(),
+		WithClient(false),
+		WithNoAutoServer(),
+	); err != nil {
+		return 1
+	}
+
+	if c.flagInterval <= 0 {
+		c.ui.Output("The -interval flag must be greater than zero.", terminal.WithErrorStyle())
+		return 1
+	}
+
+	sg := c.ui.StepGroup()
+	defer sg.Wait()
+
+	s := sg.Add("Waiting for the Waypoint server to become available...")
+	defer func() { s.Abort() }()
+
+	project, err := c.waitForServer(c.Ctx, c.flagTimeout, c.flagInterval,
+		func(attempt int, err error) {
+			c.Log.Debug("server not yet available", "attempt", attempt, "error", err)
+			s.Update("Waiting for the Waypoint server to become available (attempt %d)...", attempt+1)
+		})
+	if err != nil {
+		s.Update("Waypoint server is not available")
+		s.Status(terminal.StatusError)
+		s.Done()
+
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+	c.project = project
+
+	s.Update("Waypoint server is available (version %s)", project.ServerVersion().Version)
+	s.Done()
+
+	return 0
+}
+
+func (c *ServerWaitCommand) Flags() *flag.Sets {
+	return c.flagSet(flagSetConnection, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.DurationVar(&flag.DurationVar{
+			Name:    "timeout",
+			Target:  &c.flagTimeout,
+			Default: 5 * time.Minute,
+			Usage:   "Maximum time to wait for the server to become available.",
+		})
+
+		f.DurationVar(&flag.DurationVar{
+			Name:    "interval",
+			Target:  &c.flagInterval,
+			Default: 2 * time.Second,
+			Usage:   "Time to wait between each attempt to connect to the server.",
+		})
+	})
+}
+
+func (c *ServerWaitCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *ServerWaitCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *ServerWaitCommand) Synopsis() string {
+	return "Wait for the Waypoint server to become available"
+}
+
+func (c *ServerWaitCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint server wait [options]
+
+  Wait for the Waypoint server to become available.
+
+  This repeatedly attempts to connect to the server configured in the
+  current context, environment, or flags until the server responds or the
+  timeout elapses. This exits with a non-zero exit code if the server did
+  not become available in time. This is useful in startup scripts and init
+  containers where the server may start after the CLI.
+
+` + c.Flags().Help())
+}
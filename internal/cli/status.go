@@ -2,7 +2,7 @@ package cli
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -358,7 +358,7 @@ func (c *StatusCommand) refreshAppStatus(
 		return nil
 	})
 	if err != nil {
-		if err != ErrSentinel {
+		if !errors.Is(err, ErrSentinel) {
 			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
 		}
 
@@ -973,14 +973,7 @@ func (c *StatusCommand) outputJsonProjectStatus(t *terminal.Table) error {
 	projects := c.formatJsonMap(t)
 	output["Projects"] = projects
 
-	data, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	c.ui.Output(string(data))
-
-	return nil
+	return c.outputJSON(nil, output)
 }
 
 func (c *StatusCommand) outputJsonProjectAppStatus(
@@ -1012,14 +1005,7 @@ func (c *StatusCommand) outputJsonProjectAppStatus(
 	app := c.formatJsonMap(t)
 	output["Applications"] = app
 
-	data, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	c.ui.Output(string(data))
-
-	return nil
+	return c.outputJSON(nil, output)
 }
 
 func (c *StatusCommand) outputJsonAppStatus(
@@ -1067,14 +1053,7 @@ func (c *StatusCommand) outputJsonAppStatus(
 	releaseResourcesSummary := c.formatJsonMap(releaseResourcesTbl)
 	output["ReleasesResourcesSummary"] = releaseResourcesSummary
 
-	data, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	c.ui.Output(string(data))
-
-	return nil
+	return c.outputJSON(nil, output)
 }
 
 //
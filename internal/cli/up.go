@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"strings"
 
 	"google.golang.org/grpc/codes"
@@ -27,11 +28,24 @@ func (c *UpCommand) Run(args []string) int {
 		WithArgs(args),
 		WithFlags(c.Flags()),
 		WithMultipleApp(),
+		WithServerAppCheck(),
+		WithProtectedWorkspaceCheck(),
+		WithPhases(phaseBuild, phaseDeploy, phaseRelease),
 	); err != nil {
 		return 1
 	}
 
+	// The "up" operation always performs all phases, so if only some were
+	// selected we perform them one at a time.
+	allPhases := c.phaseEnabled(phaseBuild) &&
+		c.phaseEnabled(phaseDeploy) &&
+		c.phaseEnabled(phaseRelease)
+
 	err := c.DoApp(c.Ctx, func(ctx context.Context, app *clientpkg.App) error {
+		if !allPhases {
+			return c.legacyUp(ctx, app)
+		}
+
 		result, err := app.Up(ctx, &pb.Job_UpOp{
 			Release: &pb.Job_ReleaseOp{
 				Prune:               c.flagPrune,
@@ -46,8 +60,7 @@ func (c *UpCommand) Run(args []string) int {
 			return c.legacyUp(ctx, app)
 		}
 		if err != nil {
-			app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-			return ErrSentinel
+			return appOpError(app.UI, err)
 		}
 
 		// Common reused values
@@ -92,7 +105,7 @@ func (c *UpCommand) Run(args []string) int {
 	})
 
 	if err != nil {
-		if err != ErrSentinel {
+		if !errors.Is(err, ErrSentinel) {
 			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
 		}
 
@@ -124,6 +137,10 @@ func (c *UpCommand) legacyRequired(err error) bool {
 // introduced a remote "up" job type that we use instead. If the user uses
 // a new client but an old server that doesn't support the up job type, this
 // will be executed instead.
+//
+// This is also used when only some phases were selected with "-only", since
+// the "up" job type always performs all of them. Skipped phases use the
+// latest result of that phase instead.
 func (c *UpCommand) legacyUp(
 	ctx context.Context,
 	app *clientpkg.App,
@@ -131,35 +148,68 @@ func (c *UpCommand) legacyUp(
 	client := c.project.Client()
 
 	// Build it
-	app.UI.Output("Building...", terminal.WithHeaderStyle())
+	if c.phaseEnabled(phaseBuild) {
+		app.UI.Output("Building...", terminal.WithHeaderStyle())
 
-	_, err := app.Build(ctx, &pb.Job_BuildOp{})
-	if err != nil {
-		app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-		return ErrSentinel
+		_, err := app.Build(ctx, &pb.Job_BuildOp{})
+		if err != nil {
+			return appOpError(app.UI, err)
+		}
 	}
 
-	// Get the most recent pushed artifact
-	push, err := client.GetLatestPushedArtifact(ctx, &pb.GetLatestPushedArtifactRequest{
-		Application: app.Ref(),
-		Workspace:   c.project.WorkspaceRef(),
-	})
-	if err != nil {
-		app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-		return ErrSentinel
+	if !c.phaseEnabled(phaseDeploy) && !c.phaseEnabled(phaseRelease) {
+		app.UI.Output("")
+		app.UI.Output("The build completed successfully.", terminal.WithSuccessStyle())
+		return nil
 	}
 
-	// Push it
-	app.UI.Output("Deploying...", terminal.WithHeaderStyle())
+	var deployment *pb.Deployment
+	if c.phaseEnabled(phaseDeploy) {
+		// Get the most recent pushed artifact
+		push, err := client.GetLatestPushedArtifact(ctx, &pb.GetLatestPushedArtifactRequest{
+			Application: app.Ref(),
+			Workspace:   c.project.WorkspaceRef(),
+		})
+		if err != nil {
+			return appOpError(app.UI, err)
+		}
 
-	result, err := app.Deploy(ctx, &pb.Job_DeployOp{
-		Artifact: push,
-	})
-	if err != nil {
-		app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-		return ErrSentinel
+		// Push it
+		app.UI.Output("Deploying...", terminal.WithHeaderStyle())
+
+		result, err := app.Deploy(ctx, &pb.Job_DeployOp{
+			Artifact: push,
+		})
+		if err != nil {
+			return appOpError(app.UI, err)
+		}
+		deployment = result.Deployment
+	} else {
+		// Release the latest deployment
+		resp, err := client.ListDeployments(ctx, &pb.ListDeploymentsRequest{
+			Application:   app.Ref(),
+			Workspace:     c.project.WorkspaceRef(),
+			PhysicalState: pb.Operation_CREATED,
+			Order: &pb.OperationOrder{
+				Limit: 1,
+				Order: pb.OperationOrder_COMPLETE_TIME,
+				Desc:  true,
+			},
+		})
+		if err != nil {
+			return appOpError(app.UI, err)
+		}
+		if len(resp.Deployments) == 0 {
+			app.UI.Output(strings.TrimSpace(releaseNoDeploys), terminal.WithErrorStyle())
+			return ErrSentinel
+		}
+		deployment = resp.Deployments[0]
+	}
+
+	var deployUrl string
+	if deployment.Preload != nil {
+		deployUrl = deployment.Preload.DeployUrl
 	}
-	deployUrl := result.Deployment.Preload.DeployUrl
 
 	// Try to get the hostname
 	var hostname *pb.Hostname
@@ -167,8 +217,8 @@ func (c *UpCommand) legacyUp(
 		Target: &pb.Hostname_Target{
 			Target: &pb.Hostname_Target_Application{
 				Application: &pb.Hostname_TargetApp{
-					Application: result.Deployment.Application,
-					Workspace:   result.Deployment.Workspace,
+					Application: deployment.Application,
+					Workspace:   deployment.Workspace,
 				},
 			},
 		},
@@ -178,17 +228,21 @@ func (c *UpCommand) legacyUp(
 	}
 
 	// We're releasing, do that too.
-	app.UI.Output("Releasing...", terminal.WithHeaderStyle())
-	releaseResult, err := app.Release(ctx, &pb.Job_ReleaseOp{
-		Deployment: result.Deployment,
-		Prune:      true,
-	})
-	if err != nil {
-		app.UI.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
-		return ErrSentinel
-	}
+	var releaseUrl string
+	if c.phaseEnabled(phaseRelease) {
+		app.UI.Output("Releasing...", terminal.WithHeaderStyle())
+		releaseResult, err := app.Release(ctx, &pb.Job_ReleaseOp{
+			Deployment:          deployment,
+			Prune:               c.flagPrune,
+			PruneRetain:         int32(c.flagPruneRetain),
+			PruneRetainOverride: c.flagPruneRetain >= 0,
+		})
+		if err != nil {
+			return appOpError(app.UI, err)
+		}
 
-	releaseUrl := releaseResult.Release.Url
+		releaseUrl = releaseResult.Release.Url
+	}
 
 	// Output
 	app.UI.Output("")
@@ -245,5 +299,10 @@ Usage: waypoint up [options]
 
   Perform the build, deploy, and release steps.
 
+  Use -only to perform a subset of the steps, such as "-only=build,deploy"
+  to build and deploy without releasing. Skipped steps use the latest
+  result of that step, for example the latest build is deployed when
+  "-only=deploy" is set.
+
 ` + c.Flags().Help())
 }
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/posener/complete"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/config/variables"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+)
+
+type VarFilesCommand struct {
+	*baseCommand
+
+	flagJson bool
+}
+
+// varFile is a var file that variable values are loaded from.
+type varFile struct {
+	Path string `json:"path"`
+
+	// Source is "auto" for *.auto.wpvars(.json) files and "var-file" for
+	// files set with -var-file.
+	Source string `json:"source"`
+}
+
+func (c *VarFilesCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	flagSet := c.Flags()
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(flagSet),
+		WithNoConfig(),
+		WithClient(false),
+	); err != nil {
+		return 1
+	}
+
+	// Auto files are loaded from the directory with the configuration, or
+	// the working directory if there is no configuration.
+	dir, err := os.Getwd()
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+	path, err := c.initConfigPath("")
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+	if path != "" {
+		dir = filepath.Dir(path)
+	}
+
+	// Build the list from lowest to highest precedence.
+	files := []*varFile{}
+	// Only the values of the last auto file in the directory are used.
+	if auto := variables.AutoVarFiles(dir); len(auto) > 0 {
+		files = append(files, &varFile{Path: auto[len(auto)-1], Source: "auto"})
+	}
+	for _, f := range c.autoVarFiles {
+		files = append(files, &varFile{Path: f, Source: "auto"})
+	}
+	for _, f := range c.varFiles {
+		files = append(files, &varFile{Path: f, Source: "var-file"})
+	}
+
+	if c.flagJson {
+		if err := c.outputJSON(nil, files); err != nil {
+			c.ui.Output("Error rendering json: %s", err, terminal.WithErrorStyle())
+			return 1
+		}
+
+		return 0
+	}
+
+	if len(files) == 0 {
+		c.ui.Output("No var files found.", terminal.WithInfoStyle())
+		return 0
+	}
+
+	c.ui.Output("Var files, from lowest to highest precedence", terminal.WithHeaderStyle())
+	tbl := terminal.NewTable("", "Source", "Path")
+	for i, f := range files {
+		tbl.Rich([]string{strconv.Itoa(i + 1), f.Source, f.Path}, nil)
+	}
+	c.ui.Table(tbl)
+
+	return 0
+}
+
+func (c *VarFilesCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.StringSliceVar(&flag.StringSliceVar{
+			Name:   "var-file",
+			Target: &c.flagVarFile,
+			Usage: "HCL or JSON file containing variable values, as it would " +
+				"be given to an operation. Can be specified multiple times.",
+		})
+
+		c.addAutoVarDirFlag(f)
+
+		f.BoolVar(&flag.BoolVar{
+			Name:    "json",
+			Target:  &c.flagJson,
+			Usage:   "Output the var files in JSON format.",
+			Default: false,
+		})
+	})
+}
+
+func (c *VarFilesCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *VarFilesCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *VarFilesCommand) Synopsis() string {
+	return "List the var files that variable values are loaded from."
+}
+
+func (c *VarFilesCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint var-files [options]
+
+  List the var files that variable values are loaded from, in the order
+  of precedence. Values from files later in the list override values from
+  earlier files.
+
+  This includes the "*.auto.wpvars" or "*.auto.wpvars.json" files that are
+  loaded automatically and the files given with -var-file. If there are
+  several auto files next to the waypoint.hcl, only the values of the last
+  one in lexical order are used, so only that one is listed. All of the
+  auto files in a directory given with -auto-var-dir are used.
+  Values from WP_VAR_* environment variables and -var flags aren't from
+  files and so aren't listed.
+
+  Note that for remote operations the runner loads auto files from the
+  project's data source, which may differ from the local files.
+
+` + c.Flags().Help())
+}
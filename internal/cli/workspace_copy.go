@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/posener/complete"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	"github.com/hashicorp/waypoint/internal/clierrors"
+	"github.com/hashicorp/waypoint/internal/pkg/flag"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+type WorkspaceCopyCommand struct {
+	*baseCommand
+
+	flagFrom  string
+	flagTo    string
+	flagForce bool
+}
+
+func (c *WorkspaceCopyCommand) Run(args []string) int {
+	// Initialize. If we fail, we just exit since Init handles the UI.
+	flagSet := c.Flags()
+	if err := c.Init(
+		WithArgs(args),
+		WithFlags(flagSet),
+		WithNoConfig(),
+	); err != nil {
+		return 1
+	}
+
+	if c.flagTo == "" || len(flagSet.Args()) > 0 {
+		c.ui.Output(c.Help(), terminal.WithErrorStyle())
+		return 1
+	}
+
+	// Default the source to the current workspace
+	from := c.flagFrom
+	if from == "" {
+		from = c.refWorkspace.Workspace
+	}
+	if from == c.flagTo {
+		c.ui.Output("The -from and -to workspaces must be different.", terminal.WithErrorStyle())
+		return 1
+	}
+
+	client := c.project.Client()
+	source, err := getWorkspace(c.Ctx, client, from)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	// Refuse to copy into a workspace that is already in use.
+	if !c.flagForce {
+		resp, err := client.GetWorkspace(c.Ctx, &pb.GetWorkspaceRequest{
+			Workspace: &pb.Ref_Workspace{Workspace: c.flagTo},
+		})
+		if err != nil && status.Code(err) != codes.NotFound {
+			c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+			return 1
+		}
+		if len(resp.GetWorkspace().GetProjects()) > 0 {
+			c.ui.Output(errWorkspaceCopyNotEmpty, c.flagTo, terminal.WithErrorStyle())
+			return 1
+		}
+	}
+
+	// Collect the config vars that are scoped to the source workspace for
+	// every project in it.
+	vars, existing, err := workspaceCopyVars(c.Ctx, client, source.Projects, from, c.flagTo)
+	if err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+	if existing > 0 && !c.flagForce {
+		c.ui.Output(errWorkspaceCopyNotEmpty, c.flagTo, terminal.WithErrorStyle())
+		return 1
+	}
+
+	if len(vars) == 0 {
+		c.ui.Output("No workspace-scoped configuration found in workspace %q to copy.",
+			from, terminal.WithWarningStyle())
+		return 0
+	}
+
+	if _, err := client.SetConfig(c.Ctx, &pb.ConfigSetRequest{Variables: vars}); err != nil {
+		c.ui.Output(clierrors.Humanize(err), terminal.WithErrorStyle())
+		return 1
+	}
+
+	c.ui.Output("Copied to workspace %q", c.flagTo, terminal.WithHeaderStyle())
+	tbl := terminal.NewTable("Scope", "Name")
+	for _, cv := range vars {
+		tbl.Rich([]string{
+			workspaceCopyScope(cv.Target),
+			cv.Name,
+		}, nil)
+	}
+	c.ui.Table(tbl)
+	c.ui.Output("Copied %d configuration variable(s) from workspace %q to %q.",
+		len(vars), from, c.flagTo, terminal.WithSuccessStyle())
+
+	return 0
+}
+
+// workspaceCopyVars returns copies of the config vars that are scoped to
+// the from workspace for every project, retargeted to the to workspace.
+// This also returns the number of vars already scoped to the to workspace.
+func workspaceCopyVars(
+	ctx context.Context,
+	client pb.WaypointClient,
+	projects []*pb.Workspace_Project,
+	from, to string,
+) ([]*pb.ConfigVar, int, error) {
+	// We request config for all workspaces so that we can also detect any
+	// config already set for the target workspace.
+	var vars []*pb.ConfigVar
+	var existing int
+	for _, wp := range projects {
+		resp, err := client.GetConfig(ctx, &pb.ConfigGetRequest{
+			Scope: &pb.ConfigGetRequest_Project{
+				Project: wp.Project,
+			},
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, cv := range resp.Variables {
+			switch cv.Target.GetWorkspace().GetWorkspace() {
+			case from:
+				copied := proto.Clone(cv).(*pb.ConfigVar)
+				copied.Target.Workspace = &pb.Ref_Workspace{Workspace: to}
+				vars = append(vars, copied)
+
+			case to:
+				existing++
+			}
+		}
+	}
+
+	return vars, existing, nil
+}
+
+// workspaceCopyScope returns a human-friendly description of the app scope
+// of a config var target.
+func workspaceCopyScope(t *pb.ConfigVar_Target) string {
+	switch scope := t.AppScope.(type) {
+	case *pb.ConfigVar_Target_Project:
+		return fmt.Sprintf("project %s", scope.Project.Project)
+
+	case *pb.ConfigVar_Target_Application:
+		return fmt.Sprintf("app %s/%s", scope.Application.Project, scope.Application.Application)
+
+	default:
+		return "global"
+	}
+}
+
+func (c *WorkspaceCopyCommand) Flags() *flag.Sets {
+	return c.flagSet(0, func(set *flag.Sets) {
+		f := set.NewSet("Command Options")
+		f.StringVar(&flag.StringVar{
+			Name:   "from",
+			Target: &c.flagFrom,
+			Usage:  "Workspace to copy from. Defaults to the current workspace.",
+		})
+
+		f.StringVar(&flag.StringVar{
+			Name:   "to",
+			Target: &c.flagTo,
+			Usage:  "Workspace to copy to. This is required.",
+		})
+
+		f.BoolVar(&flag.BoolVar{
+			Name:   "force",
+			Target: &c.flagForce,
+			Usage:  "Copy even if the target workspace is already in use.",
+		})
+	})
+}
+
+func (c *WorkspaceCopyCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *WorkspaceCopyCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *WorkspaceCopyCommand) Synopsis() string {
+	return "Copy configuration from one workspace to another."
+}
+
+func (c *WorkspaceCopyCommand) Help() string {
+	return formatHelp(`
+Usage: waypoint workspace copy -to=<name> [options]
+
+  Copy the workspace-scoped configuration of every project in a workspace
+  to another workspace. This is useful to bootstrap a new workspace, such
+  as "prod-canary", from an existing one, such as "prod".
+
+  Only configuration variables that are set specifically for the source
+  workspace are copied. Deployments and other operation history are not.
+
+  If the target workspace is already in use, this will refuse to copy
+  unless -force is set. With -force, copied configuration variables
+  overwrite any with the same name and scope in the target workspace.
+
+` + c.Flags().Help())
+}
+
+const errWorkspaceCopyNotEmpty = `The workspace %q is already in use. Copying into it could overwrite
+its configuration. Rerun the command with -force to copy anyway.`
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess"
+)
+
+func TestWorkspaceCopyVars(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	client := singleprocess.TestServer(t)
+	projectVar := func(project, workspace, name string) *pb.ConfigVar {
+		target := &pb.ConfigVar_Target{
+			AppScope: &pb.ConfigVar_Target_Project{
+				Project: &pb.Ref_Project{Project: project},
+			},
+		}
+		if workspace != "" {
+			target.Workspace = &pb.Ref_Workspace{Workspace: workspace}
+		}
+
+		return &pb.ConfigVar{
+			Target: target,
+			Name:   name,
+			Value:  &pb.ConfigVar_Static{Static: "value"},
+		}
+	}
+
+	_, err := client.SetConfig(ctx, &pb.ConfigSetRequest{
+		Variables: []*pb.ConfigVar{
+			projectVar("a", "dev", "DATABASE_URL"),
+			projectVar("a", "", "LOG_LEVEL"),
+			projectVar("b", "dev", "API_KEY"),
+			projectVar("b", "prod", "API_KEY"),
+		},
+	})
+	require.NoError(err)
+
+	projects := []*pb.Workspace_Project{
+		{Project: &pb.Ref_Project{Project: "a"}},
+		{Project: &pb.Ref_Project{Project: "b"}},
+	}
+
+	// Only vars scoped to the source workspace are copied, retargeted to
+	// the new workspace.
+	vars, existing, err := workspaceCopyVars(ctx, client, projects, "dev", "staging")
+	require.NoError(err)
+	require.Equal(0, existing)
+	require.Len(vars, 2)
+	for _, cv := range vars {
+		require.Equal("staging", cv.Target.Workspace.Workspace)
+	}
+	require.Equal("DATABASE_URL", vars[0].Name)
+	require.Equal("project a", workspaceCopyScope(vars[0].Target))
+	require.Equal("API_KEY", vars[1].Name)
+	require.Equal("project b", workspaceCopyScope(vars[1].Target))
+
+	// Vars already scoped to the target workspace are counted.
+	vars, existing, err = workspaceCopyVars(ctx, client, projects, "dev", "prod")
+	require.NoError(err)
+	require.Equal(1, existing)
+	require.Len(vars, 2)
+}
@@ -96,7 +96,7 @@ func (c *WorkspaceInspectCommand) Flags() *flag.Sets {
 }
 
 func (c *WorkspaceInspectCommand) AutocompleteArgs() complete.Predictor {
-	return complete.PredictNothing
+	return predictWorkspaces()
 }
 
 func (c *WorkspaceInspectCommand) AutocompleteFlags() complete.Flags {
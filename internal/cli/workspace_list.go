@@ -44,6 +44,9 @@ func (c *WorkspaceListCommand) Run(args []string) int {
 		result = append(result, p.Name)
 	}
 
+	// Remember the workspaces for completion and validation later.
+	c.cacheWorkspaces(result)
+
 	if len(result) == 0 {
 		c.ui.Output("No workspaces found.")
 		return 0
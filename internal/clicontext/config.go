@@ -1,8 +1,13 @@
 package clicontext
 
 import (
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2/gohcl"
@@ -22,6 +27,11 @@ type Config struct {
 	// can be set instead of using the -workspace CLI flag. If empty, the
 	// default value is "default"
 	Workspace string `hcl:"workspace,optional"`
+
+	// DataSourceRef is the default ref to use for projects that use a Git
+	// data source when running remote operations. This is equivalent to
+	// "-remote-source=ref=<value>" and an explicit flag always wins.
+	DataSourceRef string `hcl:"datasource_ref,optional"`
 }
 
 // LoadPath loads a context configuration from a filepath.
@@ -31,12 +41,85 @@ func LoadPath(path string) (*Config, error) {
 	return &cfg, err
 }
 
+// LoadDir loads a context configuration from a directory with a file for
+// each field, as is common for secrets mounted into containers by Docker or
+// Kubernetes. The files are:
+//
+//   - server-addr: the server address (required)
+//   - server-tls: "true" or "false" to connect with TLS (default true)
+//   - server-tls-skip-verify: "true" or "false" (default false)
+//   - token: the auth token, if the server requires auth
+//
+// Leading and trailing whitespace in each file is ignored.
+func LoadDir(dir string) (*Config, error) {
+	read := func(name string) (string, bool, error) {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+
+		return strings.TrimSpace(string(data)), true, nil
+	}
+	readBool := func(name string, def bool) (bool, error) {
+		v, ok, err := read(name)
+		if err != nil || !ok {
+			return def, err
+		}
+
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, fmt.Errorf(
+				"The file %q must contain \"true\" or \"false\", got %q.",
+				filepath.Join(dir, name), v)
+		}
+
+		return b, nil
+	}
+
+	var cfg Config
+	addr, ok, err := read("server-addr")
+	if err != nil {
+		return nil, err
+	}
+	if !ok || addr == "" {
+		return nil, fmt.Errorf(
+			"The server config directory %q must contain a \"server-addr\" file "+
+				"with the address of the server.", dir)
+	}
+	cfg.Server.Address = addr
+
+	if cfg.Server.Tls, err = readBool("server-tls", true); err != nil {
+		return nil, err
+	}
+	if cfg.Server.TlsSkipVerify, err = readBool("server-tls-skip-verify", false); err != nil {
+		return nil, err
+	}
+
+	token, ok, err := read("token")
+	if err != nil {
+		return nil, err
+	}
+	if ok && token != "" {
+		cfg.Server.RequireAuth = true
+		cfg.Server.AuthToken = token
+	}
+
+	return &cfg, nil
+}
+
 // FromURL parses a URL to a Waypoint server and populates as much of the
 // context configuration as possible. This makes a number of assumptions:
 //
 //   - assumes TLS
 //   - assumes TLS skip verify
 //
+// A URL with the "srv" scheme, such as "srv://_waypoint._tcp.example.com",
+// is kept as-is so that the server address is resolved from DNS SRV records
+// each time we connect.
+//
 // The skip verify bit is a bad default but it is the most common UX
 // getting started and this URL is most commonly used with `waypoint login`
 // so we want to provide the smoothest experience there at the expense
@@ -64,6 +147,13 @@ func (c *Config) FromURL(v string) error {
 	// We then only override the address if we're sure we got a better value.
 	c.Server.Address = v
 
+	// An "srv" URL is resolved using DNS SRV records when connecting, so
+	// we keep the scheme and don't default the port since the record has it.
+	if u.Scheme == "srv" {
+		c.Server.Address = "srv://" + u.Host
+		return nil
+	}
+
 	// Override
 	if u.Host != "" {
 		c.Server.Address = u.Host
@@ -1,6 +1,9 @@
 package clicontext
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/hashicorp/waypoint/internal/serverconfig"
@@ -37,6 +40,18 @@ func TestConfigFromURL(t *testing.T) {
 			},
 		},
 
+		{
+			"SRV record",
+			"srv://_waypoint._tcp.example.com",
+			Config{
+				Server: serverconfig.Client{
+					Address:       "srv://_waypoint._tcp.example.com",
+					Tls:           true,
+					TlsSkipVerify: true,
+				},
+			},
+		},
+
 		{
 			"IP only",
 			"127.1.2.3",
@@ -97,3 +112,88 @@ func TestConfigFromURL(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadDir(t *testing.T) {
+	write := func(t *testing.T, dir string, files map[string]string) {
+		for name, v := range files {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(v), 0600))
+		}
+	}
+
+	t.Run("all fields", func(t *testing.T) {
+		require := require.New(t)
+
+		td, err := ioutil.TempDir("", "waypoint-test")
+		require.NoError(err)
+		defer os.RemoveAll(td)
+
+		write(t, td, map[string]string{
+			"server-addr":            " foo.com:1234\n",
+			"server-tls":             "false\n",
+			"server-tls-skip-verify": "true",
+			"token":                  "abc\n",
+		})
+
+		cfg, err := LoadDir(td)
+		require.NoError(err)
+		require.Equal(&Config{
+			Server: serverconfig.Client{
+				Address:       "foo.com:1234",
+				Tls:           false,
+				TlsSkipVerify: true,
+				RequireAuth:   true,
+				AuthToken:     "abc",
+			},
+		}, cfg)
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		require := require.New(t)
+
+		td, err := ioutil.TempDir("", "waypoint-test")
+		require.NoError(err)
+		defer os.RemoveAll(td)
+
+		write(t, td, map[string]string{"server-addr": "foo.com:1234"})
+
+		cfg, err := LoadDir(td)
+		require.NoError(err)
+		require.Equal(&Config{
+			Server: serverconfig.Client{
+				Address: "foo.com:1234",
+				Tls:     true,
+			},
+		}, cfg)
+	})
+
+	t.Run("missing address", func(t *testing.T) {
+		require := require.New(t)
+
+		td, err := ioutil.TempDir("", "waypoint-test")
+		require.NoError(err)
+		defer os.RemoveAll(td)
+
+		write(t, td, map[string]string{"token": "abc"})
+
+		_, err = LoadDir(td)
+		require.Error(err)
+		require.Contains(err.Error(), "server-addr")
+	})
+
+	t.Run("invalid bool", func(t *testing.T) {
+		require := require.New(t)
+
+		td, err := ioutil.TempDir("", "waypoint-test")
+		require.NoError(err)
+		defer os.RemoveAll(td)
+
+		write(t, td, map[string]string{
+			"server-addr": "foo.com:1234",
+			"server-tls":  "maybe",
+		})
+
+		_, err = LoadDir(td)
+		require.Error(err)
+		require.Contains(err.Error(), "server-tls")
+	})
+}
@@ -13,6 +13,7 @@ type App struct {
 
 	project     *Project
 	application *pb.Ref_Application
+	variables   []*pb.Variable
 }
 
 // App returns the app-specific operations client.
@@ -24,6 +25,7 @@ func (c *Project) App(n string) *App {
 			Project:     c.project.Project,
 			Application: n,
 		},
+		variables: c.appVariables[n],
 	}
 }
 
@@ -33,10 +35,14 @@ func (c *App) Ref() *pb.Ref_Application {
 }
 
 // job is the same as Project.job except this also sets the application
-// reference.
+// reference and any app-specific variables, which replace the variables
+// of the project. See WithAppVariables.
 func (c *App) job() *pb.Job {
 	job := c.project.job()
 	job.Application = c.application
+	if c.variables != nil {
+		job.Variables = c.variables
+	}
 	return job
 }
 
@@ -3,10 +3,8 @@ package client
 import (
 	"context"
 	"fmt"
-	"io"
 	"time"
 
-	"github.com/hashicorp/go-hclog"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -80,6 +78,18 @@ func (c *Project) doJobMonitored(ctx context.Context, job *pb.Job, ui terminal.U
 		}
 	}
 
+	// If we're only printing jobs then we stop short of queueing it.
+	if c.printJobWriter != nil {
+		return nil, c.printJob(job)
+	}
+
+	// Export the job before queueing it if requested.
+	if c.exportJobWriter != nil {
+		if err := writeJob(c.exportJobWriter, job); err != nil {
+			return nil, err
+		}
+	}
+
 	return c.queueAndStreamJob(ctx, job, ui, monCh)
 }
 
@@ -112,6 +122,10 @@ func (c *Project) queueAndStreamJob(
 	}
 	log = log.With("job_id", queueResp.JobId)
 
+	// Track the job until we see it complete so that it can be canceled
+	// if we're interrupted. See IncompleteJobs.
+	c.trackJob(queueResp.JobId, job)
+
 	// Get the stream
 	log.Debug("opening job stream")
 	stream, err := c.client.GetJobStream(ctx, &pb.GetJobStreamRequest{
@@ -132,24 +146,21 @@ func (c *Project) queueAndStreamJob(
 			resp.Event)
 	}
 
-	type stepData struct {
-		terminal.Step
-
-		out io.Writer
-	}
-
 	// Process events
 	var (
 		completed bool
 
 		stateEventTimer *time.Timer
-		tstatus         terminal.Status
+	)
 
-		stdout, stderr io.Writer
+	out := newTerminalOutput(ui, log)
+	defer out.Close()
 
-		sg    terminal.StepGroup
-		steps = map[int32]*stepData{}
-	)
+	defer func() {
+		if completed {
+			c.untrackJob(queueResp.JobId)
+		}
+	}()
 
 	if c.local {
 		defer func() {
@@ -170,6 +181,7 @@ func (c *Project) queueAndStreamJob(
 				log.Warn("error canceling job", "err", err)
 			} else {
 				log.Info("job cancelled successfully")
+				c.untrackJob(queueResp.JobId)
 			}
 		}()
 	}
@@ -211,111 +223,8 @@ func (c *Project) queueAndStreamJob(
 				continue
 			}
 
-			for _, ev := range event.Terminal.Events {
-				log.Trace("job terminal output", "event", ev)
-
-				switch ev := ev.Event.(type) {
-				case *pb.GetJobStreamResponse_Terminal_Event_Line_:
-					ui.Output(ev.Line.Msg, terminal.WithStyle(ev.Line.Style))
-				case *pb.GetJobStreamResponse_Terminal_Event_NamedValues_:
-					var values []terminal.NamedValue
-
-					for _, tnv := range ev.NamedValues.Values {
-						values = append(values, terminal.NamedValue{
-							Name:  tnv.Name,
-							Value: tnv.Value,
-						})
-					}
-
-					ui.NamedValues(values)
-				case *pb.GetJobStreamResponse_Terminal_Event_Status_:
-					if tstatus == nil {
-						tstatus = ui.Status()
-						defer tstatus.Close()
-					}
-
-					if ev.Status.Msg == "" && !ev.Status.Step {
-						tstatus.Close()
-					} else if ev.Status.Step {
-						tstatus.Step(ev.Status.Status, ev.Status.Msg)
-					} else {
-						tstatus.Update(ev.Status.Msg)
-					}
-				case *pb.GetJobStreamResponse_Terminal_Event_Raw_:
-					if stdout == nil {
-						stdout, stderr, err = ui.OutputWriters()
-						if err != nil {
-							return nil, err
-						}
-					}
-
-					if ev.Raw.Stderr {
-						stderr.Write(ev.Raw.Data)
-					} else {
-						stdout.Write(ev.Raw.Data)
-					}
-				case *pb.GetJobStreamResponse_Terminal_Event_Table_:
-					tbl := terminal.NewTable(ev.Table.Headers...)
-
-					for _, row := range ev.Table.Rows {
-						var trow []terminal.TableEntry
-
-						for _, ent := range row.Entries {
-							trow = append(trow, terminal.TableEntry{
-								Value: ent.Value,
-								Color: ent.Color,
-							})
-						}
-					}
-
-					ui.Table(tbl)
-				case *pb.GetJobStreamResponse_Terminal_Event_StepGroup_:
-					if sg != nil {
-						sg.Wait()
-					}
-
-					if !ev.StepGroup.Close {
-						sg = ui.StepGroup()
-					}
-				case *pb.GetJobStreamResponse_Terminal_Event_Step_:
-					if sg == nil {
-						continue
-					}
-
-					step, ok := steps[ev.Step.Id]
-					if !ok {
-						step = &stepData{
-							Step: sg.Add(ev.Step.Msg),
-						}
-						steps[ev.Step.Id] = step
-					} else {
-						if ev.Step.Msg != "" {
-							step.Update(ev.Step.Msg)
-						}
-					}
-
-					if ev.Step.Status != "" {
-						if ev.Step.Status == terminal.StatusAbort {
-							step.Abort()
-						} else {
-							step.Status(ev.Step.Status)
-						}
-					}
-
-					if len(ev.Step.Output) > 0 {
-						if step.out == nil {
-							step.out = step.TermOutput()
-						}
-
-						step.out.Write(ev.Step.Output)
-					}
-
-					if ev.Step.Close {
-						step.Done()
-					}
-				default:
-					c.logger.Error("Unknown terminal event seen", "type", hclog.Fmt("%T", ev))
-				}
+			if err := out.Write(event.Terminal.Events); err != nil {
+				return nil, err
 			}
 		case *pb.GetJobStreamResponse_State_:
 			// Stop any state event timers if we have any since the state
@@ -332,7 +241,7 @@ func (c *Project) queueAndStreamJob(
 				stateEventTimer = time.AfterFunc(stateEventPause, func() {
 					ui.Output("Operation is queued. Waiting for runner assignment...",
 						terminal.WithHeaderStyle())
-					ui.Output("If you interrupt this command, the job will still run in the background.",
+					ui.Output("If you interrupt this command, the job will be canceled.",
 						terminal.WithInfoStyle())
 				})
 
@@ -340,7 +249,7 @@ func (c *Project) queueAndStreamJob(
 				stateEventTimer = time.AfterFunc(stateEventPause, func() {
 					ui.Output("Operation is assigned to a runner. Waiting for start...",
 						terminal.WithHeaderStyle())
-					ui.Output("If you interrupt this command, the job will still run in the background.",
+					ui.Output("If you interrupt this command, the job will be canceled.",
 						terminal.WithInfoStyle())
 				})
 			}
@@ -360,6 +269,39 @@ func (c *Project) queueAndStreamJob(
 	}
 }
 
+// IncompleteJobs returns the jobs queued by this client, keyed by job ID,
+// that it didn't see complete. This happens if the context is canceled
+// while waiting on a job, for example because the user interrupted the
+// CLI, in which case the job may still be running on the server. Jobs
+// that this client already canceled are not included.
+func (c *Project) IncompleteJobs() map[string]*pb.Job {
+	c.incompleteJobsLock.Lock()
+	defer c.incompleteJobsLock.Unlock()
+
+	result := make(map[string]*pb.Job, len(c.incompleteJobs))
+	for id, job := range c.incompleteJobs {
+		result[id] = job
+	}
+
+	return result
+}
+
+func (c *Project) trackJob(id string, job *pb.Job) {
+	c.incompleteJobsLock.Lock()
+	defer c.incompleteJobsLock.Unlock()
+
+	if c.incompleteJobs == nil {
+		c.incompleteJobs = map[string]*pb.Job{}
+	}
+	c.incompleteJobs[id] = job
+}
+
+func (c *Project) untrackJob(id string) {
+	c.incompleteJobsLock.Lock()
+	defer c.incompleteJobsLock.Unlock()
+	delete(c.incompleteJobs, id)
+}
+
 // The time here is meant to encompass the typical case for an operation to begin.
 // With the introduction of ondemand runners, we bumped it up from 1500 to 3000
 // to accomidate the additional time before the job was picked up when testing in
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// jobLogsIdle is how long JobLogs waits for more stored output from a job
+// that is still running before it stops, if it isn't following the job.
+// The server sends all of the stored output at once when the stream opens,
+// so this only needs to cover the network latency.
+const jobLogsIdle = 1 * time.Second
+
+// JobLogs writes the output of the job with the given ID to the UI. This
+// can be any job on the server, not only those queued by this client.
+//
+// The output of a completed job is replayed in full. For a job that hasn't
+// completed, this writes the output so far, or if follow is true, streams
+// the output until the job completes. This returns the job as of the last
+// output so that the caller can report its state.
+func (c *Project) JobLogs(
+	ctx context.Context,
+	id string,
+	ui terminal.UI,
+	follow bool,
+) (*pb.Job, error) {
+	log := c.logger.With("job_id", id)
+
+	job, err := c.client.GetJob(ctx, &pb.GetJobRequest{JobId: id})
+	if status.Code(err) == codes.NotFound {
+		return nil, status.Errorf(codes.NotFound,
+			"No job with ID %q was found. The ID may be wrong, or the server may\n"+
+				"have pruned the job since it completed.", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// If we're not following a running job, we stop reading once the
+	// stored output has been sent, which we can only detect by the stream
+	// going idle.
+	completed := job.State == pb.Job_SUCCESS || job.State == pb.Job_ERROR
+	var idle *time.Timer
+	if !completed && !follow {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+
+		idle = time.AfterFunc(jobLogsIdle, cancel)
+		defer idle.Stop()
+	}
+
+	stream, err := c.client.GetJobStream(ctx, &pb.GetJobStreamRequest{
+		JobId: id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := newTerminalOutput(ui, log)
+	defer out.Close()
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			// If we canceled the stream because it went idle, we're done.
+			if !completed && !follow && status.Code(err) == codes.Canceled {
+				return job, nil
+			}
+
+			return nil, err
+		}
+
+		switch event := resp.Event.(type) {
+		case *pb.GetJobStreamResponse_Open_:
+			log.Debug("job stream opened")
+
+		case *pb.GetJobStreamResponse_Terminal_:
+			if !follow && !completed && !event.Terminal.Buffered {
+				// This is new output, so we've seen all the stored output.
+				return job, nil
+			}
+
+			if err := out.Write(event.Terminal.Events); err != nil {
+				return nil, err
+			}
+
+		case *pb.GetJobStreamResponse_Job:
+			job = event.Job.Job
+
+		case *pb.GetJobStreamResponse_Complete_:
+			// Refresh the job so that the caller sees its final state.
+			if v, err := c.client.GetJob(ctx, &pb.GetJobRequest{JobId: id}); err == nil {
+				job = v
+			}
+
+			return job, nil
+
+		case *pb.GetJobStreamResponse_Error_:
+			st := status.FromProto(event.Error.Error)
+			log.Warn("job stream failure", "code", st.Code(), "message", st.Message())
+			return nil, st.Err()
+		}
+
+		if idle != nil {
+			idle.Reset(jobLogsIdle)
+		}
+	}
+}
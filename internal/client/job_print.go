@@ -0,0 +1,64 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/hashicorp/waypoint/internal/runner"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// ErrJobPrinted is returned by any operation when the client was created
+// with WithPrintJob. The job was written to the configured writer but was
+// never queued on the server.
+var ErrJobPrinted = errors.New("job was printed and not executed")
+
+// redactedValue replaces variable values when printing jobs.
+const redactedValue = "<redacted>"
+
+// PrintedJobs returns the number of jobs that were printed rather than
+// executed because the client was created with WithPrintJob.
+func (c *Project) PrintedJobs() int {
+	return int(atomic.LoadInt32(&c.printedJobs))
+}
+
+// printJob writes the job as JSON to the configured writer instead of
+// queueing it.
+func (c *Project) printJob(job *pb.Job) error {
+	if err := writeJob(c.printJobWriter, job); err != nil {
+		return err
+	}
+
+	atomic.AddInt32(&c.printedJobs, 1)
+	return ErrJobPrinted
+}
+
+// writeJob writes the job as JSON to w. Variable values are redacted since
+// they can hold secrets, but the names and sources are kept to aid
+// debugging. Sensitive runner env labels only name the runner env var with
+// the value, but are redacted as well since they're about secrets.
+func writeJob(w io.Writer, job *pb.Job) error {
+	job = proto.Clone(job).(*pb.Job)
+	for _, v := range job.Variables {
+		v.Value = &pb.Variable_Str{Str: redactedValue}
+	}
+	for k := range job.Labels {
+		if strings.HasPrefix(k, runner.JobEnvSensitiveLabelPrefix) {
+			job.Labels[k] = redactedValue
+		}
+	}
+
+	m := jsonpb.Marshaler{Indent: "  "}
+	out, err := m.MarshalToString(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, out+"\n")
+	return err
+}
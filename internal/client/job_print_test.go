@@ -0,0 +1,80 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint/internal/runner"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/singleprocess"
+)
+
+func TestProjectPrintJob(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+	client := singleprocess.TestServer(t)
+
+	var buf bytes.Buffer
+	c := TestProject(t,
+		WithClient(client),
+		WithLocal(),
+		WithPrintJob(&buf),
+		WithVariables([]*pb.Variable{
+			{
+				Name:  "secret",
+				Value: &pb.Variable_Str{Str: "hunter2"},
+			},
+		}),
+	)
+	defer c.Close()
+	app := c.App(TestApp(t, c))
+
+	// The job should be printed rather than executed
+	err := app.Noop(ctx)
+	require.Equal(ErrJobPrinted, err)
+	require.Equal(1, c.PrintedJobs())
+
+	// Variable values should never be printed
+	require.Contains(buf.String(), "secret")
+	require.Contains(buf.String(), redactedValue)
+	require.NotContains(buf.String(), "hunter2")
+
+	// The job is the protobuf JSON encoding, including oneof fields
+	var job pb.Job
+	require.NoError(jsonpb.Unmarshal(&buf, &job))
+	require.Len(job.Variables, 1)
+	require.Equal(redactedValue, job.Variables[0].GetStr())
+	require.NotNil(job.GetNoop())
+}
+
+func TestProjectExportJob(t *testing.T) {
+	ctx := context.Background()
+	require := require.New(t)
+	client := singleprocess.TestServer(t)
+
+	var buf bytes.Buffer
+	c := TestProject(t,
+		WithClient(client),
+		WithLocal(),
+		WithExportJob(&buf),
+		WithLabels(map[string]string{
+			"team": "payments",
+			runner.JobEnvSensitiveLabelPrefix + "TOKEN": "hunter2",
+		}),
+	)
+	defer c.Close()
+	app := c.App(TestApp(t, c))
+
+	// The job should be exported and still executed
+	require.NoError(app.Noop(ctx))
+	require.Equal(0, c.PrintedJobs())
+
+	// Sensitive runner env values should never be exported
+	require.Contains(buf.String(), "payments")
+	require.Contains(buf.String(), runner.JobEnvSensitiveLabelPrefix+"TOKEN")
+	require.NotContains(buf.String(), "hunter2")
+}
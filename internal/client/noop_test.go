@@ -7,6 +7,7 @@ import (
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/require"
 
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
 	"github.com/hashicorp/waypoint/internal/server/singleprocess"
 )
 
@@ -29,4 +30,73 @@ func TestProjectNoop(t *testing.T) {
 
 	// Noop
 	require.NoError(app.Noop(ctx))
+
+	// Completed jobs aren't tracked
+	require.Empty(c.IncompleteJobs())
+}
+
+func TestProjectIncompleteJobs(t *testing.T) {
+	require := require.New(t)
+
+	c := TestProject(t)
+	defer c.Close()
+
+	job := c.job()
+	c.trackJob("A", job)
+	c.trackJob("B", job)
+	c.untrackJob("A")
+
+	jobs := c.IncompleteJobs()
+	require.Len(jobs, 1)
+	require.Equal(job, jobs["B"])
+
+	// The result is a copy
+	delete(jobs, "B")
+	require.Len(c.IncompleteJobs(), 1)
+}
+
+func TestProjectJobLogs(t *testing.T) {
+	ctx := context.Background()
+	client := singleprocess.TestServer(t)
+
+	c, err := New(ctx,
+		WithClient(client),
+		WithProjectRef(&pb.Ref_Project{Project: "test_p"}),
+	)
+	require.NoError(t, err)
+	defer c.Close()
+	appName := TestApp(t, c)
+
+	t.Run("unknown job", func(t *testing.T) {
+		require := require.New(t)
+
+		_, err := c.JobLogs(ctx, "nope", terminal.ConsoleUI(ctx), false)
+		require.Error(err)
+		require.Equal(codes.NotFound, status.Code(err))
+	})
+
+	t.Run("job that hasn't completed", func(t *testing.T) {
+		require := require.New(t)
+
+		// Remote jobs need a data source on the project
+		resp, err := client.GetProject(ctx, &pb.GetProjectRequest{Project: c.Ref()})
+		require.NoError(err)
+		resp.Project.DataSource = &pb.Job_DataSource{
+			Source: &pb.Job_DataSource_Git{
+				Git: &pb.Job_Git{Url: "https://github.com/hashicorp/waypoint.git"},
+			},
+		}
+		_, err = client.UpsertProject(ctx, &pb.UpsertProjectRequest{Project: resp.Project})
+		require.NoError(err)
+
+		// There are no runners, so the job stays queued.
+		job := c.job()
+		job.Application.Application = appName
+		queueResp, err := client.QueueJob(ctx, &pb.QueueJobRequest{Job: job})
+		require.NoError(err)
+
+		result, err := c.JobLogs(ctx, queueResp.JobId, terminal.ConsoleUI(ctx), false)
+		require.NoError(err)
+		require.Equal(pb.Job_QUEUED, result.State)
+	})
 }
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"io"
 	"sync"
 
 	"github.com/hashicorp/go-hclog"
@@ -26,14 +27,33 @@ type Project struct {
 	runner              *pb.Ref_Runner
 	labels              map[string]string
 	variables           []*pb.Variable
+	appVariables        map[string][]*pb.Variable
 	dataSourceOverrides map[string]string
 	cleanupFunc         func()
 	serverVersion       *pb.VersionInfo
 
 	local bool
 
+	// printJobWriter, if set, receives the JSON of every job instead of
+	// the job being queued. printedJobs counts the jobs written to it.
+	printJobWriter io.Writer
+	printedJobs    int32
+
+	// exportJobWriter, if set, receives the JSON of every job before the
+	// job is queued.
+	exportJobWriter io.Writer
+
+	// incompleteJobs are the jobs queued by this client, keyed by ID, that
+	// we haven't seen complete. See IncompleteJobs.
+	incompleteJobs     map[string]*pb.Job
+	incompleteJobsLock sync.Mutex
+
 	localServer bool // True when a local server is created
 
+	// localServerDir is the directory for the state of the local server.
+	// If this is empty, the working directory is used.
+	localServerDir string
+
 	// These are used to manage a local runner and its job processing
 	// in a goroutine.
 	wg           sync.WaitGroup
@@ -236,6 +256,16 @@ func WithVariables(m []*pb.Variable) Option {
 	}
 }
 
+// WithAppVariables sets the variable values for the operations of specific
+// apps, keyed by app name. For those apps these are used in place of the
+// values set with WithVariables, so they must include every value.
+func WithAppVariables(m map[string][]*pb.Variable) Option {
+	return func(c *Project, cfg *config) error {
+		c.appVariables = m
+		return nil
+	}
+}
+
 // WithLabels sets the labels or any operations.
 func WithLabels(m map[string]string) Option {
 	return func(c *Project, cfg *config) error {
@@ -262,6 +292,36 @@ func WithLocal() Option {
 	}
 }
 
+// WithPrintJob configures the client to write every job it would queue
+// as JSON to w rather than executing it. Operations will return
+// ErrJobPrinted in this mode.
+func WithPrintJob(w io.Writer) Option {
+	return func(c *Project, cfg *config) error {
+		c.printJobWriter = w
+		return nil
+	}
+}
+
+// WithExportJob configures the client to write every job as JSON to w
+// before queueing it, with the same redaction as WithPrintJob. Unlike
+// WithPrintJob, the jobs are still executed.
+func WithExportJob(w io.Writer) Option {
+	return func(c *Project, cfg *config) error {
+		c.exportJobWriter = w
+		return nil
+	}
+}
+
+// WithLocalServerDir sets the directory where the in-memory local server
+// started in local mode stores its state. The directory must exist. This
+// has no effect if the client connects to an existing server.
+func WithLocalServerDir(dir string) Option {
+	return func(c *Project, cfg *config) error {
+		c.localServerDir = dir
+		return nil
+	}
+}
+
 // WithLogger sets the logger for the client.
 func WithLogger(log hclog.Logger) Option {
 	return func(c *Project, cfg *config) error {
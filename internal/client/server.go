@@ -85,8 +85,7 @@ func (c *Project) initLocalServer(ctx context.Context) (*grpc.ClientConn, error)
 		}
 	}()
 
-	// TODO(mitchellh): path to this
-	path := filepath.Join("data.db")
+	path := filepath.Join(c.localServerDir, "data.db")
 	log.Debug("opening local mode DB", "path", path)
 
 	// Open our database
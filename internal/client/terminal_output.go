@@ -0,0 +1,160 @@
+package client
+
+import (
+	"io"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+// terminalOutput renders the terminal events of a job stream to a UI. This
+// keeps the state that spans events, such as the open status and step
+// group, so a single value must be used for all the events of a job.
+type terminalOutput struct {
+	ui  terminal.UI
+	log hclog.Logger
+
+	status         terminal.Status
+	stdout, stderr io.Writer
+
+	sg    terminal.StepGroup
+	steps map[int32]*terminalOutputStep
+}
+
+type terminalOutputStep struct {
+	terminal.Step
+
+	out io.Writer
+}
+
+func newTerminalOutput(ui terminal.UI, log hclog.Logger) *terminalOutput {
+	return &terminalOutput{
+		ui:    ui,
+		log:   log,
+		steps: map[int32]*terminalOutputStep{},
+	}
+}
+
+// Close closes the status, if one was opened by the events.
+func (o *terminalOutput) Close() error {
+	if o.status != nil {
+		return o.status.Close()
+	}
+
+	return nil
+}
+
+// Write renders the given events to the UI.
+func (o *terminalOutput) Write(events []*pb.GetJobStreamResponse_Terminal_Event) error {
+	ui := o.ui
+	for _, ev := range events {
+		o.log.Trace("job terminal output", "event", ev)
+
+		switch ev := ev.Event.(type) {
+		case *pb.GetJobStreamResponse_Terminal_Event_Line_:
+			ui.Output(ev.Line.Msg, terminal.WithStyle(ev.Line.Style))
+		case *pb.GetJobStreamResponse_Terminal_Event_NamedValues_:
+			var values []terminal.NamedValue
+
+			for _, tnv := range ev.NamedValues.Values {
+				values = append(values, terminal.NamedValue{
+					Name:  tnv.Name,
+					Value: tnv.Value,
+				})
+			}
+
+			ui.NamedValues(values)
+		case *pb.GetJobStreamResponse_Terminal_Event_Status_:
+			if o.status == nil {
+				o.status = ui.Status()
+			}
+
+			if ev.Status.Msg == "" && !ev.Status.Step {
+				o.status.Close()
+			} else if ev.Status.Step {
+				o.status.Step(ev.Status.Status, ev.Status.Msg)
+			} else {
+				o.status.Update(ev.Status.Msg)
+			}
+		case *pb.GetJobStreamResponse_Terminal_Event_Raw_:
+			if o.stdout == nil {
+				var err error
+				o.stdout, o.stderr, err = ui.OutputWriters()
+				if err != nil {
+					return err
+				}
+			}
+
+			if ev.Raw.Stderr {
+				o.stderr.Write(ev.Raw.Data)
+			} else {
+				o.stdout.Write(ev.Raw.Data)
+			}
+		case *pb.GetJobStreamResponse_Terminal_Event_Table_:
+			tbl := terminal.NewTable(ev.Table.Headers...)
+
+			for _, row := range ev.Table.Rows {
+				var trow []terminal.TableEntry
+
+				for _, ent := range row.Entries {
+					trow = append(trow, terminal.TableEntry{
+						Value: ent.Value,
+						Color: ent.Color,
+					})
+				}
+			}
+
+			ui.Table(tbl)
+		case *pb.GetJobStreamResponse_Terminal_Event_StepGroup_:
+			if o.sg != nil {
+				o.sg.Wait()
+			}
+
+			if !ev.StepGroup.Close {
+				o.sg = ui.StepGroup()
+			}
+		case *pb.GetJobStreamResponse_Terminal_Event_Step_:
+			if o.sg == nil {
+				continue
+			}
+
+			step, ok := o.steps[ev.Step.Id]
+			if !ok {
+				step = &terminalOutputStep{
+					Step: o.sg.Add(ev.Step.Msg),
+				}
+				o.steps[ev.Step.Id] = step
+			} else {
+				if ev.Step.Msg != "" {
+					step.Update(ev.Step.Msg)
+				}
+			}
+
+			if ev.Step.Status != "" {
+				if ev.Step.Status == terminal.StatusAbort {
+					step.Abort()
+				} else {
+					step.Status(ev.Step.Status)
+				}
+			}
+
+			if len(ev.Step.Output) > 0 {
+				if step.out == nil {
+					step.out = step.TermOutput()
+				}
+
+				step.out.Write(ev.Step.Output)
+			}
+
+			if ev.Step.Close {
+				step.Done()
+			}
+		default:
+			o.log.Error("Unknown terminal event seen", "type", hclog.Fmt("%T", ev))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/waypoint-plugin-sdk/terminal"
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
+)
+
+func TestTerminalOutput(t *testing.T) {
+	require := require.New(t)
+
+	ui := &terminalOutputUI{}
+	out := newTerminalOutput(ui, hclog.L())
+
+	require.NoError(out.Write([]*pb.GetJobStreamResponse_Terminal_Event{
+		{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Line_{
+				Line: &pb.GetJobStreamResponse_Terminal_Event_Line{Msg: "hello"},
+			},
+		},
+		{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_NamedValues_{
+				NamedValues: &pb.GetJobStreamResponse_Terminal_Event_NamedValues{
+					Values: []*pb.GetJobStreamResponse_Terminal_Event_NamedValue{
+						{Name: "url", Value: "https://example.com"},
+					},
+				},
+			},
+		},
+		{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Raw_{
+				Raw: &pb.GetJobStreamResponse_Terminal_Event_Raw{Data: []byte("out")},
+			},
+		},
+		{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Raw_{
+				Raw: &pb.GetJobStreamResponse_Terminal_Event_Raw{Data: []byte("err"), Stderr: true},
+			},
+		},
+		{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Status_{
+				Status: &pb.GetJobStreamResponse_Terminal_Event_Status{Msg: "deploying"},
+			},
+		},
+	}))
+
+	// The state spans writes, so the status opened above is reused.
+	require.NoError(out.Write([]*pb.GetJobStreamResponse_Terminal_Event{
+		{
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Status_{
+				Status: &pb.GetJobStreamResponse_Terminal_Event_Status{
+					Status: terminal.StatusOK,
+					Msg:    "deployed",
+					Step:   true,
+				},
+			},
+		},
+		{
+			// Steps without a step group are ignored.
+			Event: &pb.GetJobStreamResponse_Terminal_Event_Step_{
+				Step: &pb.GetJobStreamResponse_Terminal_Event_Step{Id: 1, Msg: "ignored"},
+			},
+		},
+	}))
+
+	require.Equal([]string{"hello"}, ui.lines)
+	require.Equal([]terminal.NamedValue{{Name: "url", Value: "https://example.com"}}, ui.values)
+	require.Equal("out", ui.stdout.String())
+	require.Equal("err", ui.stderr.String())
+	require.Equal(1, ui.statuses)
+	require.Equal([]string{"deploying", terminal.StatusOK + ": deployed"}, ui.status.updates)
+
+	// Closing the output closes the open status.
+	require.False(ui.status.closed)
+	require.NoError(out.Close())
+	require.True(ui.status.closed)
+}
+
+// terminalOutputUI records what a terminalOutput renders. Only the methods
+// used by the tests are implemented.
+type terminalOutputUI struct {
+	terminal.UI
+
+	lines          []string
+	values         []terminal.NamedValue
+	stdout, stderr bytes.Buffer
+	statuses       int
+	status         *terminalOutputStatus
+}
+
+func (u *terminalOutputUI) Output(msg string, raw ...interface{}) {
+	u.lines = append(u.lines, msg)
+}
+
+func (u *terminalOutputUI) NamedValues(values []terminal.NamedValue, opts ...terminal.Option) {
+	u.values = append(u.values, values...)
+}
+
+func (u *terminalOutputUI) OutputWriters() (io.Writer, io.Writer, error) {
+	return &u.stdout, &u.stderr, nil
+}
+
+func (u *terminalOutputUI) Status() terminal.Status {
+	u.statuses++
+	u.status = &terminalOutputStatus{}
+	return u.status
+}
+
+type terminalOutputStatus struct {
+	terminal.Status
+
+	updates []string
+	closed  bool
+}
+
+func (s *terminalOutputStatus) Update(msg string) {
+	s.updates = append(s.updates, msg)
+}
+
+func (s *terminalOutputStatus) Step(status, msg string) {
+	s.updates = append(s.updates, status+": "+msg)
+}
+
+func (s *terminalOutputStatus) Close() error {
+	s.closed = true
+	return nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
@@ -21,6 +22,10 @@ type App struct {
 	URL    *AppURL           `hcl:"url,block" default:"{}"`
 	Config *genericConfig    `hcl:"config,block"`
 
+	// Workspaces restricts the app to the listed workspaces. If this is
+	// empty, the app is available in every workspace. See InWorkspace.
+	Workspaces []string `hcl:"workspaces,optional"`
+
 	BuildRaw   *hclBuild `hcl:"build,block"`
 	DeployRaw  *hclStage `hcl:"deploy,block"`
 	ReleaseRaw *hclStage `hcl:"release,block"`
@@ -31,6 +36,21 @@ type App struct {
 	config *Config
 }
 
+// InWorkspace returns true if the app is available in the workspace ws.
+func (c *App) InWorkspace(ws string) bool {
+	if len(c.Workspaces) == 0 {
+		return true
+	}
+
+	for _, v := range c.Workspaces {
+		if v == ws {
+			return true
+		}
+	}
+
+	return false
+}
+
 // AppURL configures the App-specific URL settings.
 type AppURL struct {
 	AutoHostname *bool `hcl:"auto_hostname,optional"`
@@ -45,8 +65,12 @@ type hclApp struct {
 	DeployRaw  *hclStage `hcl:"deploy,block"`
 	ReleaseRaw *hclStage `hcl:"release,block"`
 
-	Body   hcl.Body `hcl:",body"`
-	Remain hcl.Body `hcl:",remain"`
+	Body      hcl.Body  `hcl:",body"`
+	Remain    hcl.Body  `hcl:",remain"`
+	DeclRange hcl.Range `hcl:",def_range"`
+
+	// profile is the override for this app from the selected profile.
+	profile *hclProfileApp
 }
 
 // hclLabeled is used to partially decode only the labels from a
@@ -66,6 +90,69 @@ func (c *Config) Apps() []string {
 	return result
 }
 
+// AppDecl is the name of an app and where it is declared.
+type AppDecl struct {
+	Name  string
+	Range hcl.Range
+}
+
+// AppDecls returns the names of all the apps along with where they're
+// declared, in the order they're declared.
+func (c *Config) AppDecls() []*AppDecl {
+	var result []*AppDecl
+	for _, app := range c.hclConfig.Apps {
+		result = append(result, &AppDecl{
+			Name:  app.Name,
+			Range: app.DeclRange,
+		})
+	}
+
+	return result
+}
+
+// AppForDir returns the name of the app whose path contains dir, so that
+// commands run from within an app's directory can target that app. dir
+// must be absolute. Apps whose path is the project directory are ignored
+// since every directory in the project is within them. If more than one
+// app has the most specific path, or no app contains dir, this returns an
+// empty string.
+func (c *Config) AppForDir(dir string) string {
+	project := filepath.Clean(c.pathData["project"])
+	dir = filepath.Clean(dir)
+
+	var result string
+	var resultLen int
+	ambiguous := false
+	for _, app := range c.hclConfig.Apps {
+		appPath := app.Path
+		if !filepath.IsAbs(appPath) {
+			appPath = filepath.Join(project, appPath)
+		}
+		appPath = filepath.Clean(appPath)
+		if appPath == project {
+			continue
+		}
+
+		rel, err := filepath.Rel(appPath, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		switch {
+		case len(appPath) > resultLen:
+			result, resultLen, ambiguous = app.Name, len(appPath), false
+		case len(appPath) == resultLen:
+			ambiguous = true
+		}
+	}
+
+	if ambiguous {
+		return ""
+	}
+
+	return result
+}
+
 // App returns the configured app named n. If the app doesn't exist, this
 // will return (nil, nil).
 func (c *Config) App(n string, ctx *hcl.EvalContext) (*App, error) {
@@ -105,6 +192,12 @@ func (c *Config) App(n string, ctx *hcl.EvalContext) (*App, error) {
 	if diag := gohcl.DecodeBody(rawApp.Body, finalizeContext(ctx), &app); diag.HasErrors() {
 		return nil, diag
 	}
+	if rawApp.profile != nil {
+		// The stages were already merged with the profile during Load.
+		app.BuildRaw = rawApp.BuildRaw
+		app.DeployRaw = rawApp.DeployRaw
+		app.ReleaseRaw = rawApp.ReleaseRaw
+	}
 	app.Name = rawApp.Name
 	app.Path = appPath
 	app.ctx = ctx
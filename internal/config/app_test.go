@@ -67,6 +67,27 @@ func TestConfigApp_compare(t *testing.T) {
 			},
 		},
 
+		{
+			"app_workspaces.hcl",
+			"foo",
+			func(t *testing.T, c *App) {
+				require := require.New(t)
+				require.Equal([]string{"staging", "prod"}, c.Workspaces)
+				require.True(c.InWorkspace("prod"))
+				require.False(c.InWorkspace("default"))
+			},
+		},
+
+		{
+			"app_workspaces.hcl",
+			"bar",
+			func(t *testing.T, c *App) {
+				require := require.New(t)
+				require.Empty(c.Workspaces)
+				require.True(c.InWorkspace("default"))
+			},
+		},
+
 		{
 			"build.hcl",
 			"test",
@@ -369,6 +390,58 @@ func TestConfigApp_compare(t *testing.T) {
 	}
 }
 
+func TestConfigAppDecls(t *testing.T) {
+	require := require.New(t)
+
+	cfg, err := Load(filepath.Join("testdata", "compare", "app_labels.hcl"), &LoadOptions{
+		Workspace: "default",
+	})
+	require.NoError(err)
+
+	decls := cfg.AppDecls()
+	require.Len(decls, 1)
+	require.Equal("bar", decls[0].Name)
+	require.Equal(3, decls[0].Range.Start.Line)
+	require.Contains(decls[0].Range.Filename, "app_labels.hcl")
+}
+
+func TestConfigAppForDir(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := filepath.Abs(filepath.Join("testdata", "app_for_dir"))
+	require.NoError(err)
+
+	cfg, err := Load(filepath.Join(dir, "waypoint.hcl"), nil)
+	require.NoError(err)
+
+	cases := []struct {
+		Dir      string
+		Expected string
+	}{
+		// The project directory is in every app with the default path.
+		{"", ""},
+		{"apps", ""},
+
+		{"apps/web", "web"},
+		{"apps/web/src", "web"},
+		{"apps/webby", ""},
+
+		// The most specific path wins
+		{"apps/web/admin", "web-admin"},
+		{"apps/web/admin/src", "web-admin"},
+
+		// More than one app with the same path is ambiguous
+		{"apps/api", ""},
+
+		// Outside of the project
+		{"..", ""},
+	}
+
+	for _, tt := range cases {
+		require.Equal(tt.Expected, cfg.AppForDir(filepath.Join(dir, filepath.FromSlash(tt.Dir))), tt.Dir)
+	}
+}
+
 func TestAppValidate(t *testing.T) {
 	cases := []struct {
 		File string
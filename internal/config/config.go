@@ -1,12 +1,16 @@
 package config
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/hcl/v2/hclsimple"
 
 	"github.com/hashicorp/waypoint/internal/config/variables"
@@ -20,19 +24,30 @@ type Config struct {
 	ctx      *hcl.EvalContext
 	path     string
 	pathData map[string]string
+	profile  string
 
 	InputVariables map[string]*variables.Variable
 }
 
 type hclConfig struct {
-	Project   string                   `hcl:"project,optional"`
-	Runner    *Runner                  `hcl:"runner,block" default:"{}"`
-	Labels    map[string]string        `hcl:"labels,optional"`
-	Variables []*variables.HclVariable `hcl:"variable,block"`
-	Plugin    []*Plugin                `hcl:"plugin,block"`
-	Config    *genericConfig           `hcl:"config,block"`
-	Apps      []*hclApp                `hcl:"app,block"`
-	Body      hcl.Body                 `hcl:",body"`
+	Project     string                   `hcl:"project,optional"`
+	Runner      *Runner                  `hcl:"runner,block" default:"{}"`
+	Labels      map[string]string        `hcl:"labels,optional"`
+	RequiredEnv []string                 `hcl:"required_env,optional"`
+	Variables   []*variables.HclVariable `hcl:"variable,block"`
+	Plugin      []*Plugin                `hcl:"plugin,block"`
+	Config      *genericConfig           `hcl:"config,block"`
+	Apps        []*hclApp                `hcl:"app,block"`
+	Profiles    []*hclProfile            `hcl:"profile,block"`
+	Body        hcl.Body                 `hcl:",body"`
+
+	// WorkspaceAliases are short names for workspaces, mapping the alias
+	// to the workspace name. See WorkspaceAlias.
+	WorkspaceAliases map[string]string `hcl:"workspace_aliases,optional"`
+
+	// ProtectedWorkspaces are the workspaces that operations require
+	// confirmation for. See WorkspaceProtected.
+	ProtectedWorkspaces []string `hcl:"protected_workspaces,optional"`
 }
 
 // Runner is the configuration for supporting runners in this project.
@@ -69,6 +84,27 @@ type LoadOptions struct {
 	// Workspace is the workspace that we are executing in. This is used to
 	// setup `workspace.name` variables.
 	Workspace string
+
+	// Variables are input variable values, such as those set with the CLI,
+	// that are made available as `var.<name>` while evaluating the
+	// configuration. These are combined with values from the "env" field of
+	// variables and "*.auto.wpvars" files using the same precedence as
+	// operations. Variables without any value are left undefined since they
+	// may still be set later, such as on the server.
+	//
+	// If this is nil, no input variables are available to the configuration.
+	Variables []*pb.Variable
+
+	// ProjectPath is an optional path to a file with project-level settings,
+	// such as the runner, that is merged with the file being loaded. This
+	// lets project policy live separately from the app definitions. Setting
+	// the same attribute or block in both files is an error.
+	ProjectPath string
+
+	// Profile is the name of a profile in the configuration to merge with
+	// the base configuration. A profile can override the runner, labels,
+	// and the stages of apps. It is an error if the profile doesn't exist.
+	Profile string
 }
 
 // Load loads the configuration file from the given path.
@@ -119,8 +155,14 @@ func Load(path string, opts *LoadOptions) (*Config, error) {
 
 	// Decode
 	var cfg hclConfig
-	if err := hclsimple.DecodeFile(path, finalizeContext(ctx), &cfg); err != nil {
-		return nil, err
+	if opts.ProjectPath == "" {
+		if err := hclsimple.DecodeFile(path, finalizeContext(ctx), &cfg); err != nil {
+			return nil, err
+		}
+	} else {
+		if diags := decodeMerged(path, opts.ProjectPath, finalizeContext(ctx), &cfg); diags.HasErrors() {
+			return nil, diags
+		}
 	}
 
 	// Decode variable blocks
@@ -138,6 +180,26 @@ func Load(path string, opts *LoadOptions) (*Config, error) {
 		return nil, err
 	}
 
+	// Merge the selected profile, if any, before anything reads the apps.
+	if opts.Profile != "" {
+		if diags := applyProfile(&cfg, opts.Profile); diags.HasErrors() {
+			return nil, diags
+		}
+	}
+
+	// If we have variable values, make them available to the rest of the
+	// configuration. This uses a child context since setting input variables
+	// replaces all other variables in the context.
+	if opts.Variables != nil && len(vs) > 0 {
+		values, diags := configVariables(filepath.Dir(path), vs, opts.Variables)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		ctx = ctx.NewChild()
+		variables.AddInputVariables(ctx, values)
+	}
+
 	// Set some values
 	if cfg.Config != nil {
 		cfg.Config.ctx = ctx
@@ -153,11 +215,121 @@ func Load(path string, opts *LoadOptions) (*Config, error) {
 		ctx:            ctx,
 		path:           filepath.Dir(path),
 		pathData:       pathData,
+		profile:        opts.Profile,
 		InputVariables: vs,
 	}, nil
 }
 
+// decodeMerged decodes the configuration at path merged with the project
+// configuration at projectPath. Attributes and single blocks such as
+// "runner" that are set in both files are reported by the decoder with the
+// positions of both. Apps can't be defined in both files either.
+func decodeMerged(path, projectPath string, ctx *hcl.EvalContext, cfg *hclConfig) hcl.Diagnostics {
+	parser := hclparse.NewParser()
+
+	var files []*hcl.File
+	for _, p := range []string{projectPath, path} {
+		var f *hcl.File
+		var diags hcl.Diagnostics
+		if strings.HasSuffix(p, ".json") {
+			f, diags = parser.ParseJSONFile(p)
+		} else {
+			f, diags = parser.ParseHCLFile(p)
+		}
+		if diags.HasErrors() {
+			return diags
+		}
+
+		files = append(files, f)
+	}
+
+	if diags := gohcl.DecodeBody(hcl.MergeFiles(files), ctx, cfg); diags.HasErrors() {
+		return diags
+	}
+
+	var diags hcl.Diagnostics
+	seen := map[string]*hclApp{}
+	for _, app := range cfg.Apps {
+		if prev, ok := seen[app.Name]; ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Duplicate app %q", app.Name),
+				Detail: fmt.Sprintf(
+					"The app %q was already defined at %s.", app.Name, prev.DeclRange),
+				Subject: app.DeclRange.Ptr(),
+			})
+			continue
+		}
+
+		seen[app.Name] = app
+	}
+
+	return diags
+}
+
+// configVariables evaluates the input variable values available to the
+// configuration, in the same order of precedence as operations.
+func configVariables(
+	dir string,
+	vs map[string]*variables.Variable,
+	pbVars []*pb.Variable,
+) (variables.Values, hcl.Diagnostics) {
+	envVars, diags := variables.LoadEnvValues(vs)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	autoVars, diags := variables.LoadAutoFiles(dir)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var all []*pb.Variable
+	all = append(all, envVars...)
+	all = append(all, autoVars...)
+	all = append(all, pbVars...)
+
+	return variables.EvaluatePartialVariables(all, vs, hclog.NewNullLogger())
+}
+
 // HCLContext returns the eval context for this configuration.
 func (c *Config) HCLContext() *hcl.EvalContext {
 	return c.ctx.NewChild()
 }
+
+// WorkspaceAlias returns the workspace that the given workspace name is
+// an alias for in "workspace_aliases". Aliases are only resolved once, and
+// a name that isn't an alias is returned as-is.
+func (c *Config) WorkspaceAlias(name string) string {
+	if v, ok := c.WorkspaceAliases[name]; ok {
+		return v
+	}
+
+	return name
+}
+
+// WorkspaceProtected returns true if the workspace is listed in
+// "protected_workspaces".
+func (c *Config) WorkspaceProtected(name string) bool {
+	for _, v := range c.ProtectedWorkspaces {
+		if v == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MissingEnv returns the env vars listed in "required_env" that aren't set,
+// in the order they're listed. This only checks that each env var is
+// present, so an env var that is set to an empty value isn't missing.
+func (c *Config) MissingEnv() []string {
+	var result []string
+	for _, name := range c.RequiredEnv {
+		if _, ok := os.LookupEnv(name); !ok {
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
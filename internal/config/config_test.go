@@ -1,10 +1,13 @@
 package config
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	pb "github.com/hashicorp/waypoint/internal/server/gen"
 )
 
 func TestLoad_compare(t *testing.T) {
@@ -65,6 +68,249 @@ func TestLoad_compare(t *testing.T) {
 	}
 }
 
+func TestLoad_variables(t *testing.T) {
+	path := filepath.Join("testdata", "compare", "variables.hcl")
+
+	t.Run("default value", func(t *testing.T) {
+		require := require.New(t)
+
+		cfg, err := Load(path, &LoadOptions{Variables: []*pb.Variable{}})
+		require.NoError(err)
+
+		app, err := cfg.App("bar", nil)
+		require.NoError(err)
+		require.Equal("main", app.Labels["ref"])
+	})
+
+	t.Run("set value", func(t *testing.T) {
+		require := require.New(t)
+
+		cfg, err := Load(path, &LoadOptions{
+			Variables: []*pb.Variable{
+				{
+					Name:   "ref",
+					Value:  &pb.Variable_Str{Str: "release"},
+					Source: &pb.Variable_Cli{},
+				},
+			},
+		})
+		require.NoError(err)
+
+		app, err := cfg.App("bar", nil)
+		require.NoError(err)
+		require.Equal("release", app.Labels["ref"])
+	})
+
+	t.Run("undefined variable", func(t *testing.T) {
+		require := require.New(t)
+
+		_, err := Load(path, &LoadOptions{
+			Variables: []*pb.Variable{
+				{
+					Name:   "nope",
+					Value:  &pb.Variable_Str{Str: "release"},
+					Source: &pb.Variable_Cli{},
+				},
+			},
+		})
+		require.Error(err)
+		require.Contains(err.Error(), "Undefined variable")
+	})
+}
+
+func TestLoad_projectPath(t *testing.T) {
+	dir := filepath.Join("testdata", "project_path")
+	projectPath := filepath.Join(dir, "project.hcl")
+
+	t.Run("merged", func(t *testing.T) {
+		require := require.New(t)
+
+		cfg, err := Load(filepath.Join(dir, "apps.hcl"), &LoadOptions{
+			ProjectPath: projectPath,
+		})
+		require.NoError(err)
+		require.Equal("foo", cfg.Project)
+		require.True(cfg.Runner.Enabled)
+		require.Equal([]string{"web"}, cfg.Apps())
+	})
+
+	cases := []struct {
+		File string
+		Err  string
+	}{
+		{"conflict_project.hcl", "Duplicate argument"},
+		{"conflict_runner.hcl", "Duplicate runner block"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.File, func(t *testing.T) {
+			require := require.New(t)
+
+			_, err := Load(filepath.Join(dir, tt.File), &LoadOptions{
+				ProjectPath: projectPath,
+			})
+			require.Error(err)
+			require.Contains(err.Error(), tt.Err)
+
+			// The error points at both files
+			require.Contains(err.Error(), "project.hcl")
+			require.Contains(err.Error(), tt.File)
+		})
+	}
+
+	t.Run("duplicate app", func(t *testing.T) {
+		require := require.New(t)
+
+		_, err := Load(filepath.Join(dir, "conflict_app.hcl"), &LoadOptions{
+			ProjectPath: filepath.Join(dir, "apps.hcl"),
+		})
+		require.Error(err)
+		require.Contains(err.Error(), "Duplicate app")
+		require.Contains(err.Error(), "apps.hcl")
+		require.Contains(err.Error(), "conflict_app.hcl")
+	})
+}
+
+func TestLoad_profile(t *testing.T) {
+	dir := filepath.Join("testdata", "profile")
+	path := filepath.Join(dir, "waypoint.hcl")
+
+	t.Run("base", func(t *testing.T) {
+		require := require.New(t)
+
+		cfg, err := Load(path, &LoadOptions{})
+		require.NoError(err)
+		require.Empty(cfg.Profile())
+		require.Equal([]string{"prod", "staging"}, cfg.Profiles())
+		require.False(cfg.Runner.Enabled)
+		require.Equal("dev", cfg.Labels["env"])
+
+		app, err := cfg.App("web", nil)
+		require.NoError(err)
+		use, err := app.DeployUse(nil)
+		require.NoError(err)
+		require.Equal("docker", use)
+	})
+
+	t.Run("prod", func(t *testing.T) {
+		require := require.New(t)
+
+		cfg, err := Load(path, &LoadOptions{Profile: "prod"})
+		require.NoError(err)
+		require.NoError(cfg.Validate())
+		require.Equal("prod", cfg.Profile())
+		require.True(cfg.Runner.Enabled)
+		require.Equal(map[string]string{"env": "prod", "team": "web"}, cfg.Labels)
+
+		app, err := cfg.App("web", nil)
+		require.NoError(err)
+		use, err := app.BuildUse(nil)
+		require.NoError(err)
+		require.Equal("pack", use)
+		use, err = app.DeployUse(nil)
+		require.NoError(err)
+		require.Equal("kubernetes", use)
+
+		// The registry of the base build is kept.
+		use, err = app.RegistryUse(nil)
+		require.NoError(err)
+		require.Equal("docker", use)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		require := require.New(t)
+
+		_, err := Load(path, &LoadOptions{Profile: "nope"})
+		require.Error(err)
+		require.Contains(err.Error(), `Unknown profile "nope"`)
+		require.Contains(err.Error(), "prod, staging")
+	})
+
+	t.Run("unknown app", func(t *testing.T) {
+		require := require.New(t)
+
+		_, err := Load(filepath.Join(dir, "unknown_app.hcl"), &LoadOptions{Profile: "prod"})
+		require.Error(err)
+		require.Contains(err.Error(), `Unknown app "api" in profile "prod"`)
+	})
+}
+
+func TestConfigSettings(t *testing.T) {
+	// settings returns the settings keyed by name.
+	settings := func(t *testing.T, cfg *Config) map[string]*Setting {
+		list, diags := cfg.Settings()
+		require.False(t, diags.HasErrors(), diags.Error())
+
+		result := map[string]*Setting{}
+		for _, s := range list {
+			result[s.Name] = s
+		}
+
+		return result
+	}
+
+	t.Run("project path", func(t *testing.T) {
+		require := require.New(t)
+
+		dir := filepath.Join("testdata", "project_path")
+		cfg, err := Load(filepath.Join(dir, "apps.hcl"), &LoadOptions{
+			ProjectPath: filepath.Join(dir, "project.hcl"),
+		})
+		require.NoError(err)
+
+		s := settings(t, cfg)
+		require.Equal(`"foo"`, s["project"].Value)
+		require.Equal("project.hcl", filepath.Base(s["project"].Range.Filename))
+		require.Equal("enabled = true", s["runner"].Value)
+		require.Equal("project.hcl", filepath.Base(s["runner"].Range.Filename))
+		require.Equal("apps.hcl", filepath.Base(s["app.web"].Range.Filename))
+		require.Equal(`use "docker"`, s["app.web.deploy"].Value)
+		require.Empty(s["app.web.deploy"].Profile)
+	})
+
+	t.Run("profile", func(t *testing.T) {
+		require := require.New(t)
+
+		cfg, err := Load(filepath.Join("testdata", "profile", "waypoint.hcl"), &LoadOptions{
+			Profile: "prod",
+		})
+		require.NoError(err)
+
+		s := settings(t, cfg)
+		require.Equal(`"prod"`, s["labels.env"].Value)
+		require.Equal("prod", s["labels.env"].Profile)
+		require.Empty(s["labels.team"].Profile)
+		require.Equal("prod", s["runner"].Profile)
+		require.Equal(`use "kubernetes"`, s["app.web.deploy"].Value)
+		require.Equal("prod", s["app.web.deploy"].Profile)
+
+		// The registry of the base build is kept.
+		require.Equal(`use "docker"`, s["app.web.registry"].Value)
+		require.Empty(s["app.web.registry"].Profile)
+
+		// Profiles are only shown as the settings they override.
+		require.NotContains(s, "profile.prod")
+	})
+}
+
+func TestConfigMissingEnv(t *testing.T) {
+	require := require.New(t)
+
+	os.Setenv("WP_TEST_REQUIRED_SET", "")
+	defer os.Unsetenv("WP_TEST_REQUIRED_SET")
+	os.Unsetenv("WP_TEST_REQUIRED_UNSET")
+
+	cfg := TestConfig(t, `
+project = "foo"
+
+required_env = ["WP_TEST_REQUIRED_UNSET", "WP_TEST_REQUIRED_SET"]
+`)
+	require.Equal([]string{"WP_TEST_REQUIRED_UNSET"}, cfg.MissingEnv())
+
+	cfg = TestConfig(t, `project = "foo"`)
+	require.Empty(cfg.MissingEnv())
+}
+
 func TestConfig_variableDecode(t *testing.T) {
 	cases := []struct {
 		file string
@@ -105,3 +351,31 @@ func TestConfig_variableDecode(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigWorkspaceAlias(t *testing.T) {
+	require := require.New(t)
+
+	cfg := TestConfig(t, `
+project = "foo"
+
+workspace_aliases = {
+  prod = "production-us-east-1"
+}
+`)
+
+	require.Equal("production-us-east-1", cfg.WorkspaceAlias("prod"))
+	require.Equal("staging", cfg.WorkspaceAlias("staging"))
+}
+
+func TestConfigWorkspaceProtected(t *testing.T) {
+	require := require.New(t)
+
+	cfg := TestConfig(t, `
+project = "foo"
+
+protected_workspaces = ["production"]
+`)
+
+	require.True(cfg.WorkspaceProtected("production"))
+	require.False(cfg.WorkspaceProtected("staging"))
+}
@@ -3,13 +3,15 @@ package funcs
 import (
 	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/hashicorp/go-hclog"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/function"
 )
@@ -34,8 +36,16 @@ type VCSGit struct {
 	// even if it exists.
 	GoGitOnly bool
 
+	// Log is used to warn when some metadata can't be determined, such as
+	// the changes in a bare repository. This defaults to hclog.L().
+	Log hclog.Logger
+
 	initErr error
 	repo    *git.Repository
+
+	// cli is true if we use the "git" CLI rather than go-git. This is the
+	// case for linked worktrees, which go-git doesn't support.
+	cli bool
 }
 
 // RefPrettyFunc returns a string format of the current Git ref. This function
@@ -60,30 +70,18 @@ func (s *VCSGit) refPrettyFunc(args []cty.Value, retType cty.Type) (cty.Value, e
 		return cty.UnknownVal(cty.String), err
 	}
 
-	ref, err := s.repo.Head()
+	result, err := s.head()
 	if err != nil {
-		return cty.UnknownVal(cty.String), fmt.Errorf("error getting repo HEAD reference - this repo may have no commits: %w", err)
+		return cty.UnknownVal(cty.String), err
 	}
-	result := ref.Hash().String()
 
 	// Get the tags
-	iter, err := s.repo.Tags()
+	tags, err := s.headTags(result)
 	if err != nil {
-		return cty.UnknownVal(cty.String), fmt.Errorf("error getting repo tags: %w", err)
+		return cty.UnknownVal(cty.String), err
 	}
-	defer iter.Close()
-	for {
-		tagRef, err := iter.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return cty.UnknownVal(cty.String), err
-		}
-		if tagRef.Hash() == ref.Hash() {
-			result = tagRef.Name().Short()
-			break
-		}
+	if len(tags) > 0 {
+		result = tags[0]
 	}
 
 	// To determine if there are changes we subprocess because go-git's Status
@@ -110,8 +108,15 @@ func (s *VCSGit) refPrettyFunc(args []cty.Value, retType cty.Type) (cty.Value, e
 				return cty.UnknownVal(cty.String), fmt.Errorf("error executing git: %s", err)
 			}
 
-			if exitError.ExitCode() != 0 {
+			// "git diff" exits with 1 if there are changes. Any other
+			// code is an error, such as in a bare repository that has no
+			// working tree, so we can't tell if there are changes.
+			switch exitError.ExitCode() {
+			case 1:
 				result += fmt.Sprintf("_CHANGES_%d", time.Now().Unix())
+			default:
+				s.log().Warn("unable to determine if the git working tree has changes",
+					"path", s.Path, "exit_code", exitError.ExitCode())
 			}
 		}
 	}
@@ -119,6 +124,11 @@ func (s *VCSGit) refPrettyFunc(args []cty.Value, retType cty.Type) (cty.Value, e
 	// If we want to use go-git for change detection, then do that now.
 	if goGitChanges {
 		wt, err := s.repo.Worktree()
+		if err == git.ErrIsBareRepository {
+			s.log().Warn("unable to determine if the git working tree has changes "+
+				"since the repository is bare", "path", s.Path)
+			return cty.StringVal(result), nil
+		}
 		if err != nil {
 			return cty.UnknownVal(cty.String), err
 		}
@@ -152,12 +162,12 @@ func (s *VCSGit) refHashFunc(args []cty.Value, retType cty.Type) (cty.Value, err
 		return cty.UnknownVal(cty.String), err
 	}
 
-	ref, err := s.repo.Head()
+	hash, err := s.head()
 	if err != nil {
-		return cty.UnknownVal(cty.String), fmt.Errorf("error getting repo HEAD reference - this repo may have no commits: %w", err)
+		return cty.UnknownVal(cty.String), err
 	}
 
-	return cty.StringVal(ref.Hash().String()), nil
+	return cty.StringVal(hash), nil
 }
 
 // RefTagFunc returns the tag of the HEAD ref or empty if not tag is found.
@@ -176,30 +186,18 @@ func (s *VCSGit) refTagFunc(args []cty.Value, retType cty.Type) (cty.Value, erro
 		return cty.UnknownVal(cty.String), err
 	}
 
-	ref, err := s.repo.Head()
+	hash, err := s.head()
 	if err != nil {
-		return cty.UnknownVal(cty.String), fmt.Errorf("error getting repo HEAD reference - this repo may have no commits: %w", err)
-	}
-
-	// Get the tags
-	iter, err := s.repo.Tags()
-	if err != nil {
-		return cty.UnknownVal(cty.String), fmt.Errorf("error getting repo tags: %w", err)
+		return cty.UnknownVal(cty.String), err
 	}
 
-	var tagRefStr string
-	err = iter.ForEach(func(t *plumbing.Reference) error {
-		if t.Hash() == ref.Hash() {
-			tagRefStr = t.Name().Short()
-		}
-		return nil
-	})
+	tags, err := s.headTags(hash)
 	if err != nil {
 		return cty.UnknownVal(cty.String), err
 	}
 
-	if tagRefStr != "" {
-		return cty.StringVal(tagRefStr), nil
+	if len(tags) > 0 {
+		return cty.StringVal(tags[len(tags)-1]), nil
 	}
 
 	return cty.StringVal(""), nil
@@ -229,6 +227,20 @@ func (s *VCSGit) remoteUrlFunc(args []cty.Value, retType cty.Type) (cty.Value, e
 
 	name := args[0].AsString()
 
+	if s.cli {
+		out, err := s.git("remote", "get-url", name)
+		if err != nil {
+			// git exits non-zero if the remote doesn't exist.
+			if _, ok := err.(*exec.ExitError); ok {
+				err = nil
+			}
+
+			return cty.UnknownVal(cty.String), err
+		}
+
+		return cty.StringVal(out), nil
+	}
+
 	remote, err := s.repo.Remote(name)
 	if err != nil {
 		if err == git.ErrRemoteNotFound {
@@ -251,10 +263,20 @@ func (s *VCSGit) init() error {
 	if s.initErr != nil {
 		return s.initErr
 	}
-	if s.repo != nil {
+	if s.repo != nil || s.cli {
 		return nil
 	}
 
+	// go-git doesn't support linked worktrees, which share the objects
+	// and refs of the main checkout from a separate git dir. If this is
+	// one, we use the git CLI instead.
+	if !s.GoGitOnly {
+		if linked, err := s.linkedWorktree(); err == nil && linked {
+			s.cli = true
+			return nil
+		}
+	}
+
 	// Open the repo
 	repo, err := git.PlainOpenWithOptions(s.Path, &git.PlainOpenOptions{
 		DetectDotGit: true,
@@ -266,3 +288,105 @@ func (s *VCSGit) init() error {
 	s.repo = repo
 	return nil
 }
+
+// linkedWorktree returns true if the path is within a linked worktree
+// created with "git worktree add". Its git dir differs from the common
+// dir, which is the ".git" of the main checkout.
+func (s *VCSGit) linkedWorktree() (bool, error) {
+	out, err := s.git("rev-parse", "--git-dir", "--git-common-dir")
+	if err != nil {
+		return false, err
+	}
+
+	dirs := strings.Split(out, "\n")
+	if len(dirs) != 2 {
+		return false, fmt.Errorf("unexpected output from git rev-parse: %q", out)
+	}
+
+	for i, dir := range dirs {
+		// The dirs are relative to the path unless they're absolute.
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(s.Path, dir)
+		}
+		if v, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = v
+		}
+
+		dirs[i] = filepath.Clean(dir)
+	}
+
+	return dirs[0] != dirs[1], nil
+}
+
+// head returns the hash of HEAD. This works with a detached HEAD.
+func (s *VCSGit) head() (string, error) {
+	if s.cli {
+		out, err := s.git("rev-parse", "--verify", "HEAD")
+		if err != nil {
+			return "", fmt.Errorf("error getting repo HEAD reference - this repo may have no commits: %w", err)
+		}
+
+		return out, nil
+	}
+
+	ref, err := s.repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("error getting repo HEAD reference - this repo may have no commits: %w", err)
+	}
+
+	return ref.Hash().String(), nil
+}
+
+// headTags returns the names of the tags that point to the hash of HEAD.
+func (s *VCSGit) headTags(hash string) ([]string, error) {
+	if s.cli {
+		out, err := s.git("tag", "--points-at", hash)
+		if err != nil {
+			return nil, fmt.Errorf("error getting repo tags: %w", err)
+		}
+		if out == "" {
+			return nil, nil
+		}
+
+		return strings.Split(out, "\n"), nil
+	}
+
+	iter, err := s.repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("error getting repo tags: %w", err)
+	}
+
+	var result []string
+	err = iter.ForEach(func(t *plumbing.Reference) error {
+		if t.Hash().String() == hash {
+			result = append(result, t.Name().Short())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// git runs the git CLI within the path and returns its trimmed output.
+func (s *VCSGit) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = s.Path
+	cmd.Stderr = ioutil.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (s *VCSGit) log() hclog.Logger {
+	if s.Log == nil {
+		return hclog.L()
+	}
+
+	return s.Log
+}
@@ -182,3 +182,45 @@ func testGitFixture(t *testing.T, path string) {
 	require.NoError(t, os.Rename(original, newPath))
 	t.Cleanup(func() { os.Rename(newPath, original) })
 }
+
+func TestVCSGit_worktree(t *testing.T) {
+	if !testHasGit {
+		t.Skip("git not installed")
+		return
+	}
+
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "git")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	path := filepath.Join(td, "fixture")
+	require.NoError(copy.CopyDir(filepath.Join("testdata", "git-tag"), path))
+	testGitFixture(t, path)
+
+	// Create a linked worktree with a detached HEAD, like CI checkouts.
+	wt := filepath.Join(td, "worktree")
+	cmd := exec.Command("git", "worktree", "add", "--detach", wt)
+	cmd.Dir = path
+	out, err := cmd.CombinedOutput()
+	require.NoError(err, string(out))
+
+	s := &VCSGit{Path: wt}
+	result, err := s.refHashFunc(nil, cty.String)
+	require.NoError(err)
+	require.True(s.cli)
+	require.Equal("e5bde566e1270e2ed44a9a5a01d51b4eb9c8850b", result.AsString())
+
+	result, err = s.refTagFunc(nil, cty.String)
+	require.NoError(err)
+	require.Equal("hello", result.AsString())
+
+	result, err = s.refPrettyFunc(nil, cty.String)
+	require.NoError(err)
+	require.Equal("hello", result.AsString())
+
+	result, err = s.remoteUrlFunc([]cty.Value{cty.StringVal("origin")}, cty.String)
+	require.NoError(err)
+	require.False(result.IsKnown())
+}
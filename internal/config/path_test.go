@@ -0,0 +1,63 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindPath(t *testing.T) {
+	td, err := ioutil.TempDir("", "waypoint-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	nested := filepath.Join(td, "a", "b")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(td, "waypoint-app.hcl"), nil, 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(td, "a", "other.hcl.json"), nil, 0644))
+
+	cases := []struct {
+		Name         string
+		Filename     string
+		SearchParent bool
+		Expected     string
+	}{
+		{
+			"custom filename in a parent",
+			"waypoint-app.hcl",
+			true,
+			filepath.Join(td, "waypoint-app.hcl"),
+		},
+		{
+			"custom filename without searching parents",
+			"waypoint-app.hcl",
+			false,
+			"",
+		},
+		{
+			"custom JSON filename",
+			"other.hcl",
+			true,
+			filepath.Join(td, "a", "other.hcl.json"),
+		},
+		{
+			"default filename isn't found",
+			"",
+			true,
+			"",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			require := require.New(t)
+
+			path, err := FindPath(nested, tt.Filename, tt.SearchParent)
+			require.NoError(err)
+			require.Equal(tt.Expected, path)
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// hclProfile is a named set of overrides for the base configuration, such
+// as for a single environment. A profile is selected when loading with
+// LoadOptions.Profile.
+type hclProfile struct {
+	Name      string            `hcl:",label"`
+	Runner    *Runner           `hcl:"runner,block"`
+	Labels    map[string]string `hcl:"labels,optional"`
+	Apps      []*hclProfileApp  `hcl:"app,block"`
+	DeclRange hcl.Range         `hcl:",def_range"`
+}
+
+// hclProfileApp overrides the stages of an app in a profile. Each stage
+// replaces the stage of the same type in the base app. A build without a
+// registry keeps the registry of the base app.
+type hclProfileApp struct {
+	Name       string    `hcl:",label"`
+	BuildRaw   *hclBuild `hcl:"build,block"`
+	DeployRaw  *hclStage `hcl:"deploy,block"`
+	ReleaseRaw *hclStage `hcl:"release,block"`
+	DeclRange  hcl.Range `hcl:",def_range"`
+}
+
+// Profiles returns the names of the profiles in the configuration.
+func (c *Config) Profiles() []string {
+	return profileNames(c.hclConfig.Profiles)
+}
+
+// Profile returns the name of the profile the configuration was loaded
+// with, or an empty string if no profile was selected.
+func (c *Config) Profile() string {
+	return c.profile
+}
+
+func profileNames(profiles []*hclProfile) []string {
+	var result []string
+	for _, p := range profiles {
+		result = append(result, p.Name)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// applyProfile merges the profile named name into the base configuration.
+func applyProfile(cfg *hclConfig, name string) hcl.Diagnostics {
+	var profile *hclProfile
+	for _, p := range cfg.Profiles {
+		if p.Name == name {
+			profile = p
+			break
+		}
+	}
+	if profile == nil {
+		available := "There are no profiles in the configuration."
+		if names := profileNames(cfg.Profiles); len(names) > 0 {
+			available = "The available profiles are: " + strings.Join(names, ", ")
+		}
+
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("Unknown profile %q", name),
+			Detail:   available,
+		}}
+	}
+
+	if profile.Runner != nil {
+		cfg.Runner = profile.Runner
+	}
+
+	if len(profile.Labels) > 0 {
+		labels := map[string]string{}
+		for k, v := range cfg.Labels {
+			labels[k] = v
+		}
+		for k, v := range profile.Labels {
+			labels[k] = v
+		}
+		cfg.Labels = labels
+	}
+
+	var diags hcl.Diagnostics
+	for _, override := range profile.Apps {
+		var app *hclApp
+		for _, a := range cfg.Apps {
+			if a.Name == override.Name {
+				app = a
+				break
+			}
+		}
+		if app == nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Unknown app %q in profile %q", override.Name, name),
+				Detail:   "Profiles can only override apps defined in the configuration.",
+				Subject:  override.DeclRange.Ptr(),
+			})
+			continue
+		}
+
+		app.profile = override
+		app.applyProfile()
+	}
+
+	return diags
+}
+
+// applyProfile replaces the stages of the app with the stages set in its
+// profile override, if any.
+func (app *hclApp) applyProfile() {
+	override := app.profile
+	if override == nil {
+		return
+	}
+
+	if v := override.BuildRaw; v != nil {
+		build := *v
+		if build.Registry == nil && app.BuildRaw != nil {
+			build.Registry = app.BuildRaw.Registry
+		}
+
+		app.BuildRaw = &build
+	}
+	if v := override.DeployRaw; v != nil {
+		app.DeployRaw = v
+	}
+	if v := override.ReleaseRaw; v != nil {
+		app.ReleaseRaw = v
+	}
+}
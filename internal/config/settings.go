@@ -0,0 +1,255 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Setting is a single top-level setting of the loaded configuration along
+// with where it was set. Settings are used to show the effective
+// configuration after the project configuration and profile are merged,
+// so that it's clear which file contributed each setting.
+type Setting struct {
+	// Name is the name of the setting, such as "project", "labels.env",
+	// or "app.web.deploy".
+	Name string
+
+	// Value is a summary of the value. For attributes this is the value
+	// in HCL syntax. For blocks this is a summary such as the plugin type,
+	// or empty if there is nothing to summarize.
+	Value string
+
+	// Range is where the setting is defined.
+	Range hcl.Range
+
+	// Profile is the name of the profile that set or overrode the setting,
+	// if any. Range is the profile in that case.
+	Profile string
+}
+
+// Settings returns the top-level settings of the configuration as they
+// were merged while loading. Attributes are first, followed by blocks in
+// the order they're defined. App stages are included individually since
+// a profile can override them.
+func (c *Config) Settings() ([]*Setting, hcl.Diagnostics) {
+	schema, _ := gohcl.ImpliedBodySchema(&hclConfig{})
+	content, diags := c.hclConfig.Body.Content(schema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var profile *hclProfile
+	for _, p := range c.hclConfig.Profiles {
+		if p.Name == c.profile {
+			profile = p
+			break
+		}
+	}
+
+	var result []*Setting
+
+	// Attributes are in a map, so we order them by file and position.
+	attrs := make([]*hcl.Attribute, 0, len(content.Attributes))
+	for _, attr := range content.Attributes {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return rangeLess(attrs[i].Range, attrs[j].Range)
+	})
+
+	for _, attr := range attrs {
+		if attr.Name == "labels" {
+			result = append(result, c.labelSettings(attr, profile)...)
+			continue
+		}
+
+		result = append(result, &Setting{
+			Name:  attr.Name,
+			Value: c.settingValue(attr),
+			Range: attr.Range,
+		})
+	}
+
+	// Labels that are only set by the profile have no attribute.
+	if _, ok := content.Attributes["labels"]; !ok {
+		result = append(result, c.labelSettings(nil, profile)...)
+	}
+
+	sawRunner := false
+	for _, block := range content.Blocks {
+		name := block.Type
+		if len(block.Labels) > 0 {
+			name += "." + block.Labels[0]
+		}
+
+		switch block.Type {
+		case "runner":
+			sawRunner = true
+			s := &Setting{Name: name, Range: block.DefRange}
+			if profile != nil && profile.Runner != nil {
+				s.Range = profile.DeclRange
+				s.Profile = profile.Name
+			}
+			s.Value = runnerSummary(c.hclConfig.Runner)
+			result = append(result, s)
+
+		case "app":
+			result = append(result, c.appSettings(name, block, profile)...)
+
+		case "profile":
+			// Profiles are shown as the settings they override.
+
+		default:
+			result = append(result, &Setting{Name: name, Range: block.DefRange})
+		}
+	}
+
+	// A runner that is only set by the profile has no block.
+	if !sawRunner && profile != nil && profile.Runner != nil {
+		result = append(result, &Setting{
+			Name:    "runner",
+			Value:   runnerSummary(c.hclConfig.Runner),
+			Range:   profile.DeclRange,
+			Profile: profile.Name,
+		})
+	}
+
+	return result, nil
+}
+
+// labelSettings returns a setting per label, noting the labels that the
+// profile set.
+func (c *Config) labelSettings(attr *hcl.Attribute, profile *hclProfile) []*Setting {
+	keys := make([]string, 0, len(c.hclConfig.Labels))
+	for k := range c.hclConfig.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var result []*Setting
+	for _, k := range keys {
+		s := &Setting{
+			Name:  "labels." + k,
+			Value: fmt.Sprintf("%q", c.hclConfig.Labels[k]),
+		}
+		if _, ok := profile.labels()[k]; ok {
+			s.Range = profile.DeclRange
+			s.Profile = profile.Name
+		} else if attr != nil {
+			s.Range = attr.Range
+		}
+
+		result = append(result, s)
+	}
+
+	return result
+}
+
+// appSettings returns the settings for the app block, with a setting for
+// each of its stages.
+func (c *Config) appSettings(name string, block *hcl.Block, profile *hclProfile) []*Setting {
+	var app *hclApp
+	for _, a := range c.hclConfig.Apps {
+		if a.DeclRange == block.DefRange {
+			app = a
+			break
+		}
+	}
+	if app == nil {
+		return []*Setting{{Name: name, Range: block.DefRange}}
+	}
+
+	result := []*Setting{{
+		Name:  name,
+		Value: fmt.Sprintf("path = %q", app.Path),
+		Range: app.DeclRange,
+	}}
+
+	stage := func(stage string, use *Use, overridden bool) {
+		if use == nil {
+			return
+		}
+
+		s := &Setting{
+			Name:  name + "." + stage,
+			Value: fmt.Sprintf("use %q", use.Type),
+			Range: app.DeclRange,
+		}
+		if overridden {
+			s.Range = app.profile.DeclRange
+			s.Profile = profile.Name
+		}
+
+		result = append(result, s)
+	}
+
+	// The stages overridden by the profile are replaced wholesale, except
+	// for a build registry that the profile doesn't set.
+	override := app.profile
+	if v := app.BuildRaw; v != nil {
+		stage("build", v.Use, override != nil && override.BuildRaw != nil)
+		if v.Registry != nil {
+			stage("registry", v.Registry.Use,
+				override != nil && override.BuildRaw != nil && override.BuildRaw.Registry != nil)
+		}
+	}
+	if v := app.DeployRaw; v != nil {
+		stage("deploy", v.Use, override != nil && override.DeployRaw != nil)
+	}
+	if v := app.ReleaseRaw; v != nil {
+		stage("release", v.Use, override != nil && override.ReleaseRaw != nil)
+	}
+
+	return result
+}
+
+// settingValue returns the value of the attribute in HCL syntax. If the
+// value can't be evaluated without more context, this is the source of
+// the expression.
+func (c *Config) settingValue(attr *hcl.Attribute) string {
+	v, diags := attr.Expr.Value(c.ctx)
+	if diags.HasErrors() || !v.IsWhollyKnown() {
+		rng := attr.Expr.Range()
+		return fmt.Sprintf("(expression at %s)", rng.String())
+	}
+
+	return strings.TrimSpace(string(hclwrite.TokensForValue(v).Bytes()))
+}
+
+// runnerSummary summarizes the runner settings.
+func runnerSummary(r *Runner) string {
+	if r == nil {
+		return ""
+	}
+
+	result := fmt.Sprintf("enabled = %t", r.Enabled)
+	if r.DataSource != nil {
+		result += fmt.Sprintf(", data_source %q", r.DataSource.Type)
+	}
+
+	return result
+}
+
+// labels returns the labels set by the profile. This is safe to call on a
+// nil profile.
+func (p *hclProfile) labels() map[string]string {
+	if p == nil {
+		return nil
+	}
+
+	return p.Labels
+}
+
+// rangeLess orders ranges by file and then by position.
+func rangeLess(a, b hcl.Range) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+
+	return a.Start.Byte < b.Start.Byte
+}
@@ -0,0 +1,59 @@
+project = "foo"
+
+app "root" {
+  build {
+    use "docker" {}
+  }
+
+  deploy {
+    use "docker" {}
+  }
+}
+
+app "web" {
+  path = "./apps/web"
+
+  build {
+    use "docker" {}
+  }
+
+  deploy {
+    use "docker" {}
+  }
+}
+
+app "web-admin" {
+  path = "./apps/web/admin"
+
+  build {
+    use "docker" {}
+  }
+
+  deploy {
+    use "docker" {}
+  }
+}
+
+app "api" {
+  path = "./apps/api"
+
+  build {
+    use "docker" {}
+  }
+
+  deploy {
+    use "docker" {}
+  }
+}
+
+app "api-worker" {
+  path = "./apps/api"
+
+  build {
+    use "docker" {}
+  }
+
+  deploy {
+    use "docker" {}
+  }
+}
@@ -0,0 +1,8 @@
+project = "foo"
+
+app "foo" {
+  workspaces = ["staging", "prod"]
+}
+
+app "bar" {
+}
@@ -0,0 +1,16 @@
+project = "foo"
+
+variable "ref" {
+  type    = string
+  default = "main"
+}
+
+variable "unset" {
+  type = string
+}
+
+app "bar" {
+  labels = {
+    "ref" = var.ref
+  }
+}
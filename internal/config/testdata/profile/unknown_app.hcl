@@ -0,0 +1,19 @@
+project = "foo"
+
+app "web" {
+  build {
+    use "docker" {}
+  }
+
+  deploy {
+    use "docker" {}
+  }
+}
+
+profile "prod" {
+  app "api" {
+    deploy {
+      use "kubernetes" {}
+    }
+  }
+}
@@ -0,0 +1,48 @@
+project = "foo"
+
+labels = {
+  env  = "dev"
+  team = "web"
+}
+
+app "web" {
+  build {
+    use "docker" {}
+
+    registry {
+      use "docker" {
+        image = "dev/web"
+      }
+    }
+  }
+
+  deploy {
+    use "docker" {}
+  }
+}
+
+profile "prod" {
+  runner {
+    enabled = true
+  }
+
+  labels = {
+    env = "prod"
+  }
+
+  app "web" {
+    build {
+      use "pack" {}
+    }
+
+    deploy {
+      use "kubernetes" {}
+    }
+  }
+}
+
+profile "staging" {
+  labels = {
+    env = "staging"
+  }
+}
@@ -0,0 +1,9 @@
+app "web" {
+  build {
+    use "docker" {}
+  }
+
+  deploy {
+    use "docker" {}
+  }
+}
@@ -0,0 +1,3 @@
+project = "foo"
+
+app "web" {}
@@ -0,0 +1,3 @@
+project = "bar"
+
+app "web" {}
@@ -0,0 +1,3 @@
+runner {
+  enabled = false
+}
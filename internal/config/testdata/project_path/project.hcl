@@ -0,0 +1,5 @@
+project = "foo"
+
+runner {
+  enabled = true
+}
@@ -0,0 +1,9 @@
+project = "foo"
+
+required_env = ["DATABASE_URL", "BAD=NAME"]
+
+app "web" {
+    build {}
+
+    deploy {}
+}
@@ -0,0 +1,12 @@
+project = "foo"
+
+workspace_aliases = {
+  prod = "production"
+  production = "production-us-east-1"
+}
+
+app "web" {
+    build {}
+
+    deploy {}
+}
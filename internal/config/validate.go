@@ -1,9 +1,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/go-multierror"
@@ -17,24 +19,30 @@ import (
 // requires duplication between this struct and the other config structs
 // since we don't do any lazy loading here.
 type validateStruct struct {
-	Project   string              `hcl:"project,optional"`
-	Runner    *Runner             `hcl:"runner,block" default:"{}"`
-	Labels    map[string]string   `hcl:"labels,optional"`
-	Variables []*validateVariable `hcl:"variable,block"`
-	Plugin    []*Plugin           `hcl:"plugin,block"`
-	Apps      []*validateApp      `hcl:"app,block"`
-	Config    *genericConfig      `hcl:"config,block"`
+	Project     string              `hcl:"project,optional"`
+	Runner      *Runner             `hcl:"runner,block" default:"{}"`
+	Labels      map[string]string   `hcl:"labels,optional"`
+	RequiredEnv []string            `hcl:"required_env,optional"`
+	Variables   []*validateVariable `hcl:"variable,block"`
+	Plugin      []*Plugin           `hcl:"plugin,block"`
+	Apps        []*validateApp      `hcl:"app,block"`
+	Config      *genericConfig      `hcl:"config,block"`
+	Profiles    []*hclProfile       `hcl:"profile,block"`
+
+	WorkspaceAliases    map[string]string `hcl:"workspace_aliases,optional"`
+	ProtectedWorkspaces []string          `hcl:"protected_workspaces,optional"`
 }
 
 type validateApp struct {
-	Name    string            `hcl:",label"`
-	Path    string            `hcl:"path,optional"`
-	Labels  map[string]string `hcl:"labels,optional"`
-	URL     *AppURL           `hcl:"url,block" default:"{}"`
-	Build   *Build            `hcl:"build,block"`
-	Deploy  *Deploy           `hcl:"deploy,block"`
-	Release *Release          `hcl:"release,block"`
-	Config  *genericConfig    `hcl:"config,block"`
+	Name       string            `hcl:",label"`
+	Path       string            `hcl:"path,optional"`
+	Labels     map[string]string `hcl:"labels,optional"`
+	Workspaces []string          `hcl:"workspaces,optional"`
+	URL        *AppURL           `hcl:"url,block" default:"{}"`
+	Build      *Build            `hcl:"build,block"`
+	Deploy     *Deploy           `hcl:"deploy,block"`
+	Release    *Release          `hcl:"release,block"`
+	Config     *genericConfig    `hcl:"config,block"`
 }
 
 // validateVariable is separate from HclVariable because of the limitations
@@ -85,6 +93,35 @@ func (c *Config) Validate() error {
 		result = multierror.Append(result, errs...)
 	}
 
+	// Validate required env var names
+	for _, name := range c.RequiredEnv {
+		if name == "" || strings.ContainsAny(name, "= ") {
+			result = multierror.Append(result, fmt.Errorf(
+				"'required_env' contains an invalid env var name: %q", name))
+		}
+	}
+
+	// Validate workspace aliases. An alias can't refer to another alias
+	// since aliases are only resolved once.
+	aliases := make([]string, 0, len(c.WorkspaceAliases))
+	for alias := range c.WorkspaceAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		target := c.WorkspaceAliases[alias]
+		if alias == "" || target == "" {
+			result = multierror.Append(result, errors.New(
+				"'workspace_aliases' can't contain an empty workspace name"))
+			continue
+		}
+
+		if _, ok := c.WorkspaceAliases[target]; ok {
+			result = multierror.Append(result, fmt.Errorf(
+				"workspace alias %q refers to %q, which is also an alias", alias, target))
+		}
+	}
+
 	return result
 }
 
@@ -31,6 +31,16 @@ func TestConfigValidate(t *testing.T) {
 			"build_scoped.hcl",
 			"",
 		},
+
+		{
+			"required_env_invalid.hcl",
+			"invalid env var name",
+		},
+
+		{
+			"workspace_aliases_recursive.hcl",
+			"which is also an alias",
+		},
 	}
 
 	for _, tt := range cases {
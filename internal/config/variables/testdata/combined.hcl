@@ -0,0 +1,11 @@
+server {
+  address = "localhost:9701"
+  tls     = true
+}
+
+workspace = "dev"
+
+variables {
+  art  = "gdbee"
+  port = 8080
+}
@@ -0,0 +1,7 @@
+variables {
+  art = "gdbee"
+}
+
+variables {
+  port = 8080
+}
@@ -0,0 +1,9 @@
+variable "token" {
+  type      = string
+  sensitive = true
+}
+
+variable "region" {
+  default = "us-east-1"
+  type    = string
+}
@@ -47,7 +47,7 @@ var (
 	}
 
 	// The attributes we expect to see in variable blocks
-	// Future expansion here could include `sensitive`, `validations`, etc
+	// Future expansion here could include `validations`, etc
 	variableBlockSchema = &hcl.BodySchema{
 		Attributes: []hcl.AttributeSchema{
 			{
@@ -62,6 +62,9 @@ var (
 			{
 				Name: "env",
 			},
+			{
+				Name: "sensitive",
+			},
 		},
 	}
 )
@@ -88,6 +91,10 @@ type Variable struct {
 	// Description of the variable
 	Description string
 
+	// Sensitive is true if the value is a secret. Sensitive values are
+	// never written out by the CLI, such as to variable lockfiles.
+	Sensitive bool
+
 	// The location of the variable definition block in the waypoint.hcl
 	Range hcl.Range
 }
@@ -103,6 +110,7 @@ type HclVariable struct {
 	Type        hcl.Expression `hcl:"type,optional"`
 	Description string         `hcl:"description,optional"`
 	Env         []string       `hcl:"env,optional"`
+	Sensitive   bool           `hcl:"sensitive,optional"`
 }
 
 // Values are used to store values collected from various sources.
@@ -193,6 +201,14 @@ func decodeVariableBlock(block *hcl.Block) (*Variable, hcl.Diagnostics) {
 		}
 	}
 
+	if attr, exists := content.Attributes["sensitive"]; exists {
+		valDiags := gohcl.DecodeExpression(attr.Expr, nil, &v.Sensitive)
+		diags = append(diags, valDiags...)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+	}
+
 	if attr, exists := content.Attributes["default"]; exists {
 		val, valDiags := attr.Expr.Value(nil)
 		diags = append(diags, valDiags...)
@@ -232,19 +248,58 @@ func decodeVariableBlock(block *hcl.Block) (*Variable, hcl.Diagnostics) {
 	return &v, diags
 }
 
+// Precedence controls whether values from -var or -var-file win when the
+// same variable is set by both.
+type Precedence string
+
+const (
+	// PrecedenceFlags means -var values override -var-file values. This is
+	// the default.
+	PrecedenceFlags Precedence = "flags"
+
+	// PrecedenceFiles means -var-file values override -var values, for
+	// when files are the source of truth and flags are fallbacks.
+	PrecedenceFiles Precedence = "files"
+)
+
 // LoadVariableValues collects values set via the CLI (-var, -varfile) and
 // local env vars (WP_VAR_*) and translates those values to pb.Variables. These
 // pb.Variables can then be set on the job for eventual parsing on the runner,
 // after the runner has decoded the variables defined in the waypoint.hcl.
 // All values are set as protobuf strings, with the expectation that later
 // evaluation will convert them to their defined types.
+//
+// This uses PrecedenceFlags. See LoadVariableValuesPrecedence.
 func LoadVariableValues(vars map[string]string, files []string) ([]*pb.Variable, hcl.Diagnostics) {
+	result, _, diags := LoadVariableValuesPrecedence(vars, files, PrecedenceFlags)
+	return result, diags
+}
+
+// LoadVariableValuesPrecedence is the same as LoadVariableValues but allows
+// choosing the relative precedence of -var and -var-file values. The full
+// chain of precedence once the job reaches the runner is, from lowest to
+// highest:
+//
+//   - variable defaults in the waypoint.hcl
+//   - values set on the server for the project
+//   - environment variables set with the "env" field of a variable
+//   - *.auto.wpvars(.json) files
+//   - WP_VAR_* environment variables
+//   - -var-file and -var values, in the order chosen by p
+//
+// This also returns the paths of the var files that were read, in the order
+// they were read. Values from later files override earlier ones.
+func LoadVariableValuesPrecedence(
+	vars map[string]string,
+	files []string,
+	p Precedence,
+) ([]*pb.Variable, []string, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 	ret := []*pb.Variable{}
 
 	// The order here is important, as the order in which values are evaluated
 	// dictate their precedence. We therefore evalute these three sources in order
-	// of env, file, cli.
+	// of env, then file and cli in the order set by the precedence.
 
 	// process env values ("env" source)
 	{
@@ -273,25 +328,41 @@ func LoadVariableValues(vars map[string]string, files []string) ([]*pb.Variable,
 	}
 
 	// process -var-file args ("file" source)
+	var fileVars []*pb.Variable
+	var readFiles []string
 	for _, file := range files {
 		if file != "" {
-			pbv, diags := parseFileValues(file, sourceFile)
-			if diags.HasErrors() {
-				return nil, diags
+			pbv, fileDiags := parseFileValues(file, sourceFile)
+			diags = append(diags, fileDiags...)
+			if fileDiags.HasErrors() {
+				return nil, nil, diags
 			}
-			ret = append(ret, pbv...)
+			fileVars = append(fileVars, pbv...)
+			readFiles = append(readFiles, file)
 		}
 	}
 
 	// process -var args ("cli" source)
+	var cliVars []*pb.Variable
 	for name, val := range vars {
-		ret = append(ret, &pb.Variable{
+		cliVars = append(cliVars, &pb.Variable{
 			Name:   name,
 			Value:  &pb.Variable_Str{Str: val},
 			Source: &pb.Variable_Cli{},
 		})
 	}
-	return ret, diags
+
+	switch p {
+	case PrecedenceFiles:
+		ret = append(ret, cliVars...)
+		ret = append(ret, fileVars...)
+
+	default:
+		ret = append(ret, fileVars...)
+		ret = append(ret, cliVars...)
+	}
+
+	return ret, readFiles, diags
 }
 
 // LoadEnvValues loads the variable values from environment variables
@@ -341,6 +412,34 @@ func EvaluateVariables(
 	pbvars []*pb.Variable,
 	vs map[string]*Variable,
 	log hclog.Logger,
+) (Values, hcl.Diagnostics) {
+	return evaluateVariables(pbvars, vs, log, true)
+}
+
+// EvaluatePartialVariables is the same as EvaluateVariables except that
+// variables without a value are allowed and omitted from the result. This
+// is used where only some values are known, such as when evaluating the
+// configuration in the CLI before values from the server are available.
+func EvaluatePartialVariables(
+	pbvars []*pb.Variable,
+	vs map[string]*Variable,
+	log hclog.Logger,
+) (Values, hcl.Diagnostics) {
+	iv, diags := evaluateVariables(pbvars, vs, log, false)
+	for k, v := range iv {
+		if v == nil {
+			delete(iv, k)
+		}
+	}
+
+	return iv, diags
+}
+
+func evaluateVariables(
+	pbvars []*pb.Variable,
+	vs map[string]*Variable,
+	log hclog.Logger,
+	requireAll bool,
 ) (Values, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 	iv := Values{}
@@ -462,6 +561,9 @@ func EvaluateVariables(
 	}
 
 	// check that all variables have a set value, including default of null
+	if !requireAll {
+		return iv, diags
+	}
 	for name, variable := range vs {
 		v, ok := iv[name]
 		if !ok || v == nil {
@@ -484,7 +586,20 @@ func LoadAutoFiles(wd string) ([]*pb.Variable, hcl.Diagnostics) {
 	var pbv []*pb.Variable
 	var diags hcl.Diagnostics
 
-	// Check working directory (vcs or local) for *.auto.wpvars(.json) files
+	// Every file is parsed, but only the values of the last one are used.
+	for _, f := range AutoVarFiles(wd) {
+		pbv, diags = parseFileValues(f, sourceVCS)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+	}
+	return pbv, nil
+}
+
+// AutoVarFiles returns the paths to the *.auto.wpvars(.json) files in the
+// directory wd in the order they're read by LoadAutoFiles. Only the values
+// of the last file are used.
+func AutoVarFiles(wd string) []string {
 	var varFiles []string
 	if files, err := ioutil.ReadDir(wd); err == nil {
 		for _, f := range files {
@@ -496,15 +611,7 @@ func LoadAutoFiles(wd string) ([]*pb.Variable, hcl.Diagnostics) {
 		}
 	}
 
-	for _, f := range varFiles {
-		if f != "" {
-			pbv, diags = parseFileValues(f, sourceVCS)
-			if diags.HasErrors() {
-				return nil, diags
-			}
-		}
-	}
-	return pbv, nil
+	return varFiles
 }
 
 // parseFileValues is a helper function to extract variable values from the
@@ -516,7 +623,13 @@ func parseFileValues(filename string, source string) ([]*pb.Variable, hcl.Diagno
 		return nil, diags
 	}
 
-	attrs, moreDiags := f.Body.JustAttributes()
+	body, moreDiags := valuesBody(filename, f)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+
+	attrs, moreDiags := body.JustAttributes()
 	diags = append(diags, moreDiags...)
 	// We grab all variables here; we'll later check set variables against the
 	// known variables defined in the waypoint.hcl on the runner when we
@@ -569,6 +682,42 @@ func parseFileValues(filename string, source string) ([]*pb.Variable, hcl.Diagno
 	return pbv, diags
 }
 
+// valuesBody returns the body of the file that contains the variable
+// values. For HCL files, if there is a top-level "variables" block then
+// only its contents are the values and any other blocks or attributes in
+// the file are ignored. This lets a single file hold other settings as
+// well as the values. Otherwise, the whole file is the values.
+//
+// JSON files are always treated as a flat map of values, since a
+// "variables" property could just as well be a value for a variable named
+// "variables".
+func valuesBody(filename string, f *hcl.File) (hcl.Body, hcl.Diagnostics) {
+	if strings.HasSuffix(filename, ".json") {
+		return f.Body, nil
+	}
+
+	content, _, _ := f.Body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "variables"}},
+	})
+	switch len(content.Blocks) {
+	case 0:
+		return f.Body, nil
+
+	case 1:
+		return content.Blocks[0].Body, nil
+
+	default:
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Duplicate variables block",
+			Detail: fmt.Sprintf("Only one \"variables\" block is allowed in %s. "+
+				"The block was already defined at %s.",
+				filename, content.Blocks[0].DefRange),
+			Subject: &content.Blocks[1].DefRange,
+		}}
+	}
+}
+
 // readFileValues is a helper function that loads a file, parses if it is
 // hcl or json, and checks for any errant variable definition blocks. It returns
 // the files contents for further evaluation.
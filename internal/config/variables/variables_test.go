@@ -1,6 +1,7 @@
 package variables
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -74,6 +75,22 @@ func TestVariables_DecodeVariableBlock(t *testing.T) {
 	}
 }
 
+func TestVariables_DecodeVariableBlock_sensitive(t *testing.T) {
+	require := require.New(t)
+
+	base := testConfig{}
+	require.NoError(hclsimple.DecodeFile(filepath.Join("testdata", "sensitive.hcl"), nil, &base))
+
+	schema, _ := gohcl.ImpliedBodySchema(&testConfig{})
+	content, diags := base.Body.Content(schema)
+	require.False(diags.HasErrors())
+
+	vs, diags := DecodeVariableBlocks(content)
+	require.False(diags.HasErrors(), diags.Error())
+	require.True(vs["token"].Sensitive)
+	require.False(vs["region"].Sensitive)
+}
+
 func TestVariables_parseFileValues(t *testing.T) {
 	cases := []struct {
 		file     string
@@ -107,6 +124,26 @@ func TestVariables_parseFileValues(t *testing.T) {
 			},
 			err: "",
 		},
+		{
+			file: "combined.hcl",
+			expected: []*pb.Variable{
+				{
+					Name:   "art",
+					Value:  &pb.Variable_Str{Str: "gdbee"},
+					Source: &pb.Variable_File_{},
+				},
+				{
+					Name:   "port",
+					Value:  &pb.Variable_Num{Num: 8080},
+					Source: &pb.Variable_File_{},
+				},
+			},
+			err: "",
+		},
+		{
+			file: "combined_duplicate.hcl",
+			err:  "Duplicate variables block",
+		},
 		{
 			file: "nofile.wpvars",
 			err:  "Given variables file testdata/nofile.wpvars does not exist",
@@ -176,6 +213,35 @@ func TestVariables_LoadVCSFile(t *testing.T) {
 	}
 }
 
+func TestVariables_AutoVarFiles(t *testing.T) {
+	require := require.New(t)
+	require.Equal(
+		[]string{filepath.Join("testdata", "test.auto.wpvars")},
+		AutoVarFiles("testdata"),
+	)
+	require.Empty(AutoVarFiles(filepath.Join("testdata", "nope")))
+}
+
+func TestVariables_LoadAutoFiles_lastFileWins(t *testing.T) {
+	require := require.New(t)
+
+	td, err := ioutil.TempDir("", "waypoint-test")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+
+	require.NoError(ioutil.WriteFile(filepath.Join(td, "a.auto.wpvars"),
+		[]byte("mug = \"ceramic\"\ncup = \"paper\"\n"), 0644))
+	require.NoError(ioutil.WriteFile(filepath.Join(td, "b.auto.wpvars"),
+		[]byte("mug = \"steel\"\n"), 0644))
+
+	// Only the values of the last file are used, so "cup" isn't set.
+	vars, diags := LoadAutoFiles(td)
+	require.False(diags.HasErrors())
+	require.Len(vars, 1)
+	require.Equal("mug", vars[0].Name)
+	require.Equal("steel", vars[0].Value.(*pb.Variable_Str).Str)
+}
+
 func TestVariables_EvalInputValues(t *testing.T) {
 	cases := []struct {
 		name        string
@@ -408,6 +474,53 @@ func TestVariables_SetJobInputVariables(t *testing.T) {
 	}
 }
 
+func TestVariables_LoadVariableValuesPrecedence(t *testing.T) {
+	files := []string{filepath.Join("testdata", "values.wpvars")}
+	cliArgs := map[string]string{"art": "cli"}
+
+	cases := []struct {
+		name       string
+		precedence Precedence
+		expected   *pb.Variable
+	}{
+		{
+			"flags",
+			PrecedenceFlags,
+			&pb.Variable{
+				Name:   "art",
+				Value:  &pb.Variable_Str{Str: "cli"},
+				Source: &pb.Variable_Cli{},
+			},
+		},
+		{
+			"files",
+			PrecedenceFiles,
+			&pb.Variable{
+				Name:   "art",
+				Value:  &pb.Variable_Str{Str: "gdbee"},
+				Source: &pb.Variable_File_{},
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			require := require.New(t)
+			vars, read, diags := LoadVariableValuesPrecedence(cliArgs, files, tt.precedence)
+			require.False(diags.HasErrors())
+			require.Equal(files, read)
+
+			// The last value for a variable is the one that wins
+			var last *pb.Variable
+			for _, v := range vars {
+				if v.Name == "art" {
+					last = v
+				}
+			}
+			require.Equal(tt.expected, last)
+		})
+	}
+}
+
 func TestLoadEnvValues(t *testing.T) {
 	cases := []struct {
 		name     string
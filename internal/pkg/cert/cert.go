@@ -3,6 +3,7 @@ package cert
 import (
 	"context"
 	"crypto/tls"
+	"os"
 	"sync"
 	"time"
 
@@ -20,12 +21,34 @@ type Cert struct {
 	cert              *tls.Certificate
 	watcher           *fsnotify.Watcher
 	watcherStop       context.CancelFunc
+
+	// pollInterval is set to poll the files for changes rather than use
+	// filesystem events. See WithPollInterval.
+	pollInterval time.Duration
+}
+
+// DefaultPollInterval is the interval the files are polled at if
+// filesystem events aren't available.
+const DefaultPollInterval = 10 * time.Second
+
+// Option is an option for New.
+type Option func(*Cert)
+
+// WithPollInterval polls the certificate and key files for changes to
+// their modification time or size at the given interval, rather than
+// watching for filesystem events. Events aren't reliable on some network
+// filesystems and container bind mounts. Zero uses filesystem events.
+func WithPollInterval(d time.Duration) Option {
+	return func(c *Cert) {
+		c.pollInterval = d
+	}
 }
 
 // New initializes a certificate from a PEM-encoded certificate and private key
 // written to disk. This loads the initial certificate and sets up file watchers
-// to watch for any changes to reload the certificate.
-func New(log hclog.Logger, crtPath, keyPath string) (*Cert, error) {
+// to watch for any changes to reload the certificate. If filesystem events
+// aren't available, this falls back to polling with DefaultPollInterval.
+func New(log hclog.Logger, crtPath, keyPath string, opts ...Option) (*Cert, error) {
 	if log == nil {
 		log = hclog.L()
 	}
@@ -37,27 +60,43 @@ func New(log hclog.Logger, crtPath, keyPath string) (*Cert, error) {
 		keyFile:  keyPath,
 		lock:     &lock,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
 
 	// Do an initial reload
 	if err := c.reload(); err != nil {
 		return nil, err
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	c.watcherStop = cancel
+
+	// If requested, poll rather than using filesystem events.
+	if c.pollInterval > 0 {
+		go c.poll(ctx, c.pollInterval)
+		return c, nil
+	}
+
 	// Initialize watcher to watch for file changes. We just watch for
 	// certificate file changes because any change in the certificate
 	// must have a change in the key (and vice versa) so whenever the
 	// certificate changes we reload everything.
 	w, err := fsnotify.NewWatcher()
-	if err != nil {
-		return nil, err
+	if err == nil {
+		if err = w.Add(crtPath); err != nil {
+			w.Close()
+		}
 	}
-	if err := w.Add(crtPath); err != nil {
-		w.Close()
-		return nil, err
+	if err != nil {
+		log.Warn("filesystem events are unavailable, polling the certificate for changes",
+			"err", err,
+			"interval", DefaultPollInterval)
+		go c.poll(ctx, DefaultPollInterval)
+		return c, nil
 	}
-	ctx, cancel := context.WithCancel(context.Background())
+
 	c.watcher = w
-	c.watcherStop = cancel
 	go c.watch(ctx, w)
 
 	return c, nil
@@ -68,7 +107,8 @@ func New(log hclog.Logger, crtPath, keyPath string) (*Cert, error) {
 // will use the new certificate.
 func (c *Cert) Replace(crtPath, keyPath string) error {
 	// Create a new cert, it is easier to handle errors.
-	newCert, err := New(hclog.NewNullLogger(), crtPath, keyPath)
+	newCert, err := New(hclog.NewNullLogger(), crtPath, keyPath,
+		WithPollInterval(c.pollInterval))
 	if err != nil {
 		return err
 	}
@@ -154,20 +194,79 @@ func (c *Cert) watch(ctx context.Context, w *fsnotify.Watcher) {
 				"op", event.Op.String(),
 			)
 
-			// Reload and retry a few times. We retry because sometimes
-			// we get a notification for the cert before the key is ready.
-			for i := 0; i < 50; i++ {
-				if err := c.reload(); err != nil {
-					c.log.Warn("error during reload", "err", err, "i", i)
-					time.Sleep(100 * time.Millisecond)
-					continue
-				}
-
-				break
-			}
+			c.reloadRetry()
 
 		case err := <-w.Errors:
 			c.log.Warn("error in filesystem watch", "err", err)
 		}
 	}
 }
+
+// poll checks the certificate and key files for changes at the given
+// interval, for when filesystem events aren't available.
+func (c *Cert) poll(ctx context.Context, interval time.Duration) {
+	c.lock.RLock()
+	crtPath, keyPath := c.certFile, c.keyFile
+	c.lock.RUnlock()
+
+	last := fileStamps(crtPath, keyPath)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-t.C:
+			current := fileStamps(crtPath, keyPath)
+			if current == last {
+				continue
+			}
+			last = current
+
+			c.log.Warn("certificate change detected", "name", crtPath, "op", "poll")
+			c.reloadRetry()
+		}
+	}
+}
+
+// fileStamp is the modification time and size of a file, used to detect
+// changes when polling. An error reading the file is a stamp of its own so
+// that the file appearing again is a change.
+type fileStamp struct {
+	modTime int64
+	size    int64
+	missing bool
+}
+
+func fileStamps(crtPath, keyPath string) [2]fileStamp {
+	var result [2]fileStamp
+	for i, path := range []string{crtPath, keyPath} {
+		info, err := os.Stat(path)
+		if err != nil {
+			result[i].missing = true
+			continue
+		}
+
+		result[i].modTime = info.ModTime().UnixNano()
+		result[i].size = info.Size()
+	}
+
+	return result
+}
+
+// reloadRetry reloads the certificate, retrying a few times. We retry
+// because sometimes we see the change to the cert before the key is ready.
+func (c *Cert) reloadRetry() {
+	for i := 0; i < 50; i++ {
+		if err := c.reload(); err != nil {
+			c.log.Warn("error during reload", "err", err, "i", i)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		break
+	}
+}
@@ -153,3 +153,47 @@ func TestCert_watchCrtFirst(t *testing.T) {
 		return !bytes.Equal(cert.Certificate[0], cert2.Certificate[0])
 	}, 5*time.Second, 100*time.Millisecond)
 }
+
+func TestCert_poll(t *testing.T) {
+	require := require.New(t)
+
+	// Copy to a temporary directory
+	td, err := ioutil.TempDir("", "go-cert")
+	require.NoError(err)
+	defer os.RemoveAll(td)
+	path := filepath.Join(td, "testdata")
+
+	// Copy
+	require.NoError(copy.CopyDir("testdata", path))
+
+	// Our test cert
+	crtPath := filepath.Join(path, "tls.crt")
+	keyPath := filepath.Join(path, "tls.key")
+
+	// Create it, polling rather than watching
+	c, err := New(nil, crtPath, keyPath, WithPollInterval(50*time.Millisecond))
+	require.NoError(err)
+	defer c.Close()
+	require.Nil(c.watcher)
+
+	// Get the certificate
+	cert, err := c.GetCertificate(nil)
+	require.NoError(err)
+	require.NotNil(cert)
+
+	// Move tls2 over.
+	require.NoError(copy.CopyFile(
+		filepath.Join(path, "tls2.key"),
+		keyPath))
+	require.NoError(copy.CopyFile(
+		filepath.Join(path, "tls2.crt"),
+		crtPath))
+
+	// Should change
+	require.Eventually(func() bool {
+		cert2, err := c.GetCertificate(nil)
+		require.NoError(err)
+		require.NotNil(cert)
+		return !bytes.Equal(cert.Certificate[0], cert2.Certificate[0])
+	}, 5*time.Second, 100*time.Millisecond)
+}
@@ -82,6 +82,32 @@ func (f *Sets) Visit(fn func(*flag.Flag)) {
 	f.unionSet.Visit(fn)
 }
 
+// Defined returns true if the flag with the given name or alias is one
+// of the flags in the sets, whether or not it was set.
+func (f *Sets) Defined(name string) bool {
+	return f.unionSet.Lookup(name) != nil
+}
+
+// IsSet returns true if the flag with the given name was explicitly set
+// while parsing, either by its name or by any of its aliases. This allows
+// distinguishing a flag explicitly set to its default value from a flag
+// that was never specified.
+func (f *Sets) IsSet(name string) bool {
+	target := f.unionSet.Lookup(name)
+	if target == nil {
+		return false
+	}
+
+	set := false
+	f.unionSet.Visit(func(fl *flag.Flag) {
+		if fl.Value == target.Value {
+			set = true
+		}
+	})
+
+	return set
+}
+
 // Help builds custom help for this command, grouping by flag set.
 func (fs *Sets) Help() string {
 	var out bytes.Buffer
@@ -33,3 +33,40 @@ func TestSets(t *testing.T) {
 	require.Equal(int(21), valA)
 	require.Equal(int(42), valB)
 }
+
+func TestSets_IsSet(t *testing.T) {
+	require := require.New(t)
+
+	var valA, valB, valC bool
+	sets := NewSets()
+	{
+		set := sets.NewSet("A")
+		set.BoolVar(&BoolVar{
+			Name:   "a",
+			Target: &valA,
+		})
+		set.BoolVar(&BoolVar{
+			Name:    "b",
+			Aliases: []string{"bee"},
+			Target:  &valB,
+		})
+		set.BoolVar(&BoolVar{
+			Name:   "c",
+			Target: &valC,
+		})
+	}
+
+	err := sets.Parse([]string{"-a=false", "-bee"})
+	require.NoError(err)
+
+	require.True(sets.IsSet("a"))
+	require.True(sets.IsSet("b"))
+	require.True(sets.IsSet("bee"))
+	require.False(sets.IsSet("c"))
+	require.False(sets.IsSet("nope"))
+
+	// Defined doesn't depend on the flag being set.
+	require.True(sets.Defined("c"))
+	require.True(sets.Defined("bee"))
+	require.False(sets.Defined("nope"))
+}
@@ -21,6 +21,12 @@ import (
 // unique context.
 var InsideODR bool
 
+// ProcessHook, if set, is called with the PID of each plugin process that
+// Factory launches once it is running, and again with started set to false
+// once it is killed. This lets the CLI track the plugin processes of a local
+// runner so that they can be stopped if the CLI exits without closing them.
+var ProcessHook func(pid int, started bool)
+
 // exePath contains the value of os.Executable. We cache the value because
 // we use it a lot and subsequent calls perform syscalls.
 var exePath string
@@ -88,11 +94,25 @@ func Factory(cmd *exec.Cmd, typ component.Type) interface{} {
 			return nil, err
 		}
 
+		// Report the process so that it can be tracked.
+		var pid int
+		if hook := ProcessHook; hook != nil {
+			if rc := client.ReattachConfig(); rc != nil {
+				pid = rc.Pid
+				hook(pid, true)
+			}
+		}
+
 		log.Debug("plugin successfully launched and connected")
 		return &Instance{
 			Component: raw,
 			Mappers:   mappers,
-			Close:     func() { client.Kill() },
+			Close: func() {
+				client.Kill()
+				if hook := ProcessHook; hook != nil && pid != 0 {
+					hook(pid, false)
+				}
+			},
 		}, nil
 	}
 }
@@ -29,6 +29,12 @@ func (r *Runner) AcceptMany(ctx context.Context) {
 				return
 			}
 
+			// Give another runner the chance to be assigned the job.
+			if err == errJobLabelMismatch {
+				time.Sleep(labelMismatchBackoff)
+				continue
+			}
+
 			switch status.Code(err) {
 			case codes.Canceled:
 				// Ideally we'd get ErrClosed, but there are cases where we'll observe
@@ -218,6 +224,20 @@ func (r *Runner) accept(ctx context.Context, id string) error {
 		}
 
 		log.Trace("assigned job matches expected ID for local mode")
+	} else if missing := missingRunnerLabels(
+		jobRunnerLabels(assignment.Assignment.Job.Labels), r.labels); len(missing) > 0 {
+		// The job requires labels we don't have, so nack it so that the
+		// server can assign it to a runner that does.
+		log.Info("job requires runner labels we don't have, nacking", "missing", missing)
+		if err := client.Send(&pb.RunnerJobStreamRequest{
+			Event: &pb.RunnerJobStreamRequest_Error_{
+				Error: &pb.RunnerJobStreamRequest_Error{},
+			},
+		}); err != nil {
+			return err
+		}
+
+		return errJobLabelMismatch
 	}
 
 	// Ack the assignment
@@ -15,6 +15,7 @@ import (
 
 	configpkg "github.com/hashicorp/waypoint/internal/config"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/grpcmetadata"
 	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
 	"github.com/hashicorp/waypoint/internal/server/singleprocess"
 )
@@ -55,6 +56,124 @@ func TestRunnerAccept(t *testing.T) {
 	require.Equal(pb.Job_SUCCESS, job.State)
 }
 
+func TestRunnerAccept_runnerLabels(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// Setup a runner without the required label
+	client := singleprocess.TestServer(t)
+	runner := TestRunner(t, WithClient(client), WithLabels(map[string]string{"gpu": "false"}))
+	require.NoError(runner.Start())
+
+	// Initialize our app
+	singleprocess.TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	// Queue a job that requires a GPU runner
+	job := serverptypes.TestJobNew(t, nil)
+	job.Labels = map[string]string{
+		"team":                       "ml",
+		JobRunnerLabelPrefix + "gpu": "true",
+	}
+	queueResp, err := client.QueueJob(ctx, &pb.QueueJobRequest{Job: job})
+	require.NoError(err)
+	jobId := queueResp.JobId
+
+	// Accept should nack the job
+	err = runner.Accept(ctx)
+	require.Equal(errJobLabelMismatch, err)
+
+	// The job should be queued for another runner
+	require.Eventually(func() bool {
+		result, err := client.GetJob(ctx, &pb.GetJobRequest{JobId: jobId})
+		require.NoError(err)
+		return result.State == pb.Job_QUEUED
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A runner with the label executes it. This only succeeds because the
+	// reserved runner label is removed before the project is created.
+	gpuRunner := TestRunner(t, WithClient(client), WithLabels(map[string]string{"gpu": "true"}))
+	require.NoError(gpuRunner.Start())
+	require.NoError(gpuRunner.Accept(ctx))
+
+	result, err := client.GetJob(ctx, &pb.GetJobRequest{JobId: jobId})
+	require.NoError(err)
+	require.Equal(pb.Job_SUCCESS, result.State)
+}
+
+func TestRunnerAccept_targeting(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// Setup our runner
+	client := singleprocess.TestServer(t)
+	runner := TestRunner(t, WithClient(client))
+	require.NoError(runner.Start())
+
+	// Initialize our app
+	singleprocess.TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	// Queue a job with the targeting that the CLI sends
+	job := serverptypes.TestJobNew(t, nil)
+	job.Labels = map[string]string{"team": "platform"}
+	queueResp, err := client.QueueJob(grpcmetadata.AddTargeting(ctx, map[string]string{
+		"project":   job.Application.Project,
+		"workspace": job.Workspace.Workspace,
+		"remote":    "true",
+	}), &pb.QueueJobRequest{Job: job})
+	require.NoError(err)
+	jobId := queueResp.JobId
+
+	// The server records the targeting as reserved labels
+	result, err := client.GetJob(ctx, &pb.GetJobRequest{JobId: jobId})
+	require.NoError(err)
+	require.Equal("true", result.Labels["waypoint/targeting/remote"])
+
+	// Accept should complete, since the reserved labels aren't set on the
+	// project, which rejects them.
+	require.NoError(runner.Accept(ctx))
+
+	result, err = client.GetJob(ctx, &pb.GetJobRequest{JobId: jobId})
+	require.NoError(err)
+	require.Equal(pb.Job_SUCCESS, result.State)
+}
+
+func TestRunnerAccept_configProfile(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	// Setup our runner with a configuration that has a profile
+	client := singleprocess.TestServer(t)
+	runner := TestRunner(t, WithClient(client))
+	require.NoError(runner.Start())
+	configpkg.TestConfigFile(t, `
+project = "test"
+
+profile "prod" {
+  labels = {
+    env = "prod"
+  }
+}
+`)
+
+	// Initialize our app
+	singleprocess.TestApp(t, client, serverptypes.TestJobNew(t, nil).Application)
+
+	// Queue a job that selects the profile
+	job := serverptypes.TestJobNew(t, nil)
+	job.Labels = map[string]string{JobConfigProfileLabel: "prod"}
+	queueResp, err := client.QueueJob(ctx, &pb.QueueJobRequest{Job: job})
+	require.NoError(err)
+	jobId := queueResp.JobId
+
+	// Accept should complete, since the reserved profile label isn't set
+	// on the project, which rejects it.
+	require.NoError(runner.Accept(ctx))
+
+	result, err := client.GetJob(ctx, &pb.GetJobRequest{JobId: jobId})
+	require.NoError(err)
+	require.Equal(pb.Job_SUCCESS, result.State)
+}
+
 func TestRunnerAccept_timeout(t *testing.T) {
 	require := require.New(t)
 	ctx := context.Background()
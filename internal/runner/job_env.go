@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// JobEnvLabelPrefix is the prefix of job labels that set an env var
+	// while the runner executes the job. The rest of the label key is the
+	// name of the env var. These are set with "-runner-env" in the CLI.
+	JobEnvLabelPrefix = "waypoint/runner-env/"
+
+	// JobEnvSensitiveLabelPrefix is the same as JobEnvLabelPrefix but the
+	// label value is the name of an env var of the runner, such as one set
+	// with "waypoint config set -runner", that has the value. The value is
+	// resolved by the runner so that secrets are never stored with the job,
+	// and it is never logged. These are set with "-runner-env-sensitive".
+	JobEnvSensitiveLabelPrefix = "waypoint/runner-env-sensitive/"
+)
+
+// jobEnv splits the job labels into the env vars to set for the job, the
+// names of the env vars that are sensitive, and the remaining labels. The
+// value of a sensitive env var is the name of the runner env var to read
+// the value from, see setJobEnv. The env labels are removed so that they
+// aren't stored on the resulting operations.
+func jobEnv(labels map[string]string) (env map[string]string, sensitive map[string]bool, rest map[string]string) {
+	for k, v := range labels {
+		var key string
+		switch {
+		case strings.HasPrefix(k, JobEnvSensitiveLabelPrefix):
+			key = strings.TrimPrefix(k, JobEnvSensitiveLabelPrefix)
+			if sensitive == nil {
+				sensitive = map[string]bool{}
+			}
+			sensitive[key] = true
+
+		case strings.HasPrefix(k, JobEnvLabelPrefix):
+			key = strings.TrimPrefix(k, JobEnvLabelPrefix)
+
+		default:
+			if rest == nil {
+				rest = map[string]string{}
+			}
+			rest[k] = v
+			continue
+		}
+
+		if env == nil {
+			env = map[string]string{}
+		}
+		env[key] = v
+	}
+
+	return env, sensitive, rest
+}
+
+// setJobEnv sets the env vars for a job. The value of each sensitive env
+// var is read from the runner env var that it names. The returned function
+// restores the previous values and must be called once the job is
+// complete, even if an error is returned.
+//
+// The env is process-wide, so this relies on the runner executing one job
+// at a time as remote runners do. The CLI rejects job env vars for local
+// runners, which may execute jobs in parallel.
+func setJobEnv(log hclog.Logger, env map[string]string, sensitive map[string]bool) (func(), error) {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	// Resolve the sensitive values before setting anything, so that they
+	// are read from the env of the runner rather than of the job.
+	values := map[string]string{}
+	var merr error
+	for _, k := range keys {
+		if !sensitive[k] {
+			values[k] = env[k]
+			continue
+		}
+
+		value, ok := os.LookupEnv(env[k])
+		if !ok {
+			merr = multierror.Append(merr, fmt.Errorf(
+				"the env var %q for the sensitive job env var %q isn't set on the runner",
+				env[k], k))
+			continue
+		}
+		values[k] = value
+	}
+	if merr != nil {
+		return func() {}, merr
+	}
+
+	type prevValue struct {
+		value string
+		ok    bool
+	}
+	prev := map[string]prevValue{}
+	restore := func() {
+		for k, p := range prev {
+			if p.ok {
+				os.Setenv(k, p.value)
+			} else {
+				os.Unsetenv(k)
+			}
+		}
+	}
+
+	for _, k := range keys {
+		if sensitive[k] {
+			log.Debug("setting job env var", "key", k, "from", env[k])
+		} else {
+			log.Debug("setting job env var", "key", k, "value", env[k])
+		}
+
+		value, ok := os.LookupEnv(k)
+		prev[k] = prevValue{value: value, ok: ok}
+		if err := os.Setenv(k, values[k]); err != nil {
+			merr = multierror.Append(merr, err)
+		}
+	}
+
+	return restore, merr
+}
@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobEnv(t *testing.T) {
+	require := require.New(t)
+
+	env, sensitive, rest := jobEnv(map[string]string{
+		"team":                           "platform",
+		JobEnvLabelPrefix + "REGION":     "us-east-1",
+		JobEnvSensitiveLabelPrefix + "T": "secret",
+	})
+	require.Equal(map[string]string{"REGION": "us-east-1", "T": "secret"}, env)
+	require.Equal(map[string]bool{"T": true}, sensitive)
+	require.Equal(map[string]string{"team": "platform"}, rest)
+}
+
+func TestSetJobEnv(t *testing.T) {
+	require := require.New(t)
+
+	const existing = "WP_TEST_JOB_ENV_EXISTING"
+	const unset = "WP_TEST_JOB_ENV_UNSET"
+	const source = "WP_TEST_JOB_ENV_SOURCE"
+	require.NoError(os.Setenv(existing, "before"))
+	defer os.Unsetenv(existing)
+	require.NoError(os.Unsetenv(unset))
+	require.NoError(os.Setenv(source, "secret"))
+	defer os.Unsetenv(source)
+
+	// The sensitive value is read from the runner env var it names.
+	restore, err := setJobEnv(hclog.L(), map[string]string{
+		existing: "during",
+		unset:    source,
+	}, map[string]bool{unset: true})
+	require.NoError(err)
+	require.Equal("during", os.Getenv(existing))
+	require.Equal("secret", os.Getenv(unset))
+
+	// Restoring should put back the previous values, including unsetting
+	// vars that weren't set before.
+	restore()
+	require.Equal("before", os.Getenv(existing))
+	_, ok := os.LookupEnv(unset)
+	require.False(ok)
+
+	// A sensitive value from a runner env var that isn't set is an error,
+	// and nothing is set.
+	restore, err = setJobEnv(hclog.L(), map[string]string{
+		existing: "during",
+		unset:    "WP_TEST_JOB_ENV_MISSING",
+	}, map[string]bool{unset: true})
+	require.Error(err)
+	restore()
+	require.Equal("before", os.Getenv(existing))
+}
+
+func TestOperationLabels(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal(map[string]string{"team": "platform"}, operationLabels(map[string]string{
+		"team":                         "platform",
+		"waypoint/targeting/workspace": "default",
+		JobRunnerLabelPrefix + "gpu":   "true",
+		JobConfigProfileLabel:          "prod",
+	}))
+	require.Nil(operationLabels(map[string]string{"waypoint/targeting/remote": "true"}))
+}
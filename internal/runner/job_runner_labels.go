@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JobRunnerLabelPrefix is the prefix of job labels that require the runner
+// executing the job to have a label. The rest of the label key is the key
+// of the runner label. These are set with "-runner-label" in the CLI. Like
+// the other reserved labels, these aren't set on the operations of the job.
+const JobRunnerLabelPrefix = "waypoint/runner-label/"
+
+// errJobLabelMismatch is returned by accept when the assigned job requires
+// runner labels that this runner doesn't have. The job is nacked so that
+// the server can assign it to another runner.
+var errJobLabelMismatch = errors.New("job requires runner labels this runner doesn't have")
+
+// labelMismatchBackoff is how long AcceptMany waits after nacking a job
+// for a label mismatch, so that another runner can be assigned the job
+// rather than this runner being assigned it again right away.
+var labelMismatchBackoff = 2 * time.Second
+
+// jobRunnerLabels returns the runner labels that the job labels require.
+func jobRunnerLabels(labels map[string]string) map[string]string {
+	var result map[string]string
+	for k, v := range labels {
+		if !strings.HasPrefix(k, JobRunnerLabelPrefix) {
+			continue
+		}
+
+		if result == nil {
+			result = map[string]string{}
+		}
+		result[strings.TrimPrefix(k, JobRunnerLabelPrefix)] = v
+	}
+
+	return result
+}
+
+// missingRunnerLabels returns the required labels, as "key=value", that
+// the runner labels don't match. This is sorted and empty if all match.
+func missingRunnerLabels(required, labels map[string]string) []string {
+	var result []string
+	for k, v := range required {
+		if actual, ok := labels[k]; !ok || actual != v {
+			result = append(result, k+"="+v)
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingRunnerLabels(t *testing.T) {
+	require := require.New(t)
+
+	required := jobRunnerLabels(map[string]string{
+		"team":                        "platform",
+		JobRunnerLabelPrefix + "gpu":  "true",
+		JobRunnerLabelPrefix + "zone": "a",
+	})
+	require.Equal(map[string]string{"gpu": "true", "zone": "a"}, required)
+
+	// No labels matches nothing
+	require.Equal([]string{"gpu=true", "zone=a"}, missingRunnerLabels(required, nil))
+
+	// Values must match, not just keys
+	require.Equal([]string{"gpu=true"}, missingRunnerLabels(required, map[string]string{
+		"gpu":  "false",
+		"zone": "a",
+	}))
+
+	// Extra runner labels are fine
+	require.Empty(missingRunnerLabels(required, map[string]string{
+		"gpu":  "true",
+		"zone": "a",
+		"os":   "linux",
+	}))
+
+	// A job without required labels runs anywhere
+	require.Empty(missingRunnerLabels(jobRunnerLabels(nil), nil))
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/hashicorp/go-hclog"
@@ -26,6 +27,34 @@ import (
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
 )
 
+// JobConfigProfileLabel is the job label with the name of the profile to
+// select when loading the configuration. This is set with "-config-profile"
+// in the CLI. This is reserved, so it isn't set on the operations of the job.
+const JobConfigProfileLabel = "waypoint/config-profile"
+
+// reservedLabelPrefix is the prefix of the job labels that are reserved for
+// system use, such as the targeting that the server records for a job.
+// These aren't valid operation labels, so they're only kept on the job.
+const reservedLabelPrefix = "waypoint/"
+
+// operationLabels returns the job labels to set on the operations of the
+// job, which are all of them except those with reservedLabelPrefix.
+func operationLabels(labels map[string]string) map[string]string {
+	var result map[string]string
+	for k, v := range labels {
+		if strings.HasPrefix(k, reservedLabelPrefix) {
+			continue
+		}
+
+		if result == nil {
+			result = map[string]string{}
+		}
+		result[k] = v
+	}
+
+	return result
+}
+
 // executeJob executes an assigned job. This will source the data (if necessary),
 // setup the project, execute the job, and return the outcome.
 func (r *Runner) executeJob(
@@ -100,6 +129,7 @@ func (r *Runner) executeJob(
 	cfg, err := configpkg.Load(path, &configpkg.LoadOptions{
 		Pwd:       filepath.Dir(path),
 		Workspace: job.Workspace.Workspace,
+		Profile:   job.Labels[JobConfigProfileLabel],
 	})
 	if err != nil {
 		return nil, err
@@ -139,6 +169,15 @@ func (r *Runner) executeJob(
 		return nil, err
 	}
 
+	// Set any env vars requested for this job. These are set before the
+	// plugins are launched so that the plugins inherit them.
+	jobEnvVars, jobEnvSensitive, jobLabels := jobEnv(job.Labels)
+	restoreEnv, err := setJobEnv(log, jobEnvVars, jobEnvSensitive)
+	defer restoreEnv()
+	if err != nil {
+		return nil, err
+	}
+
 	// Find all our plugins
 	factories, err := r.pluginFactories(log, cfg.Plugins(), wd)
 	if err != nil {
@@ -186,7 +225,7 @@ func (r *Runner) executeJob(
 		core.WithClient(r.client),
 		core.WithConfig(cfg),
 		core.WithDataDir(projDir),
-		core.WithLabels(job.Labels),
+		core.WithLabels(operationLabels(jobLabels)),
 		core.WithVariables(inputVars),
 		core.WithWorkspace(job.Workspace.Workspace),
 		core.WithJobInfo(jobInfo),
@@ -61,6 +61,10 @@ type Runner struct {
 	local       bool
 	tempDir     string
 
+	// labels are the labels of this runner. Jobs that require runner
+	// labels with "-runner-label" are only executed if these match.
+	labels map[string]string
+
 	// protects whether or not the runner is active or not.
 	runningCond *sync.Cond
 	shutdown    bool
@@ -346,6 +350,15 @@ func WithId(id string) Option {
 	}
 }
 
+// WithLabels sets the labels of the runner. Jobs that require runner labels
+// are nacked unless all of their required labels match these.
+func WithLabels(labels map[string]string) Option {
+	return func(r *Runner, cfg *config) error {
+		r.labels = labels
+		return nil
+	}
+}
+
 // WithAcceptTimeout sets a maximum amount of time to wait for a job before returning
 // that one was not accepted.
 func WithAcceptTimeout(dur time.Duration) Option {
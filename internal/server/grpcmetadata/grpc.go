@@ -4,6 +4,10 @@ package grpcmetadata
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 
 	"google.golang.org/grpc/metadata"
 )
@@ -36,3 +40,109 @@ func RunnerId(ctx context.Context) (string, bool) {
 
 	return val[0], true
 }
+
+// AddHeaders adds arbitrary gRPC metadata to RPCs sent with the returned
+// context. This is used by the CLI to send the custom headers required by
+// some proxies in front of the server. The keys must be valid metadata keys;
+// see ValidateHeader.
+func AddHeaders(ctx context.Context, headers map[string]string) context.Context {
+	if len(headers) == 0 {
+		return ctx
+	}
+
+	kv := make([]string, 0, len(headers)*2)
+	for k, v := range headers {
+		kv = append(kv, k, v)
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// ValidateHeader returns an error if the key or value can't be sent as
+// gRPC metadata with AddHeaders. Keys are case-insensitive and may only
+// contain letters, digits, "-", "_", and ".". Keys starting with "grpc-"
+// are reserved by gRPC, and keys ending in "-bin" are binary, which isn't
+// supported. Values must be printable ASCII.
+func ValidateHeader(key, value string) error {
+	if key == "" {
+		return errors.New("header name can't be empty")
+	}
+
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.':
+		default:
+			return fmt.Errorf("header name %q contains the invalid character %q", key, r)
+		}
+	}
+
+	lower := strings.ToLower(key)
+	if strings.HasPrefix(lower, "grpc-") {
+		return fmt.Errorf("header name %q is reserved by gRPC", key)
+	}
+	if strings.HasSuffix(lower, "-bin") {
+		return fmt.Errorf("header name %q is for binary values, which aren't supported", key)
+	}
+
+	for _, r := range value {
+		if r < 0x20 || r > 0x7e {
+			return fmt.Errorf("header %q value must be printable ASCII", key)
+		}
+	}
+
+	return nil
+}
+
+// The metadata key that stores how the CLI resolved the target of an
+// operation, such as the project, app, and workspace. Each value is a
+// "key=value" pair.
+const grpcMetadataTargeting = "waypoint-targeting"
+
+// AddTargeting adds gRPC metadata that describes how the client resolved
+// the target of the RPCs sent with the returned context. The server records
+// this with queued jobs so there is an audit trail of what the CLI decided.
+// This must never include secrets.
+func AddTargeting(ctx context.Context, targeting map[string]string) context.Context {
+	if len(targeting) == 0 {
+		return ctx
+	}
+
+	keys := make([]string, 0, len(targeting))
+	for k := range targeting {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kv := make([]string, 0, len(keys)*2)
+	for _, k := range keys {
+		kv = append(kv, grpcMetadataTargeting, k+"="+targeting[k])
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// Returns the targeting attached to the context as grpc Metadata. This
+// would be set by the client with AddTargeting. Values that aren't a
+// "key=value" pair are ignored.
+func Targeting(ctx context.Context) (map[string]string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	result := map[string]string{}
+	for _, v := range md.Get(grpcMetadataTargeting) {
+		idx := strings.Index(v, "=")
+		if idx <= 0 {
+			continue
+		}
+
+		result[v[:idx]] = v[idx+1:]
+	}
+	if len(result) == 0 {
+		return nil, false
+	}
+
+	return result, true
+}
@@ -0,0 +1,74 @@
+package grpcmetadata
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAddHeaders(t *testing.T) {
+	require := require.New(t)
+
+	ctx := AddHeaders(context.Background(), map[string]string{
+		"X-Team-Id": "payments",
+	})
+	md, ok := metadata.FromOutgoingContext(ctx)
+	require.True(ok)
+	require.Equal([]string{"payments"}, md.Get("x-team-id"))
+}
+
+func TestValidateHeader(t *testing.T) {
+	cases := []struct {
+		Key   string
+		Value string
+		Err   bool
+	}{
+		{"X-Team-Id", "payments", false},
+		{"x_team.id", "", false},
+		{"", "value", true},
+		{"x team", "value", true},
+		{"grpc-timeout", "1s", true},
+		{"x-data-bin", "value", true},
+		{"x-team-id", "line\nbreak", true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Key, func(t *testing.T) {
+			err := ValidateHeader(tt.Key, tt.Value)
+			if tt.Err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTargeting(t *testing.T) {
+	require := require.New(t)
+
+	// Not set
+	_, ok := Targeting(context.Background())
+	require.False(ok)
+
+	ctx := AddTargeting(context.Background(), map[string]string{
+		"project": "acme",
+		"remote":  "false",
+		"server":  "localhost:9701",
+	})
+	md, ok := metadata.FromOutgoingContext(ctx)
+	require.True(ok)
+
+	// Values are read from the incoming side, and values that aren't
+	// pairs are ignored.
+	md.Append(grpcMetadataTargeting, "invalid")
+	targeting, ok := Targeting(metadata.NewIncomingContext(context.Background(), md))
+	require.True(ok)
+	require.Equal(map[string]string{
+		"project": "acme",
+		"remote":  "false",
+		"server":  "localhost:9701",
+	}, targeting)
+}
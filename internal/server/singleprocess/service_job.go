@@ -15,12 +15,19 @@ import (
 
 	"github.com/hashicorp/waypoint/internal/server"
 	pb "github.com/hashicorp/waypoint/internal/server/gen"
+	"github.com/hashicorp/waypoint/internal/server/grpcmetadata"
 	"github.com/hashicorp/waypoint/internal/server/logbuffer"
 	serverptypes "github.com/hashicorp/waypoint/internal/server/ptypes"
 	"github.com/hashicorp/waypoint/internal/serverconfig"
 	"github.com/hashicorp/waypoint/internal/serverstate"
 )
 
+// targetingLabelPrefix is the prefix of the job labels that record how the
+// client resolved the target of the job. See grpcmetadata.AddTargeting.
+// These are only recorded on the job. The runner doesn't set labels with the
+// reserved "waypoint/" prefix on the resulting operations.
+const targetingLabelPrefix = "waypoint/targeting/"
+
 // TODO: test
 func (s *service) GetJob(
 	ctx context.Context,
@@ -151,6 +158,21 @@ func (s *service) queueJobReqToJob(
 	}
 	job.Id = id
 
+	// Record how the client resolved the target of the job, if it told us.
+	// These never override labels that the client set explicitly.
+	if targeting, ok := grpcmetadata.Targeting(ctx); ok {
+		if job.Labels == nil {
+			job.Labels = map[string]string{}
+		}
+
+		for k, v := range targeting {
+			k = targetingLabelPrefix + k
+			if _, ok := job.Labels[k]; !ok {
+				job.Labels[k] = v
+			}
+		}
+	}
+
 	// Validate expiry if we have one
 	job.ExpireTime = nil
 	if req.ExpiresIn != "" {
@@ -0,0 +1,81 @@
+package serverclient
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// TLSCAPath trusts the CA certificates in the "*.pem" and "*.crt" files of
+// the directory at path to verify the server. These are in addition to the
+// system roots, unless only is true. This overrides a CA path from the
+// context or environment if path isn't empty.
+func TLSCAPath(path string, only bool) ConnectOption {
+	return func(c *connectConfig) error {
+		if path == "" {
+			return nil
+		}
+
+		// The path is absolute so that it still works if it's saved to a
+		// context and used from another directory.
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+
+		c.TlsCaPath = path
+		c.TlsCaOnly = only
+		return nil
+	}
+}
+
+// loadCAPath returns a pool with the CA certificates in the directory
+// added to base, or the system roots if base is nil. If only is true, the
+// pool has only the certificates in the directory.
+func loadCAPath(dir string, base *x509.CertPool, only bool) (*x509.CertPool, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the CA certificate directory %q: %w", dir, err)
+	}
+
+	pool := base
+	if only {
+		pool = x509.NewCertPool()
+	} else if pool == nil {
+		pool, err = x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+	}
+
+	count := 0
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".pem", ".crt":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA certificate %q: %w", path, err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("the file %q doesn't contain any PEM-encoded certificates", path)
+		}
+
+		count++
+	}
+	if count == 0 {
+		return nil, fmt.Errorf(
+			"the CA certificate directory %q doesn't contain any \"*.pem\" or \"*.crt\" files", dir)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,128 @@
+package serverclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSCAPath(t *testing.T) {
+	require := require.New(t)
+
+	wd, err := os.Getwd()
+	require.NoError(err)
+
+	// An empty path keeps the CA path from the context.
+	cfg := connectConfig{}
+	cfg.TlsCaPath = "/etc/waypoint/ca"
+	require.NoError(TLSCAPath("", true)(&cfg))
+	require.Equal("/etc/waypoint/ca", cfg.TlsCaPath)
+	require.False(cfg.TlsCaOnly)
+
+	// Relative paths are made absolute.
+	require.NoError(TLSCAPath("ca", true)(&cfg))
+	require.Equal(filepath.Join(wd, "ca"), cfg.TlsCaPath)
+	require.True(cfg.TlsCaOnly)
+}
+
+func TestLoadCAPath(t *testing.T) {
+	t.Run("certificates", func(t *testing.T) {
+		require := require.New(t)
+
+		dir, err := ioutil.TempDir("", "waypoint-test")
+		require.NoError(err)
+		defer os.RemoveAll(dir)
+
+		a := testCACert(t, "a")
+		b := testCACert(t, "b")
+		require.NoError(ioutil.WriteFile(filepath.Join(dir, "a.pem"), testCertPEM(a), 0644))
+		require.NoError(ioutil.WriteFile(filepath.Join(dir, "b.CRT"), testCertPEM(b), 0644))
+		require.NoError(ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a cert"), 0644))
+		require.NoError(os.Mkdir(filepath.Join(dir, "old.pem"), 0755))
+
+		pool, err := loadCAPath(dir, x509.NewCertPool(), false)
+		require.NoError(err)
+		require.Len(pool.Subjects(), 2)
+
+		// The base pool is kept unless only is set.
+		base := x509.NewCertPool()
+		base.AddCert(testCACert(t, "base"))
+		pool, err = loadCAPath(dir, base, false)
+		require.NoError(err)
+		require.Len(pool.Subjects(), 3)
+
+		base = x509.NewCertPool()
+		base.AddCert(testCACert(t, "base"))
+		pool, err = loadCAPath(dir, base, true)
+		require.NoError(err)
+		require.Len(pool.Subjects(), 2)
+	})
+
+	t.Run("no certificates", func(t *testing.T) {
+		require := require.New(t)
+
+		dir, err := ioutil.TempDir("", "waypoint-test")
+		require.NoError(err)
+		defer os.RemoveAll(dir)
+
+		_, err = loadCAPath(dir, nil, false)
+		require.Error(err)
+		require.Contains(err.Error(), "doesn't contain any")
+	})
+
+	t.Run("invalid certificate", func(t *testing.T) {
+		require := require.New(t)
+
+		dir, err := ioutil.TempDir("", "waypoint-test")
+		require.NoError(err)
+		defer os.RemoveAll(dir)
+
+		require.NoError(ioutil.WriteFile(filepath.Join(dir, "ca.pem"), []byte("not a cert"), 0644))
+		_, err = loadCAPath(dir, nil, false)
+		require.Error(err)
+		require.Contains(err.Error(), "PEM-encoded")
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := loadCAPath(filepath.Join(os.TempDir(), "waypoint-test-missing"), nil, false)
+		require.Error(t, err)
+	})
+}
+
+// testCACert returns a new self-signed CA certificate.
+func testCACert(t *testing.T, name string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+// testCertPEM returns the PEM encoding of the certificate.
+func testCertPEM(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
@@ -3,12 +3,18 @@ package serverclient
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-multierror"
+	"golang.org/x/crypto/ssh"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
@@ -52,9 +58,67 @@ func Connect(ctx context.Context, opts ...ConnectOption) (*grpc.ClientConn, erro
 		return nil, ErrNoServerConfig
 	}
 
+	// If the address is an SRV record, we try each target in order until
+	// one connects. Each target gets the full timeout.
+	if strings.HasPrefix(cfg.Addr, srvPrefix) {
+		targets, err := lookupSRV(ctx, strings.TrimPrefix(cfg.Addr, srvPrefix))
+		if err != nil {
+			return nil, err
+		}
+
+		var result error
+		for _, target := range targets {
+			cfg.Log.Debug("attempting SRV target", "addr", target.addr)
+			conn, err := dial(ctx, &cfg, target.addr, target.host)
+			if err == nil {
+				return conn, nil
+			}
+
+			cfg.Log.Warn("failed to connect to SRV target", "addr", target.addr, "error", err)
+			result = multierror.Append(result, fmt.Errorf("%s: %w", target.addr, err))
+		}
+
+		return nil, fmt.Errorf(
+			"failed to connect to any server from SRV record %q: %w", cfg.Addr, result)
+	}
+
+	return dial(ctx, &cfg, cfg.Addr, "")
+}
+
+// dial connects to the server at addr using the given configuration. If
+// serverName is non-empty, it is used to verify the TLS certificate.
+func dial(
+	ctx context.Context,
+	cfg *connectConfig,
+	addr string,
+	serverName string,
+) (*grpc.ClientConn, error) {
+	// If we're tunneling through a bastion, connect to it first so that
+	// the server is dialed through it. The tunnel uses the parent context
+	// since it must outlive the dial.
+	var bastion *ssh.Client
+	if cfg.Bastion != nil {
+		if cfg.Network != "" {
+			return nil, errors.New(
+				"The server IP version can't be constrained when connecting " +
+					"through an SSH bastion.")
+		}
+
+		var err error
+		bastion, err = dialSSHBastion(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
 	defer cancel()
 
+	keepaliveTime := cfg.Keepalive
+	if keepaliveTime == 0 {
+		keepaliveTime = DefaultKeepalive
+	}
+
 	// Build our options
 	grpcOpts := []grpc.DialOption{
 		grpc.WithBlock(),
@@ -63,21 +127,66 @@ func Connect(ctx context.Context, opts ...ConnectOption) (*grpc.ClientConn, erro
 		grpc.WithKeepaliveParams(
 			keepalive.ClientParameters{
 				// ping after this amount of time of inactivity
-				Time: 30 * time.Second,
+				Time: keepaliveTime,
 				// send keepalive pings even if there is no active streams
 				PermitWithoutStream: true,
 			}),
 	}
 
+	// If we're constrained to an IP version, verify the server has an
+	// address for it so we can error clearly rather than time out, and
+	// then only dial that network.
+	if cfg.Network != "" {
+		if err := checkNetworkAddr(ctx, cfg.Network, addr); err != nil {
+			return nil, err
+		}
+
+		network := cfg.Network
+		grpcOpts = append(grpcOpts, grpc.WithContextDialer(
+			func(ctx context.Context, addr string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			}))
+	}
+
+	if bastion != nil {
+		grpcOpts = append(grpcOpts, grpc.WithContextDialer(
+			func(ctx context.Context, addr string) (net.Conn, error) {
+				return bastion.Dial("tcp", addr)
+			}))
+	}
+
 	if !cfg.Tls {
+		if cfg.ClientCert != nil {
+			return nil, errors.New(
+				"A client certificate can only be used when connecting with TLS.")
+		}
+		if cfg.TlsCaPath != "" {
+			return nil, errors.New(
+				"A CA certificate directory can only be used when connecting with TLS.")
+		}
+
 		grpcOpts = append(grpcOpts, grpc.WithInsecure())
-	} else if cfg.TlsSkipVerify {
-		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(
-			credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}),
-		))
 	} else {
+		tlsConfig := &tls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: cfg.TlsSkipVerify,
+			RootCAs:            cfg.RootCAs,
+		}
+		if cfg.ClientCert != nil {
+			tlsConfig.Certificates = []tls.Certificate{*cfg.ClientCert}
+		}
+		if cfg.TlsCaPath != "" {
+			roots, err := loadCAPath(cfg.TlsCaPath, cfg.RootCAs, cfg.TlsCaOnly)
+			if err != nil {
+				return nil, err
+			}
+
+			tlsConfig.RootCAs = roots
+		}
+
 		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(
-			credentials.NewTLS(&tls.Config{}),
+			credentials.NewTLS(tlsConfig),
 		))
 	}
 
@@ -95,16 +204,54 @@ func Connect(ctx context.Context, opts ...ConnectOption) (*grpc.ClientConn, erro
 		grpcOpts = append(grpcOpts, grpc.WithPerRPCCredentials(StaticToken(token)))
 	}
 
+	// Retry once if an idle connection was dropped, such as by a proxy.
+	// This is chained before the interceptors below so that the retry
+	// goes through them again and sends the same metadata.
+	grpcOpts = append(grpcOpts,
+		grpc.WithChainUnaryInterceptor(reconnectUnaryInterceptor(cfg.Log, cfg.Timeout)),
+		grpc.WithChainStreamInterceptor(reconnectStreamInterceptor(cfg.Log, cfg.Timeout)),
+	)
+
+	// Limit the rate of RPCs if requested. This is chained after the retry
+	// above so that a retry also waits for the limit.
+	if rps := cfg.RateLimit; rps > 0 {
+		l := newRateLimiter(rps)
+		grpcOpts = append(grpcOpts,
+			grpc.WithChainUnaryInterceptor(rateLimitUnaryInterceptor(l)),
+			grpc.WithChainStreamInterceptor(rateLimitStreamInterceptor(l)),
+		)
+	}
+
+	// Send any custom headers with every RPC, such as for a proxy.
+	if len(cfg.Headers) > 0 {
+		grpcOpts = append(grpcOpts,
+			grpc.WithChainUnaryInterceptor(headersUnaryInterceptor(cfg.Headers)),
+			grpc.WithChainStreamInterceptor(headersStreamInterceptor(cfg.Headers)),
+		)
+	}
+
 	cfg.Log.Debug("connection information",
-		"address", cfg.Addr,
+		"address", addr,
 		"tls", cfg.Tls,
 		"tls_skip_verify", cfg.TlsSkipVerify,
 		"send_auth", cfg.Auth,
 		"has_token", token != "",
+		"network", cfg.Network,
+		"ssh_bastion", cfg.Bastion != nil,
+		"client_cert", cfg.ClientCert != nil,
+		"tls_ca_path", cfg.TlsCaPath,
+		"headers", redactedHeaders(cfg.Headers),
+		"keepalive", keepaliveTime,
+		"rate_limit", cfg.RateLimit,
 	)
 
 	// Connect to this server
-	return grpc.DialContext(ctx, cfg.Addr, grpcOpts...)
+	conn, err := grpc.DialContext(ctx, addr, grpcOpts...)
+	if err != nil && bastion != nil {
+		bastion.Close()
+	}
+
+	return conn, err
 }
 
 // ContextConfig will return the context configuration for the given connection
@@ -124,6 +271,8 @@ func ContextConfig(opts ...ConnectOption) (*clicontext.Config, error) {
 			Address:       cfg.Addr,
 			Tls:           cfg.Tls,
 			TlsSkipVerify: cfg.TlsSkipVerify,
+			TlsCaPath:     cfg.TlsCaPath,
+			TlsCaOnly:     cfg.TlsCaOnly,
 			RequireAuth:   cfg.Token != "",
 			AuthToken:     cfg.Token,
 		},
@@ -136,7 +285,16 @@ type connectConfig struct {
 	TlsSkipVerify bool
 	Auth          bool
 	Token         string
-	Optional      bool // See Optional func
+	Optional      bool              // See Optional func
+	Network       string            // See IPVersion func
+	Bastion       *sshBastion       // See SSHBastion func
+	ClientCert    *tls.Certificate  // See TLSPKCS12 func
+	RootCAs       *x509.CertPool    // See TLSPKCS12 func
+	TlsCaPath     string            // See TLSCAPath func
+	TlsCaOnly     bool              // See TLSCAPath func
+	Headers       map[string]string // See Headers func
+	Keepalive     time.Duration     // See Keepalive func
+	RateLimit     float64           // See RateLimit func
 	Timeout       time.Duration
 	Log           hclog.Logger
 }
@@ -159,6 +317,14 @@ func FromEnv() ConnectOption {
 				return err
 			}
 
+			if v := os.Getenv(EnvServerTlsCaPath); v != "" {
+				c.TlsCaPath = v
+				c.TlsCaOnly, err = env.GetBool(EnvServerTlsCaOnly, false)
+				if err != nil {
+					return err
+				}
+			}
+
 			c.Auth = os.Getenv(EnvServerToken) != ""
 		}
 
@@ -173,6 +339,8 @@ func FromContextConfig(cfg *clicontext.Config) ConnectOption {
 			c.Addr = cfg.Server.Address
 			c.Tls = cfg.Server.Tls
 			c.TlsSkipVerify = cfg.Server.TlsSkipVerify
+			c.TlsCaPath = cfg.Server.TlsCaPath
+			c.TlsCaOnly = cfg.Server.TlsCaOnly
 			if cfg.Server.RequireAuth {
 				c.Auth = true
 				c.Token = cfg.Server.AuthToken
@@ -249,6 +417,109 @@ func Timeout(t time.Duration) ConnectOption {
 	}
 }
 
+// IPVersion constrains the connection to the server to IPv4 ("4") or
+// IPv6 ("6"). The default "auto" uses whichever address the dialer picks.
+func IPVersion(v string) ConnectOption {
+	return func(c *connectConfig) error {
+		switch v {
+		case "", "auto":
+			c.Network = ""
+		case "4":
+			c.Network = "tcp4"
+		case "6":
+			c.Network = "tcp6"
+		default:
+			return fmt.Errorf("invalid IP version %q, must be 4, 6, or auto", v)
+		}
+
+		return nil
+	}
+}
+
+// checkNetworkAddr verifies that the host in addr has an address for the
+// given network ("tcp4" or "tcp6").
+func checkNetworkAddr(ctx context.Context, network, addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		// No port, so the whole thing is the host.
+		host = addr
+	}
+
+	ipVersion := "IPv4"
+	if network == "tcp6" {
+		ipVersion = "IPv6"
+	}
+
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("error resolving server address %q: %w", host, err)
+	}
+	for _, ip := range ips {
+		if (ip.IP.To4() != nil) == (network == "tcp4") {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(
+		"The server address %q has no %s address. Use a different IP version "+
+			"or \"auto\" to connect using any address.", host, ipVersion)
+}
+
+// srvPrefix is the prefix for server addresses that should be resolved
+// using DNS SRV records, such as "srv://_waypoint._tcp.example.com".
+const srvPrefix = "srv://"
+
+// srvTarget is a single resolved target of an SRV record.
+type srvTarget struct {
+	addr string // host:port to dial
+	host string // host without the trailing dot, for TLS
+}
+
+// lookupSRV resolves the SRV record with the given name. The targets are
+// returned in the order they should be attempted: sorted by priority and
+// randomized by weight within a priority, per RFC 2782.
+func lookupSRV(ctx context.Context, name string) ([]srvTarget, error) {
+	// An empty service and proto looks up the name directly.
+	_, addrs, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving SRV record %q: %w", name, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("SRV record %q has no targets", name)
+	}
+
+	result := make([]srvTarget, 0, len(addrs))
+	for _, a := range addrs {
+		host := strings.TrimSuffix(a.Target, ".")
+		result = append(result, srvTarget{
+			addr: net.JoinHostPort(host, strconv.Itoa(int(a.Port))),
+			host: host,
+		})
+	}
+
+	return result, nil
+}
+
+// ExpandAddr transforms the server address that was set by the options
+// before it. This must be given after the options that set the address,
+// and is used to expand templated addresses. The function is only called
+// if an address is set.
+func ExpandAddr(f func(string) (string, error)) ConnectOption {
+	return func(c *connectConfig) error {
+		if c.Addr == "" {
+			return nil
+		}
+
+		addr, err := f(c.Addr)
+		if err != nil {
+			return err
+		}
+
+		c.Addr = addr
+		return nil
+	}
+}
+
 // Logger is the logger to use.
 func Logger(v hclog.Logger) ConnectOption {
 	return func(c *connectConfig) error {
@@ -268,6 +539,16 @@ const (
 	EnvServerTls           = "WAYPOINT_SERVER_TLS"
 	EnvServerTlsSkipVerify = "WAYPOINT_SERVER_TLS_SKIP_VERIFY"
 
+	// EnvServerTlsCaPath is a directory of CA certificates to trust to
+	// verify the server. If EnvServerTlsCaOnly is true, the system roots
+	// aren't trusted. See TLSCAPath.
+	EnvServerTlsCaPath = "WAYPOINT_SERVER_TLS_CA_PATH"
+	EnvServerTlsCaOnly = "WAYPOINT_SERVER_TLS_CA_ONLY"
+
+	// EnvServerTlsPKCS12Passphrase is the passphrase of the PKCS#12 file
+	// given to TLSPKCS12, if one isn't given directly.
+	EnvServerTlsPKCS12Passphrase = "WAYPOINT_SERVER_TLS_PKCS12_PASSPHRASE"
+
 	// EnvServerToken is the token for authenticated with the server.
 	EnvServerToken = "WAYPOINT_SERVER_TOKEN"
 
@@ -0,0 +1,112 @@
+package serverclient
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+
+	"github.com/hashicorp/waypoint/internal/server/grpcmetadata"
+)
+
+// EnvHeaderPrefix is the prefix of environment variables that set headers
+// to send with every RPC. The rest of the variable name is the header name
+// with underscores replaced by dashes, so WAYPOINT_HEADER_X_TEAM_ID sets the
+// "x-team-id" header.
+const EnvHeaderPrefix = "WAYPOINT_HEADER_"
+
+// Headers sets headers to send as gRPC metadata with every RPC, such as
+// the headers required by an authenticating proxy in front of the server.
+// Headers from the environment, see EnvHeaderPrefix, are also sent, but
+// the given headers take precedence. Header names are case-insensitive.
+func Headers(headers map[string]string) ConnectOption {
+	return func(c *connectConfig) error {
+		result := map[string]string{}
+		for _, kv := range os.Environ() {
+			idx := strings.Index(kv, "=")
+			if idx < 0 || !strings.HasPrefix(kv[:idx], EnvHeaderPrefix) {
+				continue
+			}
+
+			k := strings.ReplaceAll(strings.TrimPrefix(kv[:idx], EnvHeaderPrefix), "_", "-")
+			if err := grpcmetadata.ValidateHeader(k, kv[idx+1:]); err != nil {
+				return fmt.Errorf("invalid header in %s: %w", kv[:idx], err)
+			}
+
+			result[strings.ToLower(k)] = kv[idx+1:]
+		}
+
+		for k, v := range headers {
+			if err := grpcmetadata.ValidateHeader(k, v); err != nil {
+				return fmt.Errorf("invalid header: %w", err)
+			}
+
+			result[strings.ToLower(k)] = v
+		}
+
+		c.Headers = result
+		return nil
+	}
+}
+
+// redactedHeaders returns the headers for logging, with the values of any
+// headers that look like they contain credentials redacted.
+func redactedHeaders(headers map[string]string) []string {
+	result := make([]string, 0, len(headers))
+	for k, v := range headers {
+		if sensitiveHeader(k) {
+			v = "<redacted>"
+		}
+
+		result = append(result, k+"="+v)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// sensitiveHeader returns true if the header name suggests that its value
+// is a credential.
+func sensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{
+		"auth", "token", "secret", "password", "passwd", "key", "cookie", "session", "credential",
+	} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// headersUnaryInterceptor adds the headers as metadata to unary RPCs.
+func headersUnaryInterceptor(headers map[string]string) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		return invoker(grpcmetadata.AddHeaders(ctx, headers), method, req, reply, cc, opts...)
+	}
+}
+
+// headersStreamInterceptor adds the headers as metadata to streaming RPCs.
+func headersStreamInterceptor(headers map[string]string) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		return streamer(grpcmetadata.AddHeaders(ctx, headers), desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,77 @@
+package serverclient
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestHeaders(t *testing.T) {
+	require := require.New(t)
+
+	defer os.Unsetenv(EnvHeaderPrefix + "X_TEAM_ID")
+	defer os.Unsetenv(EnvHeaderPrefix + "X_ENV")
+	require.NoError(os.Setenv(EnvHeaderPrefix+"X_TEAM_ID", "payments"))
+	require.NoError(os.Setenv(EnvHeaderPrefix+"X_ENV", "staging"))
+
+	// The given headers override those from the env, regardless of case.
+	var cfg connectConfig
+	require.NoError(Headers(map[string]string{
+		"X-Env":      "prod",
+		"X-Trace-Id": "abc",
+	})(&cfg))
+	require.Equal(map[string]string{
+		"x-team-id":  "payments",
+		"x-env":      "prod",
+		"x-trace-id": "abc",
+	}, cfg.Headers)
+
+	// Invalid headers are an error whether from the env or the flags.
+	require.Error(Headers(map[string]string{"grpc-timeout": "1"})(&cfg))
+	require.NoError(os.Setenv(EnvHeaderPrefix+"X_ENV", "new\nline"))
+	require.Error(Headers(nil)(&cfg))
+}
+
+func TestRedactedHeaders(t *testing.T) {
+	require.Equal(t, []string{
+		"authorization=<redacted>",
+		"x-api-key=<redacted>",
+		"x-team-id=payments",
+	}, redactedHeaders(map[string]string{
+		"x-team-id":     "payments",
+		"authorization": "Bearer hunter2",
+		"x-api-key":     "hunter2",
+	}))
+}
+
+func TestHeadersInterceptors(t *testing.T) {
+	require := require.New(t)
+
+	headers := map[string]string{"x-team-id": "payments"}
+
+	var md metadata.MD
+	err := headersUnaryInterceptor(headers)(
+		context.Background(), "/test", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			md, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		},
+	)
+	require.NoError(err)
+	require.Equal([]string{"payments"}, md.Get("x-team-id"))
+
+	md = nil
+	_, err = headersStreamInterceptor(headers)(
+		context.Background(), &grpc.StreamDesc{}, nil, "/test",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			md, _ = metadata.FromOutgoingContext(ctx)
+			return nil, nil
+		},
+	)
+	require.NoError(err)
+	require.Equal([]string{"payments"}, md.Get("x-team-id"))
+}
@@ -0,0 +1,133 @@
+package serverclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// TLSPKCS12 authenticates to the server with the client certificate and
+// private key in the PKCS#12 (".p12") file at path, for servers that
+// require mutual TLS. Any CA certificates in the file are trusted to verify
+// the server in addition to the system roots.
+//
+// If passphrase is empty, the passphrase is read from the
+// WAYPOINT_SERVER_TLS_PKCS12_PASSPHRASE environment variable.
+func TLSPKCS12(path, passphrase string) ConnectOption {
+	return func(c *connectConfig) error {
+		if path == "" {
+			return nil
+		}
+
+		if passphrase == "" {
+			passphrase = os.Getenv(EnvServerTlsPKCS12Passphrase)
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading PKCS#12 file %q: %w", path, err)
+		}
+
+		cert, roots, err := parsePKCS12(data, passphrase)
+		if err != nil {
+			return fmt.Errorf("error loading PKCS#12 file %q: %w", path, err)
+		}
+
+		c.ClientCert = cert
+		c.RootCAs = roots
+		return nil
+	}
+}
+
+// parsePKCS12 returns the client certificate and the pool of trusted roots
+// from the PKCS#12 data. The client certificate is the certificate that
+// matches the private key and any other certificates are treated as CAs.
+func parsePKCS12(data []byte, passphrase string) (*tls.Certificate, *x509.CertPool, error) {
+	blocks, err := pkcs12.ToPEM(data, passphrase)
+	if err == pkcs12.ErrIncorrectPassword {
+		return nil, nil, errors.New("the passphrase is incorrect")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("the file is not a valid PKCS#12 file: %w", err)
+	}
+
+	var keyPEM []byte
+	var certs []*x509.Certificate
+	for _, block := range blocks {
+		switch {
+		case block.Type == "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error parsing certificate: %w", err)
+			}
+
+			certs = append(certs, cert)
+
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			if keyPEM != nil {
+				return nil, nil, errors.New("the file contains more than one private key")
+			}
+
+			keyPEM = pem.EncodeToMemory(block)
+		}
+	}
+	if keyPEM == nil {
+		return nil, nil, errors.New("the file doesn't contain a private key")
+	}
+
+	// Find the certificate for our key by trying each one. X509KeyPair
+	// verifies that the public key matches.
+	var result *tls.Certificate
+	var cas []*x509.Certificate
+	for _, cert := range certs {
+		if result == nil {
+			pair, err := tls.X509KeyPair(pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: cert.Raw,
+			}), keyPEM)
+			if err == nil {
+				result = &pair
+				continue
+			}
+		}
+
+		cas = append(cas, cert)
+	}
+	if result == nil {
+		return nil, nil, errors.New(
+			"the file doesn't contain a certificate for its private key")
+	}
+
+	// Send any intermediates with our certificate so the server can build
+	// the chain. Self-signed certificates are roots and aren't sent.
+	for _, ca := range cas {
+		if !isSelfSigned(ca) {
+			result.Certificate = append(result.Certificate, ca.Raw)
+		}
+	}
+
+	var roots *x509.CertPool
+	if len(cas) > 0 {
+		roots, err = x509.SystemCertPool()
+		if err != nil || roots == nil {
+			roots = x509.NewCertPool()
+		}
+		for _, ca := range cas {
+			roots.AddCert(ca)
+		}
+	}
+
+	return result, roots, nil
+}
+
+// isSelfSigned returns true if the certificate is signed by its own key.
+func isSelfSigned(cert *x509.Certificate) bool {
+	return cert.CheckSignatureFrom(cert) == nil
+}
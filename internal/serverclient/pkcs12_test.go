@@ -0,0 +1,83 @@
+package serverclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The test data was generated with OpenSSL using the legacy algorithms,
+// the only ones golang.org/x/crypto/pkcs12 supports:
+//
+//   openssl pkcs12 -export -legacy -in client.pem -inkey client.key \
+//     -certfile ca.pem -passout pass:hunter2 -out client.p12
+//   openssl pkcs12 -export -legacy -nokeys -in client.pem \
+//     -passout pass:hunter2 -out client-nokey.p12
+//
+// client.pem is for "client" and is signed by the self-signed "test-ca".
+
+func TestTLSPKCS12(t *testing.T) {
+	t.Run("no path", func(t *testing.T) {
+		require := require.New(t)
+
+		var cfg connectConfig
+		require.NoError(TLSPKCS12("", "hunter2")(&cfg))
+		require.Nil(cfg.ClientCert)
+		require.Nil(cfg.RootCAs)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		require := require.New(t)
+
+		var cfg connectConfig
+		require.NoError(TLSPKCS12(filepath.Join("testdata", "client.p12"), "hunter2")(&cfg))
+		require.NotNil(cfg.ClientCert)
+		require.NotNil(cfg.ClientCert.PrivateKey)
+
+		// The self-signed CA is trusted but not sent with our certificate.
+		require.Len(cfg.ClientCert.Certificate, 1)
+		require.NotNil(cfg.RootCAs)
+	})
+
+	t.Run("passphrase from env", func(t *testing.T) {
+		require := require.New(t)
+
+		defer os.Unsetenv(EnvServerTlsPKCS12Passphrase)
+		require.NoError(os.Setenv(EnvServerTlsPKCS12Passphrase, "hunter2"))
+
+		var cfg connectConfig
+		require.NoError(TLSPKCS12(filepath.Join("testdata", "client.p12"), "")(&cfg))
+		require.NotNil(cfg.ClientCert)
+	})
+
+	t.Run("incorrect passphrase", func(t *testing.T) {
+		require := require.New(t)
+
+		var cfg connectConfig
+		err := TLSPKCS12(filepath.Join("testdata", "client.p12"), "wrong")(&cfg)
+		require.Error(err)
+		require.Contains(err.Error(), "passphrase is incorrect")
+	})
+
+	t.Run("no private key", func(t *testing.T) {
+		require := require.New(t)
+
+		var cfg connectConfig
+		err := TLSPKCS12(filepath.Join("testdata", "client-nokey.p12"), "hunter2")(&cfg)
+		require.Error(err)
+		require.Contains(err.Error(), "private key")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		var cfg connectConfig
+		require.Error(t, TLSPKCS12(filepath.Join("testdata", "missing.p12"), "hunter2")(&cfg))
+	})
+}
+
+func TestParsePKCS12_invalid(t *testing.T) {
+	_, _, err := parsePKCS12([]byte("not pkcs12"), "hunter2")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not a valid PKCS#12 file")
+}
@@ -0,0 +1,123 @@
+package serverclient
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// RateLimit limits the RPCs sent on the connection to rps requests per
+// second, so that operations across many projects or apps stay within the
+// limits of the server. Bursts of up to one second of requests are allowed
+// after an idle period. Streams count as a single request when opened.
+// Zero disables the limit, which is the default.
+func RateLimit(rps float64) ConnectOption {
+	return func(c *connectConfig) error {
+		if rps < 0 {
+			return errors.New("The rate limit for requests to the server can't be negative.")
+		}
+
+		c.RateLimit = rps
+		return nil
+	}
+}
+
+// rateLimiter is a token bucket that is shared by every RPC on the
+// connection.
+type rateLimiter struct {
+	// interval is the time to refill a single token and burst is the time
+	// to refill the bucket.
+	interval time.Duration
+	burst    time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newRateLimiter(rps float64) *rateLimiter {
+	n := math.Max(1, math.Floor(rps))
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / rps),
+		burst:    time.Duration((n - 1) * float64(time.Second) / rps),
+	}
+}
+
+// Wait blocks until a token is available or the context is done.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+
+	// A full bucket is a next time that is burst in the past.
+	if min := now.Add(-l.burst); l.next.Before(min) {
+		l.next = min
+	}
+	reserved := l.next
+	wait := reserved.Sub(now)
+	l.next = reserved.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+
+	case <-ctx.Done():
+		// Give our token back so that a cancelled request doesn't delay
+		// the ones after it. If a later token was reserved in the meantime
+		// we can't, since that waiter is already scheduled after ours.
+		l.mu.Lock()
+		if l.next.Equal(reserved.Add(l.interval)) {
+			l.next = reserved
+		}
+		l.mu.Unlock()
+
+		return ctx.Err()
+	}
+}
+
+// rateLimitUnaryInterceptor waits for the limiter before each unary RPC.
+func rateLimitUnaryInterceptor(l *rateLimiter) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if err := l.Wait(ctx); err != nil {
+			return err
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// rateLimitStreamInterceptor waits for the limiter before opening each
+// stream.
+func rateLimitStreamInterceptor(l *rateLimiter) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		if err := l.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
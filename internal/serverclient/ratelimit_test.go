@@ -0,0 +1,101 @@
+package serverclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	require := require.New(t)
+
+	var cfg connectConfig
+	require.NoError(RateLimit(2.5)(&cfg))
+	require.Equal(2.5, cfg.RateLimit)
+
+	require.Error(RateLimit(-1)(&cfg))
+}
+
+func TestRateLimiter_burst(t *testing.T) {
+	require := require.New(t)
+
+	// A cancelled context only matters if we have to wait, so this checks
+	// which requests are allowed without waiting.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A full bucket allows a second of requests at once.
+	l := newRateLimiter(5)
+	for i := 0; i < 5; i++ {
+		require.NoError(l.Wait(ctx), "request %d", i)
+	}
+
+	// Then we have to wait for the next token.
+	require.Equal(context.Canceled, l.Wait(ctx))
+}
+
+func TestRateLimiter_wait(t *testing.T) {
+	require := require.New(t)
+
+	l := newRateLimiter(20)
+	l.next = time.Now()
+	require.NoError(l.Wait(context.Background()))
+
+	// The next token is available after the interval.
+	start := time.Now()
+	require.NoError(l.Wait(context.Background()))
+	require.GreaterOrEqual(int64(time.Since(start)), int64(40*time.Millisecond))
+}
+
+func TestRateLimiter_cancel(t *testing.T) {
+	require := require.New(t)
+
+	l := newRateLimiter(1)
+	require.NoError(l.Wait(context.Background()))
+	next := l.next
+
+	// A wait that is cancelled gives its token back.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.Equal(context.DeadlineExceeded, l.Wait(ctx))
+	require.Equal(next, l.next)
+
+	// wait starts a wait in the background and returns once its token is
+	// reserved.
+	wait := func(ctx context.Context) <-chan error {
+		l.mu.Lock()
+		before := l.next
+		l.mu.Unlock()
+
+		done := make(chan error, 1)
+		go func() { done <- l.Wait(ctx) }()
+		require.Eventually(func() bool {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return l.next.After(before)
+		}, time.Second, time.Millisecond)
+
+		return done
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	defer cancel1()
+	done1 := wait(ctx1)
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	done2 := wait(ctx2)
+	last := l.next
+
+	// The first token can't be given back since the second waiter is
+	// already scheduled after it.
+	cancel1()
+	require.Equal(context.Canceled, <-done1)
+	require.Equal(last, l.next)
+
+	// The last one can.
+	cancel2()
+	require.Equal(context.Canceled, <-done2)
+	require.Equal(last.Add(-l.interval), l.next)
+}
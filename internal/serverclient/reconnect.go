@@ -0,0 +1,166 @@
+package serverclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultKeepalive is the default interval of keepalive pings sent on
+	// an idle connection to the server.
+	DefaultKeepalive = 30 * time.Second
+
+	// MinKeepalive is the shortest keepalive interval. The server closes
+	// connections that send pings more often than this.
+	MinKeepalive = 20 * time.Second
+)
+
+// Keepalive sets the interval of the keepalive pings sent on an idle
+// connection, so that proxies and load balancers in front of the server
+// don't drop it. Zero uses DefaultKeepalive.
+func Keepalive(d time.Duration) ConnectOption {
+	return func(c *connectConfig) error {
+		if d != 0 && d < MinKeepalive {
+			return fmt.Errorf(
+				"The keepalive interval must be at least %s, since the server "+
+					"closes connections that send keepalive pings more often.", MinKeepalive)
+		}
+
+		c.Keepalive = d
+		return nil
+	}
+}
+
+// connectionClosed returns true if the error is from the connection to the
+// server being closed, such as by a proxy that drops idle connections.
+func connectionClosed(err error) bool {
+	if status.Code(err) != codes.Unavailable {
+		return false
+	}
+
+	msg := strings.ToLower(status.Convert(err).Message())
+	for _, s := range []string{
+		"transport is closing",
+		"connection closed",
+		"connection reset",
+		"error reading from server",
+		"eof",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// idempotentMethod returns true if the RPC only reads state, so that it is
+// safe to send again even if the server already processed it before the
+// connection closed. Names are "/package.Service/Method".
+func idempotentMethod(method string) bool {
+	name := method[strings.LastIndex(method, "/")+1:]
+	switch name {
+	case "ValidateJob", "DecodeToken", "WaypointHclFmt":
+		return true
+	}
+
+	return strings.HasPrefix(name, "Get") || strings.HasPrefix(name, "List")
+}
+
+// reconnectUnaryInterceptor retries a unary RPC once if it failed because
+// the connection was closed. Only idempotent RPCs are retried, since the
+// server may have processed the request before the connection closed.
+// Requests that never left the client are already retried transparently
+// by gRPC. The retry waits up to timeout for the connection to be
+// re-established, and otherwise has the deadline of ctx.
+func reconnectUnaryInterceptor(log hclog.Logger, timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if !connectionClosed(err) || ctx.Err() != nil || !idempotentMethod(method) {
+			return err
+		}
+
+		log.Debug("connection to the server closed, reconnecting", "method", method, "error", err)
+		cc.ResetConnectBackoff()
+
+		waitCtx, cancel := reconnectContext(ctx, timeout)
+		defer cancel()
+		if !waitReady(waitCtx, cc) {
+			return err
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// reconnectStreamInterceptor retries opening a stream once if it failed
+// because the connection was closed. A stream that was already open can't
+// be retried transparently, so errors after it opens are returned.
+func reconnectStreamInterceptor(log hclog.Logger, timeout time.Duration) grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if !connectionClosed(err) || ctx.Err() != nil {
+			return stream, err
+		}
+
+		log.Debug("connection to the server closed, reconnecting", "method", method, "error", err)
+		cc.ResetConnectBackoff()
+
+		// The stream uses ctx for its lifetime, so we can only bound the
+		// wait for the connection if the stream opens.
+		waitCtx, cancel := reconnectContext(ctx, timeout)
+		defer cancel()
+		if !waitReady(waitCtx, cc) {
+			return nil, err
+		}
+
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+// reconnectContext returns the context for a retry, which waits at most
+// timeout for the connection.
+func reconnectContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// waitReady waits until the connection is ready. This returns false if
+// ctx is done first.
+func waitReady(ctx context.Context, cc *grpc.ClientConn) bool {
+	for {
+		state := cc.GetState()
+		if state == connectivity.Ready {
+			return true
+		}
+
+		if !cc.WaitForStateChange(ctx, state) {
+			return false
+		}
+	}
+}
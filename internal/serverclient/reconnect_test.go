@@ -0,0 +1,113 @@
+package serverclient
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIdempotentMethod(t *testing.T) {
+	cases := []struct {
+		Method   string
+		Expected bool
+	}{
+		{"/hashicorp.waypoint.Waypoint/GetProject", true},
+		{"/hashicorp.waypoint.Waypoint/ListDeployments", true},
+		{"/hashicorp.waypoint.Waypoint/ValidateJob", true},
+		{"/hashicorp.waypoint.Waypoint/QueueJob", false},
+		{"/hashicorp.waypoint.Waypoint/UpsertDeployment", false},
+		{"/hashicorp.waypoint.Waypoint/GenerateLoginToken", false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Method, func(t *testing.T) {
+			require.Equal(t, tt.Expected, idempotentMethod(tt.Method))
+		})
+	}
+}
+
+func TestReconnectUnaryInterceptor(t *testing.T) {
+	cc := testConn(t)
+	closed := status.Error(codes.Unavailable, "transport is closing")
+	interceptor := reconnectUnaryInterceptor(hclog.L(), time.Second)
+
+	// invoke calls the interceptor with an invoker that fails with err the
+	// first time. It returns the contexts of each call to the invoker.
+	invoke := func(ctx context.Context, method string, err error) ([]context.Context, error) {
+		var calls []context.Context
+		result := interceptor(ctx, method, nil, nil, cc, func(
+			ctx context.Context, method string, req, reply interface{},
+			cc *grpc.ClientConn, opts ...grpc.CallOption,
+		) error {
+			calls = append(calls, ctx)
+			if len(calls) == 1 {
+				return err
+			}
+
+			return nil
+		})
+
+		return calls, result
+	}
+
+	t.Run("retries idempotent RPCs", func(t *testing.T) {
+		require := require.New(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+		defer cancel()
+
+		calls, err := invoke(ctx, "/hashicorp.waypoint.Waypoint/GetProject", closed)
+		require.NoError(err)
+		require.Len(calls, 2)
+
+		// The retry has the deadline of the caller, not the timeout for
+		// the connection.
+		deadline, ok := calls[1].Deadline()
+		require.True(ok)
+		expected, _ := ctx.Deadline()
+		require.Equal(expected, deadline)
+	})
+
+	t.Run("doesn't retry other RPCs", func(t *testing.T) {
+		require := require.New(t)
+
+		calls, err := invoke(context.Background(), "/hashicorp.waypoint.Waypoint/QueueJob", closed)
+		require.Equal(closed, err)
+		require.Len(calls, 1)
+	})
+
+	t.Run("doesn't retry other errors", func(t *testing.T) {
+		require := require.New(t)
+
+		other := status.Error(codes.Unavailable, "no runners")
+		calls, err := invoke(context.Background(), "/hashicorp.waypoint.Waypoint/GetProject", other)
+		require.Equal(other, err)
+		require.Len(calls, 1)
+	})
+}
+
+// testConn returns a connection to a server with no services, which is
+// ready once this returns.
+func testConn(t *testing.T) *grpc.ClientConn {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cc, err := grpc.DialContext(ctx, ln.Addr().String(), grpc.WithInsecure(), grpc.WithBlock())
+	require.NoError(t, err)
+	t.Cleanup(func() { cc.Close() })
+
+	return cc
+}
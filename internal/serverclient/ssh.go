@@ -0,0 +1,170 @@
+package serverclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshBastion is the SSH host that connections to the server are tunneled
+// through. See SSHBastion.
+type sshBastion struct {
+	User    string
+	Addr    string // host:port
+	KeyFile string // optional private key, in addition to the agent
+}
+
+// SSHBastion connects to the server through an SSH tunnel to the bastion
+// host dest, in the form "[user@]host[:port]". This is for servers that are
+// only reachable from within a private network.
+//
+// The bastion authenticates with the private key in keyFile, if it is
+// non-empty, and any keys in the SSH agent at SSH_AUTH_SOCK. The host key
+// of the bastion must be in the user's "~/.ssh/known_hosts".
+func SSHBastion(dest, keyFile string) ConnectOption {
+	return func(c *connectConfig) error {
+		if dest == "" {
+			if keyFile != "" {
+				return errors.New("An SSH key can only be used with an SSH bastion.")
+			}
+
+			c.Bastion = nil
+			return nil
+		}
+
+		b, err := parseSSHBastion(dest)
+		if err != nil {
+			return err
+		}
+
+		b.KeyFile = keyFile
+		c.Bastion = b
+		return nil
+	}
+}
+
+// parseSSHBastion parses a bastion destination in the form
+// "[user@]host[:port]". The user defaults to the current user and the port
+// defaults to 22.
+func parseSSHBastion(dest string) (*sshBastion, error) {
+	user := os.Getenv("USER")
+	if idx := strings.LastIndex(dest, "@"); idx >= 0 {
+		user, dest = dest[:idx], dest[idx+1:]
+	}
+	if user == "" {
+		return nil, fmt.Errorf(
+			"The SSH bastion %q has no user and the current user is unknown. "+
+				"Specify the user in the form \"user@host\".", dest)
+	}
+
+	addr := dest
+	if _, _, err := net.SplitHostPort(dest); err != nil {
+		addr = net.JoinHostPort(strings.Trim(dest, "[]"), "22")
+	}
+	if host, _, _ := net.SplitHostPort(addr); host == "" {
+		return nil, fmt.Errorf("The SSH bastion %q has no host.", dest)
+	}
+
+	return &sshBastion{User: user, Addr: addr}, nil
+}
+
+// dialSSHBastion connects to the bastion host. The returned client is closed
+// once ctx is done, so ctx should live as long as the server connection.
+func dialSSHBastion(ctx context.Context, cfg *connectConfig) (*ssh.Client, error) {
+	b := cfg.Bastion
+
+	var auth []ssh.Signer
+	if b.KeyFile != "" {
+		data, err := ioutil.ReadFile(b.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading SSH key %q: %w", b.KeyFile, err)
+		}
+
+		signer, err := ssh.ParsePrivateKey(data)
+		if _, ok := err.(*ssh.PassphraseMissingError); ok {
+			return nil, fmt.Errorf(
+				"The SSH key %q is protected by a passphrase. Add it to your "+
+					"SSH agent instead.", b.KeyFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing SSH key %q: %w", b.KeyFile, err)
+		}
+
+		auth = append(auth, signer)
+	}
+
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			cfg.Log.Warn("error connecting to SSH agent", "error", err)
+		} else {
+			// The agent is only needed to authenticate.
+			defer conn.Close()
+
+			signers, err := agent.NewClient(conn).Signers()
+			if err != nil {
+				cfg.Log.Warn("error listing SSH agent keys", "error", err)
+			}
+			auth = append(auth, signers...)
+		}
+	}
+
+	if len(auth) == 0 {
+		return nil, fmt.Errorf(
+			"No SSH keys are available to authenticate with the bastion %q. "+
+				"Specify a key file or add a key to your SSH agent.", b.Addr)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	knownHostsPath := filepath.Join(home, ".ssh", "known_hosts")
+	hostKeyCallback, err := knownhosts.New(knownHostsPath)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"error reading %q to verify the host key of the bastion: %w",
+			knownHostsPath, err)
+	}
+
+	cfg.Log.Debug("connecting to SSH bastion", "addr", b.Addr, "user", b.User)
+
+	var d net.Dialer
+	ctxTimeout, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+	conn, err := d.DialContext(ctxTimeout, "tcp", b.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to SSH bastion %q: %w", b.Addr, err)
+	}
+
+	// The handshake has no timeout of its own.
+	conn.SetDeadline(time.Now().Add(cfg.Timeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, b.Addr, &ssh.ClientConfig{
+		User:            b.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(auth...)},
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error connecting to SSH bastion %q: %w", b.Addr, err)
+	}
+	conn.SetDeadline(time.Time{})
+
+	client := ssh.NewClient(sshConn, chans, reqs)
+	go func() {
+		<-ctx.Done()
+		client.Close()
+	}()
+
+	return client, nil
+}
@@ -0,0 +1,91 @@
+package serverclient
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSSHBastion(t *testing.T) {
+	defer os.Setenv("USER", os.Getenv("USER"))
+	require.NoError(t, os.Setenv("USER", "alice"))
+
+	cases := []struct {
+		Dest     string
+		Expected *sshBastion
+		Error    bool
+	}{
+		{
+			"bastion.example.com",
+			&sshBastion{User: "alice", Addr: "bastion.example.com:22"},
+			false,
+		},
+		{
+			"bob@bastion.example.com:2222",
+			&sshBastion{User: "bob", Addr: "bastion.example.com:2222"},
+			false,
+		},
+		{
+			"bob@[::1]",
+			&sshBastion{User: "bob", Addr: "[::1]:22"},
+			false,
+		},
+		{
+			"bob@[::1]:2222",
+			&sshBastion{User: "bob", Addr: "[::1]:2222"},
+			false,
+		},
+		{
+			"bob@",
+			nil,
+			true,
+		},
+		{
+			"bob@:22",
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Dest, func(t *testing.T) {
+			require := require.New(t)
+
+			b, err := parseSSHBastion(tt.Dest)
+			if tt.Error {
+				require.Error(err)
+				return
+			}
+			require.NoError(err)
+			require.Equal(tt.Expected, b)
+		})
+	}
+
+	t.Run("no user", func(t *testing.T) {
+		require.NoError(t, os.Setenv("USER", ""))
+		defer os.Setenv("USER", "alice")
+
+		_, err := parseSSHBastion("bastion.example.com")
+		require.Error(t, err)
+	})
+}
+
+func TestSSHBastion(t *testing.T) {
+	require := require.New(t)
+
+	var cfg connectConfig
+	require.NoError(SSHBastion("bob@bastion.example.com", "/tmp/id_ed25519")(&cfg))
+	require.Equal(&sshBastion{
+		User:    "bob",
+		Addr:    "bastion.example.com:22",
+		KeyFile: "/tmp/id_ed25519",
+	}, cfg.Bastion)
+
+	// No destination clears the bastion.
+	require.NoError(SSHBastion("", "")(&cfg))
+	require.Nil(cfg.Bastion)
+
+	// A key without a bastion is a mistake.
+	require.Error(SSHBastion("", "/tmp/id_ed25519")(&cfg))
+}
@@ -20,6 +20,12 @@ type Client struct {
 	Tls           bool `hcl:"tls,optional" json:"tls,omitempty"`
 	TlsSkipVerify bool `hcl:"tls_skip_verify,optional" json:"tls_skip_verify,omitempty"`
 
+	// TlsCaPath is a directory of PEM-encoded CA certificates to trust to
+	// verify the server, in addition to the system roots unless TlsCaOnly
+	// is true.
+	TlsCaPath string `hcl:"tls_ca_path,optional" json:"tls_ca_path,omitempty"`
+	TlsCaOnly bool   `hcl:"tls_ca_only,optional" json:"tls_ca_only,omitempty"`
+
 	// AddressInternal is a temporary config to work with local deployments
 	// on platforms such as Docker for Mac. We need to discuss a more
 	// long term approach to this.
@@ -52,6 +58,11 @@ func (c *Client) EnvMap() map[string]string {
 		result["WAYPOINT_SERVER_TOKEN"] = c.AuthToken
 	}
 
+	if c.TlsCaPath != "" {
+		result["WAYPOINT_SERVER_TLS_CA_PATH"] = c.TlsCaPath
+		result["WAYPOINT_SERVER_TLS_CA_ONLY"] = strconv.FormatBool(c.TlsCaOnly)
+	}
+
 	return result
 }
 
@@ -52,4 +52,18 @@ func TestClientEnv(t *testing.T) {
 		require.Equal(env["WAYPOINT_SERVER_TLS_SKIP_VERIFY"], "true")
 		require.Equal(env["WAYPOINT_SERVER_TOKEN"], "bar")
 	})
+
+	t.Run("CA path", func(t *testing.T) {
+		require := require.New(t)
+
+		env := listToMap(t, (&Client{
+			Address:   "foo",
+			Tls:       true,
+			TlsCaPath: "/etc/waypoint/ca",
+			TlsCaOnly: true,
+		}).Env())
+
+		require.Equal(env["WAYPOINT_SERVER_TLS_CA_PATH"], "/etc/waypoint/ca")
+		require.Equal(env["WAYPOINT_SERVER_TLS_CA_ONLY"], "true")
+	})
 }